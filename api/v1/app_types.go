@@ -17,29 +17,1309 @@ limitations under the License.
 package v1
 
 import (
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// LastModifiedByAnnotation records the user or ServiceAccount that created the App or last
+// changed its spec. It is set by the App admission webhook and copied to the App's child resources.
+const LastModifiedByAnnotation = "webapp.example.com/last-modified-by"
+
+// Annotations requesting an ephemeral debug container in one of the App's pods. DebugAnnotation
+// names the pod; the container runs DebugImageAnnotation, or the controller's default debug
+// image, until DebugTTLAnnotation (a duration, 1h by default) has passed. The pod is then
+// deleted, so its Deployment replaces it with a clean one; removing DebugAnnotation ends the
+// session early the same way.
+const (
+	DebugAnnotation      = "webapp.example.com/debug"
+	DebugImageAnnotation = "webapp.example.com/debug-image"
+	DebugTTLAnnotation   = "webapp.example.com/debug-ttl"
+)
+
+// RolloutAnnotation acts on the canary or blue/green rollout of an App: "promote" resumes a
+// paused canary step, or switches a blue/green App to its preview, and "abort" scales the
+// new image down and keeps the stable one. The controller removes it once acted upon.
+const RolloutAnnotation = "webapp.example.com/rollout"
+
+// Actions of RolloutAnnotation.
+const (
+	RolloutPromote = "promote"
+	RolloutAbort   = "abort"
+)
+
 // AppSpec defines the desired state of App
+// +kubebuilder:validation:XValidation:rule="!has(self.targetCluster) || (!has(self.tls) && !has(self.mesh) && !(has(self.networkIsolation) && self.networkIsolation) && !has(self.networkPolicy) && !(has(self.vault) && has(self.vault.mode) && self.vault.mode == 'CSI'))",message="tls, mesh, networkIsolation, networkPolicy and Vault CSI mode are not available with targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.workloadType) || self.workloadType != 'Knative' || (!has(self.targetCluster) && !has(self.tls) && !has(self.service) && !has(self.platforms) && !(has(self.networkIsolation) && self.networkIsolation) && !has(self.networkPolicy))",message="targetCluster, tls, service, platforms, networkIsolation and networkPolicy are not available with the Knative workload type"
+// +kubebuilder:validation:XValidation:rule="!has(self.service) || !has(self.service.ports) || self.service.ports.all(p, p.port != self.port || (has(p.protocol) && p.protocol != 'TCP'))",message="spec.service.ports must not repeat spec.port"
+// +kubebuilder:validation:XValidation:rule="!has(self.workloadType) || self.workloadType != 'Knative' || (!has(self.containers) && !has(self.initContainers) && !has(self.sidecars))",message="containers, initContainers and sidecars are not available with the Knative workload type"
+// +kubebuilder:validation:XValidation:rule="!has(self.knative) || (has(self.workloadType) && self.workloadType == 'Knative')",message="knative requires the Knative workload type"
+// +kubebuilder:validation:XValidation:rule="!has(self.os) || self.os != 'windows' || (!has(self.mesh) && !(has(self.security) && (has(self.security.appArmorProfile) || has(self.security.containerSeccompProfile))))",message="mesh, security.appArmorProfile and security.containerSeccompProfile are not available for Windows Apps"
+// +kubebuilder:validation:XValidation:rule="!has(self.scaling) || !self.scaling.scaleToZero || ((!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster))",message="scaling.scaleToZero is not available with the Knative workload type or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.cloudIdentity) || (!has(self.serviceAccountName) && !has(self.targetCluster))",message="cloudIdentity is not available with serviceAccountName or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.serviceAccount) || (!has(self.serviceAccountName) && !has(self.targetCluster))",message="serviceAccount is not available with serviceAccountName or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.autoscaling) || (!has(self.replicas) && (!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster) && !(has(self.scaling) && self.scaling.scaleToZero))",message="autoscaling is not available with replicas, the Knative workload type, targetCluster or scaling.scaleToZero"
+// +kubebuilder:validation:XValidation:rule="!has(self.expose) || ((!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster))",message="expose is not available with the Knative workload type or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.strategy) || self.strategy.type == 'RollingUpdate' || ((!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster) && !(has(self.scaling) && self.scaling.scaleToZero))",message="the Canary and BlueGreen strategies are not available with the Knative workload type, targetCluster or scaling.scaleToZero"
+// +kubebuilder:validation:XValidation:rule="!has(self.storage) || ((!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster))",message="storage is not available with the Knative workload type or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.imageUpdate) || !self.image.contains('@')",message="imageUpdate requires an image referenced by tag, not digest"
+// +kubebuilder:validation:XValidation:rule="!has(self.highAvailability) || ((has(self.autoscaling) ? has(self.autoscaling.minReplicas) && self.autoscaling.minReplicas > 1 : has(self.replicas) && self.replicas > 1) && (!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster))",message="highAvailability requires more than one replica, or autoscaling.minReplicas above one, and is not available with the Knative workload type or targetCluster"
 type AppSpec struct {
 	// Image is the container image to deploy.
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	Image string `json:"image"`
 
-	// Replicas is the number of desired pods.
+	// ContainerName names the app container. Defaults to app-container.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	ContainerName string `json:"containerName,omitempty"`
+
+	// Command overrides the entrypoint of the app container's image.
+	// +listType=atomic
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args overrides the arguments of the app container's entrypoint.
+	// +listType=atomic
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// ImagePullPolicy is Always, IfNotPresent or Never for all the App's containers. Defaults
+	// to Always for images tagged latest or untagged, IfNotPresent otherwise.
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// ImagePullSecrets name the Secrets of the App's namespace holding the credentials of
+	// private registries its images are pulled from. They are attached to the App's pods and
+	// ServiceAccount, and used by the controller to inspect the image for spec.platforms and
+	// resolve its tag for spec.imageUpdate.
+	// +listType=atomic
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// ImageUpdate makes the controller resolve the tag of Image to the digest it points to
+	// and run the App's pods pinned to that digest, so that they all run the same image even
+	// when the tag is pushed again. With the TrackTag policy, a tag that moves rolls the App
+	// out again.
+	// +optional
+	ImageUpdate *ImageUpdateSpec `json:"imageUpdate,omitempty"`
+
+	// Replicas is the number of desired pods, 1 when unset. With the Knative workload type,
+	// it is the maximum number of pods the App scales out to. It can't be set together with
+	// Autoscaling.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Port is the port the application listens on. The admission webhook sets it to 8080 when
+	// omitted.
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Minimum=1
-	Replicas int32 `json:"replicas"`
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// AppProtocol is the application protocol served on Port: http, http2 (cleartext HTTP/2)
+	// or grpc. It is set as the appProtocol of the Service port, so meshes and load balancers
+	// handle the App's traffic at layer 7. Apps serving TLS themselves are declared https.
+	// +kubebuilder:validation:Enum=http;http2;grpc
+	// +optional
+	AppProtocol AppProtocol `json:"appProtocol,omitempty"`
+
+	// Resources are the compute resources of the app container. Accelerators and applied
+	// spec.rightSizing recommendations are set over them.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Probes configures health checks of the app container.
+	// +optional
+	Probes *ProbesSpec `json:"probes,omitempty"`
+
+	// Storage gives the App's pods a PersistentVolumeClaim, created and owned by the App and
+	// mounted into the app container.
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// Service customizes the Service exposing the App's pods.
+	// +optional
+	Service *ServiceSpec `json:"service,omitempty"`
+
+	// Expose routes requests for a host name to the App's Service, through an Ingress or a
+	// Gateway API HTTPRoute. The address it is served at is reported in status.url.
+	// +optional
+	Expose *ExposeSpec `json:"expose,omitempty"`
+
+	// OS is the operating system of the App's image. Windows Apps are scheduled on Windows
+	// nodes, tolerating the os=windows:NoSchedule taint such nodes usually carry, and get no
+	// Linux-only security settings: spec.security.seccompProfile is ignored for them.
+	// +kubebuilder:validation:Enum=linux;windows
+	// +kubebuilder:default=linux
+	// +optional
+	OS corev1.OSName `json:"os,omitempty"`
+
+	// Platforms restricts the App's pods to nodes of these CPU architectures, e.g. [amd64, arm64].
+	// The controller inspects the image's manifest and further restricts them to the
+	// architectures the image is built for, so pods never land on a node they can't run on.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Enum=amd64;arm64;arm;386;ppc64le;s390x;riscv64
+	// +listType=set
+	// +optional
+	Platforms []string `json:"platforms,omitempty"`
+
+	// RightSizing recommends CPU and memory requests for the App's pods from their actual
+	// usage, published in status.recommendations.
+	// +optional
+	RightSizing *RightSizingSpec `json:"rightSizing,omitempty"`
+
+	// Accelerators requests GPUs or other extended resources for each pod, and steers the pods
+	// to the nodes providing them.
+	// +optional
+	Accelerators *AcceleratorSpec `json:"accelerators,omitempty"`
+
+	// WorkloadType selects how the App's pods are run. Deployment, the default, creates a
+	// Deployment and a Service. Knative creates a Knative Serving Service instead, which
+	// scales the App to zero while it receives no requests.
+	// +kubebuilder:default=Deployment
+	// +optional
+	WorkloadType WorkloadType `json:"workloadType,omitempty"`
+
+	// Knative tunes the autoscaling of the Knative workload type.
+	// +optional
+	Knative *KnativeSpec `json:"knative,omitempty"`
+
+	// Scaling configures HTTP-based autoscaling of the App's Deployment.
+	// +optional
+	Scaling *ScalingSpec `json:"scaling,omitempty"`
+
+	// Autoscaling scales the App's Deployment with a HorizontalPodAutoscaler, in place of a
+	// fixed number of replicas.
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// HighAvailability keeps the App's pods spread across zones and nodes, and protects them
+	// from voluntary disruptions, such as node drains, with a PodDisruptionBudget. It requires
+	// more than one replica.
+	// +optional
+	HighAvailability *HighAvailabilitySpec `json:"highAvailability,omitempty"`
+
+	// Strategy selects how a new image is rolled out: by the Deployment's rolling update, the
+	// default, or progressively through a second Deployment, as a canary or a blue/green preview.
+	// +optional
+	Strategy *StrategySpec `json:"strategy,omitempty"`
+
+	// PodAnnotations are added to the App's pods. Values are Go templates resolved by the
+	// controller, with the variables {{ .Name }}, {{ .Namespace }} and {{ .Labels }} of the
+	// App and the {{ .ClusterName }} configured for the controller. Annotations the
+	// controller sets itself take precedence.
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// ImageScan gates the rollout of a new image on a vulnerability scan.
+	// +optional
+	ImageScan *ImageScanPolicy `json:"imageScan,omitempty"`
 
-	// Port is the port the application listens on.
+	// Provenance gates the rollout of a new image on a verified SLSA provenance attestation.
+	// +optional
+	Provenance *ProvenancePolicy `json:"provenance,omitempty"`
+
+	// Mesh enrolls the App's pods in a service mesh.
+	// +optional
+	Mesh *MeshSpec `json:"mesh,omitempty"`
+
+	// ServiceAccountName is the ServiceAccount the pods run as.
+	// Defaults to the namespace's default ServiceAccount.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// AutomountServiceAccountToken controls whether an API token is mounted into the pods.
+	// When unset, the token is only mounted if ServiceAccountName is set or Vault
+	// secrets are injected by the Vault Agent, since other apps are not expected to
+	// talk to the API server.
+	// +optional
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+
+	// ServiceAccount makes the controller run the App's pods as a ServiceAccount of their
+	// own, <name>-serviceaccount, created and owned by the App, with its pull secrets
+	// attached. It is also created for CloudIdentity.
+	// +optional
+	ServiceAccount *ServiceAccountSpec `json:"serviceAccount,omitempty"`
+
+	// CloudIdentity grants the App's pods the credentials of a cloud identity through workload
+	// identity federation. The controller runs the pods as a ServiceAccount of its own,
+	// <name>-serviceaccount, annotated for the identity.
+	// +optional
+	CloudIdentity *CloudIdentitySpec `json:"cloudIdentity,omitempty"`
+
+	// TLS makes the App serve TLS itself, with a serving certificate issued by cert-manager
+	// for its Service, so traffic stays encrypted up to the pod.
+	// +optional
+	TLS *TLSSpec `json:"tls,omitempty"`
+
+	// Config holds configuration files rendered into a ConfigMap and mounted into the app container.
+	// +optional
+	Config *ConfigSpec `json:"config,omitempty"`
+
+	// Env are environment variables of the app container. Values are templated like
+	// spec.podAnnotations. The pods roll out again when a ConfigMap or Secret value a variable
+	// references changes.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// EnvFrom populates environment variables of the app container from ConfigMaps and
+	// Secrets of the App's namespace, e.g. the generated <name>-config ConfigMap. The pods
+	// roll out again when their content changes.
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// Containers run in the App's pods next to the app container, e.g. a queue worker built
+	// from the same code base. Their ports are exposed by the App's Service.
+	// +kubebuilder:validation:MaxItems=8
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Containers []Container `json:"containers,omitempty"`
+
+	// InitContainers run to completion, one after the other, before the App's containers
+	// start, e.g. to migrate a database schema.
+	// +kubebuilder:validation:MaxItems=8
+	// +kubebuilder:validation:XValidation:rule="self.all(c, !has(c.ports))",message="init containers have no ports"
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	InitContainers []Container `json:"initContainers,omitempty"`
+
+	// Sidecars run for the whole life of the App's pods as native sidecar containers: they
+	// start before the init containers and stop after the App's containers, e.g. a proxy or a
+	// log shipper. Their ports are exposed by the App's Service.
+	// +kubebuilder:validation:MaxItems=8
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Sidecars []Container `json:"sidecars,omitempty"`
+
+	// ConfigFrom pulls configuration files from an external source into the App's ConfigMap,
+	// below the entries of Config, which win on conflicting keys. Config.MountPath applies.
+	// +optional
+	ConfigFrom *ConfigSource `json:"configFrom,omitempty"`
+
+	// Vault configures HashiCorp Vault secret injection, so the App can consume
+	// secrets without any Secret objects being stored in the cluster.
+	// +optional
+	Vault *VaultSpec `json:"vault,omitempty"`
+
+	// DependsOn lists the Apps in the same namespace that this App calls.
+	// With NetworkIsolation, traffic to them is allowed and traffic from them is accepted.
+	// Names are never namespace-qualified: Apps of other namespaces cannot be referenced.
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +listType=set
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// NetworkIsolation puts the App's pods behind a default-deny NetworkPolicy that only
+	// allows traffic derived from the App's declared port and DependsOn relationships:
+	// ingress from Apps depending on it, egress to its dependencies and cluster DNS.
+	// +optional
+	NetworkIsolation bool `json:"networkIsolation,omitempty"`
+
+	// NetworkPolicy puts the App's pods behind a default-deny NetworkPolicy that only allows
+	// ingress on the App's declared ports, spec.port, spec.service.ports and the ports of its
+	// containers and sidecars, from the selected namespaces. With NetworkIsolation, both apply to the same policy.
+	// +optional
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// Security holds the hardening options applied to the generated pods.
+	// +kubebuilder:default={}
+	// +optional
+	Security *SecuritySpec `json:"security,omitempty"`
+
+	// TargetCluster delivers the App to another cluster instead of the one it is stored in.
+	// Only the Deployment, the Service and the configuration ConfigMap are created there, so
+	// features relying on other objects (tls, mesh, networkIsolation and Vault CSI mode) are
+	// not available. Objects delivered to a previous target are not removed when it changes.
+	// +optional
+	TargetCluster *TargetCluster `json:"targetCluster,omitempty"`
+
+	// Suspend stops the controller from creating, updating or deleting the App's objects, e.g.
+	// during a maintenance window or while debugging them by hand. The App's status keeps
+	// being updated and reports a Suspended condition. Deleting a suspended App still removes
+	// its objects.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// Container defines a container of an App's pods besides the app container.
+type Container struct {
+	// Name names the container. It is unique among the App's containers, init containers
+	// and sidecars.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Image is the container image to run.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Command overrides the entrypoint of the image.
+	// +listType=atomic
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args overrides the arguments of the entrypoint.
+	// +listType=atomic
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Ports are the ports the container listens on.
+	// +kubebuilder:validation:MaxItems=16
+	// +listType=atomic
+	// +optional
+	Ports []ContainerPort `json:"ports,omitempty"`
+
+	// Env are environment variables of the container.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// EnvFrom populates environment variables of the container from ConfigMaps and Secrets
+	// of the App's namespace.
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// Resources are the compute resources of the container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ContainerPort defines a port a container listens on, exposed by the App's Service.
+type ContainerPort struct {
+	// Name names the port of the container and of the Service. Defaults to the protocol
+	// and number of the port on the Service, e.g. tcp-9090.
+	// +kubebuilder:validation:MaxLength=15
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// ContainerPort is the port number the container listens on.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	ContainerPort int32 `json:"containerPort"`
+
+	// ServicePort is the port of the Service forwarding to it. Defaults to ContainerPort.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	ServicePort int32 `json:"servicePort,omitempty"`
+
+	// Protocol is TCP, UDP or SCTP.
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	// +kubebuilder:default=TCP
+	// +optional
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+
+	// AppProtocol is the application protocol served on the port, e.g. http or grpc, for
+	// meshes and load balancers.
+	// +optional
+	AppProtocol *string `json:"appProtocol,omitempty"`
+}
+
+// AppProtocol is the application protocol of an App's port.
+type AppProtocol string
+
+const (
+	// AppProtocolHTTP is HTTP/1.1.
+	AppProtocolHTTP AppProtocol = "http"
+	// AppProtocolHTTP2 is HTTP/2 without TLS (h2c).
+	AppProtocolHTTP2 AppProtocol = "http2"
+	// AppProtocolGRPC is gRPC, over HTTP/2 without TLS.
+	AppProtocolGRPC AppProtocol = "grpc"
+)
+
+// ProbesSpec defines the health checks of an App's container.
+type ProbesSpec struct {
+	// Liveness restarts the container when it fails.
+	// +optional
+	Liveness *ProbeSpec `json:"liveness,omitempty"`
+
+	// Readiness takes the pod out of the Service's endpoints while it fails.
+	// +optional
+	Readiness *ProbeSpec `json:"readiness,omitempty"`
+
+	// Startup holds back the other probes until it succeeds once, so slow starting Apps
+	// aren't restarted. Its FailureThreshold defaults to 30, giving them 5 minutes.
+	// +optional
+	Startup *ProbeSpec `json:"startup,omitempty"`
+}
+
+// ProbeSpec defines a health check on the App's port. Without grpc, the App is checked with
+// an HTTP GET request.
+// +kubebuilder:validation:XValidation:rule="!(has(self.grpc) && has(self.http))",message="a probe takes either grpc or http"
+type ProbeSpec struct {
+	// GRPC checks the App with the standard gRPC health checking protocol, natively
+	// supported by Kubernetes 1.24 and later.
+	// +optional
+	GRPC *GRPCProbe `json:"grpc,omitempty"`
+
+	// HTTP tunes the HTTP GET request checking the App. It is sent over HTTPS when the App
+	// serves TLS itself.
+	// +optional
+	HTTP *HTTPProbe `json:"http,omitempty"`
+
+	// InitialDelaySeconds is how long to wait after the container started before probing it.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+
+	// PeriodSeconds is how often to probe. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
+
+	// TimeoutSeconds is how long a probe may take. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failures for the probe to fail. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold,omitempty"`
+}
+
+// HTTPProbe defines an HTTP health check. Responses with a status from 200 to 399 succeed.
+type HTTPProbe struct {
+	// Path is the path requested. Defaults to /.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// GRPCProbe defines a gRPC health check.
+type GRPCProbe struct {
+	// Service is the service name sent in the health check request. Empty checks the
+	// overall health of the server.
+	// +optional
+	Service string `json:"service,omitempty"`
+}
+
+// StorageSpec defines the PersistentVolumeClaim of an App. Its storage class and access modes
+// can't be changed once created, and its size can only grow, if the storage class allows
+// volume expansion.
+// +kubebuilder:validation:XValidation:rule="has(self.storageClassName) == has(oldSelf.storageClassName) && (!has(self.storageClassName) || self.storageClassName == oldSelf.storageClassName)",message="storageClassName is immutable"
+// +kubebuilder:validation:XValidation:rule="has(self.accessModes) == has(oldSelf.accessModes) && (!has(self.accessModes) || self.accessModes == oldSelf.accessModes)",message="accessModes are immutable"
+type StorageSpec struct {
+	// Size is the capacity requested for the volume, e.g. 10Gi.
+	// +kubebuilder:validation:Required
+	Size resource.Quantity `json:"size"`
+
+	// StorageClassName is the StorageClass provisioning the volume. The cluster's default
+	// StorageClass is used when unset.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// MountPath is where the volume is mounted in the app container.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^/`
+	MountPath string `json:"mountPath"`
+
+	// AccessModes are the ways the volume can be mounted, ReadWriteOnce when unset. Pods are
+	// replaced rather than rolled out while every mode is single-node, as a new pod would
+	// otherwise wait for the volume held by the old one. Canary and blue/green rollouts need
+	// ReadWriteMany, as the pods of both Deployments mount the volume.
+	// +kubebuilder:validation:items:Enum=ReadWriteOnce;ReadOnlyMany;ReadWriteMany;ReadWriteOncePod
+	// +kubebuilder:validation:MaxItems=4
+	// +optional
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+}
+
+// ExposeMode selects the object routing external requests to an App.
+type ExposeMode string
+
+const (
+	// ExposeModeIngress routes requests with an Ingress.
+	ExposeModeIngress ExposeMode = "Ingress"
+	// ExposeModeHTTPRoute routes requests with a Gateway API HTTPRoute.
+	ExposeModeHTTPRoute ExposeMode = "HTTPRoute"
+)
+
+// ExposeSpec defines how an App is reached from outside the cluster.
+// +kubebuilder:validation:XValidation:rule="!has(self.mode) || self.mode != 'HTTPRoute' || has(self.gateway)",message="gateway is required with the HTTPRoute mode"
+// +kubebuilder:validation:XValidation:rule="(has(self.mode) && self.mode == 'HTTPRoute') || !has(self.gateway)",message="gateway requires the HTTPRoute mode"
+// +kubebuilder:validation:XValidation:rule="!has(self.mode) || self.mode != 'HTTPRoute' || (!has(self.tlsSecretName) && !has(self.ingressClassName))",message="tlsSecretName and ingressClassName are not available with the HTTPRoute mode; TLS terminates at the Gateway"
+type ExposeSpec struct {
+	// Mode selects the object routing to the App: an Ingress, or an HTTPRoute attached to a Gateway.
+	// +kubebuilder:validation:Enum=Ingress;HTTPRoute
+	// +kubebuilder:default=Ingress
+	// +optional
+	Mode ExposeMode `json:"mode,omitempty"`
+	// Host is the host name the App is served at. It may be templated like
+	// spec.podAnnotations, e.g. {{ .Name }}.{{ .Namespace }}.apps.example.com.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+	// Path is the path prefix routed to the App.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +kubebuilder:default="/"
+	// +optional
+	Path string `json:"path,omitempty"`
+	// TLSSecretName is the Secret of the App's namespace holding the certificate the Ingress
+	// serves for Host. The App is served over plain HTTP when empty.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+	// IngressClassName selects the Ingress controller. The cluster's default class is used when empty.
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+	// Gateway is the Gateway the HTTPRoute attaches to. Whether it accepts routes from the
+	// App's namespace is up to its listeners' allowedRoutes.
+	// +optional
+	Gateway *GatewayReference `json:"gateway,omitempty"`
+}
+
+// GatewayReference identifies a Gateway API Gateway, or one of its listeners.
+type GatewayReference struct {
+	// Name of the Gateway.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Namespace of the Gateway. Defaults to the App's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// SectionName is the name of the listener to attach to. All listeners accepting the route
+	// are used when empty.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// ServiceSpec defines options of the Service exposing the App's pods.
+// +kubebuilder:validation:XValidation:rule="!has(self.ipFamilies) || size(self.ipFamilies) < 2 || self.ipFamilies[0] != self.ipFamilies[1]",message="ipFamilies must not repeat a family"
+// +kubebuilder:validation:XValidation:rule="!has(self.ipFamilies) || size(self.ipFamilies) < 2 || !has(self.ipFamilyPolicy) || self.ipFamilyPolicy != 'SingleStack'",message="a SingleStack Service takes a single IP family"
+// +kubebuilder:validation:XValidation:rule="!has(self.sessionAffinityTimeoutSeconds) || (has(self.sessionAffinity) && self.sessionAffinity == 'ClientIP')",message="sessionAffinityTimeoutSeconds requires ClientIP session affinity"
+type ServiceSpec struct {
+	// Type is ClusterIP, the default, to only expose the App inside the cluster, NodePort to
+	// also open its ports on every node, or LoadBalancer to provision an external load
+	// balancer through the cluster's cloud provider.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Annotations are added to the Service, e.g. to configure the cloud load balancer of a
+	// LoadBalancer Service. Annotations set by others are left alone.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Ports are further ports of the App, next to spec.port, exposed by the Service and the
+	// app container under their names. spec.port is named "app" alongside them.
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:XValidation:rule="self.all(p, self.exists_one(q, q.port == p.port && q.protocol == p.protocol))",message="ports must not repeat a port and protocol"
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Ports []ServicePortSpec `json:"ports,omitempty"`
+
+	// IPFamilies are the IP families of the Service's cluster IPs, primary family first,
+	// e.g. [IPv6] on an IPv6 cluster or [IPv4, IPv6] on a dual-stack one. The primary family
+	// cannot be changed once the Service exists. Defaults to the cluster's primary family.
+	// +kubebuilder:validation:MaxItems=2
+	// +kubebuilder:validation:items:Enum=IPv4;IPv6
+	// +listType=atomic
+	// +optional
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+
+	// IPFamilyPolicy is SingleStack, PreferDualStack or RequireDualStack. Defaults to
+	// SingleStack, or RequireDualStack when two IPFamilies are given.
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	// +optional
+	IPFamilyPolicy *corev1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+
+	// InternalTrafficPolicy is Cluster, the default, to route in-cluster traffic to any pod of
+	// the App, or Local to keep it on the caller's node, avoiding a network hop for
+	// latency-sensitive Apps. Traffic is dropped on nodes without a pod of the App.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	InternalTrafficPolicy *corev1.ServiceInternalTrafficPolicy `json:"internalTrafficPolicy,omitempty"`
+
+	// ExternalTrafficPolicy is Cluster, the default, or Local to only route traffic from
+	// outside the cluster to pods on the receiving node, preserving the client's source IP.
+	// It only applies to NodePort and LoadBalancer Services.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+
+	// SessionAffinity is None, the default, or ClientIP to send the connections of a client
+	// to the same pod, for Apps keeping sessions in memory.
+	// +kubebuilder:validation:Enum=None;ClientIP
+	// +optional
+	SessionAffinity corev1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+
+	// SessionAffinityTimeoutSeconds is how long a client sticks to its pod after its last
+	// connection with ClientIP session affinity. Defaults to 10800 (3 hours).
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +optional
+	SessionAffinityTimeoutSeconds *int32 `json:"sessionAffinityTimeoutSeconds,omitempty"`
+}
+
+// ServicePortSpec defines a further port of an App.
+type ServicePortSpec struct {
+	// Name names the port of the Service and of the app container, e.g. metrics.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=15
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:XValidation:rule="self != 'app'",message="the name app is taken by spec.port"
+	Name string `json:"name"`
+
+	// Port is the port of the Service.
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=65535
 	Port int32 `json:"port"`
+
+	// TargetPort is the port the application listens on. Defaults to Port.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	TargetPort int32 `json:"targetPort,omitempty"`
+
+	// Protocol is TCP, UDP or SCTP.
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	// +kubebuilder:default=TCP
+	// +optional
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+
+	// AppProtocol is the application protocol served on the port, e.g. http or grpc, for
+	// meshes and load balancers.
+	// +optional
+	AppProtocol *string `json:"appProtocol,omitempty"`
+}
+
+// NetworkPolicySpec defines the traffic allowed to an App's pods.
+type NetworkPolicySpec struct {
+	// FromNamespaces selects the namespaces whose pods may call the App, e.g. that of an
+	// Ingress controller. An empty selector selects all namespaces. Defaults to the App's
+	// own namespace.
+	// +optional
+	FromNamespaces *metav1.LabelSelector `json:"fromNamespaces,omitempty"`
+}
+
+// ServiceAccountSpec defines the ServiceAccount the controller creates for an App.
+type ServiceAccountSpec struct {
+	// Annotations are added to the ServiceAccount, e.g. eks.amazonaws.com/role-arn to assume
+	// an IAM role with IRSA through the EKS pod identity webhook. The annotations of
+	// spec.cloudIdentity take precedence.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// CloudIdentitySpec defines the cloud identities of an App's pods, one per cloud at most.
+// +kubebuilder:validation:MinProperties=1
+type CloudIdentitySpec struct {
+	// AWS assumes an IAM role with IAM Roles for Service Accounts (IRSA).
+	// +optional
+	AWS *AWSIdentity `json:"aws,omitempty"`
+
+	// GCP impersonates a Google service account with GKE Workload Identity.
+	// +optional
+	GCP *GCPIdentity `json:"gcp,omitempty"`
+
+	// Azure signs in as a Microsoft Entra application with Azure Workload Identity.
+	// +optional
+	Azure *AzureIdentity `json:"azure,omitempty"`
+}
+
+// AWSIdentity identifies an AWS IAM role.
+type AWSIdentity struct {
+	// RoleARN is the ARN of the IAM role, whose trust policy must allow the App's ServiceAccount.
+	// +kubebuilder:validation:Pattern=`^arn:aws[a-z-]*:iam::[0-9]{12}:role/.+$`
+	RoleARN string `json:"roleARN"`
+}
+
+// GCPIdentity identifies a Google service account.
+type GCPIdentity struct {
+	// ServiceAccount is the email of the Google service account, which must grant
+	// roles/iam.workloadIdentityUser to the App's ServiceAccount.
+	// +kubebuilder:validation:Pattern=`^[^@]+@[^@]+\.iam\.gserviceaccount\.com$`
+	ServiceAccount string `json:"serviceAccount"`
+}
+
+// AzureIdentity identifies a Microsoft Entra application or user-assigned managed identity.
+type AzureIdentity struct {
+	// ClientID is the client ID of the application or managed identity, which must have a
+	// federated credential for the App's ServiceAccount.
+	// +kubebuilder:validation:MinLength=1
+	ClientID string `json:"clientID"`
+
+	// TenantID is the tenant of the identity. Defaults to the tenant configured for the
+	// Azure Workload Identity webhook.
+	// +optional
+	TenantID string `json:"tenantID,omitempty"`
+}
+
+// RightSizingSpec defines how resource recommendations are made and used.
+type RightSizingSpec struct {
+	// Window is how far back usage is taken into account. Defaults to 24h.
+	// +kubebuilder:default="24h"
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+
+	// AutoApply sets the recommended requests and limits on the app container, rolling the
+	// pods out whenever the recommendation changes by more than a tenth.
+	// +optional
+	AutoApply bool `json:"autoApply,omitempty"`
+}
+
+// AcceleratorSpec defines the extended resources of an App's pods and the nodes providing them.
+type AcceleratorSpec struct {
+	// Resources maps extended resources to the amount each pod requests, e.g. nvidia.com/gpu: 1.
+	// The amount is set as both request and limit of the app container.
+	// +kubebuilder:validation:MinProperties=1
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k.contains('/') && !k.startsWith('kubernetes.io/') && !k.startsWith('requests.'))",message="resources must be extended resources, e.g. nvidia.com/gpu"
+	Resources map[corev1.ResourceName]resource.Quantity `json:"resources"`
+
+	// RuntimeClassName is the RuntimeClass running the pods, e.g. nvidia.
+	// +optional
+	RuntimeClassName *string `json:"runtimeClassName,omitempty"`
+
+	// NodeSelector restricts the pods to nodes with these labels, e.g. a GPU product label.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations let the pods onto nodes tainted for accelerator workloads.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// WorkloadType is the kind of workload running the App's pods.
+// +kubebuilder:validation:Enum=Deployment;Knative
+type WorkloadType string
+
+const (
+	// WorkloadTypeDeployment runs the pods with a Deployment behind a Service.
+	WorkloadTypeDeployment WorkloadType = "Deployment"
+	// WorkloadTypeKnative runs the pods with a Knative Serving Service.
+	WorkloadTypeKnative WorkloadType = "Knative"
+)
+
+// KnativeSpec defines the autoscaling of a Knative workload.
+type KnativeSpec struct {
+	// MinScale is the number of pods kept while the App receives no requests. Defaults to 0,
+	// scaling the App to zero.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinScale *int32 `json:"minScale,omitempty"`
+
+	// ContainerConcurrency is the maximum number of requests a pod serves at once.
+	// Defaults to 0, which leaves concurrency unbounded.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	ContainerConcurrency *int64 `json:"containerConcurrency,omitempty"`
+
+	// Target is the number of concurrent requests per pod the autoscaler aims for.
+	// The Knative default applies when unset.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Target *int32 `json:"target,omitempty"`
+}
+
+// ScalingSpec defines how the App's Deployment scales with HTTP traffic.
+// +kubebuilder:validation:XValidation:rule="!self.scaleToZero || has(self.hosts)",message="hosts are required with scaleToZero"
+type ScalingSpec struct {
+	// ScaleToZero scales the Deployment between zero and spec.replicas pods with the KEDA HTTP
+	// add-on. Requests for Hosts must be routed to the add-on's interceptor, which holds them
+	// while the App starts from zero and counts them to size the Deployment.
+	// +optional
+	ScaleToZero bool `json:"scaleToZero,omitempty"`
+
+	// Hosts are the HTTP hosts the interceptor routes to the App.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=set
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// ScaledownPeriod is how long the App must receive no requests, in seconds, before it is
+	// scaled to zero. Defaults to 300.
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ScaledownPeriod *int32 `json:"scaledownPeriod,omitempty"`
+}
+
+// AutoscalingSpec defines the HorizontalPodAutoscaler of an App.
+// +kubebuilder:validation:XValidation:rule="!has(self.minReplicas) || self.minReplicas <= self.maxReplicas",message="minReplicas must not exceed maxReplicas"
+type AutoscalingSpec struct {
+	// MinReplicas is the lowest number of pods. Defaults to 1.
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the highest number of pods.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilizationPercentage is the average CPU usage of the app container, relative
+	// to its CPU request, the autoscaler keeps pods at. Defaults to 80 when no other metric is set.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// TargetMemoryUtilizationPercentage is the average memory usage of the app container,
+	// relative to its memory request, the autoscaler keeps pods at.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// Metrics are additional metrics to scale on, e.g. Pods or External metrics served by a
+	// custom metrics adapter.
+	// +optional
+	Metrics []autoscalingv2.MetricSpec `json:"metrics,omitempty"`
+}
+
+// HighAvailabilitySpec defines the disruption budget and spread of an App's pods. The pods
+// of each revision are spread across zones and nodes with topology spread constraints, and
+// get a preferred pod anti-affinity on kubernetes.io/hostname, merged with the node affinity
+// of spec.platforms.
+// +kubebuilder:validation:XValidation:rule="!(has(self.minAvailable) && has(self.maxUnavailable))",message="minAvailable and maxUnavailable are mutually exclusive"
+type HighAvailabilitySpec struct {
+	// MinAvailable is the number or percentage of the App's pods that must stay available
+	// during voluntary disruptions.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	// MaxUnavailable is the number or percentage of the App's pods that may be unavailable
+	// during voluntary disruptions. Defaults to 1 when MinAvailable is unset.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// WhenUnsatisfiable is ScheduleAnyway to prefer spreading the pods evenly across zones
+	// and nodes, or DoNotSchedule to leave pods pending rather than skew them. Defaults to
+	// ScheduleAnyway.
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +kubebuilder:default=ScheduleAnyway
+	// +optional
+	WhenUnsatisfiable corev1.UnsatisfiableConstraintAction `json:"whenUnsatisfiable,omitempty"`
+}
+
+// AutoscalingStatus is the state of an App's HorizontalPodAutoscaler.
+type AutoscalingStatus struct {
+	// CurrentReplicas is the number of pods the autoscaler last saw.
+	CurrentReplicas int32 `json:"currentReplicas"`
+	// DesiredReplicas is the number of pods the autoscaler last asked for.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	// LastScaleTime is when the autoscaler last changed the number of pods.
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+}
+
+// StrategyType is a way of rolling out a new image.
+// +kubebuilder:validation:Enum=RollingUpdate;BlueGreen;Canary
+type StrategyType string
+
+const (
+	// RollingUpdateStrategy replaces the App's pods through its Deployment's rolling update.
+	RollingUpdateStrategy StrategyType = "RollingUpdate"
+	// BlueGreenStrategy runs the new image in a preview Deployment at full size, and switches
+	// the App's Service to it once promoted.
+	BlueGreenStrategy StrategyType = "BlueGreen"
+	// CanaryStrategy moves a growing share of the App's replicas to the new image, following
+	// the steps of spec.strategy.canary.
+	CanaryStrategy StrategyType = "Canary"
+)
+
+// StrategySpec defines how an App rolls out a new image. Other changes to the pod template
+// roll out to all the App's pods at once.
+// +kubebuilder:validation:XValidation:rule="self.type == 'Canary' || !has(self.canary)",message="canary requires the Canary strategy"
+type StrategySpec struct {
+	// Type is the rollout strategy.
+	// +kubebuilder:default=RollingUpdate
+	// +optional
+	Type StrategyType `json:"type,omitempty"`
+	// Canary lists the steps of the Canary strategy. Without steps, the canary runs a single
+	// replica until promoted.
+	// +optional
+	Canary *CanaryStrategySpec `json:"canary,omitempty"`
+}
+
+// CanaryStrategySpec defines the steps of a canary rollout.
+type CanaryStrategySpec struct {
+	// Steps are applied in order. The new image is promoted to the stable Deployment after the last one.
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=atomic
+	// +optional
+	Steps []CanaryStep `json:"steps,omitempty"`
+}
+
+// CanaryStep is a stage of a canary rollout.
+type CanaryStep struct {
+	// Weight is the percentage of the App's replicas running the new image, rounded up to a
+	// whole pod. The Service balances requests across all pods, so it is also the share of traffic.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+	// Pause is how long the step lasts once the canary pods are available. The step waits for
+	// the webapp.example.com/rollout=promote annotation when unset.
+	// +optional
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+// RolloutPhase is the phase of a canary or blue/green rollout.
+// +kubebuilder:validation:Enum=Progressing;Paused;Promoting;Aborted
+type RolloutPhase string
+
+const (
+	// RolloutPhaseProgressing means the new image's pods are starting, or a step's pause is running.
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// RolloutPhasePaused means the rollout waits for the webapp.example.com/rollout=promote annotation.
+	RolloutPhasePaused RolloutPhase = "Paused"
+	// RolloutPhasePromoting means the stable Deployment is rolling out the new image.
+	RolloutPhasePromoting RolloutPhase = "Promoting"
+	// RolloutPhaseAborted means the new image was scaled down. Another image restarts the rollout.
+	RolloutPhaseAborted RolloutPhase = "Aborted"
+)
+
+// RolloutStatus is the state of a canary or blue/green rollout.
+type RolloutStatus struct {
+	// Image is the image being rolled out.
+	Image string `json:"image"`
+	// StableImage is the image the stable Deployment ran when the rollout started.
+	// +optional
+	StableImage string `json:"stableImage,omitempty"`
+	// Phase is the phase of the rollout.
+	Phase RolloutPhase `json:"phase"`
+	// Step is the index of the current canary step.
+	// +optional
+	Step int32 `json:"step,omitempty"`
+	// Weight is the percentage of the App's replicas the current canary step runs the new image on.
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
+	// Replicas is the number of pods the canary or preview Deployment runs.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// StepStartedAt is when the pause of the current step started.
+	// +optional
+	StepStartedAt *metav1.Time `json:"stepStartedAt,omitempty"`
+	// Message explains the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Severity is the severity of a vulnerability.
+// +kubebuilder:validation:Enum=Critical;High;Medium;Low
+type Severity string
+
+// ImageScanAction is what happens to an image violating the scan policy.
+// +kubebuilder:validation:Enum=Block;Warn
+type ImageScanAction string
+
+const (
+	// ImageScanActionBlock keeps the previously rolled out image.
+	ImageScanActionBlock ImageScanAction = "Block"
+	// ImageScanActionWarn rolls the image out anyway and only records the violation.
+	ImageScanActionWarn ImageScanAction = "Warn"
+)
+
+// ImageScanPolicy defines the vulnerability policy new images are checked against.
+type ImageScanPolicy struct {
+	// Severity is the lowest vulnerability severity violating the policy. Defaults to Critical.
+	// +kubebuilder:default=Critical
+	// +optional
+	Severity Severity `json:"severity,omitempty"`
+
+	// Action is taken when an image violates the policy. Defaults to Block.
+	// +kubebuilder:default=Block
+	// +optional
+	Action ImageScanAction `json:"action,omitempty"`
+}
+
+// ProvenancePolicy defines the SLSA provenance new images must be attested with.
+// Images without a verified attestation, or whose attestation does not match, are not rolled out.
+type ProvenancePolicy struct {
+	// BuilderID is the identity of the trusted builder,
+	// e.g. https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v2.0.0.
+	// +kubebuilder:validation:MinLength=1
+	BuilderID string `json:"builderID"`
+
+	// SourceRepository is the repository images must be built from, e.g. https://github.com/acme/shop.
+	// Any repository is accepted when empty.
+	// +optional
+	SourceRepository string `json:"sourceRepository,omitempty"`
+}
+
+// MeshProvider is a supported service mesh.
+// +kubebuilder:validation:Enum=Istio;Linkerd
+type MeshProvider string
+
+const (
+	// MeshProviderIstio injects the Istio sidecar.
+	MeshProviderIstio MeshProvider = "Istio"
+	// MeshProviderLinkerd injects the Linkerd proxy.
+	MeshProviderLinkerd MeshProvider = "Linkerd"
+)
+
+// MeshMTLSMode is the mutual TLS mode requested for traffic to the App.
+// +kubebuilder:validation:Enum=Strict;Permissive
+type MeshMTLSMode string
+
+const (
+	// MeshMTLSModeStrict only accepts mutual TLS traffic.
+	MeshMTLSModeStrict MeshMTLSMode = "Strict"
+	// MeshMTLSModePermissive also accepts plaintext traffic, e.g. while clients are onboarded.
+	MeshMTLSModePermissive MeshMTLSMode = "Permissive"
+)
+
+// MeshSpec defines how the App's pods take part in a service mesh.
+type MeshSpec struct {
+	// Provider is the service mesh the pods are enrolled in.
+	Provider MeshProvider `json:"provider"`
+
+	// Enabled controls sidecar injection. Setting it to false opts the pods out of
+	// injection, even in namespaces enrolled as a whole. Defaults to true.
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// MTLSMode requests a mutual TLS mode for traffic to the App: an Istio PeerAuthentication,
+	// or the default inbound policy of the Linkerd proxy. The mesh default applies when empty.
+	// +optional
+	MTLSMode MeshMTLSMode `json:"mtlsMode,omitempty"`
+}
+
+// SecuritySpec defines the pod and container security settings of an App.
+type SecuritySpec struct {
+	// SeccompProfile is the seccomp profile applied to the whole pod.
+	// Defaults to RuntimeDefault.
+	// +kubebuilder:default={type: RuntimeDefault}
+	// +optional
+	SeccompProfile *SeccompProfile `json:"seccompProfile,omitempty"`
+
+	// ContainerSeccompProfile overrides the pod-level seccomp profile for the app container.
+	// +optional
+	ContainerSeccompProfile *SeccompProfile `json:"containerSeccompProfile,omitempty"`
+
+	// AppArmorProfile is the AppArmor profile applied to the pod.
+	// On clusters older than Kubernetes 1.30 it is set through the beta pod annotation instead.
+	// +optional
+	AppArmorProfile *AppArmorProfile `json:"appArmorProfile,omitempty"`
+}
+
+// SeccompProfile selects the seccomp profile used by a pod or container.
+// +kubebuilder:validation:XValidation:rule="self.type == 'Localhost' ? has(self.localhostProfile) : !has(self.localhostProfile)",message="localhostProfile must be set if and only if type is Localhost"
+type SeccompProfile struct {
+	// Type is the kind of seccomp profile to apply.
+	// +kubebuilder:validation:Enum=RuntimeDefault;Localhost
+	Type corev1.SeccompProfileType `json:"type"`
+
+	// LocalhostProfile is the path of a profile preconfigured on the node,
+	// relative to the kubelet's configured seccomp profile location.
+	// +optional
+	LocalhostProfile *string `json:"localhostProfile,omitempty"`
+}
+
+// AppArmorProfile selects the AppArmor profile used by a pod.
+// +kubebuilder:validation:XValidation:rule="self.type == 'Localhost' ? has(self.localhostProfile) : !has(self.localhostProfile)",message="localhostProfile must be set if and only if type is Localhost"
+type AppArmorProfile struct {
+	// Type is the kind of AppArmor profile to apply.
+	// +kubebuilder:validation:Enum=RuntimeDefault;Localhost
+	Type corev1.AppArmorProfileType `json:"type"`
+
+	// LocalhostProfile is the name of a profile loaded on the node.
+	// +optional
+	LocalhostProfile *string `json:"localhostProfile,omitempty"`
+}
+
+// TLSSpec defines the serving certificate of an App.
+type TLSSpec struct {
+	// IssuerRef references the cert-manager issuer signing the serving certificate.
+	IssuerRef IssuerReference `json:"issuerRef"`
+
+	// MountPath is the directory the certificate (tls.crt, tls.key and ca.crt) is mounted at.
+	// +kubebuilder:default="/etc/tls"
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// IssuerReference references a cert-manager Issuer or ClusterIssuer.
+type IssuerReference struct {
+	// Name of the issuer. An Issuer must be in the App's namespace.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Name string `json:"name"`
+
+	// Kind of the issuer. Defaults to Issuer.
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +kubebuilder:default=Issuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+}
+
+// TargetCluster selects the cluster an App is delivered to, by its kubeconfig.
+// +kubebuilder:validation:XValidation:rule="has(self.kubeconfigSecretRef) != has(self.clusterName)",message="exactly one of kubeconfigSecretRef and clusterName is required"
+type TargetCluster struct {
+	// KubeconfigSecretRef references a Secret in the App's namespace holding the kubeconfig
+	// of the target cluster. Credentials must be embedded: exec plugins, auth providers and
+	// file references are refused.
+	// +optional
+	KubeconfigSecretRef *KubeconfigSecretReference `json:"kubeconfigSecretRef,omitempty"`
+
+	// ClusterName names a Cluster API Cluster in the App's namespace. The kubeconfig is read
+	// from the <clusterName>-kubeconfig Secret Cluster API maintains for it.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+}
+
+// KubeconfigSecretReference references a kubeconfig stored in a Secret.
+type KubeconfigSecretReference struct {
+	// Name of the Secret.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Name string `json:"name"`
+
+	// Key of the kubeconfig in the Secret. Defaults to value, as used by Cluster API.
+	// +kubebuilder:default=value
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// ConfigSpec defines the configuration files of an App.
+type ConfigSpec struct {
+	// Data holds configuration files keyed by file name. A value may be a complete
+	// SOPS-encrypted YAML or JSON document; the controller decrypts it and only the
+	// generated in-cluster ConfigMap holds the plaintext.
+	// +kubebuilder:validation:XValidation:rule="self.all(k, k.matches('^[-._a-zA-Z0-9]+$'))",message="keys must be valid ConfigMap keys"
+	// +optional
+	Data map[string]string `json:"data,omitempty"`
+
+	// ConfigMapRef names a ConfigMap in the App's namespace whose entries are rendered
+	// together with Data, which wins on conflicting keys. Its entries may be SOPS-encrypted too.
+	// +kubebuilder:validation:XValidation:rule="self.name.matches('^[a-z0-9]([-a-z0-9]*[a-z0-9])?([.][a-z0-9]([-a-z0-9]*[a-z0-9])?)*$')",message="configMapRef.name must be the name of a ConfigMap in the App's namespace"
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// MountPath is the directory the configuration files are mounted at.
+	// +kubebuilder:default="/etc/app"
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// ConfigSource defines where configuration files are pulled from.
+type ConfigSource struct {
+	// Git pulls the files of a directory of a Git repository.
+	Git *GitConfigSource `json:"git"`
+}
+
+// GitConfigSource selects a directory of a Git repository. The regular files at the top of
+// the directory are rendered into the App's ConfigMap, and the App's pods are rolled out
+// again whenever a new commit changes the ref.
+type GitConfigSource struct {
+	// URL of the repository, served over HTTP(S) with Git protocol version 2.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// Ref is a branch, a tag or a full commit ID. The repository's default branch is used when empty.
+	// +optional
+	Ref string `json:"ref,omitempty"`
+
+	// Path is the directory holding the configuration files, relative to the root of the
+	// repository. Defaults to the root.
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// SecretRef names a Secret in the App's namespace holding the credentials of the
+	// repository: username and password, or bearerToken.
+	// +kubebuilder:validation:XValidation:rule="self.name.matches('^[a-z0-9]([-a-z0-9]*[a-z0-9])?([.][a-z0-9]([-a-z0-9]*[a-z0-9])?)*$')",message="secretRef.name must be the name of a Secret in the App's namespace"
+	// +optional
+	SecretRef *corev1.LocalObjectReference `json:"secretRef,omitempty"`
+
+	// Interval is how often the ref is checked for new commits. Defaults to 1m.
+	// +kubebuilder:default="1m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// ImageUpdatePolicy selects whether the controller follows the tag of an App's image.
+// +kubebuilder:validation:Enum=Pinned;TrackTag
+type ImageUpdatePolicy string
+
+const (
+	// ImageUpdatePinned resolves the tag once, when the image is set.
+	ImageUpdatePinned ImageUpdatePolicy = "Pinned"
+	// ImageUpdateTrackTag resolves the tag again every interval, rolling out the image it
+	// points to whenever it moves.
+	ImageUpdateTrackTag ImageUpdatePolicy = "TrackTag"
+)
+
+// ImageUpdateSpec defines how the tag of an App's image is resolved to a digest.
+type ImageUpdateSpec struct {
+	// Policy is Pinned or TrackTag. Defaults to Pinned.
+	// +kubebuilder:default=Pinned
+	// +optional
+	Policy ImageUpdatePolicy `json:"policy,omitempty"`
+
+	// Interval is how often a tracked tag is resolved again. Defaults to 5m.
+	// +kubebuilder:default="5m"
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// VaultMode selects how Vault secrets are delivered to the pods.
+// +kubebuilder:validation:Enum=AgentInjector;CSI
+type VaultMode string
+
+const (
+	// VaultModeAgentInjector renders secrets with the Vault Agent sidecar injector.
+	VaultModeAgentInjector VaultMode = "AgentInjector"
+	// VaultModeCSI mounts secrets through the Secrets Store CSI driver and its Vault provider.
+	VaultModeCSI VaultMode = "CSI"
+)
+
+// VaultSpec defines the Vault secrets consumed by an App.
+// +kubebuilder:validation:XValidation:rule="self.mode != 'CSI' || has(self.address)",message="address is required in CSI mode"
+type VaultSpec struct {
+	// Mode selects the delivery mechanism. Defaults to AgentInjector.
+	// +kubebuilder:default=AgentInjector
+	// +optional
+	Mode VaultMode `json:"mode,omitempty"`
+
+	// Role is the Vault Kubernetes auth role the pods authenticate as.
+	// +kubebuilder:validation:MinLength=1
+	Role string `json:"role"`
+
+	// Address is the Vault server URL. Required in CSI mode; the agent injector
+	// uses its own configured address.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Secrets lists the secrets rendered into files under /vault/secrets.
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Secrets []VaultSecret `json:"secrets"`
+}
+
+// VaultSecret is a single Vault secret rendered into a file.
+type VaultSecret struct {
+	// Name is the file name the secret is rendered to.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`
+	Name string `json:"name"`
+
+	// Path is the Vault path of the secret, e.g. "secret/data/myapp/db".
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// Key selects a single key of the secret. Only used in CSI mode.
+	// +optional
+	Key string `json:"key,omitempty"`
+
+	// Template is a Vault Agent template used to render the secret.
+	// Only used in AgentInjector mode.
+	// +optional
+	Template string `json:"template,omitempty"`
 }
 
 // AppStatus defines the observed state of App.
@@ -48,12 +1328,202 @@ type AppStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
 	// Replicas is the number of actual pods running for this App.
 	Replicas int32 `json:"replicas"`
+	// URL is the address the App is served at, with the Knative workload type or
+	// spec.expose, once its Ingress or HTTPRoute is admitted.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// ObservedGeneration is the generation of the spec the controller last applied. Together
+	// with the Ready, Reconciling and Stalled conditions it follows kstatus conventions, so
+	// GitOps tools can tell when a change is rolled out.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// Conditions represent the latest available observations of an object's state
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ImageScan is the result of the vulnerability scan of the latest image.
+	// +optional
+	ImageScan *ImageScanStatus `json:"imageScan,omitempty"`
+	// Provenance is the result of the provenance verification of the latest image.
+	// +optional
+	Provenance *ProvenanceStatus `json:"provenance,omitempty"`
+	// ConfigFrom records the configuration last pulled for spec.configFrom.
+	// +optional
+	ConfigFrom *ConfigSourceStatus `json:"configFrom,omitempty"`
+	// Platforms records the architectures of the latest image, for spec.platforms.
+	// +optional
+	Platforms *PlatformsStatus `json:"platforms,omitempty"`
+	// ImageUpdate records the digest the tag of spec.image was last resolved to, for
+	// spec.imageUpdate.
+	// +optional
+	ImageUpdate *ImageUpdateStatus `json:"imageUpdate,omitempty"`
+	// Recommendations are the resources recommended for the App's pods, for spec.rightSizing.
+	// +optional
+	Recommendations *ResourceRecommendations `json:"recommendations,omitempty"`
+	// EnvHash is a hash of the ConfigMap and Secret values spec.env and spec.envFrom
+	// reference. It is recorded on the pod template, so the pods roll out when it changes.
+	// +optional
+	EnvHash string `json:"envHash,omitempty"`
+	// Autoscaling is the state of the App's HorizontalPodAutoscaler, for spec.autoscaling.
+	// +optional
+	Autoscaling *AutoscalingStatus `json:"autoscaling,omitempty"`
+	// Debug records the debug session requested through the webapp.example.com/debug annotation.
+	// +optional
+	Debug *DebugStatus `json:"debug,omitempty"`
+	// Rollout is the state of the canary or blue/green rollout of a new image, for spec.strategy.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+	// Storage is the state of the App's PersistentVolumeClaim, for spec.storage.
+	// +optional
+	Storage *StorageStatus `json:"storage,omitempty"`
+}
+
+// StorageStatus is the state of an App's PersistentVolumeClaim.
+type StorageStatus struct {
+	// ClaimName is the name of the PersistentVolumeClaim.
+	ClaimName string `json:"claimName"`
+	// Phase is the phase of the claim: Pending until a volume is bound to it, then Bound.
+	// +optional
+	Phase corev1.PersistentVolumeClaimPhase `json:"phase,omitempty"`
+	// Capacity is the capacity of the bound volume.
+	// +optional
+	Capacity *resource.Quantity `json:"capacity,omitempty"`
+}
+
+// DebugPhase is the lifecycle phase of a debug session.
+// +kubebuilder:validation:Enum=Waiting;Running;Terminated;Expired;Failed
+type DebugPhase string
+
+const (
+	// DebugPhaseWaiting means the debug container was added and has not started yet.
+	DebugPhaseWaiting DebugPhase = "Waiting"
+	// DebugPhaseRunning means the debug container is running.
+	DebugPhaseRunning DebugPhase = "Running"
+	// DebugPhaseTerminated means the debug container exited before its TTL.
+	DebugPhaseTerminated DebugPhase = "Terminated"
+	// DebugPhaseExpired means the TTL passed and the debugged pod was deleted.
+	DebugPhaseExpired DebugPhase = "Expired"
+	// DebugPhaseFailed means the debug container could not be added.
+	DebugPhaseFailed DebugPhase = "Failed"
+)
+
+// DebugStatus records an ephemeral debug container attached to one of the App's pods.
+type DebugStatus struct {
+	// Pod is the name of the debugged pod.
+	Pod string `json:"pod"`
+	// Container is the name of the ephemeral debug container, for kubectl attach.
+	// +optional
+	Container string `json:"container,omitempty"`
+	// Image is the image the debug container runs.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Phase is the lifecycle phase of the session.
+	Phase DebugPhase `json:"phase"`
+	// StartedAt is when the debug container was added.
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// ExpiresAt is when the debugged pod is deleted.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// Message explains the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ResourceRecommendations are the requests and limits recommended from a pod's usage.
+type ResourceRecommendations struct {
+	// Requests are the recommended CPU and memory requests of the app container: the 90th
+	// percentile of its CPU usage and its peak memory usage over the window, plus a margin.
+	// +optional
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	// Limits is the recommended memory limit of the app container.
+	// +optional
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+	// Samples is the number of usage samples the recommendation is based on.
+	Samples int32 `json:"samples"`
+	// UpdatedAt is when the recommendation last changed.
+	// +optional
+	UpdatedAt metav1.Time `json:"updatedAt,omitempty"`
+	// Message explains why there is no recommendation yet.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PlatformsStatus records the architectures an image is built for.
+type PlatformsStatus struct {
+	// Image is the inspected image reference.
+	Image string `json:"image"`
+	// InspectedAt is when the image's manifest was read.
+	InspectedAt metav1.Time `json:"inspectedAt"`
+	// Architectures are the CPU architectures the image runs on, for the App's OS.
+	// +optional
+	Architectures []string `json:"architectures,omitempty"`
+}
+
+// ImageUpdateStatus records the digest an image's tag was resolved to.
+type ImageUpdateStatus struct {
+	// Image is the resolved image reference, as in spec.image.
+	Image string `json:"image"`
+	// Digest is the digest the tag pointed to when last checked. The App's pods run the
+	// image pinned to it.
+	Digest string `json:"digest"`
+	// CheckedAt is when the tag was last resolved.
+	CheckedAt metav1.Time `json:"checkedAt"`
+}
+
+// ConfigSourceStatus records the configuration pulled from an external source.
+type ConfigSourceStatus struct {
+	// Commit is the ID of the Git commit the configuration files were read from.
+	Commit string `json:"commit"`
+	// FetchedAt is when Commit was first fetched.
+	FetchedAt metav1.Time `json:"fetchedAt"`
+}
+
+// ProvenanceStatus records the provenance verification of an image.
+type ProvenanceStatus struct {
+	// Image is the verified image reference.
+	Image string `json:"image"`
+	// VerifiedAt is when the verification completed.
+	VerifiedAt metav1.Time `json:"verifiedAt"`
+	// BuilderID is the builder identity attested for the image.
+	// +optional
+	BuilderID string `json:"builderID,omitempty"`
+	// SourceRepository is the source repository attested for the image.
+	// +optional
+	SourceRepository string `json:"sourceRepository,omitempty"`
+	// Verified is true when the image's attestation satisfies the provenance policies of the
+	// App and of the AppPolicies applying to it.
+	Verified bool `json:"verified"`
+	// Message explains why verification failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ImageScanStatus records the vulnerability scan of an image.
+type ImageScanStatus struct {
+	// Image is the scanned image reference.
+	Image string `json:"image"`
+	// ScannedAt is when the scan completed.
+	ScannedAt metav1.Time `json:"scannedAt"`
+	// Critical is the number of critical vulnerabilities found.
+	Critical int32 `json:"critical"`
+	// High is the number of high severity vulnerabilities found.
+	High int32 `json:"high"`
+	// Medium is the number of medium severity vulnerabilities found.
+	Medium int32 `json:"medium"`
+	// Low is the number of low severity vulnerabilities found.
+	Low int32 `json:"low"`
+	// Violation is true when the image violates the App's scan policy.
+	Violation bool `json:"violation"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
+// +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.status.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+// +kubebuilder:printcolumn:name="Suspended",type=boolean,JSONPath=`.spec.suspend`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // App is the Schema for the apps API
 type App struct {
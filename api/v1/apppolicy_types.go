@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AppPolicySpec defines the rules the Apps of the selected namespaces must follow.
+type AppPolicySpec struct {
+	// NamespaceSelector selects the namespaces whose Apps the policy applies to. The policy
+	// applies to every namespace when it is unset.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Rules are the CEL expressions an App must satisfy to be created or updated.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	// +kubebuilder:validation:MaxItems=64
+	Rules []AppPolicyRule `json:"rules,omitempty"`
+
+	// ReferenceGrants allow the Apps of the selected namespaces to reference objects outside
+	// their namespace. Without a grant, an App can only reference objects of its own
+	// namespace.
+	// +optional
+	// +kubebuilder:validation:MaxItems=64
+	ReferenceGrants []AppPolicyReferenceGrant `json:"referenceGrants,omitempty"`
+
+	// Provenance gates the rollout of new images of the selected Apps on a verified SLSA
+	// provenance attestation, in addition to the App's own spec.provenance: an image must
+	// satisfy every policy applying to it.
+	// +optional
+	Provenance *ProvenancePolicy `json:"provenance,omitempty"`
+
+	// ValidatingAdmissionPolicy compiles the rules into a ValidatingAdmissionPolicy and a
+	// ValidatingAdmissionPolicyBinding named after the AppPolicy, so the API server enforces
+	// them even while the operator's webhook is unavailable. The webhook enforces the rules
+	// either way.
+	// +optional
+	ValidatingAdmissionPolicy bool `json:"validatingAdmissionPolicy,omitempty"`
+}
+
+// AppPolicyRule is a CEL expression Apps must satisfy.
+type AppPolicyRule struct {
+	// Name identifies the rule within the policy.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// Expression is evaluated with object, the v1 App, and oldObject, its previous version on
+	// updates and null on creation, and must return true for the App to be admitted. It has
+	// the CEL libraries of ValidatingAdmissionPolicy expressions.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=4096
+	Expression string `json:"expression"`
+
+	// Message is the reason given when the expression returns false. It defaults to one
+	// naming the policy and the rule.
+	// +optional
+	// +kubebuilder:validation:MaxLength=1024
+	Message string `json:"message,omitempty"`
+}
+
+// Kinds of objects outside an App's namespace that an AppPolicy grants references to.
+const (
+	// ReferenceKindClusterIssuer is a cert-manager ClusterIssuer, in spec.tls.issuerRef.
+	ReferenceKindClusterIssuer = "ClusterIssuer"
+	// ReferenceKindGateway is a Gateway API Gateway of another namespace, in spec.expose.gateway.
+	ReferenceKindGateway = "Gateway"
+)
+
+// AppPolicyReferenceGrant allows Apps to reference objects outside their namespace, in the
+// manner of a Gateway API ReferenceGrant.
+// +kubebuilder:validation:XValidation:rule="self.kind == 'Gateway' ? has(self.__namespace__) : !has(self.__namespace__)",message="namespace must be set if and only if kind is Gateway"
+type AppPolicyReferenceGrant struct {
+	// Kind of the objects: ClusterIssuer, a cluster-scoped cert-manager issuer, or Gateway,
+	// a Gateway of another namespace.
+	// +kubebuilder:validation:Enum=ClusterIssuer;Gateway
+	Kind string `json:"kind"`
+
+	// Namespace of the Gateways.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the object. Every object of the kind, in the namespace for Gateways, is
+	// granted when empty.
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// AppPolicyStatus defines the observed state of AppPolicy.
+type AppPolicyStatus struct {
+	// ObservedGeneration is the generation of the spec the controller last processed.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions represent the latest available observations of the policy's state. Ready is
+	// False while a rule or the namespace selector is invalid, or the
+	// ValidatingAdmissionPolicy can't be written.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Admission Policy",type=boolean,JSONPath=`.spec.validatingAdmissionPolicy`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// AppPolicy is the Schema for the apppolicies API. Cluster administrators use it to restrict
+// the Apps of selected namespaces beyond what the App schema allows.
+type AppPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the rules of the AppPolicy
+	// +required
+	Spec AppPolicySpec `json:"spec"`
+
+	// status defines the observed state of AppPolicy
+	// +optional
+	Status AppPolicyStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// AppPolicyList contains a list of AppPolicy
+type AppPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AppPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AppPolicy{}, &AppPolicyList{})
+}
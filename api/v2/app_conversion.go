@@ -0,0 +1,326 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// defaultContainerName is the name of the app container of a v1 App without
+// spec.containerName.
+const defaultContainerName = "app-container"
+
+// ConversionDataAnnotation holds, on a v1 App converted from v2, what v1 can't express of
+// the v2 app container's ports and spec.service: the name, protocol and Service port of the
+// first port, unnamed further ports, and an empty spec.service. ConvertFrom restores them
+// while the v1 fields they map to are unchanged.
+const ConversionDataAnnotation = "webapp.example.com/v2-conversion-data"
+
+// conversionData is the content of ConversionDataAnnotation.
+type conversionData struct {
+	// Ports are the ports of the app container.
+	Ports []webappv1.ContainerPort `json:"ports,omitempty"`
+	// Service is spec.service.
+	Service *ServiceSpec `json:"service,omitempty"`
+}
+
+// ConvertTo converts the App to v1. Its first container becomes the v1 app container, whose
+// first port is spec.port and whose further ports are spec.service.ports; its other
+// containers become spec.containers. What v1 can't express of the ports and Service is kept
+// in ConversionDataAnnotation.
+func (src *App) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*webappv1.App)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Annotations = withoutAnnotation(src.Annotations, ConversionDataAnnotation)
+	dst.Status = src.Status
+	dst.Spec = webappv1.AppSpec{
+		ImagePullPolicy:              src.Spec.ImagePullPolicy,
+		ImagePullSecrets:             src.Spec.ImagePullSecrets,
+		ImageUpdate:                  src.Spec.ImageUpdate,
+		Replicas:                     src.Spec.Replicas,
+		Probes:                       src.Spec.Probes,
+		Storage:                      src.Spec.Storage,
+		Expose:                       src.Spec.Expose,
+		OS:                           src.Spec.OS,
+		Platforms:                    src.Spec.Platforms,
+		RightSizing:                  src.Spec.RightSizing,
+		Accelerators:                 src.Spec.Accelerators,
+		WorkloadType:                 src.Spec.WorkloadType,
+		Knative:                      src.Spec.Knative,
+		Scaling:                      src.Spec.Scaling,
+		Autoscaling:                  src.Spec.Autoscaling,
+		HighAvailability:             src.Spec.HighAvailability,
+		Strategy:                     src.Spec.Strategy,
+		PodAnnotations:               src.Spec.PodAnnotations,
+		ImageScan:                    src.Spec.ImageScan,
+		Provenance:                   src.Spec.Provenance,
+		Mesh:                         src.Spec.Mesh,
+		ServiceAccountName:           src.Spec.ServiceAccountName,
+		AutomountServiceAccountToken: src.Spec.AutomountServiceAccountToken,
+		ServiceAccount:               src.Spec.ServiceAccount,
+		CloudIdentity:                src.Spec.CloudIdentity,
+		TLS:                          src.Spec.TLS,
+		Config:                       src.Spec.Config,
+		InitContainers:               src.Spec.InitContainers,
+		Sidecars:                     src.Spec.Sidecars,
+		ConfigFrom:                   src.Spec.ConfigFrom,
+		Vault:                        src.Spec.Vault,
+		DependsOn:                    src.Spec.DependsOn,
+		NetworkIsolation:             src.Spec.NetworkIsolation,
+		NetworkPolicy:                src.Spec.NetworkPolicy,
+		Security:                     src.Spec.Security,
+		TargetCluster:                src.Spec.TargetCluster,
+		Suspend:                      src.Spec.Suspend,
+	}
+
+	var ports []webappv1.ContainerPort
+	if len(src.Spec.Containers) > 0 {
+		app := src.Spec.Containers[0]
+		dst.Spec.Image = app.Image
+		if app.Name != defaultContainerName {
+			dst.Spec.ContainerName = app.Name
+		}
+		dst.Spec.Command = app.Command
+		dst.Spec.Args = app.Args
+		dst.Spec.Env = app.Env
+		dst.Spec.EnvFrom = app.EnvFrom
+		dst.Spec.Resources = app.Resources
+		ports = app.Ports
+		if len(src.Spec.Containers) > 1 {
+			dst.Spec.Containers = src.Spec.Containers[1:]
+		}
+	}
+	dst.Spec.Port, dst.Spec.AppProtocol, dst.Spec.Service = hubPorts(ports, src.Spec.Service)
+
+	restoredPorts, restoredService := spokePorts(dst.Spec.Port, dst.Spec.AppProtocol, dst.Spec.Service)
+	if !equality.Semantic.DeepEqual(restoredPorts, ports) || !equality.Semantic.DeepEqual(restoredService, src.Spec.Service) {
+		data, err := json.Marshal(conversionData{Ports: ports, Service: src.Spec.Service})
+		if err != nil {
+			return err
+		}
+		dst.Annotations = maps.Clone(dst.Annotations)
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[ConversionDataAnnotation] = string(data)
+	}
+	return nil
+}
+
+// ConvertFrom converts a v1 App to this version, the reverse of ConvertTo.
+func (dst *App) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*webappv1.App)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = src.Status
+	dst.Spec = AppSpec{
+		ImagePullPolicy:              src.Spec.ImagePullPolicy,
+		ImagePullSecrets:             src.Spec.ImagePullSecrets,
+		ImageUpdate:                  src.Spec.ImageUpdate,
+		Replicas:                     src.Spec.Replicas,
+		Probes:                       src.Spec.Probes,
+		Storage:                      src.Spec.Storage,
+		Expose:                       src.Spec.Expose,
+		OS:                           src.Spec.OS,
+		Platforms:                    src.Spec.Platforms,
+		RightSizing:                  src.Spec.RightSizing,
+		Accelerators:                 src.Spec.Accelerators,
+		WorkloadType:                 src.Spec.WorkloadType,
+		Knative:                      src.Spec.Knative,
+		Scaling:                      src.Spec.Scaling,
+		Autoscaling:                  src.Spec.Autoscaling,
+		HighAvailability:             src.Spec.HighAvailability,
+		Strategy:                     src.Spec.Strategy,
+		PodAnnotations:               src.Spec.PodAnnotations,
+		ImageScan:                    src.Spec.ImageScan,
+		Provenance:                   src.Spec.Provenance,
+		Mesh:                         src.Spec.Mesh,
+		ServiceAccountName:           src.Spec.ServiceAccountName,
+		AutomountServiceAccountToken: src.Spec.AutomountServiceAccountToken,
+		ServiceAccount:               src.Spec.ServiceAccount,
+		CloudIdentity:                src.Spec.CloudIdentity,
+		TLS:                          src.Spec.TLS,
+		Config:                       src.Spec.Config,
+		InitContainers:               src.Spec.InitContainers,
+		Sidecars:                     src.Spec.Sidecars,
+		ConfigFrom:                   src.Spec.ConfigFrom,
+		Vault:                        src.Spec.Vault,
+		DependsOn:                    src.Spec.DependsOn,
+		NetworkIsolation:             src.Spec.NetworkIsolation,
+		NetworkPolicy:                src.Spec.NetworkPolicy,
+		Security:                     src.Spec.Security,
+		TargetCluster:                src.Spec.TargetCluster,
+		Suspend:                      src.Spec.Suspend,
+	}
+
+	app := webappv1.Container{
+		Name:      src.Spec.ContainerName,
+		Image:     src.Spec.Image,
+		Command:   src.Spec.Command,
+		Args:      src.Spec.Args,
+		Env:       src.Spec.Env,
+		EnvFrom:   src.Spec.EnvFrom,
+		Resources: src.Spec.Resources,
+	}
+	if app.Name == "" {
+		app.Name = defaultContainerName
+	}
+	app.Ports, dst.Spec.Service = spokePorts(src.Spec.Port, src.Spec.AppProtocol, src.Spec.Service)
+	if data, ok := src.Annotations[ConversionDataAnnotation]; ok {
+		dst.Annotations = withoutAnnotation(src.Annotations, ConversionDataAnnotation)
+		// Data that doesn't parse, or no longer matches the v1 fields because a v1 client
+		// changed them, is dropped for the ports and Service the v1 fields describe.
+		var restored conversionData
+		if json.Unmarshal([]byte(data), &restored) == nil {
+			port, appProtocol, service := hubPorts(restored.Ports, restored.Service)
+			if port == src.Spec.Port && appProtocol == src.Spec.AppProtocol && equality.Semantic.DeepEqual(service, src.Spec.Service) {
+				app.Ports, dst.Spec.Service = restored.Ports, restored.Service
+			}
+		}
+	}
+	dst.Spec.Containers = append([]webappv1.Container{app}, src.Spec.Containers...)
+	return nil
+}
+
+// hubPorts returns the v1 spec.port, spec.appProtocol and spec.service of the ports of the
+// v2 app container and its spec.service.
+func hubPorts(ports []webappv1.ContainerPort, service *ServiceSpec) (int32, webappv1.AppProtocol, *webappv1.ServiceSpec) {
+	var port int32
+	var appProtocol webappv1.AppProtocol
+	var servicePorts []webappv1.ServicePortSpec
+	if len(ports) > 0 {
+		port = ports[0].ContainerPort
+		if ports[0].AppProtocol != nil {
+			appProtocol = webappv1.AppProtocol(*ports[0].AppProtocol)
+		}
+		for _, p := range ports[1:] {
+			servicePorts = append(servicePorts, hubServicePort(p))
+		}
+	}
+	if service == nil && len(servicePorts) == 0 {
+		return port, appProtocol, nil
+	}
+	out := &webappv1.ServiceSpec{Ports: servicePorts}
+	if service != nil {
+		out.Type = service.Type
+		out.Annotations = service.Annotations
+		out.IPFamilies = service.IPFamilies
+		out.IPFamilyPolicy = service.IPFamilyPolicy
+		out.InternalTrafficPolicy = service.InternalTrafficPolicy
+		out.ExternalTrafficPolicy = service.ExternalTrafficPolicy
+		out.SessionAffinity = service.SessionAffinity
+		out.SessionAffinityTimeoutSeconds = service.SessionAffinityTimeoutSeconds
+	}
+	return port, appProtocol, out
+}
+
+// spokePorts returns the ports of the v2 app container and the v2 spec.service of the v1
+// spec.port, spec.appProtocol and spec.service, the reverse of hubPorts.
+func spokePorts(port int32, appProtocol webappv1.AppProtocol, service *webappv1.ServiceSpec) ([]webappv1.ContainerPort, *ServiceSpec) {
+	var ports []webappv1.ContainerPort
+	if port != 0 {
+		first := webappv1.ContainerPort{ContainerPort: port, Protocol: corev1.ProtocolTCP}
+		if appProtocol != "" {
+			first.AppProtocol = ptr.To(string(appProtocol))
+		}
+		ports = append(ports, first)
+	}
+	if service == nil {
+		return ports, nil
+	}
+	for _, p := range service.Ports {
+		ports = append(ports, spokeContainerPort(p))
+	}
+	out := ServiceSpec{
+		Type:                          service.Type,
+		Annotations:                   service.Annotations,
+		IPFamilies:                    service.IPFamilies,
+		IPFamilyPolicy:                service.IPFamilyPolicy,
+		InternalTrafficPolicy:         service.InternalTrafficPolicy,
+		ExternalTrafficPolicy:         service.ExternalTrafficPolicy,
+		SessionAffinity:               service.SessionAffinity,
+		SessionAffinityTimeoutSeconds: service.SessionAffinityTimeoutSeconds,
+	}
+	if reflect.ValueOf(out).IsZero() {
+		return ports, nil
+	}
+	return ports, &out
+}
+
+// withoutAnnotation returns a copy of annotations without key, nil if none remain.
+func withoutAnnotation(annotations map[string]string, key string) map[string]string {
+	if _, ok := annotations[key]; !ok {
+		return annotations
+	}
+	out := maps.Clone(annotations)
+	delete(out, key)
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// hubServicePort returns the v1 spec.service.ports entry of a further port of the app
+// container. An unnamed port is named after its protocol and Service port, e.g. tcp-9090,
+// as the controller names the unnamed ports of other containers.
+func hubServicePort(port webappv1.ContainerPort) webappv1.ServicePortSpec {
+	protocol := port.Protocol
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	out := webappv1.ServicePortSpec{
+		Name:        port.Name,
+		Port:        port.ServicePort,
+		Protocol:    protocol,
+		AppProtocol: port.AppProtocol,
+	}
+	if out.Port == 0 {
+		out.Port = port.ContainerPort
+	}
+	if out.Port != port.ContainerPort {
+		out.TargetPort = port.ContainerPort
+	}
+	if out.Name == "" {
+		out.Name = fmt.Sprintf("%s-%d", strings.ToLower(string(protocol)), out.Port)
+	}
+	return out
+}
+
+// spokeContainerPort returns the app container port of a v1 spec.service.ports entry.
+func spokeContainerPort(port webappv1.ServicePortSpec) webappv1.ContainerPort {
+	out := webappv1.ContainerPort{
+		Name:          port.Name,
+		ContainerPort: port.Port,
+		Protocol:      port.Protocol,
+		AppProtocol:   port.AppProtocol,
+	}
+	if port.TargetPort != 0 && port.TargetPort != port.Port {
+		out.ContainerPort = port.TargetPort
+		out.ServicePort = port.Port
+	}
+	return out
+}
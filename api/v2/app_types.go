@@ -0,0 +1,378 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// AppSpec defines the desired state of App
+// +kubebuilder:validation:XValidation:rule="!has(self.targetCluster) || (!has(self.tls) && !has(self.mesh) && !(has(self.networkIsolation) && self.networkIsolation) && !has(self.networkPolicy) && !(has(self.vault) && has(self.vault.mode) && self.vault.mode == 'CSI'))",message="tls, mesh, networkIsolation, networkPolicy and Vault CSI mode are not available with targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.workloadType) || self.workloadType != 'Knative' || (!has(self.targetCluster) && !has(self.tls) && !has(self.service) && !has(self.platforms) && !(has(self.networkIsolation) && self.networkIsolation) && !has(self.networkPolicy))",message="targetCluster, tls, service, platforms, networkIsolation and networkPolicy are not available with the Knative workload type"
+// +kubebuilder:validation:XValidation:rule="!has(self.containers[0].ports) || size(self.containers[0].ports) == 0 || ((!has(self.containers[0].ports[0].name) || self.containers[0].ports[0].name == 'app') && (!has(self.containers[0].ports[0].servicePort) || self.containers[0].ports[0].servicePort == self.containers[0].ports[0].containerPort) && (!has(self.containers[0].ports[0].protocol) || self.containers[0].ports[0].protocol == 'TCP') && (!has(self.containers[0].ports[0].appProtocol) || self.containers[0].ports[0].appProtocol in ['http', 'http2', 'grpc']))",message="the first port of the app container is the App's port: named app if at all, served on the same port over TCP, with the http, http2 or grpc app protocol"
+// +kubebuilder:validation:XValidation:rule="!has(self.containers[0].ports) || self.containers[0].ports.all(p, self.containers[0].ports.exists_one(q, (has(q.servicePort) ? q.servicePort : q.containerPort) == (has(p.servicePort) ? p.servicePort : p.containerPort) && q.protocol == p.protocol))",message="the ports of the app container must not repeat a Service port and protocol"
+// +kubebuilder:validation:XValidation:rule="!has(self.workloadType) || self.workloadType != 'Knative' || (size(self.containers) == 1 && (!has(self.containers[0].ports) || size(self.containers[0].ports) <= 1) && !has(self.initContainers) && !has(self.sidecars))",message="the Knative workload type runs a single container with a single port, without initContainers or sidecars"
+// +kubebuilder:validation:XValidation:rule="!has(self.knative) || (has(self.workloadType) && self.workloadType == 'Knative')",message="knative requires the Knative workload type"
+// +kubebuilder:validation:XValidation:rule="!has(self.os) || self.os != 'windows' || (!has(self.mesh) && !(has(self.security) && (has(self.security.appArmorProfile) || has(self.security.containerSeccompProfile))))",message="mesh, security.appArmorProfile and security.containerSeccompProfile are not available for Windows Apps"
+// +kubebuilder:validation:XValidation:rule="!has(self.scaling) || !self.scaling.scaleToZero || ((!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster))",message="scaling.scaleToZero is not available with the Knative workload type or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.cloudIdentity) || (!has(self.serviceAccountName) && !has(self.targetCluster))",message="cloudIdentity is not available with serviceAccountName or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.serviceAccount) || (!has(self.serviceAccountName) && !has(self.targetCluster))",message="serviceAccount is not available with serviceAccountName or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.autoscaling) || (!has(self.replicas) && (!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster) && !(has(self.scaling) && self.scaling.scaleToZero))",message="autoscaling is not available with replicas, the Knative workload type, targetCluster or scaling.scaleToZero"
+// +kubebuilder:validation:XValidation:rule="!has(self.expose) || ((!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster))",message="expose is not available with the Knative workload type or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.strategy) || self.strategy.type == 'RollingUpdate' || ((!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster) && !(has(self.scaling) && self.scaling.scaleToZero))",message="the Canary and BlueGreen strategies are not available with the Knative workload type, targetCluster or scaling.scaleToZero"
+// +kubebuilder:validation:XValidation:rule="!has(self.storage) || ((!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster))",message="storage is not available with the Knative workload type or targetCluster"
+// +kubebuilder:validation:XValidation:rule="!has(self.imageUpdate) || !self.containers[0].image.contains('@')",message="imageUpdate requires an app container image referenced by tag, not digest"
+// +kubebuilder:validation:XValidation:rule="!has(self.highAvailability) || ((has(self.autoscaling) ? has(self.autoscaling.minReplicas) && self.autoscaling.minReplicas > 1 : has(self.replicas) && self.replicas > 1) && (!has(self.workloadType) || self.workloadType != 'Knative') && !has(self.targetCluster))",message="highAvailability requires more than one replica, or autoscaling.minReplicas above one, and is not available with the Knative workload type or targetCluster"
+type AppSpec struct {
+	// Containers are the containers of the App's pods. The first is the app container: its
+	// first port is the App's port, 8080 when it declares none, which Probes check and
+	// Expose routes to, and the image scan and provenance policies apply to its image. The
+	// ports of all containers are exposed by the App's Service.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=9
+	// +listType=map
+	// +listMapKey=name
+	// +required
+	Containers []webappv1.Container `json:"containers"`
+
+	// ImagePullPolicy is Always, IfNotPresent or Never for all the App's containers. Defaults
+	// to Always for images tagged latest or untagged, IfNotPresent otherwise.
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// ImagePullSecrets name the Secrets of the App's namespace holding the credentials of
+	// private registries its images are pulled from. They are attached to the App's pods and
+	// ServiceAccount, and used by the controller to inspect the image for spec.platforms and
+	// resolve its tag for spec.imageUpdate.
+	// +listType=atomic
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// ImageUpdate makes the controller resolve the tag of the app container's image to the
+	// digest it points to and run the App's pods pinned to that digest. With the TrackTag
+	// policy, a tag that moves rolls the App out again. The images of the other containers
+	// are left as they are.
+	// +optional
+	ImageUpdate *webappv1.ImageUpdateSpec `json:"imageUpdate,omitempty"`
+
+	// Replicas is the number of desired pods, 1 when unset. With the Knative workload type,
+	// it is the maximum number of pods the App scales out to. It can't be set together with
+	// Autoscaling.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Probes configures health checks of the app container.
+	// +optional
+	Probes *webappv1.ProbesSpec `json:"probes,omitempty"`
+
+	// Storage gives the App's pods a PersistentVolumeClaim, created and owned by the App and
+	// mounted into the app container.
+	// +optional
+	Storage *webappv1.StorageSpec `json:"storage,omitempty"`
+
+	// Service customizes the Service exposing the App's pods.
+	// +optional
+	Service *ServiceSpec `json:"service,omitempty"`
+
+	// Expose routes requests for a host name to the App's Service, through an Ingress or a
+	// Gateway API HTTPRoute. The address it is served at is reported in status.url.
+	// +optional
+	Expose *webappv1.ExposeSpec `json:"expose,omitempty"`
+
+	// OS is the operating system of the App's image. Windows Apps are scheduled on Windows
+	// nodes, tolerating the os=windows:NoSchedule taint such nodes usually carry, and get no
+	// Linux-only security settings: spec.security.seccompProfile is ignored for them.
+	// +kubebuilder:validation:Enum=linux;windows
+	// +kubebuilder:default=linux
+	// +optional
+	OS corev1.OSName `json:"os,omitempty"`
+
+	// Platforms restricts the App's pods to nodes of these CPU architectures, e.g. [amd64, arm64].
+	// The controller inspects the image's manifest and further restricts them to the
+	// architectures the image is built for, so pods never land on a node they can't run on.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Enum=amd64;arm64;arm;386;ppc64le;s390x;riscv64
+	// +listType=set
+	// +optional
+	Platforms []string `json:"platforms,omitempty"`
+
+	// RightSizing recommends CPU and memory requests for the App's pods from their actual
+	// usage, published in status.recommendations.
+	// +optional
+	RightSizing *webappv1.RightSizingSpec `json:"rightSizing,omitempty"`
+
+	// Accelerators requests GPUs or other extended resources for each pod, and steers the pods
+	// to the nodes providing them.
+	// +optional
+	Accelerators *webappv1.AcceleratorSpec `json:"accelerators,omitempty"`
+
+	// WorkloadType selects how the App's pods are run. Deployment, the default, creates a
+	// Deployment and a Service. Knative creates a Knative Serving Service instead, which
+	// scales the App to zero while it receives no requests.
+	// +kubebuilder:default=Deployment
+	// +optional
+	WorkloadType webappv1.WorkloadType `json:"workloadType,omitempty"`
+
+	// Knative tunes the autoscaling of the Knative workload type.
+	// +optional
+	Knative *webappv1.KnativeSpec `json:"knative,omitempty"`
+
+	// Scaling configures HTTP-based autoscaling of the App's Deployment.
+	// +optional
+	Scaling *webappv1.ScalingSpec `json:"scaling,omitempty"`
+
+	// Autoscaling scales the App's Deployment with a HorizontalPodAutoscaler, in place of a
+	// fixed number of replicas.
+	// +optional
+	Autoscaling *webappv1.AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// HighAvailability keeps the App's pods spread across zones and nodes, and protects them
+	// from voluntary disruptions, such as node drains, with a PodDisruptionBudget. It requires
+	// more than one replica.
+	// +optional
+	HighAvailability *webappv1.HighAvailabilitySpec `json:"highAvailability,omitempty"`
+
+	// Strategy selects how a new image is rolled out: by the Deployment's rolling update, the
+	// default, or progressively through a second Deployment, as a canary or a blue/green preview.
+	// +optional
+	Strategy *webappv1.StrategySpec `json:"strategy,omitempty"`
+
+	// PodAnnotations are added to the App's pods. Values are Go templates resolved by the
+	// controller, with the variables {{ .Name }}, {{ .Namespace }} and {{ .Labels }} of the
+	// App and the {{ .ClusterName }} configured for the controller. Annotations the
+	// controller sets itself take precedence.
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// ImageScan gates the rollout of a new image on a vulnerability scan.
+	// +optional
+	ImageScan *webappv1.ImageScanPolicy `json:"imageScan,omitempty"`
+
+	// Provenance gates the rollout of a new image on a verified SLSA provenance attestation.
+	// +optional
+	Provenance *webappv1.ProvenancePolicy `json:"provenance,omitempty"`
+
+	// Mesh enrolls the App's pods in a service mesh.
+	// +optional
+	Mesh *webappv1.MeshSpec `json:"mesh,omitempty"`
+
+	// ServiceAccountName is the ServiceAccount the pods run as.
+	// Defaults to the namespace's default ServiceAccount.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// AutomountServiceAccountToken controls whether an API token is mounted into the pods.
+	// When unset, the token is only mounted if ServiceAccountName is set or Vault
+	// secrets are injected by the Vault Agent, since other apps are not expected to
+	// talk to the API server.
+	// +optional
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+
+	// ServiceAccount makes the controller run the App's pods as a ServiceAccount of their
+	// own, <name>-serviceaccount, created and owned by the App, with its pull secrets
+	// attached. It is also created for CloudIdentity.
+	// +optional
+	ServiceAccount *webappv1.ServiceAccountSpec `json:"serviceAccount,omitempty"`
+
+	// CloudIdentity grants the App's pods the credentials of a cloud identity through workload
+	// identity federation. The controller runs the pods as a ServiceAccount of its own,
+	// <name>-serviceaccount, annotated for the identity.
+	// +optional
+	CloudIdentity *webappv1.CloudIdentitySpec `json:"cloudIdentity,omitempty"`
+
+	// TLS makes the App serve TLS itself, with a serving certificate issued by cert-manager
+	// for its Service, so traffic stays encrypted up to the pod.
+	// +optional
+	TLS *webappv1.TLSSpec `json:"tls,omitempty"`
+
+	// Config holds configuration files rendered into a ConfigMap and mounted into the app container.
+	// +optional
+	Config *webappv1.ConfigSpec `json:"config,omitempty"`
+
+	// InitContainers run to completion, one after the other, before the App's containers
+	// start, e.g. to migrate a database schema.
+	// +kubebuilder:validation:MaxItems=8
+	// +kubebuilder:validation:XValidation:rule="self.all(c, !has(c.ports))",message="init containers have no ports"
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	InitContainers []webappv1.Container `json:"initContainers,omitempty"`
+
+	// Sidecars run for the whole life of the App's pods as native sidecar containers: they
+	// start before the init containers and stop after the App's containers, e.g. a proxy or a
+	// log shipper. Their ports are exposed by the App's Service.
+	// +kubebuilder:validation:MaxItems=8
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Sidecars []webappv1.Container `json:"sidecars,omitempty"`
+
+	// ConfigFrom pulls configuration files from an external source into the App's ConfigMap,
+	// below the entries of Config, which win on conflicting keys. Config.MountPath applies.
+	// +optional
+	ConfigFrom *webappv1.ConfigSource `json:"configFrom,omitempty"`
+
+	// Vault configures HashiCorp Vault secret injection, so the App can consume
+	// secrets without any Secret objects being stored in the cluster.
+	// +optional
+	Vault *webappv1.VaultSpec `json:"vault,omitempty"`
+
+	// DependsOn lists the Apps in the same namespace that this App calls.
+	// With NetworkIsolation, traffic to them is allowed and traffic from them is accepted.
+	// Names are never namespace-qualified: Apps of other namespaces cannot be referenced.
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +listType=set
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// NetworkIsolation puts the App's pods behind a default-deny NetworkPolicy that only
+	// allows traffic derived from the App's declared port and DependsOn relationships:
+	// ingress from Apps depending on it, egress to its dependencies and cluster DNS.
+	// +optional
+	NetworkIsolation bool `json:"networkIsolation,omitempty"`
+
+	// NetworkPolicy puts the App's pods behind a default-deny NetworkPolicy that only allows
+	// ingress on the App's declared ports, those of its containers and sidecars, from the
+	// selected namespaces. With NetworkIsolation, both apply to the same policy.
+	// +optional
+	NetworkPolicy *webappv1.NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// Security holds the hardening options applied to the generated pods.
+	// +kubebuilder:default={}
+	// +optional
+	Security *webappv1.SecuritySpec `json:"security,omitempty"`
+
+	// TargetCluster delivers the App to another cluster instead of the one it is stored in.
+	// Only the Deployment, the Service and the configuration ConfigMap are created there, so
+	// features relying on other objects (tls, mesh, networkIsolation and Vault CSI mode) are
+	// not available. Objects delivered to a previous target are not removed when it changes.
+	// +optional
+	TargetCluster *webappv1.TargetCluster `json:"targetCluster,omitempty"`
+
+	// Suspend stops the controller from creating, updating or deleting the App's objects, e.g.
+	// during a maintenance window or while debugging them by hand. The App's status keeps
+	// being updated and reports a Suspended condition. Deleting a suspended App still removes
+	// its objects.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// ServiceSpec defines options of the Service exposing the App's pods.
+// +kubebuilder:validation:XValidation:rule="!has(self.ipFamilies) || size(self.ipFamilies) < 2 || self.ipFamilies[0] != self.ipFamilies[1]",message="ipFamilies must not repeat a family"
+// +kubebuilder:validation:XValidation:rule="!has(self.ipFamilies) || size(self.ipFamilies) < 2 || !has(self.ipFamilyPolicy) || self.ipFamilyPolicy != 'SingleStack'",message="a SingleStack Service takes a single IP family"
+// +kubebuilder:validation:XValidation:rule="!has(self.sessionAffinityTimeoutSeconds) || (has(self.sessionAffinity) && self.sessionAffinity == 'ClientIP')",message="sessionAffinityTimeoutSeconds requires ClientIP session affinity"
+type ServiceSpec struct {
+	// Type is ClusterIP, the default, to only expose the App inside the cluster, NodePort to
+	// also open its ports on every node, or LoadBalancer to provision an external load
+	// balancer through the cluster's cloud provider.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// Annotations are added to the Service, e.g. to configure the cloud load balancer of a
+	// LoadBalancer Service. Annotations set by others are left alone.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// IPFamilies are the IP families of the Service's cluster IPs, primary family first,
+	// e.g. [IPv6] on an IPv6 cluster or [IPv4, IPv6] on a dual-stack one. The primary family
+	// cannot be changed once the Service exists. Defaults to the cluster's primary family.
+	// +kubebuilder:validation:MaxItems=2
+	// +kubebuilder:validation:items:Enum=IPv4;IPv6
+	// +listType=atomic
+	// +optional
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+
+	// IPFamilyPolicy is SingleStack, PreferDualStack or RequireDualStack. Defaults to
+	// SingleStack, or RequireDualStack when two IPFamilies are given.
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	// +optional
+	IPFamilyPolicy *corev1.IPFamilyPolicy `json:"ipFamilyPolicy,omitempty"`
+
+	// InternalTrafficPolicy is Cluster, the default, to route in-cluster traffic to any pod of
+	// the App, or Local to keep it on the caller's node, avoiding a network hop for
+	// latency-sensitive Apps. Traffic is dropped on nodes without a pod of the App.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	InternalTrafficPolicy *corev1.ServiceInternalTrafficPolicy `json:"internalTrafficPolicy,omitempty"`
+
+	// ExternalTrafficPolicy is Cluster, the default, or Local to only route traffic from
+	// outside the cluster to pods on the receiving node, preserving the client's source IP.
+	// It only applies to NodePort and LoadBalancer Services.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+
+	// SessionAffinity is None, the default, or ClientIP to send the connections of a client
+	// to the same pod, for Apps keeping sessions in memory.
+	// +kubebuilder:validation:Enum=None;ClientIP
+	// +optional
+	SessionAffinity corev1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+
+	// SessionAffinityTimeoutSeconds is how long a client sticks to its pod after its last
+	// connection with ClientIP session affinity. Defaults to 10800 (3 hours).
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +optional
+	SessionAffinityTimeoutSeconds *int32 `json:"sessionAffinityTimeoutSeconds,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.containers[0].image`
+// +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.status.replicas`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Available",type=string,JSONPath=`.status.conditions[?(@.type=="Available")].status`
+// +kubebuilder:printcolumn:name="Suspended",type=boolean,JSONPath=`.spec.suspend`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// App is the Schema for the apps API. It is served from v1, the storage version, through
+// the conversion webhook.
+type App struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty,omitzero"`
+
+	// spec defines the desired state of App
+	// +required
+	Spec AppSpec `json:"spec"`
+
+	// status defines the observed state of App
+	// +optional
+	Status webappv1.AppStatus `json:"status,omitempty,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// AppList contains a list of App
+type AppList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []App `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&App{}, &AppList{})
+}
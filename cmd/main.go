@@ -21,16 +21,22 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -38,7 +44,15 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	webappv2 "github.com/your-org/my-app-controller/api/v2"
+	"github.com/your-org/my-app-controller/internal/certs"
 	controllers "github.com/your-org/my-app-controller/internal/controller"
+	"github.com/your-org/my-app-controller/internal/git"
+	"github.com/your-org/my-app-controller/internal/provenance"
+	"github.com/your-org/my-app-controller/internal/registry"
+	"github.com/your-org/my-app-controller/internal/scan"
+	"github.com/your-org/my-app-controller/internal/sops"
+	webhookwebappv1 "github.com/your-org/my-app-controller/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -50,7 +64,10 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+
 	utilruntime.Must(webappv1.AddToScheme(scheme))
+	utilruntime.Must(webappv2.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -63,6 +80,20 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var sopsAgeKeyFile string
+	var imageScannerURL string
+	var imagePullSecret string
+	var provenanceVerifierURL string
+	var statusUpdateInterval time.Duration
+	var kubeAPIQPS float64
+	var minConcurrentReconciles, maxConcurrentReconciles int
+	var retryBaseDelay, retryMaxDelay time.Duration
+	var kubeAPIBurst int
+	var certSecret string
+	var certDNSNames, certWebhookConfigurations, certConversionCRDs string
+	var clusterName string
+	var debugImage string
+	var ingressNamespace string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -79,6 +110,51 @@ func main() {
 		"The directory that contains the metrics server certificate.")
 	flag.StringVar(&metricsCertName, "metrics-cert-name", "tls.crt", "The name of the metrics server certificate file.")
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
+	flag.StringVar(&sopsAgeKeyFile, "sops-age-key-file", "",
+		"Path to an age key file (e.g. a mounted Secret) used to decrypt SOPS-encrypted App config. "+
+			"SOPS-encrypted config is rejected when unset.")
+	flag.StringVar(&imageScannerURL, "image-scanner-url", "",
+		"URL of the image scanning service checking images against spec.imageScan. "+
+			"Apps with a scan policy fail to reconcile when unset.")
+	flag.StringVar(&provenanceVerifierURL, "provenance-verifier-url", "",
+		"URL of the attestation verification service checking images against spec.provenance. "+
+			"Apps with a provenance policy fail to reconcile when unset.")
+	flag.StringVar(&imagePullSecret, "image-pull-secret", "",
+		"Central registry credential, as <namespace>/<name>, replicated into every namespace with Apps "+
+			"and attached to their pods.")
+	flag.DurationVar(&statusUpdateInterval, "status-update-interval", 5*time.Second,
+		"Minimum time between two status writes of an App that only change its ready replicas or conditions, "+
+			"coalescing updates while pods flap during rollouts. 0 writes every change immediately.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 20,
+		"Maximum queries per second from the manager to the API server. "+
+			"-1 disables client-side throttling and leaves fairness to API Priority and Fairness on the server.")
+	flag.IntVar(&kubeAPIBurst, "kube-api-burst", 30,
+		"Maximum burst of queries from the manager to the API server, above --kube-api-qps.")
+	flag.IntVar(&minConcurrentReconciles, "min-concurrent-reconciles", 1,
+		"Minimum number of Apps reconciled at once.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 10,
+		"Maximum number of Apps reconciled at once. Workers are added while Apps wait in the work queue, "+
+			"and removed once it is idle. Set to --min-concurrent-reconciles for a fixed number of workers.")
+	flag.DurationVar(&retryBaseDelay, "retry-base-delay", 5*time.Millisecond,
+		"Delay before retrying an App that failed to reconcile, doubled on each further failure.")
+	flag.DurationVar(&retryMaxDelay, "retry-max-delay", 1000*time.Second,
+		"Maximum delay between two retries of an App that keeps failing to reconcile.")
+	flag.StringVar(&certSecret, "cert-secret", "",
+		"Secret, as <namespace>/<name>, in which the manager generates and rotates a CA and the serving certificate "+
+			"of its webhook and metrics servers, instead of reading them from --webhook-cert-path and --metrics-cert-path.")
+	flag.StringVar(&certDNSNames, "cert-dns-names", "",
+		"Comma-separated DNS names of the serving certificate generated with --cert-secret.")
+	flag.StringVar(&certWebhookConfigurations, "cert-webhook-configurations", "",
+		"Comma-separated names of the webhook configurations trusting the CA generated with --cert-secret.")
+	flag.StringVar(&certConversionCRDs, "cert-conversion-crds", "",
+		"Comma-separated names of the CustomResourceDefinitions whose conversion webhook trusts the CA generated with --cert-secret.")
+	flag.StringVar(&clusterName, "cluster-name", "",
+		"Name of the cluster the manager runs in, available to templated App spec values as {{ .ClusterName }}.")
+	flag.StringVar(&debugImage, "debug-image", "",
+		"Image of the ephemeral debug containers requested with the webapp.example.com/debug annotation "+
+			"without webapp.example.com/debug-image. Defaults to busybox.")
+	flag.StringVar(&ingressNamespace, "ingress-controller-namespace", "ingress-nginx",
+		"Namespace of the Ingress controller, let in by the NetworkPolicies of Apps exposed through an Ingress.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
 	opts := zap.Options{
@@ -107,6 +183,29 @@ func main() {
 	// Create watchers for metrics and webhooks certificates
 	var metricsCertWatcher, webhookCertWatcher *certwatcher.CertWatcher
 
+	// Or generate and rotate them in a Secret, without cert-manager.
+	var certRotator *certs.Rotator
+	if len(certSecret) > 0 {
+		namespace, name, ok := strings.Cut(certSecret, "/")
+		if !ok || namespace == "" || name == "" {
+			setupLog.Error(nil, "cert secret must be given as <namespace>/<name>", "cert-secret", certSecret)
+			os.Exit(1)
+		}
+		if len(splitList(certDNSNames)) == 0 {
+			setupLog.Error(nil, "--cert-secret requires --cert-dns-names")
+			os.Exit(1)
+		}
+		if len(webhookCertPath) > 0 || len(metricsCertPath) > 0 {
+			setupLog.Error(nil, "--cert-secret cannot be combined with --webhook-cert-path or --metrics-cert-path")
+			os.Exit(1)
+		}
+		certRotator = certs.NewRotator(types.NamespacedName{Namespace: namespace, Name: name},
+			splitList(certDNSNames), splitList(certWebhookConfigurations), splitList(certConversionCRDs))
+		tlsOpts = append(tlsOpts, func(config *tls.Config) {
+			config.GetCertificate = certRotator.GetCertificate
+		})
+	}
+
 	// Initial webhook TLS options
 	webhookTLSOpts := tlsOpts
 
@@ -178,8 +277,23 @@ func main() {
 		})
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	var pullSecret types.NamespacedName
+	if len(imagePullSecret) > 0 {
+		namespace, name, ok := strings.Cut(imagePullSecret, "/")
+		if !ok || namespace == "" || name == "" {
+			setupLog.Error(nil, "image pull secret must be given as <namespace>/<name>", "image-pull-secret", imagePullSecret)
+			os.Exit(1)
+		}
+		pullSecret = types.NamespacedName{Namespace: namespace, Name: name}
+	}
+
+	restConfig := ctrl.GetConfigOrDie()
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  controllers.CacheOptions(pullSecret.Namespace), // Only the controller's own children, without bulky metadata
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -202,13 +316,88 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Detect whether the cluster serves securityContext.appArmorProfile (Kubernetes 1.30+);
+	// older clusters only understand the beta AppArmor annotation.
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create discovery client")
+		os.Exit(1)
+	}
+	serverVersion, err := discoveryClient.ServerVersion()
+	if err != nil {
+		setupLog.Error(err, "unable to get server version")
+		os.Exit(1)
+	}
+	appArmorField, err := controllers.SupportsAppArmorField(serverVersion.GitVersion)
+	if err != nil {
+		setupLog.Error(err, "unable to parse server version", "version", serverVersion.GitVersion)
+		os.Exit(1)
+	}
+
+	var sopsDecryptor *sops.Decryptor
+	if len(sopsAgeKeyFile) > 0 {
+		sopsDecryptor, err = sops.NewDecryptorFromKeyFile(sopsAgeKeyFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load SOPS age key file", "sops-age-key-file", sopsAgeKeyFile)
+			os.Exit(1)
+		}
+	}
+
+	var imageScanner scan.Scanner
+	if len(imageScannerURL) > 0 {
+		imageScanner = scan.NewHTTPScanner(imageScannerURL)
+	}
+
+	var provenanceVerifier provenance.Verifier
+	if len(provenanceVerifierURL) > 0 {
+		provenanceVerifier = provenance.NewHTTPVerifier(provenanceVerifierURL)
+	}
+
+	if certRotator != nil {
+		if err := certRotator.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to set up serving certificate rotation")
+			os.Exit(1)
+		}
+	}
+
 	if err := (&controllers.AppReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:           client.WithFieldOwner(mgr.GetClient(), controllers.FieldManager),
+		Scheme:           mgr.GetScheme(),
+		APIReader:        mgr.GetAPIReader(),
+		ClusterName:      clusterName,
+		LegacyAppArmor:   !appArmorField,
+		Sops:             sopsDecryptor,
+		PullSecret:       pullSecret,
+		Scanner:          imageScanner,
+		Verifier:         provenanceVerifier,
+		Git:              git.NewHTTPFetcher(),
+		Registry:         registry.NewHTTPInspector(),
+		DebugImage:       debugImage,
+		IngressNamespace: ingressNamespace,
+		StatusInterval:   statusUpdateInterval,
+		Recorder:         mgr.GetEventRecorderFor("app-controller"),
+		MinWorkers:       minConcurrentReconciles,
+		MaxWorkers:       maxConcurrentReconciles,
+		RetryBaseDelay:   retryBaseDelay,
+		RetryMaxDelay:    retryMaxDelay,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "App")
 		os.Exit(1)
 	}
+	if err := (&controllers.AppPolicyReconciler{
+		Client: client.WithFieldOwner(mgr.GetClient(), controllers.FieldManager),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AppPolicy")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookwebappv1.SetupAppWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "App")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -242,3 +431,14 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
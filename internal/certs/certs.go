@@ -0,0 +1,392 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package certs generates and rotates the serving certificates of the manager's webhook and
+// metrics servers, so its admission stack does not depend on cert-manager.
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// caValidity is how long a generated CA is valid.
+	caValidity = 10 * 365 * 24 * time.Hour
+	// servingValidity is how long a generated serving certificate is valid.
+	servingValidity = 365 * 24 * time.Hour
+	// checkInterval is how often certificates are checked for renewal.
+	checkInterval = time.Hour
+	// retryInterval is how soon a failed check is retried.
+	retryInterval = 30 * time.Second
+
+	// CAKey is the Secret key holding the CA bundle: the current CA, followed by the previous
+	// one while it is still valid so clients trusting it keep working during a rotation.
+	CAKey = "ca.crt"
+	// caPrivateKeyKey is the Secret key holding the private key of the current CA.
+	caPrivateKeyKey = "ca.key"
+)
+
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations;validatingwebhookconfigurations,verbs=get;update
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;update
+
+// Rotator keeps a CA and a serving certificate in a Secret, renewing each when less than a
+// third of its lifetime remains, serves the certificate to TLS servers and injects the CA
+// bundle into webhook configurations and conversion webhooks. Every manager replica runs it; the Secret is shared,
+// so replicas agree on the certificate whichever one renews it.
+type Rotator struct {
+	secret                types.NamespacedName
+	dnsNames              []string
+	webhookConfigurations []string
+	conversionCRDs        []string
+
+	client client.Client
+	reader client.Reader
+	now    func() time.Time
+	cert   atomic.Pointer[tls.Certificate]
+}
+
+// NewRotator returns a Rotator storing its certificates in secret, issuing serving
+// certificates for dnsNames and injecting the CA bundle into the Mutating- and
+// ValidatingWebhookConfigurations named in webhookConfigurations and into the conversion
+// webhooks of the CustomResourceDefinitions named in conversionCRDs.
+func NewRotator(secret types.NamespacedName, dnsNames, webhookConfigurations, conversionCRDs []string) *Rotator {
+	return &Rotator{
+		secret:                secret,
+		dnsNames:              dnsNames,
+		webhookConfigurations: webhookConfigurations,
+		conversionCRDs:        conversionCRDs,
+		now:                   time.Now,
+	}
+}
+
+// SetupWithManager runs the Rotator with the Manager and reports the manager ready only
+// once a serving certificate is available. Reads bypass the cache, which holds neither
+// the Secret, webhook configurations nor CustomResourceDefinitions.
+func (r *Rotator) SetupWithManager(mgr ctrl.Manager) error {
+	r.client = mgr.GetClient()
+	r.reader = mgr.GetAPIReader()
+	if err := mgr.Add(r); err != nil {
+		return err
+	}
+	return mgr.AddReadyzCheck("serving-certs", r.ReadyCheck)
+}
+
+// Start implements manager.Runnable. It checks the certificates until ctx is done.
+func (r *Rotator) Start(ctx context.Context) error {
+	log := logf.Log.WithName("certs")
+	for {
+		wait := checkInterval
+		if err := r.refresh(ctx); err != nil {
+			log.Error(err, "Failed to refresh serving certificates", "Secret", r.secret)
+			wait = retryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Every replica serves webhooks.
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+// GetCertificate returns the current serving certificate, for tls.Config.GetCertificate.
+func (r *Rotator) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := r.cert.Load()
+	if cert == nil {
+		return nil, errors.New("serving certificate not generated yet")
+	}
+	return cert, nil
+}
+
+// ReadyCheck is a healthz.Checker failing until a serving certificate is available.
+func (r *Rotator) ReadyCheck(_ *http.Request) error {
+	_, err := r.GetCertificate(nil)
+	return err
+}
+
+// refresh renews the certificates in the Secret when needed, loads the serving certificate
+// and makes sure every webhook configuration trusts the CA bundle.
+func (r *Rotator) refresh(ctx context.Context) error {
+	secret := &corev1.Secret{}
+	err := r.reader.Get(ctx, r.secret, secret)
+	notFound := apierrors.IsNotFound(err)
+	if err != nil && !notFound {
+		return err
+	}
+
+	data, changed, err := renew(secret.Data, r.dnsNames, r.now())
+	if err != nil {
+		return err
+	}
+	if changed {
+		secret.Data = data
+		if notFound {
+			secret.ObjectMeta = metav1.ObjectMeta{Name: r.secret.Name, Namespace: r.secret.Namespace}
+			secret.Type = corev1.SecretTypeTLS
+			err = r.client.Create(ctx, secret)
+		} else {
+			err = r.client.Update(ctx, secret)
+		}
+		if apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err) {
+			// Another replica renewed the certificates first; use its.
+			return r.refresh(ctx)
+		}
+		if err != nil {
+			return err
+		}
+		logf.Log.WithName("certs").Info("Renewed serving certificates", "Secret", r.secret)
+	}
+
+	cert, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return err
+	}
+	r.cert.Store(&cert)
+	return r.injectCABundle(ctx, data[CAKey])
+}
+
+// injectCABundle sets the CA bundle of every webhook in the configured webhook configurations
+// and of the conversion webhooks of the configured CustomResourceDefinitions. Configurations
+// that don't exist, e.g. because webhooks are disabled, and CustomResourceDefinitions without
+// a conversion webhook are skipped.
+func (r *Rotator) injectCABundle(ctx context.Context, bundle []byte) error {
+	for _, name := range r.webhookConfigurations {
+		key := types.NamespacedName{Name: name}
+
+		mutating := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		if err := r.reader.Get(ctx, key, mutating); err == nil {
+			var configs []*admissionregistrationv1.WebhookClientConfig
+			for i := range mutating.Webhooks {
+				configs = append(configs, &mutating.Webhooks[i].ClientConfig)
+			}
+			if setCABundle(configs, bundle) {
+				if err := r.client.Update(ctx, mutating); err != nil {
+					return err
+				}
+			}
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		validating := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		if err := r.reader.Get(ctx, key, validating); err == nil {
+			var configs []*admissionregistrationv1.WebhookClientConfig
+			for i := range validating.Webhooks {
+				configs = append(configs, &validating.Webhooks[i].ClientConfig)
+			}
+			if setCABundle(configs, bundle) {
+				if err := r.client.Update(ctx, validating); err != nil {
+					return err
+				}
+			}
+		} else if !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	for _, name := range r.conversionCRDs {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := r.reader.Get(ctx, types.NamespacedName{Name: name}, crd); apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Webhook == nil || conversion.Webhook.ClientConfig == nil ||
+			bytes.Equal(conversion.Webhook.ClientConfig.CABundle, bundle) {
+			continue
+		}
+		conversion.Webhook.ClientConfig.CABundle = bundle
+		if err := r.client.Update(ctx, crd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setCABundle sets bundle on every client config, and reports whether any changed.
+func setCABundle(configs []*admissionregistrationv1.WebhookClientConfig, bundle []byte) bool {
+	changed := false
+	for _, config := range configs {
+		if !bytes.Equal(config.CABundle, bundle) {
+			config.CABundle = bundle
+			changed = true
+		}
+	}
+	return changed
+}
+
+// renew returns data with a new CA and/or serving certificate where the current ones are
+// missing, invalid or due for renewal, and reports whether anything changed.
+func renew(data map[string][]byte, dnsNames []string, now time.Time) (map[string][]byte, bool, error) {
+	out := maps.Clone(data)
+	if out == nil {
+		out = map[string][]byte{}
+	}
+	changed := false
+
+	ca, caKey, err := parsePair(out[CAKey], out[caPrivateKeyKey])
+	if err != nil || renewalDue(ca, now) {
+		// Keep trusting the current CA while serving certificates it signed may still be in use.
+		var previous []byte
+		if err == nil && now.Before(ca.NotAfter) {
+			previous = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+		}
+		var certPEM, keyPEM []byte
+		if ca, caKey, certPEM, keyPEM, err = newCA(now); err != nil {
+			return nil, false, err
+		}
+		out[CAKey] = append(certPEM, previous...)
+		out[caPrivateKeyKey] = keyPEM
+		changed = true
+	}
+
+	serving, _, err := parsePair(out[corev1.TLSCertKey], out[corev1.TLSPrivateKeyKey])
+	if changed || err != nil || renewalDue(serving, now) || !slices.Equal(serving.DNSNames, dnsNames) || serving.CheckSignatureFrom(ca) != nil {
+		certPEM, keyPEM, err := newServingCert(ca, caKey, dnsNames, now)
+		if err != nil {
+			return nil, false, err
+		}
+		out[corev1.TLSCertKey] = certPEM
+		out[corev1.TLSPrivateKeyKey] = keyPEM
+		changed = true
+	}
+	return out, changed, nil
+}
+
+// renewalDue reports whether less than a third of cert's lifetime remains.
+func renewalDue(cert *x509.Certificate, now time.Time) bool {
+	lifetime := cert.NotAfter.Sub(cert.NotBefore)
+	return now.After(cert.NotAfter.Add(-lifetime / 3))
+}
+
+// parsePair parses the first certificate of certPEM and its PKCS #8 private key.
+func parsePair(certPEM, keyPEM []byte) (*x509.Certificate, crypto.Signer, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, errors.New("missing certificate or key")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return cert, signer, nil
+}
+
+// newCA generates a self-signed CA.
+func newCA(now time.Time) (*x509.Certificate, crypto.Signer, []byte, []byte, error) {
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "app-controller-ca"},
+		NotBefore:             now.Add(-time.Hour), // Tolerate clock skew
+		NotAfter:              now.Add(caValidity),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	return issue(template, nil, nil)
+}
+
+// newServingCert generates a serving certificate for dnsNames signed by ca. It expires no
+// later than ca.
+func newServingCert(ca *x509.Certificate, caKey crypto.Signer, dnsNames []string, now time.Time) ([]byte, []byte, error) {
+	if len(dnsNames) == 0 {
+		return nil, nil, errors.New("no DNS names to issue a serving certificate for")
+	}
+	notAfter := now.Add(servingValidity)
+	if ca.NotAfter.Before(notAfter) {
+		notAfter = ca.NotAfter
+	}
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:    dnsNames,
+		NotBefore:   now.Add(-time.Hour), // Tolerate clock skew
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	_, _, certPEM, keyPEM, err := issue(template, ca, caKey)
+	return certPEM, keyPEM, err
+}
+
+// issue generates a key pair and a certificate from template, signed by parent or, when
+// parent is nil, self-signed. It returns the certificate and key in parsed and PEM form.
+func issue(template, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer, []byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return cert, key, certPEM, keyPEM, nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certs
+
+import (
+	"context"
+	"crypto/x509"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Rotator", func() {
+	var (
+		ctx     = context.Background()
+		key     = types.NamespacedName{Namespace: "system", Name: "serving-cert"}
+		now     time.Time
+		c       client.Client
+		rotator *Rotator
+	)
+
+	stored := func() map[string][]byte {
+		secret := &corev1.Secret{}
+		Expect(c.Get(ctx, key, secret)).To(Succeed())
+		return secret.Data
+	}
+	verify := func(data map[string][]byte, at time.Time) {
+		roots := x509.NewCertPool()
+		Expect(roots.AppendCertsFromPEM(data[CAKey])).To(BeTrue())
+		cert, _, err := parsePair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		_, err = cert.Verify(x509.VerifyOptions{DNSName: "webhook.system.svc", Roots: roots, CurrentTime: at})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(apiextensionsv1.AddToScheme(scheme)).To(Succeed())
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&admissionregistrationv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhooks"},
+				Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "mapp-v1.kb.io"}},
+			},
+			&apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "apps.webapp.example.com"},
+				Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+					Conversion: &apiextensionsv1.CustomResourceConversion{
+						Strategy: apiextensionsv1.WebhookConverter,
+						Webhook: &apiextensionsv1.WebhookConversion{
+							ClientConfig: &apiextensionsv1.WebhookClientConfig{},
+						},
+					},
+				},
+			},
+		).Build()
+		rotator = NewRotator(key, []string{"webhook.system.svc"}, []string{"webhooks", "absent"},
+			[]string{"apps.webapp.example.com", "absent.webapp.example.com"})
+		rotator.client, rotator.reader = c, c
+		rotator.now = func() time.Time { return now }
+	})
+
+	It("should generate certificates and inject the CA bundle", func() {
+		Expect(rotator.ReadyCheck(nil)).NotTo(Succeed())
+		Expect(rotator.refresh(ctx)).To(Succeed())
+
+		data := stored()
+		verify(data, now)
+		Expect(rotator.ReadyCheck(nil)).To(Succeed())
+
+		webhooks := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "webhooks"}, webhooks)).To(Succeed())
+		Expect(webhooks.Webhooks[0].ClientConfig.CABundle).To(Equal(data[CAKey]))
+
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "apps.webapp.example.com"}, crd)).To(Succeed())
+		Expect(crd.Spec.Conversion.Webhook.ClientConfig.CABundle).To(Equal(data[CAKey]))
+	})
+
+	It("should keep certificates until they are due for renewal", func() {
+		Expect(rotator.refresh(ctx)).To(Succeed())
+		first := stored()
+
+		now = now.Add(200 * 24 * time.Hour)
+		Expect(rotator.refresh(ctx)).To(Succeed())
+		Expect(stored()).To(Equal(first))
+
+		now = now.Add(50 * 24 * time.Hour)
+		Expect(rotator.refresh(ctx)).To(Succeed())
+		renewed := stored()
+		Expect(renewed[corev1.TLSCertKey]).NotTo(Equal(first[corev1.TLSCertKey]))
+		Expect(renewed[CAKey]).To(Equal(first[CAKey]))
+		verify(renewed, now)
+	})
+
+	It("should trust the previous CA while rotating it", func() {
+		Expect(rotator.refresh(ctx)).To(Succeed())
+		first := stored()
+
+		now = now.Add(7 * 365 * 24 * time.Hour)
+		Expect(rotator.refresh(ctx)).To(Succeed())
+		rotated := stored()
+		Expect(rotated[CAKey]).NotTo(Equal(first[CAKey]))
+		Expect(string(rotated[CAKey])).To(HaveSuffix(string(first[CAKey])))
+		verify(rotated, now)
+	})
+
+	It("should reissue the serving certificate when its DNS names change", func() {
+		Expect(rotator.refresh(ctx)).To(Succeed())
+		first := stored()
+
+		rotator.dnsNames = append(rotator.dnsNames, "metrics.system.svc")
+		Expect(rotator.refresh(ctx)).To(Succeed())
+		cert, _, err := parsePair(stored()[corev1.TLSCertKey], stored()[corev1.TLSPrivateKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.DNSNames).To(ConsistOf("webhook.system.svc", "metrics.system.svc"))
+		Expect(stored()[CAKey]).To(Equal(first[CAKey]))
+	})
+})
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// conditionAcceleratorsAvailable reports whether a node can host a pod of an App requesting accelerators.
+const conditionAcceleratorsAvailable = "AcceleratorsAvailable"
+
+// acceleratorResources returns the extended resources of the app container: requested and
+// limited to the same amount, as Kubernetes requires for extended resources.
+func acceleratorResources(app *webappv1.App) corev1.ResourceRequirements {
+	if app.Spec.Accelerators == nil {
+		return corev1.ResourceRequirements{}
+	}
+	return corev1.ResourceRequirements{
+		Limits:   maps.Clone(app.Spec.Accelerators.Resources),
+		Requests: maps.Clone(app.Spec.Accelerators.Resources),
+	}
+}
+
+// acceleratorRuntimeClassName returns the RuntimeClass of the App's pods, if any.
+func acceleratorRuntimeClassName(app *webappv1.App) *string {
+	if app.Spec.Accelerators == nil {
+		return nil
+	}
+	return app.Spec.Accelerators.RuntimeClassName
+}
+
+// acceleratorNodeSelector returns the node labels required by the App's accelerators.
+func acceleratorNodeSelector(app *webappv1.App) map[string]string {
+	if app.Spec.Accelerators == nil {
+		return nil
+	}
+	return app.Spec.Accelerators.NodeSelector
+}
+
+// acceleratorTolerations returns the tolerations of the App's accelerator nodes.
+func acceleratorTolerations(app *webappv1.App) []corev1.Toleration {
+	if app.Spec.Accelerators == nil {
+		return nil
+	}
+	return app.Spec.Accelerators.Tolerations
+}
+
+// acceleratorsCondition reports whether any node selected for the App's pods has the
+// allocatable extended resources a pod requests. Current usage is not taken into account:
+// the condition catches requests no node could ever satisfy, such as a missing device plugin.
+// It returns nil for Apps without accelerators.
+func (r *AppReconciler) acceleratorsCondition(ctx context.Context, app *webappv1.App) (*metav1.Condition, error) {
+	if app.Spec.Accelerators == nil {
+		return nil, nil
+	}
+	nodes := &corev1.NodeList{}
+	selector := labels.SelectorFromSet(mergeMaps(osNodeSelector(app), acceleratorNodeSelector(app)))
+	if err := r.List(ctx, nodes, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	condition := &metav1.Condition{
+		Type:               conditionAcceleratorsAvailable,
+		Status:             metav1.ConditionFalse,
+		Reason:             "InsufficientCapacity",
+		ObservedGeneration: app.Generation,
+	}
+	requested := app.Spec.Accelerators.Resources
+	for _, node := range nodes.Items {
+		if fits(node.Status.Allocatable, requested) {
+			condition.Status = metav1.ConditionTrue
+			condition.Reason = "NodesAvailable"
+			condition.Message = fmt.Sprintf("Node %s can host a pod of the App", node.Name)
+			return condition, nil
+		}
+	}
+
+	var wanted []string
+	for _, name := range slices.Sorted(maps.Keys(requested)) {
+		quantity := requested[name]
+		wanted = append(wanted, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	condition.Message = fmt.Sprintf("None of the %d selected nodes has %s allocatable; pods will stay pending",
+		len(nodes.Items), strings.Join(wanted, ", "))
+	return condition, nil
+}
+
+// fits reports whether allocatable covers every requested resource.
+func fits(allocatable corev1.ResourceList, requested map[corev1.ResourceName]resource.Quantity) bool {
+	for name, quantity := range requested {
+		available, ok := allocatable[name]
+		if !ok || available.Cmp(quantity) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Accelerators", func() {
+	gpuNode := func(name, gpus string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"nvidia.com/gpu.present": "true"}},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				"nvidia.com/gpu": resource.MustParse(gpus),
+			}},
+		}
+	}
+	app := &webappv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "inference", Namespace: "default", Generation: 2},
+		Spec: webappv1.AppSpec{
+			Image: "inference:1.0",
+			Accelerators: &webappv1.AcceleratorSpec{
+				Resources:        map[corev1.ResourceName]resource.Quantity{"nvidia.com/gpu": resource.MustParse("2")},
+				RuntimeClassName: ptr.To("nvidia"),
+				NodeSelector:     map[string]string{"nvidia.com/gpu.present": "true"},
+				Tolerations:      []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}},
+			},
+		},
+	}
+
+	It("should request the accelerators and schedule on their nodes", func() {
+		pod := (&AppReconciler{}).desiredDeployment(app, app.Spec.Image).Spec.Template.Spec
+
+		Expect(pod.Containers[0].Resources.Limits).To(HaveKeyWithValue(corev1.ResourceName("nvidia.com/gpu"), resource.MustParse("2")))
+		Expect(pod.Containers[0].Resources.Requests).To(Equal(pod.Containers[0].Resources.Limits))
+		Expect(pod.RuntimeClassName).To(Equal(ptr.To("nvidia")))
+		Expect(pod.NodeSelector).To(Equal(map[string]string{"nvidia.com/gpu.present": "true"}))
+		Expect(pod.Tolerations).To(HaveLen(1))
+	})
+
+	It("should report whether a selected node has the accelerators allocatable", func() {
+		r, c := newTestReconciler(gpuNode("small", "1"))
+
+		condition, err := r.acceleratorsCondition(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Message).To(ContainSubstring("nvidia.com/gpu=2"))
+
+		Expect(c.Create(ctx, gpuNode("large", "8"))).To(Succeed())
+		condition, err = r.acceleratorsCondition(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.ObservedGeneration).To(Equal(int64(2)))
+
+		condition, err = r.acceleratorsCondition(ctx, &webappv1.App{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condition).To(BeNil())
+	})
+})
@@ -3,280 +3,820 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr" // Required for ServicePort TargetPort
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	webappv1 "github.com/your-org/my-app-controller/api/v1" // Make sure this path is correct based on your init command
+	"github.com/your-org/my-app-controller/internal/git"
+	"github.com/your-org/my-app-controller/internal/policy"
+	"github.com/your-org/my-app-controller/internal/provenance"
+	"github.com/your-org/my-app-controller/internal/registry"
+	"github.com/your-org/my-app-controller/internal/scan"
+	"github.com/your-org/my-app-controller/internal/sops"
 )
 
+// appContainerName is the default name of the application container in generated pods.
+const appContainerName = "app-container"
+
+// containerName returns the name of the App's application container.
+func containerName(app *webappv1.App) string {
+	if app.Spec.ContainerName != "" {
+		return app.Spec.ContainerName
+	}
+	return appContainerName
+}
+
+// kubectlAnnotationPrefix prefixes the pod template annotations kubectl commands add, such
+// as the restart time of `kubectl rollout restart`.
+const kubectlAnnotationPrefix = "kubectl.kubernetes.io/"
+
+// FieldManager is the field manager of the controller's writes. It lets GitOps tools tell
+// the fields the controller owns apart from their own, e.g. with Argo CD's
+// ignoreDifferences.managedFieldsManagers.
+const FieldManager = "app-controller"
+
 // AppReconciler reconciles an App object
 type AppReconciler struct {
 	client.Client                 // Client provides methods to interact with the Kubernetes API server.
 	Scheme        *runtime.Scheme // Scheme contains the Go type definitions for all API kinds that this controller works with.
+	// ClusterName is the name of the cluster the controller runs in, available to templated
+	// spec values as {{ .ClusterName }}.
+	ClusterName string
+	// LegacyAppArmor selects AppArmor profiles through pod annotations instead of the
+	// securityContext field, for clusters older than Kubernetes 1.30.
+	LegacyAppArmor bool
+	// Sops decrypts SOPS-encrypted configuration. Encrypted config is rejected when nil.
+	Sops *sops.Decryptor
+	// PullSecret is the central registry credential replicated into every namespace with Apps
+	// and attached to their pods. Pull secrets are not managed when its name is empty.
+	PullSecret types.NamespacedName
+	// Scanner checks new images against spec.imageScan. Apps with a scan policy fail to reconcile when nil.
+	Scanner scan.Scanner
+	// Verifier checks new images against spec.provenance. Apps with a provenance policy fail to reconcile when nil.
+	Verifier provenance.Verifier
+	// Registry reads the platforms of images for spec.platforms. Defaults to an HTTPInspector.
+	Registry registry.Inspector
+	// IngressNamespace is the namespace of the Ingress controller, whose pods the
+	// NetworkPolicies of Apps exposed through an Ingress let in. Defaults to ingress-nginx.
+	IngressNamespace string
+	// DebugImage is the image of debug containers requested without
+	// webapp.example.com/debug-image. Defaults to busybox.
+	DebugImage string
+	// Git pulls the configuration of Apps with spec.configFrom.git. Defaults to an HTTPFetcher.
+	Git git.Fetcher
+	// APIReader reads the kubeconfig Secrets of target clusters, which the cache does not
+	// hold. Defaults to the client.
+	APIReader client.Reader
+	// NewRemoteClient builds the client of a target cluster from its kubeconfig. Defaults to a
+	// client using Scheme.
+	NewRemoteClient func(kubeconfig []byte) (client.Client, error)
+	// StatusInterval is the minimum time between two status writes of an App that only
+	// change its ready replicas or conditions. Zero writes every change immediately.
+	StatusInterval time.Duration
+	// Recorder records Events on Apps for the child resources created, updated and deleted
+	// for them, and the failures to. Events are not recorded when nil.
+	Recorder record.EventRecorder
+
+	// MinWorkers and MaxWorkers bound the number of Apps reconciled at once. Between them,
+	// workers are added while Apps wait in the queue and removed once it is idle.
+	// Zero values reconcile one App at a time.
+	MinWorkers, MaxWorkers int
+	// RetryBaseDelay and RetryMaxDelay bound the exponential backoff of Apps failing to
+	// reconcile. Zero values keep controller-runtime's defaults, 5ms and 1000s.
+	RetryBaseDelay, RetryMaxDelay time.Duration
+
+	workers         *workerScaler                                      // Set when MaxWorkers exceeds MinWorkers.
+	statusMu        sync.Mutex                                         // Guards lastStatusWrite.
+	lastStatusWrite map[types.NamespacedName]time.Time                 // When each App's status was last written.
+	remoteMu        sync.Mutex                                         // Guards remoteClients.
+	remoteClients   map[remoteClientKey]*remoteClient                  // Target cluster clients by kubeconfig Secret version.
+	gitMu           sync.Mutex                                         // Guards gitSnapshots.
+	gitSnapshots    map[types.NamespacedName]gitSnapshot               // Git configuration last pulled for each App.
+	usageMu         sync.Mutex                                         // Guards usage.
+	usage           map[types.NamespacedName][]usageSample             // Usage samples of each App with spec.rightSizing.
+	syncedMu        sync.Mutex                                         // Guards synced.
+	synced          map[types.NamespacedName]map[types.UID]syncedChild // Child resources last found in sync, by App and UID.
+	progressMu      sync.Mutex                                         // Guards progress.
+	progress        workqueue.TypedRateLimiter[types.NamespacedName]   // Backoff of the Apps whose rollout is in progress.
 }
 
 //+kubebuilder:rbac:groups=webapp.example.com,resources=apps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=webapp.example.com,resources=apps/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=webapp.example.com,resources=apps/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=gateways,verbs=get
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=backendtlspolicies,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings;rolebindings,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=core,resources=pods/ephemeralcontainers,verbs=update
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=servicecidrs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=security.istio.io,resources=peerauthentications,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=secrets-store.csi.x-k8s.io,resources=secretproviderclasses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=serving.knative.dev,resources=services,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=serving.knative.dev,resources=revisions,verbs=get;list;watch
+//+kubebuilder:rbac:groups=http.keda.sh,resources=httpscaledobjects,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is the main reconciliation loop. It fetches the App object and ensures
 // that the corresponding Deployment and Service exist and match the desired state.
-func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *AppReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	// Use a logger for structured logging.
 	log := log.FromContext(ctx)
 
 	// 1. Fetch the App instance that triggered this reconciliation.
 	app := &webappv1.App{}
-	err := r.Get(ctx, req.NamespacedName, app)
+	err = r.Get(ctx, req.NamespacedName, app)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// App object not found. This means the object has been deleted from the cluster.
 			// We can stop reconciling and return. Owned objects (Deployment, Service)
 			// will be garbage collected automatically due to owner references.
 			log.Info("App resource not found. Ignoring since object must be deleted")
+			r.forgetStatus(req.NamespacedName)
+			r.forgetGitConfig(req.NamespacedName)
+			r.forgetUsage(req.NamespacedName)
+			r.forgetSynced(req.NamespacedName)
+			r.forgetProgress(req.NamespacedName)
+			r.forgetTargetClusterClient(req.NamespacedName)
+			forgetMetrics(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object. Requeue the request to retry later.
 		log.Error(err, "Failed to get App")
 		return ctrl.Result{}, err
 	}
+	original := app.DeepCopy()
+	defer func() { observeReconcile(req.NamespacedName, err) }()
 
-	// 2. Define the desired state for the Deployment based on the App's spec.
-	desiredDeployment := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-deployment", app.Name), // Name the deployment based on the App's name
-			Namespace: app.Namespace,
-			Labels: map[string]string{
-				"app":        app.Name,
-				"controller": "app-controller",
+	// 2. Tear down a deleted App's objects in order before releasing it, and hold back the
+	// deletion of the others until then.
+	if !app.DeletionTimestamp.IsZero() {
+		teardownWait, err := r.finalize(ctx, app)
+		if err != nil {
+			log.Error(err, "Failed to tear down App")
+		}
+		return ctrl.Result{RequeueAfter: teardownWait}, err
+	}
+	if err := r.patchFinalizer(ctx, app, appCleanupFinalizer, true); err != nil {
+		log.Error(err, "Failed to add cleanup finalizer")
+		return ctrl.Result{}, err
+	}
+
+	// 3. Leave the objects of a suspended App as they are, only reporting its status.
+	if app.Spec.Suspend {
+		return r.reconcileSuspended(ctx, original, app)
+	}
+	meta.RemoveStatusCondition(&app.Status.Conditions, conditionSuspended)
+
+	// 4. Refuse to resolve references reaching outside the App's namespace without a grant.
+	policies, err := policy.ForNamespace(ctx, r.Client, app.Namespace)
+	if err != nil {
+		log.Error(err, "Failed to read the AppPolicies of the App's namespace")
+		return ctrl.Result{}, err
+	}
+	if err := checkReferences(app, policies); err != nil {
+		log.Error(err, "App references objects outside its namespace")
+		setStalledConditions(app, "InvalidReference", err.Error())
+		if _, statusErr := r.updateStatus(ctx, original, app); statusErr != nil {
+			log.Error(statusErr, "Failed to update App status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// 5. Refuse to apply templated values that don't resolve.
+	if err := r.checkTemplates(app); err != nil {
+		log.Error(err, "App has invalid templates")
+		setStalledConditions(app, "InvalidTemplate", err.Error())
+		if _, statusErr := r.updateStatus(ctx, original, app); statusErr != nil {
+			log.Error(statusErr, "Failed to update App status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// 6. Pin the App's image to the digest its tag points to, resolving the tag again when it is tracked.
+	if err := r.pinImage(ctx, app); err != nil {
+		log.Error(err, "Failed to resolve image tag", "Image", app.Spec.Image)
+		return ctrl.Result{}, err
+	}
+
+	// 7. Refuse to roll out an image built for none of the App's platforms.
+	if err := r.checkPlatforms(ctx, app); err != nil {
+		log.Error(err, "Failed to check image platforms", "Image", app.Spec.Image)
+		setStalledConditions(app, "UnsupportedPlatform", err.Error())
+		if _, statusErr := r.updateStatus(ctx, original, app); statusErr != nil {
+			log.Error(statusErr, "Failed to update App status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// 8. Deliver Apps with a target cluster there instead, and release Apps that no longer have one.
+	if app.Spec.TargetCluster != nil {
+		return r.reconcileRemote(ctx, original, app)
+	}
+	r.forgetTargetClusterClient(req.NamespacedName)
+	if err := r.patchFinalizer(ctx, app, remoteCleanupFinalizer, false); err != nil {
+		log.Error(err, "Failed to remove target cluster finalizer")
+		return ctrl.Result{}, err
+	}
+
+	// 9. Refuse to create a Service in IP families the cluster doesn't allocate.
+	if err := r.checkIPFamilies(ctx, app); err != nil {
+		log.Error(err, "App's Service IP families are not supported")
+		setStalledConditions(app, "UnsupportedIPFamily", err.Error())
+		if _, statusErr := r.updateStatus(ctx, original, app); statusErr != nil {
+			log.Error(statusErr, "Failed to update App status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	// 10. Make sure the SecretProviderClass backing Vault CSI mode exists before pods mount it.
+	if err := r.reconcileSecretProviderClass(ctx, app); err != nil {
+		log.Error(err, "Failed to reconcile SecretProviderClass")
+		return ctrl.Result{}, err
+	}
+
+	// 11. Request the App's serving certificate from cert-manager when it serves TLS.
+	if err := r.reconcileCertificate(ctx, app); err != nil {
+		log.Error(err, "Failed to reconcile Certificate")
+		return ctrl.Result{}, err
+	}
+
+	// 12. Apply the App's mTLS mode through an Istio PeerAuthentication when requested.
+	if err := r.reconcilePeerAuthentication(ctx, app); err != nil {
+		log.Error(err, "Failed to reconcile PeerAuthentication")
+		return ctrl.Result{}, err
+	}
+
+	// 13. Render the App's configuration, pulled from Git and decrypting SOPS documents, into its ConfigMap,
+	// and hash the ConfigMaps and Secrets its environment references so pods roll out when they change.
+	if err := r.reconcileConfigMap(ctx, app); err != nil {
+		log.Error(err, "Failed to reconcile ConfigMap")
+		return ctrl.Result{}, err
+	}
+	if err := r.hashEnvSources(ctx, app); err != nil {
+		log.Error(err, "Failed to hash environment sources")
+		return ctrl.Result{}, err
+	}
+
+	// 14. Replicate the central registry credential the App's pods pull with.
+	if err := r.reconcilePullSecret(ctx, app); err != nil {
+		log.Error(err, "Failed to reconcile pull Secret")
+		return ctrl.Result{}, err
+	}
+
+	// 15. Create the ServiceAccount carrying the App's cloud identity before pods run as it.
+	if err := r.reconcileServiceAccount(ctx, app); err != nil {
+		log.Error(err, "Failed to reconcile ServiceAccount")
+		return ctrl.Result{}, err
+	}
+
+	// 16. Create the PersistentVolumeClaim the App's pods mount, or expand it.
+	claim, err := r.reconcilePersistentVolumeClaim(ctx, app)
+	if err != nil {
+		log.Error(err, "Failed to reconcile PersistentVolumeClaim")
+		return ctrl.Result{}, err
+	}
+
+	// 17. Hold back new images that fail the App's vulnerability scan or provenance policy.
+	image, err := r.admittedImage(ctx, r.Client, app)
+	if err != nil {
+		log.Error(err, "Failed to check image against supply-chain policies", "Image", app.Spec.Image)
+		return ctrl.Result{}, err
+	}
+
+	// 18. Sample the App's resource usage and recommend requests from it, for the workload to
+	// apply when the App opts in. Usage is advisory: failing to read it doesn't hold the App back.
+	if err := r.recommendResources(ctx, app); err != nil {
+		log.Error(err, "Failed to sample resource usage")
+	}
+
+	// 19. Move the canary or blue/green rollout of a new image along. The stable Deployment keeps
+	// the image it runs until the new one is promoted.
+	image, rolloutWait, err := r.reconcileRollout(ctx, app, image)
+	if err != nil {
+		log.Error(err, "Failed to reconcile rollout", "Image", image)
+		return ctrl.Result{}, err
+	}
+
+	// 20. Apply the App's workload (a Deployment, Service, HorizontalPodAutoscaler and
+	// PodDisruptionBudget, with the canary or preview ones of a rollout, or a Knative Service),
+	// its Ingress or HTTPRoute and its NetworkPolicy concurrently. They don't depend on each
+	// other, and each is attempted even when another one fails.
+	var deployment *appsv1.Deployment
+	var knativeService *unstructured.Unstructured
+	var exposedURL string
+	var hpa *autoscalingv2.HorizontalPodAutoscaler
+	applyExpose := func(ctx context.Context) (err error) {
+		exposedURL, err = r.reconcileExpose(ctx, app)
+		if err != nil {
+			log.Error(err, "Failed to reconcile Ingress or HTTPRoute")
+		}
+		return err
+	}
+	applyNetworkPolicy := func(ctx context.Context) error {
+		err := r.reconcileNetworkPolicy(ctx, app)
+		if err != nil {
+			log.Error(err, "Failed to reconcile NetworkPolicy")
+		}
+		return err
+	}
+	if isKnative(app) {
+		err = applyConcurrently(ctx,
+			func(ctx context.Context) (err error) {
+				knativeService, err = r.reconcileKnativeService(ctx, app, image)
+				return err
 			},
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &app.Spec.Replicas, // Set replicas from AppSpec
-			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app": app.Name, // Selector to match pods created by this deployment
-				},
+			func(ctx context.Context) error { return r.deleteWorkload(ctx, app) },
+			func(ctx context.Context) error { return r.reconcileHTTPScaledObject(ctx, app) },
+			applyExpose,
+			applyNetworkPolicy,
+		)
+	} else {
+		err = applyConcurrently(ctx,
+			func(ctx context.Context) (err error) {
+				deployment, err = r.reconcileDeployment(ctx, app, image)
+				return err
 			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"app": app.Name,
-					},
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{{
-						Name:  "app-container",
-						Image: app.Spec.Image, // Use image from AppSpec
-						Ports: []corev1.ContainerPort{{
-							ContainerPort: app.Spec.Port, // Expose port from AppSpec
-						}},
-					}},
-				},
+			func(ctx context.Context) error { return r.reconcileService(ctx, app) },
+			func(ctx context.Context) error { return r.reconcileRolloutWorkload(ctx, app) },
+			func(ctx context.Context) (err error) {
+				hpa, err = r.reconcileHPA(ctx, app)
+				return err
 			},
-		},
+			func(ctx context.Context) error { return r.reconcilePodDisruptionBudget(ctx, app) },
+			func(ctx context.Context) error {
+				_, err := r.reconcileKnativeService(ctx, app, image)
+				return err
+			},
+			func(ctx context.Context) error { return r.reconcileHTTPScaledObject(ctx, app) },
+			applyExpose,
+			applyNetworkPolicy,
+		)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 21. Attach the debug container requested through the App's annotations, and end sessions
+	// past their TTL. Debugging is best effort: failing to attach doesn't hold the App back.
+	debugWait, err := r.reconcileDebug(ctx, app)
+	if err != nil {
+		log.Error(err, "Failed to reconcile debug session")
+	}
+
+	// 22. Warn when the App's pods run with an over-privileged ServiceAccount.
+	saCondition, err := r.serviceAccountCondition(ctx, app)
+	if err != nil {
+		log.Error(err, "Failed to check ServiceAccount privileges")
+		return ctrl.Result{}, err
+	}
+	meta.SetStatusCondition(&app.Status.Conditions, saCondition)
+
+	// 23. Report whether the App's pods will join their service mesh.
+	meshCondition, err := r.meshCondition(ctx, app)
+	if err != nil {
+		log.Error(err, "Failed to check service mesh enrollment")
+		return ctrl.Result{}, err
+	}
+	if meshCondition != nil {
+		meta.SetStatusCondition(&app.Status.Conditions, *meshCondition)
+	} else {
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionMeshEnrolled)
+	}
+	meta.RemoveStatusCondition(&app.Status.Conditions, conditionTargetClusterReady)
+
+	// 24. Report whether any node can host the App's pods when they request accelerators.
+	acceleratorsCondition, err := r.acceleratorsCondition(ctx, app)
+	if err != nil {
+		log.Error(err, "Failed to check node capacity for accelerators")
+		return ctrl.Result{}, err
+	}
+	if acceleratorsCondition != nil {
+		meta.SetStatusCondition(&app.Status.Conditions, *acceleratorsCondition)
+	} else {
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionAcceleratorsAvailable)
+	}
+
+	// 25. Report the rollout of the applied spec in kstatus terms, for GitOps tools to wait on.
+	app.Status.ObservedGeneration = app.Generation
+	if isKnative(app) {
+		if err := r.setKnativeStatus(ctx, app, knativeService); err != nil {
+			log.Error(err, "Failed to read Knative Service status")
+			return ctrl.Result{}, err
+		}
+	} else {
+		app.Status.URL = exposedURL
+		app.Status.Autoscaling = autoscalingStatus(hpa)
+		app.Status.Storage = storageStatus(claim)
+		setRolloutConditions(app, deployment)
 	}
+	setScaledToZeroCondition(app, deployment)
 
-	// 3. Set the App instance as the owner of the Deployment.
+	// 26. Update the App's status only if the image checks or conditions have changed.
+	// Ready replicas are kept up to date by the status worker (see reconcileStatus).
+	statusWait, err := r.updateStatus(ctx, original, app)
+	if err != nil {
+		log.Error(err, "Failed to update App status")
+		return ctrl.Result{}, err
+	}
+
+	// 27. Requeue the request only for what no watch announces: a deferred status update, an
+	// expiring debug session, the end of a canary step's pause, periodic pulls and samples, and
+	// a rollout in progress. Changes to the App and its objects trigger a reconcile as they happen.
+	return r.nextReconcile(app, statusWait, debugWait, rolloutWait), nil
+}
+
+// reconcileDeployment creates or updates the Deployment running the App's pods with the
+// admitted image. It returns the Deployment as last written or read, nil if none was created.
+func (r *AppReconciler) reconcileDeployment(ctx context.Context, app *webappv1.App, image string) (*appsv1.Deployment, error) {
+	log := log.FromContext(ctx)
+
+	// 1. Define the desired state for the Deployment based on the App's spec.
+	desiredDeployment := r.desiredDeployment(app, image)
+
+	// 2. Set the App instance as the owner of the Deployment.
 	// This is crucial for Kubernetes' garbage collection. When the App is deleted,
 	// this owned Deployment will automatically be deleted too.
 	if err := ctrl.SetControllerReference(app, desiredDeployment, r.Scheme); err != nil {
 		log.Error(err, "Failed to set controller reference for Deployment")
-		return ctrl.Result{}, err
+		return nil, err
 	}
 
-	// 4. Check if the Deployment already exists.
+	// 3. Check if the Deployment already exists.
 	foundDeployment := &appsv1.Deployment{}
-	err = r.Get(ctx, types.NamespacedName{Name: desiredDeployment.Name, Namespace: desiredDeployment.Namespace}, foundDeployment)
-	if err != nil && errors.IsNotFound(err) {
+	err := r.Get(ctx, types.NamespacedName{Name: desiredDeployment.Name, Namespace: desiredDeployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) && image == "" {
+		// No image has passed the supply-chain policies yet, so there is nothing to roll out.
+		log.Info("Not creating Deployment until an image passes the supply-chain policies", "Image", app.Spec.Image)
+		return nil, nil
+	} else if err != nil && errors.IsNotFound(err) {
 		// Deployment does not exist, so create it.
 		log.Info("Creating a new Deployment", "Deployment.Namespace", desiredDeployment.Namespace, "Deployment.Name", desiredDeployment.Name)
-		err = r.Create(ctx, desiredDeployment)
+		err = r.createChild(ctx, app, desiredDeployment)
 		if err != nil {
 			log.Error(err, "Failed to create new Deployment", "Deployment.Namespace", desiredDeployment.Namespace, "Deployment.Name", desiredDeployment.Name)
-			return ctrl.Result{}, err
+			return nil, err
 		}
 		// Deployment created successfully.
+		return desiredDeployment, nil
 	} else if err != nil {
 		// Error getting the Deployment. Requeue.
 		log.Error(err, "Failed to get Deployment")
-		return ctrl.Result{}, err
+		return nil, err
 	} else {
-		// Deployment found. Check if an update is needed.
-		if !deploymentEqual(foundDeployment.Spec, desiredDeployment.Spec) {
-			log.Info("Updating existing Deployment", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
-			// Copy the desired spec to the found deployment object.
-			foundDeployment.Spec = desiredDeployment.Spec
-			err = r.Update(ctx, foundDeployment)
-			if err != nil {
-				log.Error(err, "Failed to update Deployment", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
-				return ctrl.Result{}, err
-			}
-		} else {
-			log.V(1).Info("Deployment is up-to-date", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
+		// Deployment found. Leave its replicas to KEDA when the App scales to zero, and to
+		// its HorizontalPodAutoscaler when it autoscales.
+		if (scalesToZero(app) || autoscaled(app)) && foundDeployment.Spec.Replicas != nil {
+			desiredDeployment.Spec.Replicas = foundDeployment.Spec.Replicas
 		}
+		// Write the whole desired spec over the stored one, so drift in any field is reverted.
+		updated := desiredDeploymentUpdate(foundDeployment, desiredDeployment)
+		syncAttribution(app, updated)
+		changed, err := r.syncChild(ctx, r.Client, app, foundDeployment, updated)
+		if err != nil {
+			log.Error(err, "Failed to update Deployment", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
+			return nil, err
+		}
+		if changed {
+			return updated, nil
+		}
+		log.V(1).Info("Deployment is up-to-date", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
 	}
+	return foundDeployment, nil
+}
 
-	// 5. Define the desired state for the Service based on the App's spec.
-	desiredService := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-service", app.Name), // Name the service based on the App's name
-			Namespace: app.Namespace,
-			Labels: map[string]string{
-				"app":        app.Name,
-				"controller": "app-controller",
-			},
-		},
-		Spec: corev1.ServiceSpec{
-			Selector: map[string]string{
-				"app": app.Name, // Selector to match pods created by the deployment
-			},
-			Ports: []corev1.ServicePort{{
-				Protocol:   corev1.ProtocolTCP,
-				Port:       app.Spec.Port,
-				TargetPort: intstr.FromInt(int(app.Spec.Port)), // Target the container port
-			}},
-			Type: corev1.ServiceTypeClusterIP, // Expose service internally
-		},
-	}
+// reconcileService creates or updates the Service exposing the App's pods.
+func (r *AppReconciler) reconcileService(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	// 1. Define the desired state for the Service based on the App's spec.
+	desiredService := desiredService(app)
 
-	// 6. Set the App instance as the owner of the Service.
+	// 2. Set the App instance as the owner of the Service.
 	if err := ctrl.SetControllerReference(app, desiredService, r.Scheme); err != nil {
 		log.Error(err, "Failed to set controller reference for Service")
-		return ctrl.Result{}, err
+		return err
 	}
 
-	// 7. Check if the Service already exists.
+	// 3. Check if the Service already exists.
 	foundService := &corev1.Service{}
-	err = r.Get(ctx, types.NamespacedName{Name: desiredService.Name, Namespace: desiredService.Namespace}, foundService)
+	err := r.Get(ctx, types.NamespacedName{Name: desiredService.Name, Namespace: desiredService.Namespace}, foundService)
 	if err != nil && errors.IsNotFound(err) {
 		// Service does not exist, so create it.
 		log.Info("Creating a new Service", "Service.Namespace", desiredService.Namespace, "Service.Name", desiredService.Name)
-		err = r.Create(ctx, desiredService)
+		err = r.createChild(ctx, app, desiredService)
 		if err != nil {
 			log.Error(err, "Failed to create new Service", "Service.Namespace", desiredService.Namespace, "Service.Name", desiredService.Name)
-			return ctrl.Result{}, err
+			return err
 		}
 	} else if err != nil {
 		// Error getting the Service. Requeue.
 		log.Error(err, "Failed to get Service")
-		return ctrl.Result{}, err
+		return err
 	} else {
-		// Service found. Check if an update is needed (simplified check for example).
-		// In a real controller, you'd want a more robust comparison.
-		if !serviceEqual(foundService.Spec, desiredService.Spec) {
-			log.Info("Updating existing Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
-			foundService.Spec = desiredService.Spec
-			err = r.Update(ctx, foundService)
-			if err != nil {
-				log.Error(err, "Failed to update Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
-				return ctrl.Result{}, err
-			}
-		} else {
+		// Service found. Write the whole desired spec over the stored one; the API server keeps
+		// the cluster IPs and node ports it allocated.
+		updated := foundService.DeepCopy()
+		updated.Labels = mergeMaps(updated.Labels, desiredService.Labels)
+		updated.Spec = desiredService.Spec
+		syncServiceAnnotations(app, updated)
+		syncAttribution(app, updated)
+		changed, err := r.syncChild(ctx, r.Client, app, foundService, updated)
+		if err != nil {
+			log.Error(err, "Failed to update Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
+			return err
+		}
+		if !changed {
 			log.V(1).Info("Service is up-to-date", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
 		}
 	}
+	return nil
+}
 
-	// 8. Update the App's status based on the actual state of its pods.
-	// List pods managed by the Deployment created for this App.
-	pods := &corev1.PodList{}
-	listOpts := []client.ListOption{
-		client.InNamespace(app.Namespace),
-		client.MatchingLabels(map[string]string{"app": app.Name}), // Match pods by the common 'app' label
-	}
-	if err = r.List(ctx, pods, listOpts...); err != nil {
-		log.Error(err, "Failed to list pods for App")
-		return ctrl.Result{}, err
+// desiredDeployment returns the Deployment running the App's pods with the given image,
+// without owner.
+func (r *AppReconciler) desiredDeployment(app *webappv1.App, image string) *appsv1.Deployment {
+	vaultVols, vaultMounts := vaultVolumes(app)
+	configVols, configMounts := configVolumes(app)
+	tlsVols, tlsMounts := tlsVolumes(app)
+	identityVols, identityMounts := cloudIdentityVolumes(app)
+	storageVols, storageMounts := storageVolumes(app)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-deployment", app.Name), // Name the deployment based on the App's name
+			Namespace:   app.Namespace,
+			Annotations: attributionAnnotations(app),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To(stableReplicas(app)), // Set replicas from AppSpec, less those of a canary
+			Strategy: deploymentStrategy(app),     // Recreate pods holding a single-node volume
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app": app.Name, // Selector to match pods created by this deployment
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: mergeMaps(map[string]string{
+						"app": app.Name,
+					}, meshLabels(app), cloudIdentityLabels(app), stableTrackLabels(app)),
+					Annotations: mergeMaps(r.podAnnotations(app), appArmorAnnotations(app, r.LegacyAppArmor), vaultAnnotations(app), meshAnnotations(app), gitConfigAnnotations(app), envHashAnnotations(app)),
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName:           podServiceAccountName(app),                // Generated for spec.serviceAccount and cloud identities
+					ImagePullSecrets:             r.imagePullSecrets(app),                   // The central registry credential and spec.imagePullSecrets
+					AutomountServiceAccountToken: automountServiceAccountToken(app),         // Off unless the App needs API access
+					SecurityContext:              podSecurityContext(app, r.LegacyAppArmor), // Seccomp/AppArmor profiles from AppSpec
+					OS:                           podOS(app),
+					RuntimeClassName:             acceleratorRuntimeClassName(app),
+					NodeSelector:                 mergeMaps(osNodeSelector(app), acceleratorNodeSelector(app)), // Windows and accelerator nodes
+					Tolerations:                  concat(osTolerations(app), acceleratorTolerations(app)),
+					Affinity:                     podAffinity(app, image),        // Architectures the image runs on, separate nodes for spec.highAvailability
+					TopologySpreadConstraints:    topologySpreadConstraints(app), // Zones and nodes, for spec.highAvailability
+					InitContainers:               initContainers(app),            // Sidecars, then init containers
+					Containers: append([]corev1.Container{{
+						Name:            containerName(app),
+						Image:           image, // Image from AppSpec, once admitted by the supply-chain policies
+						ImagePullPolicy: app.Spec.ImagePullPolicy,
+						Command:         app.Spec.Command,
+						Args:            app.Spec.Args,
+						Ports:           containerPorts(app),     // spec.port and spec.service.ports
+						Resources:       containerResources(app), // spec.resources, accelerators and recommended requests
+						LivenessProbe:   livenessProbe(app),      // gRPC or HTTP health checks
+						ReadinessProbe:  readinessProbe(app),
+						StartupProbe:    startupProbe(app),
+						Env:             r.appEnv(app), // Cloud identity, then spec.env
+						EnvFrom:         app.Spec.EnvFrom,
+						VolumeMounts:    concat(vaultMounts, configMounts, tlsMounts, identityMounts, storageMounts),
+						SecurityContext: containerSecurityContext(app),
+					}}, appContainers(app, app.Spec.Containers)...), // spec.containers next to the app container
+					Volumes: concat(vaultVols, configVols, tlsVols, identityVols, storageVols),
+				},
+			},
+		},
 	}
+}
 
-	// Count ready pods.
-	readyPods := int32(0)
-	for _, pod := range pods.Items {
-		for _, condition := range pod.Status.Conditions {
-			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
-				readyPods++
-				break
-			}
-		}
+// desiredService returns the Service exposing the App's pods, without owner.
+func desiredService(app *webappv1.App) *corev1.Service {
+	ipFamilies, ipFamilyPolicy := serviceIPFamilies(app)
+	affinity, affinityConfig := sessionAffinity(app)
+	serviceType := serviceType(app) // ClusterIP unless spec.service says otherwise
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-service", app.Name), // Name the service based on the App's name
+			Namespace:   app.Namespace,
+			Annotations: mergeMaps(attributionAnnotations(app), serviceAnnotations(app)),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector:              serviceSelector(app), // Pods created by the deployment, or one track of a blue/green App
+			Ports:                 servicePorts(app),    // spec.port, spec.service.ports, then container ports
+			Type:                  serviceType,
+			IPFamilies:            ipFamilies, // IPv6 and dual-stack clusters
+			IPFamilyPolicy:        ipFamilyPolicy,
+			InternalTrafficPolicy: internalTrafficPolicy(app),
+			ExternalTrafficPolicy: externalTrafficPolicy(app, serviceType),
+			SessionAffinity:       affinity, // Sticky sessions
+			SessionAffinityConfig: affinityConfig,
+		},
 	}
+}
 
-	// Update the App's status only if the number of ready replicas has changed.
-	if app.Status.Replicas != readyPods {
-		app.Status.Replicas = readyPods
-		if err := r.Status().Update(ctx, app); err != nil {
-			log.Error(err, "Failed to update App status")
-			return ctrl.Result{}, err
-		}
-		log.Info("App status updated", "Replicas", app.Status.Replicas)
+// applyConcurrently runs independent apply functions in parallel and waits for all of them.
+// Unlike an errgroup, a failure does not cancel the others; all errors are returned together.
+func applyConcurrently(ctx context.Context, fns ...func(context.Context) error) error {
+	errs := make([]error, len(fns))
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(ctx)
+		}()
 	}
-
-	// 9. Requeue the request after a short duration. This ensures the controller
-	// periodically re-checks the state, even if no events occur.
-	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
 }
 
-// deploymentEqual is a helper function to check if two DeploymentSpecs are functionally equivalent
-// for our purposes (simplified for this example). In a production controller,
-// this comparison would need to be much more robust, potentially using a deep equality library.
-func deploymentEqual(a, b appsv1.DeploymentSpec) bool {
-	// Pointers for Replicas need to be dereferenced for comparison
-	if *a.Replicas != *b.Replicas {
-		return false
-	}
-	if len(a.Template.Spec.Containers) != len(b.Template.Spec.Containers) {
-		return false
-	}
-	if len(a.Template.Spec.Containers) > 0 {
-		if a.Template.Spec.Containers[0].Image != b.Template.Spec.Containers[0].Image {
-			return false
-		}
-		if len(a.Template.Spec.Containers[0].Ports) != len(b.Template.Spec.Containers[0].Ports) {
-			return false
-		}
-		if len(a.Template.Spec.Containers[0].Ports) > 0 && a.Template.Spec.Containers[0].Ports[0].ContainerPort != b.Template.Spec.Containers[0].Ports[0].ContainerPort {
-			return false
+// desiredDeploymentUpdate returns stored with the labels and spec of desired written over it.
+// Annotations `kubectl rollout restart` and other kubectl commands add to the pod template
+// are kept, so they aren't reverted with another rollout.
+func desiredDeploymentUpdate(stored, desired *appsv1.Deployment) *appsv1.Deployment {
+	updated := stored.DeepCopy()
+	updated.Labels = mergeMaps(updated.Labels, desired.Labels)
+	updated.Spec = *desired.Spec.DeepCopy()
+	for key, value := range stored.Spec.Template.Annotations {
+		if strings.HasPrefix(key, kubectlAnnotationPrefix) {
+			if updated.Spec.Template.Annotations == nil {
+				updated.Spec.Template.Annotations = map[string]string{}
+			}
+			updated.Spec.Template.Annotations[key] = value
 		}
 	}
-	return true
+	return updated
 }
 
-// serviceEqual is a helper function to check if two ServiceSpecs are functionally equivalent
-// for our purposes (simplified for this example).
-func serviceEqual(a, b corev1.ServiceSpec) bool {
-	if a.Type != b.Type {
-		return false
+// concat concatenates slices into a new slice. It returns nil if all inputs are empty.
+func concat[T any](slices ...[]T) []T {
+	var out []T
+	for _, s := range slices {
+		out = append(out, s...)
 	}
-	if len(a.Ports) != len(b.Ports) {
-		return false
-	}
-	if len(a.Ports) > 0 && len(b.Ports) > 0 {
-		if a.Ports[0].Port != b.Ports[0].Port || a.Ports[0].TargetPort.IntValue() != b.Ports[0].TargetPort.IntValue() || a.Ports[0].Protocol != b.Ports[0].Protocol {
-			return false
+	return out
+}
+
+// mergeMaps merges string maps left to right into a new map. It returns nil if all inputs are empty.
+func mergeMaps(maps ...map[string]string) map[string]string {
+	var out map[string]string
+	for _, m := range maps {
+		for k, v := range m {
+			if out == nil {
+				out = map[string]string{}
+			}
+			out[k] = v
 		}
 	}
-	// You might want to compare selectors, cluster IP (if applicable), etc. for a more robust check.
-	return true
+	return out
 }
 
 // SetupWithManager sets up the controller with the Manager.
 // It configures what resources the controller watches and which objects it owns.
 func (r *AppReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&webappv1.App{}).       // The primary resource this controller watches
-		Owns(&appsv1.Deployment{}). // Watches Deployments that are owned by an App
-		Owns(&corev1.Service{}).    // Watches Services that are owned by an App
+	// Index Apps by their dependencies to find the Apps allowed to call an isolated App.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &webappv1.App{}, dependsOnIndex, indexDependsOn); err != nil {
+		return err
+	}
+	// Index Apps by the ConfigMaps their environment reads to roll them out when one changes.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &webappv1.App{}, envConfigMapIndex, indexEnvConfigMaps); err != nil {
+		return err
+	}
+
+	// The status worker only follows the ready pods of each App's Deployment.
+	if err := ctrl.NewControllerManagedBy(mgr).
+		Named("appstatus").
+		Watches(&appsv1.Deployment{}, handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &webappv1.App{}, handler.OnlyControllerOwner()),
+			builder.WithPredicates(deploymentStatusChanged)).
+		Complete(reconcile.Func(r.reconcileStatus)); err != nil {
+		return err
+	}
+
+	// Reconciles user changes ahead of periodic requeues and resyncs.
+	options := controller.Options{
+		UsePriorityQueue:        ptr.To(true),
+		NewQueue:                newPriorityQueue,
+		MaxConcurrentReconciles: r.MinWorkers,
+		RateLimiter:             newRateLimiter(r.RetryBaseDelay, r.RetryMaxDelay),
+	}
+	if r.MaxWorkers > max(r.MinWorkers, 1) {
+		r.workers = newWorkerScaler("app", max(r.MinWorkers, 1), r.MaxWorkers)
+		options.MaxConcurrentReconciles = r.MaxWorkers
+		options.NewQueue = r.workers.newQueue
+		if err := mgr.Add(r.workers); err != nil {
+			return err
+		}
+	}
+
+	bldr := ctrl.NewControllerManagedBy(mgr).
+		// The primary resource this controller watches. Status updates, including the
+		// controller's own, don't change the generation or metadata and are skipped.
+		For(&webappv1.App{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{},
+			predicate.AnnotationChangedPredicate{}, predicate.LabelChangedPredicate{}))).
+		// Watches Deployments that are owned by an App. Status changes go to the status worker.
+		Owns(&appsv1.Deployment{}, builder.WithPredicates(specChanged)).
+		Owns(&corev1.Service{}).        // Watches Services that are owned by an App
+		Owns(&corev1.ConfigMap{}).      // Watches ConfigMaps that are owned by an App
+		Owns(&corev1.ServiceAccount{}). // Watches ServiceAccounts that are owned by an App
+		// Watches PersistentVolumeClaims that are owned by an App, for their phase.
+		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&networkingv1.NetworkPolicy{}). // Watches NetworkPolicies that are owned by an App
+		Owns(&networkingv1.Ingress{}).       // Watches Ingresses that are owned by an App
+		// Watches HorizontalPodAutoscalers that are owned by an App, for their replica counts.
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Owns(&policyv1.PodDisruptionBudget{}). // Watches PodDisruptionBudgets that are owned by an App
+		// Watches the pods of an App's Deployments, two owners down. Only metadata is cached.
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(enqueueAppForPod), builder.OnlyMetadata).
+		// Rolls out Apps when a ConfigMap their environment reads changes.
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.enqueueEnvConfigMapApps)).
+		// Re-derives the NetworkPolicies of the Apps an App depends on when its spec changes.
+		Watches(&webappv1.App{}, handler.EnqueueRequestsFromMapFunc(enqueueDependencies),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// Re-checks mesh enrollment, and the AppPolicies granting references, when namespace
+		// labels change. Only metadata is cached.
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(r.enqueueNamespaceApps),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}), builder.OnlyMetadata).
+		// Re-checks references leaving an App's namespace when the grants of AppPolicies change.
+		Watches(&webappv1.AppPolicy{}, handler.EnqueueRequestsFromMapFunc(r.enqueueReferencingApps),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	if r.PullSecret.Name != "" {
+		// Refreshes the copies of the central pull secret when it or a copy changes.
+		bldr = bldr.Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.enqueuePullSecretApps))
+	}
+	return bldr.
+		WithOptions(options).
 		Complete(r)
 }
+
+// enqueueAppForPod maps a pod to the App whose Deployment it belongs to: the pod's "app"
+// label names the App, and its controller is a ReplicaSet of one of the App's Deployments.
+// Pods of other workloads sharing the label are ignored.
+func enqueueAppForPod(ctx context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()["app"]
+	owner := metav1.GetControllerOf(obj)
+	if name == "" || owner == nil || owner.Kind != "ReplicaSet" {
+		return nil
+	}
+	for _, deployment := range []string{name + "-deployment-", name + "-canary-deployment-", name + "-preview-deployment-"} {
+		if strings.HasPrefix(owner.Name, deployment) {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+		}
+	}
+	return nil
+}
@@ -18,6 +18,8 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"sync/atomic"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -82,3 +84,22 @@ var _ = Describe("App Controller", func() {
 		})
 	})
 })
+
+var _ = Describe("Concurrent apply", func() {
+	It("should attempt every child resource and return all errors", func() {
+		var applied atomic.Int32
+		apply := func(err error) func(context.Context) error {
+			return func(context.Context) error {
+				applied.Add(1)
+				return err
+			}
+		}
+
+		err := applyConcurrently(ctx, apply(nil), apply(fmt.Errorf("deployment failed")), apply(fmt.Errorf("service failed")))
+		Expect(applied.Load()).To(Equal(int32(3)))
+		Expect(err).To(MatchError(ContainSubstring("deployment failed")))
+		Expect(err).To(MatchError(ContainSubstring("service failed")))
+
+		Expect(applyConcurrently(ctx, apply(nil), apply(nil))).To(Succeed())
+	})
+})
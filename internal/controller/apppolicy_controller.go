@@ -0,0 +1,250 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/policy"
+)
+
+// AppPolicyReconciler compiles AppPolicies into ValidatingAdmissionPolicies and their
+// bindings. The App webhook enforces AppPolicies itself; the admission policies keep them
+// enforced by the API server while the webhook is unavailable.
+type AppPolicyReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=webapp.example.com,resources=apppolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=webapp.example.com,resources=apppolicies/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingadmissionpolicies;validatingadmissionpolicybindings,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile generates the ValidatingAdmissionPolicy and binding of an AppPolicy with
+// spec.validatingAdmissionPolicy whose rules compile, removes them otherwise, and reports
+// the outcome in the Ready condition.
+func (r *AppPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	appPolicy := &webappv1.AppPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, appPolicy); err != nil {
+		// Generated objects are garbage collected with their AppPolicy.
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	original := appPolicy.DeepCopy()
+
+	ready := metav1.Condition{
+		Type:               conditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Compiled",
+		Message:            "The rules compile",
+		ObservedGeneration: appPolicy.Generation,
+	}
+	_, compileErr := policy.Compile(appPolicy)
+	if compileErr != nil {
+		ready.Status, ready.Reason, ready.Message = metav1.ConditionFalse, "InvalidRule", compileErr.Error()
+	}
+
+	generate := appPolicy.Spec.ValidatingAdmissionPolicy && compileErr == nil
+	err := r.reconcileAdmissionPolicy(ctx, appPolicy, generate)
+	switch {
+	case err != nil:
+		log.Error(err, "Failed to reconcile the ValidatingAdmissionPolicy of AppPolicy")
+		ready.Status, ready.Reason, ready.Message = metav1.ConditionFalse, "AdmissionPolicyFailed", err.Error()
+	case generate:
+		ready.Reason, ready.Message = "AdmissionPolicyGenerated", fmt.Sprintf("The rules are enforced by ValidatingAdmissionPolicy %s", admissionPolicyName(appPolicy))
+	}
+
+	appPolicy.Status.ObservedGeneration = appPolicy.Generation
+	meta.SetStatusCondition(&appPolicy.Status.Conditions, ready)
+	if !equality.Semantic.DeepEqual(original.Status, appPolicy.Status) {
+		if statusErr := r.Status().Patch(ctx, appPolicy, client.MergeFrom(original)); statusErr != nil {
+			return ctrl.Result{}, statusErr
+		}
+	}
+	return ctrl.Result{}, err
+}
+
+// admissionPolicyName returns the name of the ValidatingAdmissionPolicy, and of its binding,
+// generated from an AppPolicy.
+func admissionPolicyName(appPolicy *webappv1.AppPolicy) string {
+	return fmt.Sprintf("apppolicy-%s", appPolicy.Name)
+}
+
+// desiredValidatingAdmissionPolicy returns the ValidatingAdmissionPolicy enforcing the rules
+// of an AppPolicy, without owner. It matches the creation and update of Apps in any version,
+// converted to v1 as the rules expect, and fails closed like the App webhook. Fields the API
+// server defaults are set, so an unchanged policy compares equal to the stored one.
+func desiredValidatingAdmissionPolicy(appPolicy *webappv1.AppPolicy) *admissionregistrationv1.ValidatingAdmissionPolicy {
+	validations := make([]admissionregistrationv1.Validation, 0, len(appPolicy.Spec.Rules))
+	for _, rule := range appPolicy.Spec.Rules {
+		validations = append(validations, admissionregistrationv1.Validation{
+			Expression: rule.Expression,
+			Message:    policy.Message(appPolicy.Name, rule),
+		})
+	}
+	return &admissionregistrationv1.ValidatingAdmissionPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   admissionPolicyName(appPolicy),
+			Labels: appPolicyLabels(appPolicy),
+		},
+		Spec: admissionregistrationv1.ValidatingAdmissionPolicySpec{
+			FailurePolicy: ptr.To(admissionregistrationv1.Fail),
+			MatchConstraints: &admissionregistrationv1.MatchResources{
+				NamespaceSelector: &metav1.LabelSelector{},
+				ObjectSelector:    &metav1.LabelSelector{},
+				MatchPolicy:       ptr.To(admissionregistrationv1.Equivalent),
+				ResourceRules: []admissionregistrationv1.NamedRuleWithOperations{{
+					RuleWithOperations: admissionregistrationv1.RuleWithOperations{
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{webappv1.GroupVersion.Group},
+							APIVersions: []string{webappv1.GroupVersion.Version},
+							Resources:   []string{"apps"},
+							Scope:       ptr.To(admissionregistrationv1.NamespacedScope),
+						},
+					},
+				}},
+			},
+			Validations: validations,
+		},
+	}
+}
+
+// desiredValidatingAdmissionPolicyBinding returns the binding denying the Apps of the
+// namespaces selected by an AppPolicy that don't satisfy its ValidatingAdmissionPolicy,
+// without owner.
+func desiredValidatingAdmissionPolicyBinding(appPolicy *webappv1.AppPolicy) *admissionregistrationv1.ValidatingAdmissionPolicyBinding {
+	namespaceSelector := &metav1.LabelSelector{}
+	if appPolicy.Spec.NamespaceSelector != nil {
+		namespaceSelector = appPolicy.Spec.NamespaceSelector.DeepCopy()
+	}
+	return &admissionregistrationv1.ValidatingAdmissionPolicyBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   admissionPolicyName(appPolicy),
+			Labels: appPolicyLabels(appPolicy),
+		},
+		Spec: admissionregistrationv1.ValidatingAdmissionPolicyBindingSpec{
+			PolicyName:        admissionPolicyName(appPolicy),
+			ValidationActions: []admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny},
+			MatchResources: &admissionregistrationv1.MatchResources{
+				NamespaceSelector: namespaceSelector,
+				ObjectSelector:    &metav1.LabelSelector{},
+				MatchPolicy:       ptr.To(admissionregistrationv1.Equivalent),
+			},
+		},
+	}
+}
+
+// appPolicyLabels returns the labels of the objects generated from an AppPolicy.
+func appPolicyLabels(appPolicy *webappv1.AppPolicy) map[string]string {
+	return map[string]string{
+		"app-policy": appPolicy.Name,
+		"controller": "app-controller",
+	}
+}
+
+// reconcileAdmissionPolicy creates or updates the ValidatingAdmissionPolicy and binding of an
+// AppPolicy when generate is set, and removes those it generated earlier otherwise.
+func (r *AppPolicyReconciler) reconcileAdmissionPolicy(ctx context.Context, appPolicy *webappv1.AppPolicy, generate bool) error {
+	desiredPolicy := desiredValidatingAdmissionPolicy(appPolicy)
+	desiredBinding := desiredValidatingAdmissionPolicyBinding(appPolicy)
+
+	if !generate {
+		// The binding goes first, so the API server never binds a missing policy.
+		for _, obj := range []client.Object{&admissionregistrationv1.ValidatingAdmissionPolicyBinding{}, &admissionregistrationv1.ValidatingAdmissionPolicy{}} {
+			if err := r.deleteOwned(ctx, appPolicy, obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The policy goes first, so the binding never refers to a missing one.
+	policyObj := &admissionregistrationv1.ValidatingAdmissionPolicy{ObjectMeta: metav1.ObjectMeta{Name: desiredPolicy.Name}}
+	if err := r.createOrUpdateOwned(ctx, appPolicy, policyObj, func() {
+		policyObj.Labels = mergeMaps(policyObj.Labels, desiredPolicy.Labels)
+		policyObj.Spec = desiredPolicy.Spec
+	}); err != nil {
+		return err
+	}
+	binding := &admissionregistrationv1.ValidatingAdmissionPolicyBinding{ObjectMeta: metav1.ObjectMeta{Name: desiredBinding.Name}}
+	return r.createOrUpdateOwned(ctx, appPolicy, binding, func() {
+		binding.Labels = mergeMaps(binding.Labels, desiredBinding.Labels)
+		binding.Spec = desiredBinding.Spec
+	})
+}
+
+// createOrUpdateOwned creates obj, or updates it when mutate changes it, controlled by the
+// AppPolicy. An existing object the AppPolicy doesn't control is left alone.
+func (r *AppPolicyReconciler) createOrUpdateOwned(ctx context.Context, appPolicy *webappv1.AppPolicy, obj client.Object, mutate func()) error {
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, obj, func() error {
+		if obj.GetResourceVersion() != "" && !metav1.IsControlledBy(obj, appPolicy) {
+			return fmt.Errorf("%T %q exists and is not owned by the AppPolicy", obj, obj.GetName())
+		}
+		mutate()
+		return ctrl.SetControllerReference(appPolicy, obj, r.Scheme)
+	})
+	if result != controllerutil.OperationResultNone {
+		log.FromContext(ctx).Info("Reconciled admission policy object", "Kind", fmt.Sprintf("%T", obj), "Name", obj.GetName(), "Operation", result)
+	}
+	return err
+}
+
+// deleteOwned deletes the object named after the AppPolicy into which obj is read, when the
+// AppPolicy controls it.
+func (r *AppPolicyReconciler) deleteOwned(ctx context.Context, appPolicy *webappv1.AppPolicy, obj client.Object) error {
+	if err := r.Get(ctx, types.NamespacedName{Name: admissionPolicyName(appPolicy)}, obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, appPolicy) {
+		return nil
+	}
+	log.FromContext(ctx).Info("Deleting admission policy object no longer used by AppPolicy", "Kind", fmt.Sprintf("%T", obj), "Name", obj.GetName())
+	if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AppPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		// Status updates, including the controller's own, don't change the generation and are skipped.
+		For(&webappv1.AppPolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&admissionregistrationv1.ValidatingAdmissionPolicy{}).        // Watches the generated ValidatingAdmissionPolicies
+		Owns(&admissionregistrationv1.ValidatingAdmissionPolicyBinding{}). // Watches the generated bindings
+		Named("apppolicy").
+		Complete(r)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("App policies", func() {
+	var appPolicy *webappv1.AppPolicy
+
+	BeforeEach(func() {
+		appPolicy = &webappv1.AppPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "production", UID: "policy-uid", Generation: 1},
+			Spec: webappv1.AppPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+				Rules: []webappv1.AppPolicyRule{
+					{Name: "replicated", Expression: "object.spec.replicas >= 2", Message: "production Apps run at least two replicas"},
+					{Name: "pinned", Expression: "!object.spec.image.endsWith(':latest')"},
+				},
+				ValidatingAdmissionPolicy: true,
+			},
+		}
+	})
+
+	// reconcilePolicy reconciles the AppPolicy and returns it with the objects generated from it.
+	reconcilePolicy := func(c client.Client) (*admissionregistrationv1.ValidatingAdmissionPolicy, *admissionregistrationv1.ValidatingAdmissionPolicyBinding) {
+		r := &AppPolicyReconciler{Client: c, Scheme: c.Scheme()}
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(appPolicy)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(appPolicy), appPolicy)).To(Succeed())
+
+		key := types.NamespacedName{Name: "apppolicy-production"}
+		policyObj := &admissionregistrationv1.ValidatingAdmissionPolicy{}
+		if err := c.Get(ctx, key, policyObj); errors.IsNotFound(err) {
+			policyObj = nil
+		} else {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		binding := &admissionregistrationv1.ValidatingAdmissionPolicyBinding{}
+		if err := c.Get(ctx, key, binding); errors.IsNotFound(err) {
+			binding = nil
+		} else {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		return policyObj, binding
+	}
+
+	It("should compile the rules into a ValidatingAdmissionPolicy bound to the selected namespaces", func() {
+		c := newTestClient(appPolicy)
+
+		policyObj, binding := reconcilePolicy(c)
+		Expect(policyObj).NotTo(BeNil())
+		Expect(metav1.IsControlledBy(policyObj, appPolicy)).To(BeTrue())
+		Expect(policyObj.Labels).To(HaveKeyWithValue("controller", "app-controller"))
+		Expect(*policyObj.Spec.FailurePolicy).To(Equal(admissionregistrationv1.Fail))
+		Expect(policyObj.Spec.MatchConstraints.ResourceRules).To(HaveLen(1))
+		rule := policyObj.Spec.MatchConstraints.ResourceRules[0]
+		Expect(rule.Operations).To(ConsistOf(admissionregistrationv1.Create, admissionregistrationv1.Update))
+		Expect(rule.APIGroups).To(Equal([]string{"webapp.example.com"}))
+		Expect(rule.APIVersions).To(Equal([]string{"v1"}))
+		Expect(rule.Resources).To(Equal([]string{"apps"}))
+		Expect(*policyObj.Spec.MatchConstraints.MatchPolicy).To(Equal(admissionregistrationv1.Equivalent))
+		Expect(policyObj.Spec.Validations).To(Equal([]admissionregistrationv1.Validation{
+			{Expression: "object.spec.replicas >= 2", Message: "AppPolicy production: production Apps run at least two replicas"},
+			{Expression: "!object.spec.image.endsWith(':latest')", Message: "AppPolicy production: rule pinned is not satisfied"},
+		}))
+
+		Expect(binding).NotTo(BeNil())
+		Expect(metav1.IsControlledBy(binding, appPolicy)).To(BeTrue())
+		Expect(binding.Spec.PolicyName).To(Equal("apppolicy-production"))
+		Expect(binding.Spec.ValidationActions).To(Equal([]admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny}))
+		Expect(binding.Spec.MatchResources.NamespaceSelector).To(Equal(appPolicy.Spec.NamespaceSelector))
+
+		ready := meta.FindStatusCondition(appPolicy.Status.Conditions, conditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+		Expect(ready.Reason).To(Equal("AdmissionPolicyGenerated"))
+		Expect(appPolicy.Status.ObservedGeneration).To(Equal(int64(1)))
+	})
+
+	It("should follow changes to the rules and leave an unchanged policy alone", func() {
+		c := newTestClient(appPolicy)
+		policyObj, _ := reconcilePolicy(c)
+
+		unchanged, _ := reconcilePolicy(c)
+		Expect(unchanged.ResourceVersion).To(Equal(policyObj.ResourceVersion))
+
+		appPolicy.Spec.Rules = appPolicy.Spec.Rules[:1]
+		Expect(c.Update(ctx, appPolicy)).To(Succeed())
+		updated, _ := reconcilePolicy(c)
+		Expect(updated.Spec.Validations).To(HaveLen(1))
+	})
+
+	It("should remove the generated objects when the policy is no longer compiled", func() {
+		c := newTestClient(appPolicy)
+		reconcilePolicy(c)
+
+		appPolicy.Spec.ValidatingAdmissionPolicy = false
+		Expect(c.Update(ctx, appPolicy)).To(Succeed())
+		policyObj, binding := reconcilePolicy(c)
+		Expect(policyObj).To(BeNil())
+		Expect(binding).To(BeNil())
+		Expect(meta.FindStatusCondition(appPolicy.Status.Conditions, conditionReady).Reason).To(Equal("Compiled"))
+	})
+
+	It("should not generate admission policies from rules that don't compile", func() {
+		appPolicy.Spec.Rules = append(appPolicy.Spec.Rules, webappv1.AppPolicyRule{Name: "broken", Expression: "object.spec.replicas >="})
+		c := newTestClient(appPolicy)
+
+		policyObj, binding := reconcilePolicy(c)
+		Expect(policyObj).To(BeNil())
+		Expect(binding).To(BeNil())
+		ready := meta.FindStatusCondition(appPolicy.Status.Conditions, conditionReady)
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		Expect(ready.Reason).To(Equal("InvalidRule"))
+		Expect(ready.Message).To(ContainSubstring("rule broken"))
+	})
+
+	It("should leave admission policies it doesn't own alone", func() {
+		foreign := &admissionregistrationv1.ValidatingAdmissionPolicy{ObjectMeta: metav1.ObjectMeta{Name: "apppolicy-production"}}
+		c := newTestClient(appPolicy, foreign)
+
+		r := &AppPolicyReconciler{Client: c, Scheme: c.Scheme()}
+		_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(appPolicy)})
+		Expect(err).To(MatchError(ContainSubstring("is not owned by the AppPolicy")))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(appPolicy), appPolicy)).To(Succeed())
+		Expect(meta.FindStatusCondition(appPolicy.Status.Conditions, conditionReady).Reason).To(Equal("AdmissionPolicyFailed"))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(foreign), foreign)).To(Succeed())
+		Expect(foreign.Spec.Validations).To(BeEmpty())
+	})
+})
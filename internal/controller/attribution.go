@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// attributionAnnotations returns the annotations tying an App's child resources to the
+// user who last changed the App, as recorded by the App admission webhook.
+func attributionAnnotations(app *webappv1.App) map[string]string {
+	actor := app.Annotations[webappv1.LastModifiedByAnnotation]
+	if actor == "" {
+		return nil
+	}
+	return map[string]string{webappv1.LastModifiedByAnnotation: actor}
+}
+
+// syncAttribution copies the App's last-modified-by annotation onto an existing child
+// resource, and reports whether the child's annotations changed.
+func syncAttribution(app *webappv1.App, obj metav1.Object) bool {
+	actor := app.Annotations[webappv1.LastModifiedByAnnotation]
+	annotations := obj.GetAnnotations()
+	if annotations[webappv1.LastModifiedByAnnotation] == actor {
+		return false
+	}
+	if actor == "" {
+		delete(annotations, webappv1.LastModifiedByAnnotation)
+	} else {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[webappv1.LastModifiedByAnnotation] = actor
+	}
+	obj.SetAnnotations(annotations)
+	return true
+}
+
+// eventAttribution returns the suffix of the Events recorded for changes to an App's child
+// resources, naming the user who last changed the App's spec, as in
+// "Updated Deployment web-deployment after a change by alice". It is empty without the
+// last-modified-by annotation, and while the App is being deleted, as its spec no longer
+// drives the changes then.
+func eventAttribution(app *webappv1.App) string {
+	actor := app.Annotations[webappv1.LastModifiedByAnnotation]
+	if actor == "" || !app.DeletionTimestamp.IsZero() {
+		return ""
+	}
+	return " after a change by " + actor
+}
+
+// trackedAnnotations returns annotations set from the App's spec with key listing theirs,
+// so that syncTrackedAnnotations can remove them once the App no longer sets them.
+func trackedAnnotations(annotations map[string]string, key string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return mergeMaps(annotations, map[string]string{key: strings.Join(keys, ",")})
+}
+
+// syncTrackedAnnotations writes the annotations of trackedAnnotations over a stored object
+// and removes those listed under key that the App no longer sets. Annotations added by
+// others are kept.
+func syncTrackedAnnotations(obj metav1.Object, key string, annotations map[string]string) {
+	stored := obj.GetAnnotations()
+	if previous, ok := stored[key]; ok {
+		for _, k := range strings.Split(previous, ",") {
+			delete(stored, k)
+		}
+		delete(stored, key)
+	}
+	obj.SetAnnotations(mergeMaps(stored, trackedAnnotations(annotations, key)))
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Change attribution", func() {
+	It("should copy the last modifier of the App onto child resources", func() {
+		app := &webappv1.App{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{webappv1.LastModifiedByAnnotation: "system:serviceaccount:ci:deployer"},
+		}}
+		child := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"team": "shop"}}}
+
+		Expect(syncAttribution(app, child)).To(BeTrue())
+		Expect(child.Annotations).To(Equal(map[string]string{
+			"team":                            "shop",
+			webappv1.LastModifiedByAnnotation: "system:serviceaccount:ci:deployer",
+		}))
+		Expect(syncAttribution(app, child)).To(BeFalse())
+	})
+
+	It("should drop a stale attribution when the App has none", func() {
+		child := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{webappv1.LastModifiedByAnnotation: "alice"}}}
+
+		Expect(syncAttribution(&webappv1.App{}, child)).To(BeTrue())
+		Expect(child.Annotations).To(BeEmpty())
+	})
+})
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// defaultTargetCPUUtilization is the target of autoscalers without metrics, as the
+// HorizontalPodAutoscaler API defaults it.
+const defaultTargetCPUUtilization = 80
+
+// hpaName returns the name of the HorizontalPodAutoscaler of an App.
+func hpaName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-hpa", app.Name)
+}
+
+// specReplicas returns the number of pods the App asks for: spec.replicas, 1 when unset, or
+// the minimum of its autoscaler.
+func specReplicas(app *webappv1.App) int32 {
+	if autoscaling := app.Spec.Autoscaling; autoscaling != nil {
+		if autoscaling.MinReplicas != nil {
+			return *autoscaling.MinReplicas
+		}
+		return 1
+	}
+	if app.Spec.Replicas == 0 {
+		return 1
+	}
+	return app.Spec.Replicas
+}
+
+// autoscaled reports whether the replicas of the App's Deployment are set by a
+// HorizontalPodAutoscaler.
+func autoscaled(app *webappv1.App) bool {
+	return app.Spec.Autoscaling != nil && !isKnative(app)
+}
+
+// autoscalingMetrics returns the metrics of the App's autoscaler: the utilization targets of
+// the app container, then its additional metrics.
+func autoscalingMetrics(app *webappv1.App) []autoscalingv2.MetricSpec {
+	spec := app.Spec.Autoscaling
+	utilization := func(name corev1.ResourceName, target int32) autoscalingv2.MetricSpec {
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ContainerResourceMetricSourceType,
+			ContainerResource: &autoscalingv2.ContainerResourceMetricSource{
+				Name:      name,
+				Container: containerName(app),
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: ptr.To(target),
+				},
+			},
+		}
+	}
+
+	var metrics []autoscalingv2.MetricSpec
+	if spec.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, utilization(corev1.ResourceCPU, *spec.TargetCPUUtilizationPercentage))
+	}
+	if spec.TargetMemoryUtilizationPercentage != nil {
+		metrics = append(metrics, utilization(corev1.ResourceMemory, *spec.TargetMemoryUtilizationPercentage))
+	}
+	metrics = append(metrics, spec.Metrics...)
+	if len(metrics) == 0 {
+		metrics = append(metrics, utilization(corev1.ResourceCPU, defaultTargetCPUUtilization))
+	}
+	return metrics
+}
+
+// desiredHPA builds the HorizontalPodAutoscaler scaling the App's Deployment, without owner.
+func desiredHPA(app *webappv1.App) *autoscalingv2.HorizontalPodAutoscaler {
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        hpaName(app),
+			Namespace:   app.Namespace,
+			Annotations: attributionAnnotations(app),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       fmt.Sprintf("%s-deployment", app.Name),
+			},
+			MinReplicas: ptr.To(specReplicas(app)),
+			MaxReplicas: app.Spec.Autoscaling.MaxReplicas,
+			Metrics:     autoscalingMetrics(app),
+		},
+	}
+}
+
+// reconcileHPA creates or updates the HorizontalPodAutoscaler of an App with
+// spec.autoscaling, and removes a previously generated one otherwise. It returns the
+// autoscaler as last written or read, nil when the App has none.
+func (r *AppReconciler) reconcileHPA(ctx context.Context, app *webappv1.App) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	log := log.FromContext(ctx)
+
+	found := &autoscalingv2.HorizontalPodAutoscaler{}
+	getErr := r.Get(ctx, types.NamespacedName{Name: hpaName(app), Namespace: app.Namespace}, found)
+
+	if !autoscaled(app) {
+		if errors.IsNotFound(getErr) {
+			return nil, nil
+		}
+		if getErr != nil {
+			return nil, getErr
+		}
+		if !metav1.IsControlledBy(found, app) {
+			return nil, nil
+		}
+		log.Info("Deleting HorizontalPodAutoscaler no longer used by App", "HorizontalPodAutoscaler.Name", found.Name)
+		return nil, r.deleteChild(ctx, app, found)
+	}
+
+	desired := desiredHPA(app)
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	if errors.IsNotFound(getErr) {
+		log.Info("Creating a new HorizontalPodAutoscaler", "HorizontalPodAutoscaler.Namespace", desired.Namespace, "HorizontalPodAutoscaler.Name", desired.Name)
+		return desired, r.createChild(ctx, app, desired)
+	} else if getErr != nil {
+		return nil, getErr
+	}
+	if !metav1.IsControlledBy(found, app) {
+		return nil, fmt.Errorf("HorizontalPodAutoscaler %q exists and is not owned by the App", found.Name)
+	}
+
+	updated := found.DeepCopy()
+	updated.Labels = mergeMaps(updated.Labels, desired.Labels)
+	updated.Spec = desired.Spec
+	syncAttribution(app, updated)
+	if changed, err := r.syncChild(ctx, r.Client, app, found, updated); err != nil || !changed {
+		return found, err
+	}
+	return updated, nil
+}
+
+// autoscalingStatus returns the state of an App's HorizontalPodAutoscaler, nil when it has none.
+func autoscalingStatus(hpa *autoscalingv2.HorizontalPodAutoscaler) *webappv1.AutoscalingStatus {
+	if hpa == nil {
+		return nil
+	}
+	return &webappv1.AutoscalingStatus{
+		CurrentReplicas: hpa.Status.CurrentReplicas,
+		DesiredReplicas: hpa.Status.DesiredReplicas,
+		LastScaleTime:   hpa.Status.LastScaleTime,
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Horizontal autoscaling", func() {
+	newApp := func(autoscaling *webappv1.AutoscalingSpec) *webappv1.App {
+		return &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+			Spec:       webappv1.AppSpec{Image: "web:1.0", Port: 8080, Autoscaling: autoscaling},
+		}
+	}
+
+	It("should scale the Deployment with an HPA and leave its replicas to it", func() {
+		podsMetric := autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{Name: "requests_per_second"},
+				Target: autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: ptr.To(resource.MustParse("100"))},
+			},
+		}
+		app := newApp(&webappv1.AutoscalingSpec{
+			MinReplicas:                       ptr.To[int32](2),
+			MaxReplicas:                       10,
+			TargetMemoryUtilizationPercentage: ptr.To[int32](70),
+			Metrics:                           []autoscalingv2.MetricSpec{podsMetric},
+		})
+		r, c := newTestReconciler()
+
+		hpa, err := r.reconcileHPA(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hpa.Spec.ScaleTargetRef.Name).To(Equal("web-deployment"))
+		Expect(*hpa.Spec.MinReplicas).To(Equal(int32(2)))
+		Expect(hpa.Spec.MaxReplicas).To(Equal(int32(10)))
+		Expect(hpa.Spec.Metrics).To(HaveLen(2))
+		Expect(hpa.Spec.Metrics[0].ContainerResource.Name).To(Equal(corev1.ResourceMemory))
+		Expect(hpa.Spec.Metrics[0].ContainerResource.Container).To(Equal(appContainerName))
+		Expect(hpa.Spec.Metrics[1]).To(Equal(podsMetric))
+
+		deployment, err := r.reconcileDeployment(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+		deployment.Spec.Replicas = ptr.To[int32](7)
+		Expect(c.Update(ctx, deployment)).To(Succeed())
+		deployment, err = r.reconcileDeployment(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(7)))
+
+		By("reporting the autoscaler's replica counts")
+		hpa.Status = autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 7, DesiredReplicas: 8}
+		Expect(autoscalingStatus(hpa)).To(Equal(&webappv1.AutoscalingStatus{CurrentReplicas: 7, DesiredReplicas: 8}))
+
+		By("removing the HPA when the App goes back to fixed replicas")
+		app.Spec.Autoscaling = nil
+		app.Spec.Replicas = 3
+		Expect(r.reconcileHPA(ctx, app)).To(BeNil())
+		Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "web-hpa", Namespace: "default"}, &autoscalingv2.HorizontalPodAutoscaler{}))).To(BeTrue())
+		deployment, err = r.reconcileDeployment(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(3)))
+	})
+
+	It("should target 80% CPU without other metrics", func() {
+		metrics := autoscalingMetrics(newApp(&webappv1.AutoscalingSpec{MaxReplicas: 5}))
+		Expect(metrics).To(HaveLen(1))
+		Expect(metrics[0].ContainerResource.Name).To(Equal(corev1.ResourceCPU))
+		Expect(*metrics[0].ContainerResource.Target.AverageUtilization).To(Equal(int32(80)))
+	})
+
+	It("should run one pod when neither replicas nor autoscaling are set", func() {
+		Expect(specReplicas(newApp(nil))).To(Equal(int32(1)))
+		Expect(specReplicas(newApp(&webappv1.AutoscalingSpec{MaxReplicas: 5}))).To(Equal(int32(1)))
+	})
+})
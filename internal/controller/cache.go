@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// managedSelector selects the child objects created by the controller.
+var managedSelector = labels.SelectorFromSet(labels.Set{"controller": "app-controller"})
+
+// podSelector selects the pods that may belong to an App: its Deployments label their pods
+// with the App's name (see enqueueAppForPod).
+var podSelector = func() labels.Selector {
+	hasApp, err := labels.NewRequirement("app", selection.Exists, nil)
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*hasApp)
+}()
+
+// CacheOptions returns the manager cache options of the controller.
+//
+// Deployments, Services, ServiceAccounts, PersistentVolumeClaims, NetworkPolicies, Ingresses,
+// HorizontalPodAutoscalers, PodDisruptionBudgets, ValidatingAdmissionPolicies and their
+// bindings, and Secrets are only cached when they carry the controller's label, so objects
+// the controller does not manage are not held in memory.
+// The namespace of the central pull secret (if any) is cached in full, as the source
+// Secret is not labelled. ConfigMaps stay unscoped, as Apps may reference their own.
+//
+// managedFields are stripped from every cached object. Apps, which the controller never
+// updates in full, also lose the kubectl last-applied-configuration annotation, a complete
+// copy of the object that often dominates its size. Objects the controller updates keep
+// their annotations, as an update from the cache would otherwise remove them on the server.
+func CacheOptions(pullSecretNamespace string) cache.Options {
+	secrets := cache.ByObject{Label: managedSelector}
+	if pullSecretNamespace != "" {
+		secrets.Namespaces = map[string]cache.Config{
+			pullSecretNamespace: {LabelSelector: labels.Everything()},
+			cache.AllNamespaces: {LabelSelector: managedSelector},
+		}
+	}
+	return cache.Options{
+		DefaultTransform: cache.TransformStripManagedFields(),
+		ByObject: map[client.Object]cache.ByObject{
+			&webappv1.App{}:                                             {Transform: stripReadOnlyMetadata},
+			&appsv1.Deployment{}:                                        {Label: managedSelector},
+			&corev1.Service{}:                                           {Label: managedSelector},
+			&corev1.ServiceAccount{}:                                    {Label: managedSelector},
+			&corev1.PersistentVolumeClaim{}:                             {Label: managedSelector},
+			&networkingv1.NetworkPolicy{}:                               {Label: managedSelector},
+			&networkingv1.Ingress{}:                                     {Label: managedSelector},
+			&autoscalingv2.HorizontalPodAutoscaler{}:                    {Label: managedSelector},
+			&policyv1.PodDisruptionBudget{}:                             {Label: managedSelector},
+			&admissionregistrationv1.ValidatingAdmissionPolicy{}:        {Label: managedSelector},
+			&admissionregistrationv1.ValidatingAdmissionPolicyBinding{}: {Label: managedSelector},
+			&corev1.Secret{}:                                            secrets,
+			&corev1.Pod{}:                                               {Label: podSelector},
+		},
+	}
+}
+
+// stripReadOnlyMetadata is a cache transform removing managedFields and the
+// last-applied-configuration annotation. Objects that aren't API objects, such as
+// deletion tombstones, are passed through.
+func stripReadOnlyMetadata(in interface{}) (interface{}, error) {
+	obj, err := meta.Accessor(in)
+	if err != nil {
+		return in, nil
+	}
+	obj.SetManagedFields(nil)
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		delete(annotations, corev1.LastAppliedConfigAnnotation)
+		obj.SetAnnotations(annotations)
+	}
+	return in, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Cache transforms", func() {
+	It("should strip managedFields and the last applied configuration", func() {
+		app := &webappv1.App{ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				corev1.LastAppliedConfigAnnotation: `{"apiVersion":"webapp.example.com/v1","kind":"App"}`,
+				"team":                             "shop",
+			},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		}}
+
+		out, err := stripReadOnlyMetadata(app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.(*webappv1.App).ManagedFields).To(BeNil())
+		Expect(out.(*webappv1.App).Annotations).To(Equal(map[string]string{"team": "shop"}))
+	})
+
+	It("should only cache the controller's own children", func() {
+		managed := labels.Set{"controller": "app-controller"}
+		for obj, byObject := range CacheOptions("").ByObject {
+			if _, ok := obj.(*webappv1.App); ok {
+				Expect(byObject.Label).To(BeNil())
+				continue
+			}
+			if _, ok := obj.(*corev1.Pod); ok {
+				// Pods are created by ReplicaSets and only carry the App's label.
+				Expect(byObject.Label.Matches(labels.Set{"app": "web"})).To(BeTrue())
+				Expect(byObject.Label.Matches(managed)).To(BeFalse())
+				continue
+			}
+			Expect(byObject.Label.Matches(managed)).To(BeTrue())
+			Expect(byObject.Label.Matches(labels.Set{"app": "web"})).To(BeFalse())
+		}
+
+		var secrets cache.ByObject
+		for obj, byObject := range CacheOptions("registry").ByObject {
+			if _, ok := obj.(*corev1.Secret); ok {
+				secrets = byObject
+			}
+		}
+		Expect(secrets.Namespaces).To(HaveKey("registry"))
+		Expect(secrets.Namespaces["registry"].LabelSelector.Empty()).To(BeTrue())
+		Expect(secrets.Namespaces[cache.AllNamespaces].LabelSelector.Matches(managed)).To(BeTrue())
+	})
+})
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// appCleanupFinalizer holds back the deletion of an App until its objects are torn down
+	// in order (see finalize).
+	appCleanupFinalizer = "webapp.example.com/cleanup"
+	// teardownInterval is how often the teardown of a deleted App checks whether the objects
+	// of a stage are gone.
+	teardownInterval = 5 * time.Second
+)
+
+// teardownStages returns the objects of an App deleted in turn when the App is, without
+// namespace: first those routing traffic to its pods, then its Services, then its workload.
+// Objects not listed are left to the garbage collector.
+func teardownStages(app *webappv1.App) [][]client.Object {
+	unstructuredObject := func(gvk schema.GroupVersionKind) client.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetName(app.Name)
+		return obj
+	}
+	routing := []client.Object{
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: ingressName(app)}},
+		unstructuredObject(httpRouteGVK),
+		unstructuredObject(httpScaledObjectGVK),
+	}
+	services := []client.Object{
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-service", app.Name)}},
+	}
+	workload := []client.Object{
+		&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: hpaName(app)}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-deployment", app.Name)}},
+		unstructuredObject(knativeServiceGVK),
+	}
+	for _, track := range []string{trackCanary, trackPreview} {
+		services = append(services, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s-service", app.Name, track)}})
+		workload = append(workload, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s-deployment", app.Name, track)}})
+	}
+	return [][]client.Object{routing, services, workload}
+}
+
+// finalize tears down a deleted App: its objects in a target cluster, then the stages of
+// teardownStages, so that load balancers stop sending requests to the App before the pods
+// serving them go away. A stage starts once the objects of the previous one are gone, and
+// finalize returns how long to wait for them. The App is released after the last stage.
+func (r *AppReconciler) finalize(ctx context.Context, app *webappv1.App) (time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	if err := r.finalizeRemote(ctx, app); err != nil {
+		return 0, err
+	}
+	if !controllerutil.ContainsFinalizer(app, appCleanupFinalizer) {
+		return 0, nil
+	}
+	for i, stage := range teardownStages(app) {
+		pending, err := r.teardown(ctx, app, stage...)
+		if err != nil {
+			return 0, err
+		}
+		if pending {
+			log.Info("Waiting for App's objects to be deleted", "Stage", i+1)
+			return teardownInterval, nil
+		}
+	}
+	log.Info("Tore down App's objects")
+	return 0, r.patchFinalizer(ctx, app, appCleanupFinalizer, false)
+}
+
+// teardown deletes the given objects of the App's namespace, by name, if the App controls
+// them. It reports whether any of them is still there, held back by finalizers of its own
+// such as those of a load balancer controller deregistering an Ingress. Kinds whose CRD is
+// not installed have nothing to delete.
+func (r *AppReconciler) teardown(ctx context.Context, app *webappv1.App, objs ...client.Object) (bool, error) {
+	pending := false
+	for _, obj := range objs {
+		err := r.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: app.Namespace}, obj)
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		} else if err != nil {
+			return false, err
+		}
+		if !metav1.IsControlledBy(obj, app) {
+			continue
+		}
+		if obj.GetDeletionTimestamp().IsZero() {
+			if err := r.deleteChild(ctx, app, obj); err != nil {
+				return false, err
+			}
+		}
+		if len(obj.GetFinalizers()) > 0 {
+			pending = true
+		}
+	}
+	return pending, nil
+}
+
+// patchFinalizer adds or removes a finalizer of the App. The App is patched rather than
+// updated, since its cached copy lacks fields stripped by the cache. Only the finalizers and
+// resource version of the patched App are taken back, as the reconciliation may have changed
+// the App in memory, e.g. pinned its image.
+func (r *AppReconciler) patchFinalizer(ctx context.Context, app *webappv1.App, finalizer string, present bool) error {
+	patched := app.DeepCopy()
+	var changed bool
+	if present {
+		changed = controllerutil.AddFinalizer(patched, finalizer)
+	} else {
+		changed = controllerutil.RemoveFinalizer(patched, finalizer)
+	}
+	if !changed {
+		return nil
+	}
+	if err := r.Patch(ctx, patched, client.MergeFrom(app)); err != nil {
+		return err
+	}
+	app.Finalizers = patched.Finalizers
+	app.ResourceVersion = patched.ResourceVersion
+	return nil
+}
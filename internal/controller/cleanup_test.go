@@ -0,0 +1,75 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Teardown", func() {
+	It("should tear down routing, then Services, then the workload before releasing a deleted App", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web", Namespace: "default", UID: "web-uid", Finalizers: []string{appCleanupFinalizer},
+				Annotations: map[string]string{webappv1.LastModifiedByAnnotation: "alice@example.com"},
+			},
+			Spec: webappv1.AppSpec{Image: "web:1.0", Port: 8080},
+		}
+		ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "web-ingress", Namespace: "default", Finalizers: []string{"example.com/load-balancer"}}}
+		service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web-service", Namespace: "default"}}
+		deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web-deployment", Namespace: "default"}}
+		for _, obj := range []client.Object{ingress, service, deployment} {
+			Expect(controllerutil.SetControllerReference(app, obj, scheme.Scheme)).To(Succeed())
+		}
+		r, c := newTestReconciler(app, ingress, service, deployment)
+		recorder := record.NewFakeRecorder(10)
+		r.Recorder = recorder
+		req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+		Expect(c.Delete(ctx, app)).To(Succeed())
+
+		result, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(teardownInterval))
+		Expect(recorder.Events).To(Receive(Equal("Normal Deleted Deleted Ingress web-ingress")), "the last spec change didn't lead to the teardown")
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(service), service)).To(Succeed(), "Services wait for the Ingress to be gone")
+		Expect(c.Get(ctx, req.NamespacedName, app)).To(Succeed())
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(ingress), ingress)).To(Succeed())
+		ingress.Finalizers = nil
+		Expect(c.Update(ctx, ingress)).To(Succeed())
+		result, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeZero())
+		Expect(recorder.Events).To(Receive(Equal("Normal Deleted Deleted Service web-service")))
+		Expect(recorder.Events).To(Receive(Equal("Normal Deleted Deleted Deployment web-deployment")))
+		Expect(errors.IsNotFound(c.Get(ctx, req.NamespacedName, app))).To(BeTrue())
+	})
+})
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// Annotations binding a ServiceAccount to a cloud identity, read by the clouds' token
+	// exchange and admission webhooks.
+	awsRoleARNAnnotation        = "eks.amazonaws.com/role-arn"
+	gcpServiceAccountAnnotation = "iam.gke.io/gcp-service-account"
+	azureClientIDAnnotation     = "azure.workload.identity/client-id"
+	azureTenantIDAnnotation     = "azure.workload.identity/tenant-id"
+	// azureUseLabel opts pods into the Azure Workload Identity webhook, which projects their token.
+	azureUseLabel = "azure.workload.identity/use"
+
+	// awsTokenVolumeName and awsTokenMountPath match the EKS pod identity webhook, which
+	// leaves pods that already mount the token alone.
+	awsTokenVolumeName = "aws-iam-token"
+	awsTokenMountPath  = "/var/run/secrets/eks.amazonaws.com/serviceaccount"
+	// awsTokenAudience is the audience AWS STS accepts web identity tokens for.
+	awsTokenAudience = "sts.amazonaws.com"
+	// awsTokenExpirationSeconds is the lifetime of the projected token; the kubelet renews it.
+	awsTokenExpirationSeconds = 86400
+)
+
+// cloudIdentityAnnotations returns the ServiceAccount annotations binding it to the App's cloud identities.
+func cloudIdentityAnnotations(app *webappv1.App) map[string]string {
+	identity := app.Spec.CloudIdentity
+	if identity == nil {
+		return nil
+	}
+	annotations := map[string]string{}
+	if identity.AWS != nil {
+		annotations[awsRoleARNAnnotation] = identity.AWS.RoleARN
+	}
+	if identity.GCP != nil {
+		annotations[gcpServiceAccountAnnotation] = identity.GCP.ServiceAccount
+	}
+	if identity.Azure != nil {
+		annotations[azureClientIDAnnotation] = identity.Azure.ClientID
+		if identity.Azure.TenantID != "" {
+			annotations[azureTenantIDAnnotation] = identity.Azure.TenantID
+		}
+	}
+	return annotations
+}
+
+// cloudIdentityLabels returns the pod labels an App's cloud identities require.
+func cloudIdentityLabels(app *webappv1.App) map[string]string {
+	if app.Spec.CloudIdentity == nil || app.Spec.CloudIdentity.Azure == nil {
+		return nil
+	}
+	return map[string]string{azureUseLabel: "true"}
+}
+
+// cloudIdentityVolumes returns the projected ServiceAccount token AWS SDKs exchange for the
+// credentials of the App's IAM role, with its mount. GKE and the Azure webhook provide the
+// credentials of their identities themselves.
+func cloudIdentityVolumes(app *webappv1.App) ([]corev1.Volume, []corev1.VolumeMount) {
+	if app.Spec.CloudIdentity == nil || app.Spec.CloudIdentity.AWS == nil {
+		return nil, nil
+	}
+	volume := corev1.Volume{
+		Name: awsTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{
+					ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+						Audience:          awsTokenAudience,
+						ExpirationSeconds: ptr.To[int64](awsTokenExpirationSeconds),
+						Path:              "token",
+					},
+				}},
+			},
+		},
+	}
+	mount := corev1.VolumeMount{Name: awsTokenVolumeName, MountPath: awsTokenMountPath, ReadOnly: true}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}
+}
+
+// cloudIdentityEnv returns the environment pointing AWS SDKs at the App's role and token.
+func cloudIdentityEnv(app *webappv1.App) []corev1.EnvVar {
+	if app.Spec.CloudIdentity == nil || app.Spec.CloudIdentity.AWS == nil {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: "AWS_ROLE_ARN", Value: app.Spec.CloudIdentity.AWS.RoleARN},
+		{Name: "AWS_WEB_IDENTITY_TOKEN_FILE", Value: awsTokenMountPath + "/token"},
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Cloud identity", func() {
+	newApp := func() *webappv1.App {
+		return &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "billing", Namespace: "shop", UID: "billing-uid"},
+			Spec: webappv1.AppSpec{
+				Image: "billing:1.0",
+				CloudIdentity: &webappv1.CloudIdentitySpec{
+					AWS:   &webappv1.AWSIdentity{RoleARN: "arn:aws:iam::123456789012:role/billing"},
+					Azure: &webappv1.AzureIdentity{ClientID: "00000000-0000-0000-0000-000000000001"},
+				},
+			},
+		}
+	}
+
+	It("should run pods as an annotated ServiceAccount of their own", func() {
+		app := newApp()
+		r, c := newTestReconciler()
+
+		Expect(r.reconcileServiceAccount(ctx, app)).To(Succeed())
+		sa := &corev1.ServiceAccount{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "billing-serviceaccount", Namespace: "shop"}, sa)).To(Succeed())
+		Expect(sa.Annotations).To(HaveKeyWithValue("eks.amazonaws.com/role-arn", "arn:aws:iam::123456789012:role/billing"))
+		Expect(sa.Annotations).To(HaveKeyWithValue("azure.workload.identity/client-id", "00000000-0000-0000-0000-000000000001"))
+
+		pod := r.desiredDeployment(app, app.Spec.Image).Spec.Template
+		Expect(pod.Spec.ServiceAccountName).To(Equal("billing-serviceaccount"))
+		Expect(pod.Labels).To(HaveKeyWithValue("azure.workload.identity/use", "true"))
+		Expect(pod.Spec.Volumes).To(ContainElement(HaveField("Name", "aws-iam-token")))
+		Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "AWS_WEB_IDENTITY_TOKEN_FILE", Value: "/var/run/secrets/eks.amazonaws.com/serviceaccount/token"}))
+		Expect(*pod.Spec.AutomountServiceAccountToken).To(BeFalse())
+
+		By("switching identities")
+		app.Spec.CloudIdentity = &webappv1.CloudIdentitySpec{GCP: &webappv1.GCPIdentity{ServiceAccount: "billing@shop.iam.gserviceaccount.com"}}
+		Expect(r.reconcileServiceAccount(ctx, app)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(sa), sa)).To(Succeed())
+		Expect(sa.Annotations).To(HaveKeyWithValue("iam.gke.io/gcp-service-account", "billing@shop.iam.gserviceaccount.com"))
+		Expect(sa.Annotations).NotTo(HaveKey("eks.amazonaws.com/role-arn"))
+
+		By("removing the identity")
+		app.Spec.CloudIdentity = nil
+		Expect(r.reconcileServiceAccount(ctx, app)).To(Succeed())
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(sa), sa))).To(BeTrue())
+	})
+
+	It("should not take over a ServiceAccount it didn't create", func() {
+		existing := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "billing-serviceaccount", Namespace: "shop"}}
+		r, _ := newTestReconciler(existing)
+
+		Expect(r.reconcileServiceAccount(ctx, newApp())).To(MatchError(ContainSubstring("not owned by the App")))
+	})
+})
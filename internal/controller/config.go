@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/sops"
+)
+
+const (
+	// configVolumeName is the name of the volume carrying the App's configuration files.
+	configVolumeName = "config"
+	// defaultConfigMountPath is used for Apps stored before the API server defaulted spec.config.mountPath.
+	defaultConfigMountPath = "/etc/app"
+)
+
+// configMapName returns the name of the ConfigMap generated for an App.
+func configMapName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-config", app.Name)
+}
+
+// hasConfig reports whether the App has configuration files, inline or pulled from a source.
+func hasConfig(app *webappv1.App) bool {
+	return app.Spec.Config != nil || app.Spec.ConfigFrom != nil
+}
+
+// configVolumes returns the volume and mount exposing the App's generated ConfigMap.
+func configVolumes(app *webappv1.App) ([]corev1.Volume, []corev1.VolumeMount) {
+	if !hasConfig(app) {
+		return nil, nil
+	}
+	var mountPath string
+	if app.Spec.Config != nil {
+		mountPath = app.Spec.Config.MountPath
+	}
+	if mountPath == "" {
+		mountPath = defaultConfigMountPath
+	}
+	// Set the default mode explicitly so the template matches what the API server stores.
+	defaultMode := corev1.ConfigMapVolumeSourceDefaultMode
+	volumes := []corev1.Volume{{
+		Name: configVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName(app)},
+				DefaultMode:          &defaultMode,
+			},
+		},
+	}}
+	mounts := []corev1.VolumeMount{{
+		Name:      configVolumeName,
+		MountPath: mountPath,
+		ReadOnly:  true,
+	}}
+	return volumes, mounts
+}
+
+// renderConfigData resolves the App's configuration into plain ConfigMap data:
+// files pulled from Git, overridden by entries of the referenced ConfigMap, overridden
+// by inline entries, with every SOPS-encrypted document decrypted.
+func (r *AppReconciler) renderConfigData(ctx context.Context, app *webappv1.App) (map[string]string, error) {
+	data := map[string]string{}
+	if app.Spec.ConfigFrom != nil && app.Spec.ConfigFrom.Git != nil {
+		files, err := r.gitConfigFiles(ctx, app)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(data, files)
+	} else {
+		app.Status.ConfigFrom = nil
+	}
+	if config := app.Spec.Config; config != nil {
+		if ref := config.ConfigMapRef; ref != nil {
+			source := &corev1.ConfigMap{}
+			if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: app.Namespace}, source); err != nil {
+				return nil, fmt.Errorf("getting referenced ConfigMap %q: %w", ref.Name, err)
+			}
+			maps.Copy(data, source.Data)
+		}
+		maps.Copy(data, config.Data)
+	}
+
+	for key, value := range data {
+		if !sops.IsEncrypted([]byte(value)) {
+			continue
+		}
+		if r.Sops == nil {
+			return nil, fmt.Errorf("config entry %q is SOPS-encrypted but no decryption key is configured", key)
+		}
+		plaintext, err := r.Sops.Decrypt([]byte(value))
+		if err != nil {
+			return nil, fmt.Errorf("decrypting config entry %q: %w", key, err)
+		}
+		data[key] = string(plaintext)
+	}
+	return data, nil
+}
+
+// reconcileConfigMap creates or updates the ConfigMap holding the App's rendered
+// configuration, and removes a previously generated one when spec.config and
+// spec.configFrom are unset.
+func (r *AppReconciler) reconcileConfigMap(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	found := &corev1.ConfigMap{}
+	getErr := r.Get(ctx, types.NamespacedName{Name: configMapName(app), Namespace: app.Namespace}, found)
+
+	if !hasConfig(app) {
+		app.Status.ConfigFrom = nil
+		if errors.IsNotFound(getErr) {
+			return nil
+		}
+		if getErr != nil {
+			return getErr
+		}
+		if !metav1.IsControlledBy(found, app) {
+			return nil
+		}
+		log.Info("Deleting ConfigMap no longer used by App", "ConfigMap.Name", found.Name)
+		return r.deleteChild(ctx, app, found)
+	}
+
+	data, err := r.renderConfigData(ctx, app)
+	if err != nil {
+		return err
+	}
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        configMapName(app),
+			Namespace:   app.Namespace,
+			Annotations: attributionAnnotations(app),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Data: data,
+	}
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	if errors.IsNotFound(getErr) {
+		log.Info("Creating a new ConfigMap", "ConfigMap.Namespace", desired.Namespace, "ConfigMap.Name", desired.Name)
+		return r.createChild(ctx, app, desired)
+	} else if getErr != nil {
+		return getErr
+	}
+
+	if syncAttribution(app, found) || !equality.Semantic.DeepEqual(found.Data, desired.Data) {
+		log.Info("Updating existing ConfigMap", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+		found.Data = desired.Data
+		return r.updateChild(ctx, app, found)
+	}
+	return nil
+}
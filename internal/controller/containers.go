@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// appContainers returns the pod containers of spec.containers, spec.initContainers or
+// spec.sidecars. They get the pull policy and container security settings of the app
+// container.
+func appContainers(app *webappv1.App, containers []webappv1.Container) []corev1.Container {
+	out := make([]corev1.Container, 0, len(containers))
+	for _, c := range containers {
+		container := corev1.Container{
+			Name:            c.Name,
+			Image:           c.Image,
+			ImagePullPolicy: app.Spec.ImagePullPolicy,
+			Command:         c.Command,
+			Args:            c.Args,
+			Env:             c.Env,
+			EnvFrom:         c.EnvFrom,
+			SecurityContext: containerSecurityContext(app),
+		}
+		if c.Resources != nil {
+			container.Resources = *c.Resources
+		}
+		for _, port := range c.Ports {
+			container.Ports = append(container.Ports, corev1.ContainerPort{
+				Name:          port.Name,
+				ContainerPort: port.ContainerPort,
+				Protocol:      portProtocol(port),
+			})
+		}
+		out = append(out, container)
+	}
+	return out
+}
+
+// initContainers returns the init containers of the App's pods: its sidecars, restarted for
+// the life of the pod, so they are up before its init containers run.
+func initContainers(app *webappv1.App) []corev1.Container {
+	sidecars := appContainers(app, app.Spec.Sidecars)
+	for i := range sidecars {
+		sidecars[i].RestartPolicy = ptr.To(corev1.ContainerRestartPolicyAlways)
+	}
+	return concat(sidecars, appContainers(app, app.Spec.InitContainers))
+}
+
+// portProtocol returns the protocol of a container port, TCP by default.
+func portProtocol(port webappv1.ContainerPort) corev1.Protocol {
+	if port.Protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return port.Protocol
+}
+
+// containerServicePorts returns the Service ports of the ports of spec.containers and
+// spec.sidecars. Unnamed ports are named after their protocol and number, e.g. tcp-9090.
+func containerServicePorts(app *webappv1.App) []corev1.ServicePort {
+	var ports []corev1.ServicePort
+	for _, container := range concat(app.Spec.Containers, app.Spec.Sidecars) {
+		for _, port := range container.Ports {
+			servicePort := port.ServicePort
+			if servicePort == 0 {
+				servicePort = port.ContainerPort
+			}
+			name := port.Name
+			if name == "" {
+				name = fmt.Sprintf("%s-%d", strings.ToLower(string(portProtocol(port))), servicePort)
+			}
+			ports = append(ports, corev1.ServicePort{
+				Name:        name,
+				Protocol:    portProtocol(port),
+				AppProtocol: port.AppProtocol,
+				Port:        servicePort,
+				TargetPort:  intstr.FromInt32(port.ContainerPort),
+			})
+		}
+	}
+	return ports
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("App containers", func() {
+	It("should run containers, sidecars and init containers next to the app container", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Image:         "web:1.0",
+			ContainerName: "web",
+			Command:       []string{"/web"},
+			Port:          8080,
+			Containers: []webappv1.Container{{
+				Name:  "worker",
+				Image: "worker:1.0",
+				Ports: []webappv1.ContainerPort{{ContainerPort: 9090}, {Name: "queue", ContainerPort: 5672, ServicePort: 15672}},
+			}},
+			InitContainers: []webappv1.Container{{Name: "migrate", Image: "web:1.0", Args: []string{"migrate"}}},
+			Sidecars: []webappv1.Container{{
+				Name:  "proxy",
+				Image: "envoy:1.31",
+				Ports: []webappv1.ContainerPort{{Name: "admin", ContainerPort: 9901, Protocol: corev1.ProtocolTCP}},
+			}},
+		})
+		pod := (&AppReconciler{}).desiredDeployment(app, app.Spec.Image).Spec.Template.Spec
+
+		Expect(pod.Containers).To(HaveLen(2))
+		Expect(pod.Containers[0].Name).To(Equal("web"))
+		Expect(pod.Containers[0].Command).To(Equal([]string{"/web"}))
+		Expect(pod.Containers[1].Name).To(Equal("worker"))
+		Expect(pod.Containers[1].SecurityContext).To(Equal(pod.Containers[0].SecurityContext))
+		Expect(pod.InitContainers).To(HaveLen(2))
+		Expect(pod.InitContainers[0].Name).To(Equal("proxy"), "sidecars start before init containers")
+		Expect(pod.InitContainers[0].RestartPolicy).To(Equal(ptr.To(corev1.ContainerRestartPolicyAlways)))
+		Expect(pod.InitContainers[1].Name).To(Equal("migrate"))
+		Expect(pod.InitContainers[1].RestartPolicy).To(BeNil())
+
+		ports := desiredService(app).Spec.Ports
+		Expect(ports).To(HaveLen(4))
+		Expect(ports[0].Name).To(Equal(primaryPortName))
+		Expect(ports[1].Name).To(Equal("tcp-9090"))
+		Expect(ports[2].Port).To(Equal(int32(15672)))
+		Expect(ports[2].TargetPort.IntValue()).To(Equal(5672))
+		Expect(ports[3].Name).To(Equal("admin"))
+	})
+})
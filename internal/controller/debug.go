@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// defaultDebugImage is the image of debug containers when neither the App nor the
+	// controller names one.
+	defaultDebugImage = "busybox:1.36"
+	// defaultDebugTTL is how long a debug session lasts without webapp.example.com/debug-ttl.
+	defaultDebugTTL = time.Hour
+	// debugContainerPrefix prefixes the names of the debug containers added to pods.
+	debugContainerPrefix = "debugger"
+)
+
+// reconcileDebug attaches the ephemeral debug container requested with the
+// webapp.example.com/debug annotation to the named pod of the App, and records its lifecycle
+// in status.debug. Ephemeral containers can't be removed from a pod, so a session ends by
+// deleting the debugged pod, which its Deployment replaces: when its TTL passes, when the
+// annotation is removed, or when it names another pod. It returns how long until the
+// current session expires, zero when there is none.
+func (r *AppReconciler) reconcileDebug(ctx context.Context, app *webappv1.App) (time.Duration, error) {
+	podName, requested := app.Annotations[webappv1.DebugAnnotation]
+	if session := app.Status.Debug; session != nil && (!requested || session.Pod != podName) {
+		if err := r.endDebugSession(ctx, app, session); err != nil {
+			return 0, err
+		}
+		app.Status.Debug = nil
+	}
+	if !requested {
+		return 0, nil
+	}
+	if app.Status.Debug == nil {
+		if err := r.startDebugSession(ctx, app, podName); err != nil {
+			return 0, err
+		}
+	}
+	return r.trackDebugSession(ctx, app)
+}
+
+// startDebugSession adds a debug container to a running pod of the App. Requests that can't
+// be served, such as a pod of another App, are recorded as a failed session rather than
+// returned, as retrying them won't help.
+func (r *AppReconciler) startDebugSession(ctx context.Context, app *webappv1.App, podName string) error {
+	log := log.FromContext(ctx)
+
+	failed := func(format string, args ...interface{}) error {
+		app.Status.Debug = &webappv1.DebugStatus{Pod: podName, Phase: webappv1.DebugPhaseFailed, Message: fmt.Sprintf(format, args...)}
+		return nil
+	}
+	ttl := defaultDebugTTL
+	if value, ok := app.Annotations[webappv1.DebugTTLAnnotation]; ok {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return failed("invalid %s %q: must be a positive duration such as 30m", webappv1.DebugTTLAnnotation, value)
+		}
+		ttl = parsed
+	}
+	image := app.Annotations[webappv1.DebugImageAnnotation]
+	if image == "" {
+		image = r.DebugImage
+	}
+	if image == "" {
+		image = defaultDebugImage
+	}
+
+	pod, err := r.appPod(ctx, app, podName)
+	if errors.IsNotFound(err) {
+		return failed("pod %q of the App was not found", podName)
+	} else if err != nil {
+		return err
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return failed("pod %q is %s, not Running", podName, pod.Status.Phase)
+	}
+
+	container := corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     debugContainerName(pod),
+			Image:                    image,
+			ImagePullPolicy:          corev1.PullIfNotPresent,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		// Share the process namespace of the app container, so its processes can be inspected.
+		TargetContainerName: containerName(app),
+	}
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, container)
+	log.Info("Attaching debug container", "Pod", pod.Name, "Container", container.Name, "Image", image)
+	if err := r.SubResource("ephemeralcontainers").Update(ctx, pod); errors.IsInvalid(err) || errors.IsForbidden(err) {
+		return failed("adding the debug container: %v", err)
+	} else if err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	app.Status.Debug = &webappv1.DebugStatus{
+		Pod:       pod.Name,
+		Container: container.Name,
+		Image:     image,
+		Phase:     webappv1.DebugPhaseWaiting,
+		StartedAt: &now,
+		ExpiresAt: &metav1.Time{Time: now.Add(ttl)},
+		Message:   fmt.Sprintf("Attach with: kubectl attach -it -n %s %s -c %s", pod.Namespace, pod.Name, container.Name),
+	}
+	return nil
+}
+
+// trackDebugSession follows the debug container of the App's session, and ends the session
+// once its TTL passed.
+func (r *AppReconciler) trackDebugSession(ctx context.Context, app *webappv1.App) (time.Duration, error) {
+	session := app.Status.Debug
+	if session.Phase == webappv1.DebugPhaseFailed || session.Phase == webappv1.DebugPhaseExpired {
+		return 0, nil
+	}
+	if remaining := time.Until(session.ExpiresAt.Time); remaining <= 0 {
+		if err := r.endDebugSession(ctx, app, session); err != nil {
+			return 0, err
+		}
+		session.Phase = webappv1.DebugPhaseExpired
+		session.Message = "The TTL passed and the debugged pod was deleted; remove the annotation or name another pod to debug again"
+		return 0, nil
+	}
+
+	pod, err := r.appPod(ctx, app, session.Pod)
+	if errors.IsNotFound(err) {
+		session.Phase = webappv1.DebugPhaseTerminated
+		session.Message = "The debugged pod was deleted"
+		return time.Until(session.ExpiresAt.Time), nil
+	} else if err != nil {
+		return 0, err
+	}
+	i := slices.IndexFunc(pod.Status.EphemeralContainerStatuses, func(s corev1.ContainerStatus) bool { return s.Name == session.Container })
+	if i >= 0 {
+		state := pod.Status.EphemeralContainerStatuses[i].State
+		switch {
+		case state.Running != nil:
+			session.Phase = webappv1.DebugPhaseRunning
+		case state.Terminated != nil:
+			session.Phase = webappv1.DebugPhaseTerminated
+			session.Message = fmt.Sprintf("The debug container exited with code %d", state.Terminated.ExitCode)
+		case state.Waiting != nil && state.Waiting.Reason != "":
+			session.Phase = webappv1.DebugPhaseWaiting
+			session.Message = fmt.Sprintf("The debug container is waiting: %s", state.Waiting.Reason)
+		}
+	}
+	return time.Until(session.ExpiresAt.Time), nil
+}
+
+// endDebugSession deletes the pod a debug container was attached to. Failed and expired
+// sessions have none left.
+func (r *AppReconciler) endDebugSession(ctx context.Context, app *webappv1.App, session *webappv1.DebugStatus) error {
+	if session.Phase == webappv1.DebugPhaseFailed || session.Phase == webappv1.DebugPhaseExpired {
+		return nil
+	}
+	pod, err := r.appPod(ctx, app, session.Pod)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	log.FromContext(ctx).Info("Deleting debugged pod", "Pod", pod.Name)
+	return client.IgnoreNotFound(r.Delete(ctx, pod, client.Preconditions{UID: &pod.UID}))
+}
+
+// appPod reads a pod of the App without the cache, which doesn't hold pods. A pod is the
+// App's when its controller is a ReplicaSet of one of the App's Deployments; other pods,
+// even labelled with the App's name, are reported as not found.
+func (r *AppReconciler) appPod(ctx context.Context, app *webappv1.App, name string) (*corev1.Pod, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	pod := &corev1.Pod{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, pod); err != nil {
+		return nil, err
+	}
+	notFound := errors.NewNotFound(corev1.Resource("pods"), name)
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "ReplicaSet" || !pod.DeletionTimestamp.IsZero() {
+		return nil, notFound
+	}
+	replicaSets, err := r.appReplicaSets(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(replicaSets, func(replicaSet appsv1.ReplicaSet) bool { return replicaSet.UID == owner.UID }) {
+		return nil, notFound
+	}
+	return pod, nil
+}
+
+// debugContainerName returns a container name not yet used in the pod. Ephemeral containers
+// stay in the pod spec after they exit, so each session gets a new one.
+func debugContainerName(pod *corev1.Pod) string {
+	for i := len(pod.Spec.EphemeralContainers); ; i++ {
+		name := fmt.Sprintf("%s-%d", debugContainerPrefix, i)
+		if !slices.ContainsFunc(pod.Spec.EphemeralContainers, func(c corev1.EphemeralContainer) bool { return c.Name == name }) {
+			return name
+		}
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Debug sessions", func() {
+	newApp := func(annotations map[string]string) *webappv1.App {
+		app := newTestApp(webappv1.AppSpec{})
+		app.Annotations = annotations
+		return app
+	}
+	var (
+		deployment *appsv1.Deployment
+		replicaSet *appsv1.ReplicaSet
+	)
+	BeforeEach(func() {
+		deployment, replicaSet = newTestReplicaSet(newApp(nil), "web-deployment", "abc")
+	})
+	// pod returns a pod labelled with the name of app, controlled by owner.
+	pod := func(name, app string, owner *appsv1.ReplicaSet) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name + "-uid"), Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: appContainerName, Image: "web:1.0"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		Expect(controllerutil.SetControllerReference(owner, pod, scheme.Scheme)).To(Succeed())
+		return pod
+	}
+	// The fake client only writes status through subresources, so the ephemeral containers
+	// are written as a pod update instead.
+	newReconciler := func(objs ...client.Object) *AppReconciler {
+		objs = append(objs, deployment, replicaSet)
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).
+			WithInterceptorFuncs(interceptor.Funcs{SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if subResource == "ephemeralcontainers" {
+					return c.Update(ctx, obj)
+				}
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			}}).Build()
+		return &AppReconciler{Client: c, Scheme: c.Scheme(), DebugImage: "debug-tools:1.0"}
+	}
+
+	It("should attach a debug container to the named pod and track it", func() {
+		r := newReconciler(pod("web-abc", "web", replicaSet))
+		app := newApp(map[string]string{webappv1.DebugAnnotation: "web-abc", webappv1.DebugTTLAnnotation: "30m"})
+
+		wait, err := r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wait).To(BeNumerically("~", 30*time.Minute, time.Minute))
+		Expect(app.Status.Debug.Phase).To(Equal(webappv1.DebugPhaseWaiting))
+		Expect(app.Status.Debug.Container).To(Equal("debugger-0"))
+
+		debugged := &corev1.Pod{}
+		Expect(r.Get(ctx, types.NamespacedName{Name: "web-abc", Namespace: "default"}, debugged)).To(Succeed())
+		Expect(debugged.Spec.EphemeralContainers).To(HaveLen(1))
+		Expect(debugged.Spec.EphemeralContainers[0].Image).To(Equal("debug-tools:1.0"))
+		Expect(debugged.Spec.EphemeralContainers[0].TargetContainerName).To(Equal(appContainerName))
+
+		By("following the container's state")
+		debugged.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{{
+			Name:  "debugger-0",
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		}}
+		Expect(r.Status().Update(ctx, debugged)).To(Succeed())
+		_, err = r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(app.Status.Debug.Phase).To(Equal(webappv1.DebugPhaseRunning))
+	})
+
+	It("should delete the debugged pod once the TTL passed", func() {
+		r := newReconciler(pod("web-abc", "web", replicaSet))
+		app := newApp(map[string]string{webappv1.DebugAnnotation: "web-abc"})
+		_, err := r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+
+		app.Status.Debug.ExpiresAt = &metav1.Time{Time: time.Now().Add(-time.Second)}
+		wait, err := r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(wait).To(BeZero())
+		Expect(app.Status.Debug.Phase).To(Equal(webappv1.DebugPhaseExpired))
+		Expect(errors.IsNotFound(r.Get(ctx, types.NamespacedName{Name: "web-abc", Namespace: "default"}, &corev1.Pod{}))).To(BeTrue())
+
+		By("not starting over while the annotation names the same pod")
+		_, err = r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(app.Status.Debug.Phase).To(Equal(webappv1.DebugPhaseExpired))
+	})
+
+	It("should end the session when the annotation is removed", func() {
+		r := newReconciler(pod("web-abc", "web", replicaSet))
+		app := newApp(map[string]string{webappv1.DebugAnnotation: "web-abc"})
+		_, err := r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+
+		app.Annotations = nil
+		_, err = r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(app.Status.Debug).To(BeNil())
+		Expect(errors.IsNotFound(r.Get(ctx, types.NamespacedName{Name: "web-abc", Namespace: "default"}, &corev1.Pod{}))).To(BeTrue())
+	})
+
+	It("should refuse pods of other Apps and invalid TTLs", func() {
+		db := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: "db-uid"}}
+		dbDeployment, dbReplicaSet := newTestReplicaSet(db, "db-deployment", "abc")
+		r := newReconciler(dbDeployment, dbReplicaSet, pod("db-abc", "db", dbReplicaSet), pod("web-abc", "web", replicaSet))
+
+		app := newApp(map[string]string{webappv1.DebugAnnotation: "db-abc"})
+		_, err := r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(app.Status.Debug.Phase).To(Equal(webappv1.DebugPhaseFailed))
+		Expect(app.Status.Debug.Message).To(ContainSubstring("not found"))
+
+		app = newApp(map[string]string{webappv1.DebugAnnotation: "web-abc", webappv1.DebugTTLAnnotation: "soon"})
+		_, err = r.reconcileDebug(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(app.Status.Debug.Phase).To(Equal(webappv1.DebugPhaseFailed))
+		Expect(app.Status.Debug.Message).To(ContainSubstring("positive duration"))
+
+		untouched := &corev1.Pod{}
+		Expect(r.Get(ctx, types.NamespacedName{Name: "db-abc", Namespace: "default"}, untouched)).To(Succeed())
+		Expect(untouched.Spec.EphemeralContainers).To(BeEmpty())
+	})
+
+	It("should refuse pods labelled with the App's name that its Deployments don't own", func() {
+		// A ReplicaSet named and labelled like the App's, but controlled by another Deployment.
+		other := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web-deployment-copy", Namespace: "default", UID: "copy-uid"}}
+		lookAlike := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-deployment-xyz",
+			Namespace: "default",
+			UID:       "look-alike-uid",
+			Labels:    map[string]string{"app": "web", appsv1.DefaultDeploymentUniqueLabelKey: "xyz"},
+		}}
+		Expect(controllerutil.SetControllerReference(other, lookAlike, scheme.Scheme)).To(Succeed())
+		orphan := pod("web-orphan", "web", replicaSet)
+		orphan.OwnerReferences = nil
+		r := newReconciler(other, lookAlike, pod("web-xyz", "web", lookAlike), orphan)
+
+		for _, name := range []string{"web-xyz", "web-orphan"} {
+			app := newApp(map[string]string{webappv1.DebugAnnotation: name})
+			_, err := r.reconcileDebug(ctx, app)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(app.Status.Debug.Phase).To(Equal(webappv1.DebugPhaseFailed))
+			Expect(app.Status.Debug.Message).To(ContainSubstring("not found"))
+
+			untouched := &corev1.Pod{}
+			Expect(r.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, untouched)).To(Succeed())
+			Expect(untouched.Spec.EphemeralContainers).To(BeEmpty())
+		}
+	})
+})
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// syncedChild is the state in which a child resource was last found in sync with its App.
+type syncedChild struct {
+	resourceVersion string
+	digest          [sha256.Size]byte
+}
+
+// syncChild brings a child resource in line with its App, and reports whether it updated it.
+// updated is stored with the controller's desired state written over it. Rather than
+// comparing fields one by one, the update is dry-run: the server defaults and normalizes it
+// like a real write, and the object is only updated when the result differs from stored.
+// Any field the controller sets is thus compared, so drift in any of them is reverted,
+// while fields defaulted by the server, or set by others and left unset by the controller,
+// are not fought over. The dry run is skipped while neither stored nor the desired state
+// changed since the object was last found in sync.
+//
+// Server-side apply would keep fields another field manager added, such as an extra
+// container from kubectl edit, where the update replaces them; and the fake client of
+// controller-runtime v0.21, which the controller's tests use, rejects apply patches.
+func (r *AppReconciler) syncChild(ctx context.Context, c client.Client, app *webappv1.App, stored, updated client.Object) (bool, error) {
+	digest, err := desiredDigest(updated)
+	if err != nil {
+		return false, err
+	}
+	appKey := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+	if r.inSync(appKey, stored, digest) {
+		return false, nil
+	}
+
+	changed, err := updateWouldChange(ctx, c, stored, updated)
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		gvk, err := apiutil.GVKForObject(updated, c.Scheme())
+		if err != nil {
+			return false, err
+		}
+		log.FromContext(ctx).Info("Updating existing "+gvk.Kind, gvk.Kind+".Namespace", updated.GetNamespace(), gvk.Kind+".Name", updated.GetName())
+		err = c.Update(ctx, updated)
+		r.recordChildEvent(app, updated, actionUpdate, err)
+		if err != nil {
+			return false, err
+		}
+	}
+	r.recordSynced(appKey, updated, digest)
+	return changed, nil
+}
+
+// updateWouldChange reports whether updating stored to updated would change the object on
+// the server. A dry-run update resolves the fields the API server defaults or normalizes,
+// so the real write only happens when the server would keep a different object.
+func updateWouldChange(ctx context.Context, c client.Client, stored, updated client.Object) (bool, error) {
+	dryRun := updated.DeepCopyObject().(client.Object)
+	if err := c.Update(ctx, dryRun, client.DryRunAll); err != nil {
+		return false, err
+	}
+	return !equality.Semantic.DeepEqual(withoutVolatileFields(stored), withoutVolatileFields(dryRun)), nil
+}
+
+// withoutVolatileFields returns a copy of obj without the fields that differ between a cached
+// object and the server's response to an update, even when the update changes nothing.
+func withoutVolatileFields(obj client.Object) client.Object {
+	obj = obj.DeepCopyObject().(client.Object)
+	obj.GetObjectKind().SetGroupVersionKind(schema.GroupVersionKind{})
+	obj.SetResourceVersion("")
+	obj.SetManagedFields(nil)
+	return obj
+}
+
+// desiredDigest hashes an object with its desired state written over it. The fields an
+// update changes without the desired state changing, its resource version, generation and
+// status, are left out.
+func desiredDigest(obj client.Object) ([sha256.Size]byte, error) {
+	// The converter returns the content of unstructured objects as is, not a copy.
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj.DeepCopyObject())
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "resourceVersion")
+		delete(metadata, "generation")
+		delete(metadata, "managedFields")
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// inSync reports whether stored is unchanged since it was last found in sync with the
+// desired state of the given digest.
+func (r *AppReconciler) inSync(appKey types.NamespacedName, stored client.Object, digest [sha256.Size]byte) bool {
+	r.syncedMu.Lock()
+	defer r.syncedMu.Unlock()
+	synced, ok := r.synced[appKey][stored.GetUID()]
+	return ok && synced.resourceVersion == stored.GetResourceVersion() && synced.digest == digest
+}
+
+// recordSynced records that obj, as last written or read, is in sync with the desired state
+// of the given digest.
+func (r *AppReconciler) recordSynced(appKey types.NamespacedName, obj client.Object, digest [sha256.Size]byte) {
+	r.syncedMu.Lock()
+	defer r.syncedMu.Unlock()
+	if r.synced == nil {
+		r.synced = map[types.NamespacedName]map[types.UID]syncedChild{}
+	}
+	if r.synced[appKey] == nil {
+		r.synced[appKey] = map[types.UID]syncedChild{}
+	}
+	r.synced[appKey][obj.GetUID()] = syncedChild{resourceVersion: obj.GetResourceVersion(), digest: digest}
+}
+
+// forgetSynced drops the child resources recorded in sync for an App.
+func (r *AppReconciler) forgetSynced(appKey types.NamespacedName) {
+	r.syncedMu.Lock()
+	defer r.syncedMu.Unlock()
+	delete(r.synced, appKey)
+}
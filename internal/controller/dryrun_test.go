@@ -0,0 +1,150 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Dry-run updates", func() {
+	It("should skip updates the API server would not apply", func() {
+		stored := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-isolation", Namespace: "default"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		}
+		// The server defaults policyTypes, so a desired spec without them is stored unchanged.
+		var writes int
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(stored).
+			WithInterceptorFuncs(interceptor.Funcs{Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				policy := obj.(*networkingv1.NetworkPolicy)
+				if policy.Spec.PolicyTypes == nil {
+					policy.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+				}
+				if len((&client.UpdateOptions{}).ApplyOptions(opts).DryRun) == 0 {
+					writes++
+				}
+				return c.Update(ctx, obj, opts...)
+			}}).Build()
+
+		found := &networkingv1.NetworkPolicy{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(stored), found)).To(Succeed())
+		updated := found.DeepCopy()
+		updated.Spec.PolicyTypes = nil
+		Expect(updateWouldChange(ctx, c, found, updated)).To(BeFalse())
+
+		updated.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}
+		Expect(updateWouldChange(ctx, c, found, updated)).To(BeTrue())
+		Expect(writes).To(BeZero())
+	})
+})
+
+var _ = Describe("Drift correction", func() {
+	app := &webappv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec:       webappv1.AppSpec{Image: "web:1.0", Replicas: 2, Port: 8080},
+	}
+
+	It("should revert manual edits to any field the controller sets", func() {
+		r, c := newTestReconciler()
+		deployment, err := r.reconcileDeployment(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.reconcileService(ctx, app)).To(Succeed())
+
+		deployment.Labels["app"] = "other"
+		deployment.Labels["team"] = "payments"
+		deployment.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "2026-10-15T10:00:00Z"}
+		container := &deployment.Spec.Template.Spec.Containers[0]
+		container.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}
+		container.Command = []string{"sleep", "infinity"}
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, corev1.Container{Name: "sidecar", Image: "sidecar:1.0"})
+		deployment.Spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
+		Expect(c.Update(ctx, deployment)).To(Succeed())
+		service := &corev1.Service{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-service", Namespace: "default"}, service)).To(Succeed())
+		service.Spec.Selector = map[string]string{"app": "other"}
+		service.Spec.Ports[0].Port = 9090
+		Expect(c.Update(ctx, service)).To(Succeed())
+
+		deployment, err = r.reconcileDeployment(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.reconcileService(ctx, app)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+		Expect(deployment.Labels).To(HaveKeyWithValue("app", "web"))
+		Expect(deployment.Labels).To(HaveKeyWithValue("team", "payments"), "labels set by others are kept")
+		Expect(deployment.Spec.Template.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedAt"), "restarts are not reverted")
+		Expect(deployment.Spec.Template.Spec.Containers).To(HaveLen(1))
+		Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Limits).To(BeEmpty())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Command).To(BeEmpty())
+		Expect(deployment.Spec.Strategy.Type).To(BeEmpty())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(service), service)).To(Succeed())
+		Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "web"}))
+		Expect(service.Spec.Ports[0].Port).To(Equal(int32(8080)))
+	})
+
+	It("should only dry-run updates once the object or the App changed", func() {
+		var dryRuns, writes int
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithInterceptorFuncs(interceptor.Funcs{Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if len((&client.UpdateOptions{}).ApplyOptions(opts).DryRun) > 0 {
+					dryRuns++
+				} else {
+					writes++
+				}
+				return c.Update(ctx, obj, opts...)
+			}}).Build()
+		r := &AppReconciler{Client: c, Scheme: c.Scheme()}
+
+		Expect(r.reconcileDeployment(ctx, app, app.Spec.Image)).NotTo(BeNil())
+		for range 3 {
+			Expect(r.reconcileDeployment(ctx, app, app.Spec.Image)).NotTo(BeNil())
+		}
+		Expect(dryRuns).To(Equal(1))
+		Expect(writes).To(BeZero())
+
+		By("checking again once the App changed")
+		changed := app.DeepCopy()
+		changed.Spec.Image = "web:1.1"
+		for range 3 {
+			Expect(r.reconcileDeployment(ctx, changed, changed.Spec.Image)).NotTo(BeNil())
+		}
+		Expect(dryRuns).To(Equal(2))
+		Expect(writes).To(Equal(1))
+
+		By("forgetting deleted Apps")
+		r.forgetSynced(types.NamespacedName{Name: "web", Namespace: "default"})
+		Expect(r.reconcileDeployment(ctx, changed, changed.Spec.Image)).NotTo(BeNil())
+		Expect(dryRuns).To(Equal(3))
+	})
+})
@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// envHashAnnotation records on the pod template the hash of the ConfigMap and Secret values
+// the App's environment references, so the pods roll out when one changes.
+const envHashAnnotation = "webapp.example.com/env-hash"
+
+// appEnv returns the environment of the app container: the variables of its cloud identity,
+// then spec.env with its templates resolved. Values failing to resolve are kept as is;
+// checkTemplates reports them before anything is applied.
+func (r *AppReconciler) appEnv(app *webappv1.App) []corev1.EnvVar {
+	env := cloudIdentityEnv(app)
+	for _, v := range app.Spec.Env {
+		if v.ValueFrom == nil {
+			if rendered, err := r.renderTemplate(app, v.Value); err == nil {
+				v.Value = rendered
+			}
+		}
+		env = append(env, v)
+	}
+	return env
+}
+
+// hashEnvSources records in status.envHash a hash of the ConfigMap and Secret values
+// spec.env and spec.envFrom reference. Missing objects are hashed as such, so the pods also
+// roll out when they are created. Apps delivered to a target cluster reference that cluster's
+// objects, which are not hashed.
+func (r *AppReconciler) hashEnvSources(ctx context.Context, app *webappv1.App) error {
+	if app.Spec.TargetCluster != nil {
+		app.Status.EnvHash = ""
+		return nil
+	}
+	var entries []string
+	add := func(kind, name, key string, all bool) error {
+		data, err := r.envSourceData(ctx, app, kind, name)
+		if errors.IsNotFound(err) {
+			entries = append(entries, fmt.Sprintf("%s/%s absent", kind, name))
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading %s %q referenced by the App's environment: %w", kind, name, err)
+		}
+		if !all {
+			entries = append(entries, fmt.Sprintf("%s/%s/%s=%x", kind, name, key, data[key]))
+			return nil
+		}
+		for _, k := range slices.Sorted(maps.Keys(data)) {
+			entries = append(entries, fmt.Sprintf("%s/%s/%s=%x", kind, name, k, data[k]))
+		}
+		return nil
+	}
+
+	for _, source := range app.Spec.EnvFrom {
+		var err error
+		switch {
+		case source.ConfigMapRef != nil:
+			err = add("ConfigMap", source.ConfigMapRef.Name, "", true)
+		case source.SecretRef != nil:
+			err = add("Secret", source.SecretRef.Name, "", true)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, v := range app.Spec.Env {
+		if v.ValueFrom == nil {
+			continue
+		}
+		var err error
+		switch {
+		case v.ValueFrom.ConfigMapKeyRef != nil:
+			err = add("ConfigMap", v.ValueFrom.ConfigMapKeyRef.Name, v.ValueFrom.ConfigMapKeyRef.Key, false)
+		case v.ValueFrom.SecretKeyRef != nil:
+			err = add("Secret", v.ValueFrom.SecretKeyRef.Name, v.ValueFrom.SecretKeyRef.Key, false)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(entries) == 0 {
+		app.Status.EnvHash = ""
+		return nil
+	}
+	slices.Sort(entries)
+	sum := sha256.New()
+	for _, entry := range entries {
+		sum.Write([]byte(entry))
+		sum.Write([]byte{0})
+	}
+	app.Status.EnvHash = hex.EncodeToString(sum.Sum(nil))[:16]
+	return nil
+}
+
+// readsSecrets reports whether the App's environment references Secrets. They are read
+// without the cache, so their changes aren't watched.
+func readsSecrets(app *webappv1.App) bool {
+	for _, source := range app.Spec.EnvFrom {
+		if source.SecretRef != nil {
+			return true
+		}
+	}
+	for _, v := range app.Spec.Env {
+		if v.ValueFrom != nil && v.ValueFrom.SecretKeyRef != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// envConfigMapIndex indexes Apps by the names of the ConfigMaps their environment
+// references, so the Apps to roll out when a ConfigMap changes can be listed.
+const envConfigMapIndex = ".spec.env.configMaps"
+
+// indexEnvConfigMaps is the field indexer function for envConfigMapIndex.
+func indexEnvConfigMaps(obj client.Object) []string {
+	app := obj.(*webappv1.App)
+	var names []string
+	for _, source := range app.Spec.EnvFrom {
+		if source.ConfigMapRef != nil {
+			names = append(names, source.ConfigMapRef.Name)
+		}
+	}
+	for _, v := range app.Spec.Env {
+		if v.ValueFrom != nil && v.ValueFrom.ConfigMapKeyRef != nil {
+			names = append(names, v.ValueFrom.ConfigMapKeyRef.Name)
+		}
+	}
+	return names
+}
+
+// enqueueEnvConfigMapApps maps a ConfigMap to the Apps whose environment references it.
+func (r *AppReconciler) enqueueEnvConfigMapApps(ctx context.Context, obj client.Object) []reconcile.Request {
+	apps := &webappv1.AppList{}
+	if err := r.List(ctx, apps, client.InNamespace(obj.GetNamespace()), client.MatchingFields{envConfigMapIndex: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list Apps referencing ConfigMap", "ConfigMap", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(apps.Items))
+	for _, app := range apps.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+	}
+	return requests
+}
+
+// envSourceData returns the data of a ConfigMap or Secret of the App's namespace. Secrets are
+// read without the cache, which only holds the ones the controller manages.
+func (r *AppReconciler) envSourceData(ctx context.Context, app *webappv1.App, kind, name string) (map[string][]byte, error) {
+	key := types.NamespacedName{Name: name, Namespace: app.Namespace}
+	if kind == "Secret" {
+		reader := r.APIReader
+		if reader == nil {
+			reader = r.Client
+		}
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, key, secret); err != nil {
+			return nil, err
+		}
+		return secret.Data, nil
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, key, configMap); err != nil {
+		return nil, err
+	}
+	data := maps.Clone(configMap.BinaryData)
+	if data == nil {
+		data = map[string][]byte{}
+	}
+	for k, v := range configMap.Data {
+		data[k] = []byte(v)
+	}
+	return data, nil
+}
+
+// envHashAnnotations returns the pod template annotation recording status.envHash.
+func envHashAnnotations(app *webappv1.App) map[string]string {
+	if app.Status.EnvHash == "" {
+		return nil
+	}
+	return map[string]string{envHashAnnotation: app.Status.EnvHash}
+}
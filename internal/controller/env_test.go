@@ -0,0 +1,100 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Environment", func() {
+	newApp := func() *webappv1.App {
+		return newTestApp(webappv1.AppSpec{
+			Image: "web:1.0",
+			Port:  8080,
+			Env: []corev1.EnvVar{
+				{Name: "APP_NAME", Value: "{{ .Name }}"},
+				{Name: "DB_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "db"},
+					Key:                  "password",
+				}}},
+			},
+			EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "settings"},
+			}}},
+		})
+	}
+
+	It("should inject spec.env and spec.envFrom and roll out when a referenced value changes", func() {
+		app := newApp()
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("s3cret"), "user": []byte("web")},
+		}
+		r, c := newTestReconciler(secret)
+
+		Expect(r.hashEnvSources(ctx, app)).To(Succeed())
+		missing := app.Status.EnvHash
+		Expect(missing).NotTo(BeEmpty())
+		container := r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Containers[0]
+		Expect(container.Env[0]).To(Equal(corev1.EnvVar{Name: "APP_NAME", Value: "web"}))
+		Expect(container.Env[1].ValueFrom.SecretKeyRef.Name).To(Equal("db"))
+		Expect(container.EnvFrom).To(Equal(app.Spec.EnvFrom))
+
+		By("changing the hash once the ConfigMap is created")
+		Expect(c.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"},
+			Data:       map[string]string{"LOG_LEVEL": "info"},
+		})).To(Succeed())
+		Expect(r.hashEnvSources(ctx, app)).To(Succeed())
+		created := app.Status.EnvHash
+		Expect(created).NotTo(Equal(missing))
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Annotations).To(HaveKeyWithValue(envHashAnnotation, created))
+
+		By("ignoring Secret keys the App doesn't reference")
+		secret.Data["user"] = []byte("admin")
+		Expect(c.Update(ctx, secret)).To(Succeed())
+		Expect(r.hashEnvSources(ctx, app)).To(Succeed())
+		Expect(app.Status.EnvHash).To(Equal(created))
+
+		By("changing the hash with a referenced Secret key")
+		secret.Data["password"] = []byte("rotated")
+		Expect(c.Update(ctx, secret)).To(Succeed())
+		Expect(r.hashEnvSources(ctx, app)).To(Succeed())
+		Expect(app.Status.EnvHash).NotTo(Equal(created))
+
+		By("dropping the hash when nothing is referenced")
+		app.Spec.Env, app.Spec.EnvFrom = nil, nil
+		Expect(r.hashEnvSources(ctx, app)).To(Succeed())
+		Expect(app.Status.EnvHash).To(BeEmpty())
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Annotations).NotTo(HaveKey(envHashAnnotation))
+	})
+
+	It("should reject invalid templates and namespace-qualified references", func() {
+		app := newApp()
+		app.Spec.Env[0].Value = "{{ .Missing }}"
+		app.Spec.EnvFrom[0].ConfigMapRef.Name = "other/settings"
+		r := &AppReconciler{}
+		Expect(r.checkTemplates(app)).To(MatchError(ContainSubstring("spec.env[APP_NAME]")))
+		Expect(checkReferences(app, nil)).To(MatchError(ContainSubstring("spec.envFrom[0].configMapRef.name")))
+	})
+})
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// Actions on child resources, recorded in Events on their App as <action>d, or <action>Failed.
+const (
+	actionCreate = "Create"
+	actionUpdate = "Update"
+	actionDelete = "Delete"
+)
+
+// createChild creates a child resource of the App, and records it on the App.
+func (r *AppReconciler) createChild(ctx context.Context, app *webappv1.App, obj client.Object) error {
+	err := r.Create(ctx, obj)
+	r.recordChildEvent(app, obj, actionCreate, err)
+	return err
+}
+
+// updateChild updates a child resource of the App, and records it on the App.
+func (r *AppReconciler) updateChild(ctx context.Context, app *webappv1.App, obj client.Object) error {
+	err := r.Update(ctx, obj)
+	r.recordChildEvent(app, obj, actionUpdate, err)
+	return err
+}
+
+// deleteChild deletes a child resource of the App unless it is gone already, and records it
+// on the App.
+func (r *AppReconciler) deleteChild(ctx context.Context, app *webappv1.App, obj client.Object) error {
+	err := client.IgnoreNotFound(r.Delete(ctx, obj))
+	r.recordChildEvent(app, obj, actionDelete, err)
+	return err
+}
+
+// recordChildEvent records the outcome of an action on a child resource as an Event on its
+// App: a Normal Event such as Created on success, a Warning such as CreateFailed with the
+// error otherwise. The Event names the user whose change of the App led to the action, see
+// eventAttribution. Conflicts are not recorded, as the next reconcile retries with the latest
+// object. Nothing is recorded without a Recorder.
+func (r *AppReconciler) recordChildEvent(app *webappv1.App, obj client.Object, action string, err error) {
+	if r.Recorder == nil || errors.IsConflict(err) {
+		return
+	}
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, gvkErr := apiutil.GVKForObject(obj, r.Scheme); gvkErr == nil {
+		kind = gvk.Kind
+	}
+	by := eventAttribution(app)
+	if err != nil {
+		r.Recorder.Eventf(app, corev1.EventTypeWarning, action+"Failed", "Failed to %s %s %s%s: %v", strings.ToLower(action), kind, obj.GetName(), by, err)
+		return
+	}
+	r.Recorder.Eventf(app, corev1.EventTypeNormal, action+"d", "%sd %s %s%s", action, kind, obj.GetName(), by)
+}
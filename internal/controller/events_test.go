@@ -0,0 +1,78 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Events", func() {
+	It("should record Events for the child resources it writes", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+			Spec:       webappv1.AppSpec{Image: "web:1.0", Port: 8080, Autoscaling: &webappv1.AutoscalingSpec{MaxReplicas: 3}},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithInterceptorFuncs(interceptor.Funcs{Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*corev1.Service); ok {
+					return errors.NewForbidden(corev1.Resource("services"), obj.GetName(), fmt.Errorf("quota exceeded"))
+				}
+				return c.Create(ctx, obj, opts...)
+			}}).Build()
+		recorder := record.NewFakeRecorder(10)
+		r := &AppReconciler{Client: c, Scheme: c.Scheme(), Recorder: recorder}
+
+		Expect(r.reconcileDeployment(ctx, app, app.Spec.Image)).NotTo(BeNil())
+		Expect(recorder.Events).To(Receive(Equal("Normal Created Created Deployment web-deployment")))
+		Expect(r.reconcileService(ctx, app)).NotTo(Succeed())
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning CreateFailed Failed to create Service web-service: ")))
+
+		app.Spec.Image = "web:2.0"
+		Expect(r.reconcileDeployment(ctx, app, app.Spec.Image)).NotTo(BeNil())
+		Expect(recorder.Events).To(Receive(Equal("Normal Updated Updated Deployment web-deployment")))
+		Expect(r.reconcileDeployment(ctx, app, app.Spec.Image)).NotTo(BeNil())
+		Expect(recorder.Events).NotTo(Receive(), "objects in sync are not recorded")
+
+		_, err := r.reconcileHPA(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(Equal("Normal Created Created HorizontalPodAutoscaler web-hpa")))
+		app.Annotations = map[string]string{webappv1.LastModifiedByAnnotation: "alice@example.com"}
+		app.Spec.Autoscaling = nil
+		_, err = r.reconcileHPA(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(Receive(Equal("Normal Deleted Deleted HorizontalPodAutoscaler web-hpa after a change by alice@example.com")))
+
+		app.Spec.Image = "web:3.0"
+		Expect(r.reconcileDeployment(ctx, app, app.Spec.Image)).NotTo(BeNil())
+		Expect(recorder.Events).To(Receive(Equal("Normal Updated Updated Deployment web-deployment after a change by alice@example.com")))
+	})
+})
@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// defaultExposePath is used for Apps stored before the API server defaulted spec.expose.path.
+	defaultExposePath = "/"
+	// backendProtocolAnnotation tells ingress-nginx which protocol to speak to the App's pods.
+	// Other Ingress controllers read the appProtocol of the Service port instead.
+	backendProtocolAnnotation = "nginx.ingress.kubernetes.io/backend-protocol"
+)
+
+// httpRouteGVK and gatewayGVK identify Gateway API kinds. They are handled as unstructured
+// so the Gateway API CRDs are only required by Apps exposed through an HTTPRoute.
+var (
+	httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+	gatewayGVK   = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "Gateway"}
+)
+
+// ingressName returns the name of the Ingress exposing an App.
+func ingressName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-ingress", app.Name)
+}
+
+// exposeMode returns the mode of the App's spec.expose, empty when it isn't exposed.
+func exposeMode(app *webappv1.App) webappv1.ExposeMode {
+	if app.Spec.Expose == nil || isKnative(app) {
+		return ""
+	}
+	if app.Spec.Expose.Mode == "" {
+		return webappv1.ExposeModeIngress
+	}
+	return app.Spec.Expose.Mode
+}
+
+// exposePath returns the path prefix routed to the App.
+func exposePath(app *webappv1.App) string {
+	if app.Spec.Expose.Path == "" {
+		return defaultExposePath
+	}
+	return app.Spec.Expose.Path
+}
+
+// ingressAnnotations returns the annotations of the App's Ingress: the backend protocol when
+// the App serves TLS, so the Ingress controller doesn't send it plaintext.
+func ingressAnnotations(app *webappv1.App) map[string]string {
+	if app.Spec.TLS == nil {
+		return nil
+	}
+	protocol := "HTTPS"
+	if app.Spec.AppProtocol == webappv1.AppProtocolGRPC {
+		protocol = "GRPCS"
+	}
+	return map[string]string{backendProtocolAnnotation: protocol}
+}
+
+// desiredIngress builds the Ingress routing host to the App's Service, without owner.
+func desiredIngress(app *webappv1.App, host string) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ingressName(app),
+			Namespace:   app.Namespace,
+			Annotations: mergeMaps(attributionAnnotations(app), ingressAnnotations(app)),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: app.Spec.Expose.IngressClassName,
+			Rules: []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     exposePath(app),
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: fmt.Sprintf("%s-service", app.Name),
+							Port: networkingv1.ServiceBackendPort{Number: app.Spec.Port},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+	if app.Spec.Expose.TLSSecretName != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: app.Spec.Expose.TLSSecretName}}
+	}
+	return ingress
+}
+
+// desiredHTTPRoute builds the HTTPRoute attaching host to the App's Gateway and routing it to
+// the App's Service, without owner.
+func desiredHTTPRoute(app *webappv1.App, host string) *unstructured.Unstructured {
+	gateway := app.Spec.Expose.Gateway
+	parentRef := map[string]interface{}{"name": gateway.Name}
+	if gateway.Namespace != "" {
+		parentRef["namespace"] = gateway.Namespace
+	}
+	if gateway.SectionName != "" {
+		parentRef["sectionName"] = gateway.SectionName
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(httpRouteGVK)
+	route.SetName(app.Name)
+	route.SetNamespace(app.Namespace)
+	route.SetLabels(map[string]string{
+		"app":        app.Name,
+		"controller": "app-controller",
+	})
+	route.Object["spec"] = map[string]interface{}{
+		"parentRefs": []interface{}{parentRef},
+		"hostnames":  []interface{}{host},
+		"rules": []interface{}{map[string]interface{}{
+			"matches": []interface{}{map[string]interface{}{
+				"path": map[string]interface{}{"type": "PathPrefix", "value": exposePath(app)},
+			}},
+			"backendRefs": []interface{}{map[string]interface{}{
+				"name": fmt.Sprintf("%s-service", app.Name),
+				"port": int64(app.Spec.Port),
+			}},
+		}},
+	}
+	return route
+}
+
+// reconcileExpose creates or updates the Ingress or HTTPRoute of an App with spec.expose,
+// removes the one no longer used, and returns the URL the App is served at. The URL is empty
+// until the Ingress controller assigned an address or a Gateway accepted the route.
+func (r *AppReconciler) reconcileExpose(ctx context.Context, app *webappv1.App) (string, error) {
+	mode := exposeMode(app)
+	var host string
+	if mode != "" {
+		// checkTemplates already verified that the host resolves.
+		rendered, err := r.renderTemplate(app, app.Spec.Expose.Host)
+		if err != nil {
+			return "", err
+		}
+		host = rendered
+	}
+
+	var route *unstructured.Unstructured
+	if mode == webappv1.ExposeModeHTTPRoute {
+		route = desiredHTTPRoute(app, host)
+	}
+	if err := r.reconcileUnstructured(ctx, app, httpRouteGVK, app.Name, route); err != nil {
+		return "", err
+	}
+	if err := r.reconcileBackendTLS(ctx, app); err != nil {
+		return "", err
+	}
+	ingress, err := r.reconcileIngress(ctx, app, host)
+	if err != nil {
+		return "", err
+	}
+
+	switch mode {
+	case webappv1.ExposeModeIngress:
+		if ingress == nil || len(ingress.Status.LoadBalancer.Ingress) == 0 {
+			return "", nil
+		}
+		scheme := "http"
+		if app.Spec.Expose.TLSSecretName != "" {
+			scheme = "https"
+		}
+		return exposeURL(scheme, host, exposePath(app)), nil
+	case webappv1.ExposeModeHTTPRoute:
+		return r.httpRouteURL(ctx, app, host)
+	}
+	return "", nil
+}
+
+// reconcileIngress creates or updates the Ingress of an App exposed in Ingress mode, and
+// removes a previously generated one otherwise. It returns the Ingress as last written or
+// read, nil when the App has none.
+func (r *AppReconciler) reconcileIngress(ctx context.Context, app *webappv1.App, host string) (*networkingv1.Ingress, error) {
+	log := log.FromContext(ctx)
+
+	found := &networkingv1.Ingress{}
+	getErr := r.Get(ctx, types.NamespacedName{Name: ingressName(app), Namespace: app.Namespace}, found)
+
+	if exposeMode(app) != webappv1.ExposeModeIngress {
+		if errors.IsNotFound(getErr) {
+			return nil, nil
+		}
+		if getErr != nil {
+			return nil, getErr
+		}
+		if !metav1.IsControlledBy(found, app) {
+			return nil, nil
+		}
+		log.Info("Deleting Ingress no longer used by App", "Ingress.Name", found.Name)
+		return nil, r.deleteChild(ctx, app, found)
+	}
+
+	desired := desiredIngress(app, host)
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	if errors.IsNotFound(getErr) {
+		log.Info("Creating a new Ingress", "Ingress.Namespace", desired.Namespace, "Ingress.Name", desired.Name)
+		return desired, r.createChild(ctx, app, desired)
+	} else if getErr != nil {
+		return nil, getErr
+	}
+	if !metav1.IsControlledBy(found, app) {
+		return nil, fmt.Errorf("Ingress %q exists and is not owned by the App", found.Name)
+	}
+
+	updated := found.DeepCopy()
+	updated.Labels = mergeMaps(updated.Labels, desired.Labels)
+	updated.Annotations = mergeMaps(updated.Annotations, desired.Annotations)
+	if _, ok := desired.Annotations[backendProtocolAnnotation]; !ok {
+		delete(updated.Annotations, backendProtocolAnnotation)
+	}
+	updated.Spec = desired.Spec
+	syncAttribution(app, updated)
+	if changed, err := r.syncChild(ctx, r.Client, app, found, updated); err != nil || !changed {
+		return found, err
+	}
+	return updated, nil
+}
+
+// httpRouteURL returns the URL of an App exposed through an HTTPRoute once a Gateway accepted
+// the route. It is served over https when the listeners the route attaches to terminate TLS.
+func (r *AppReconciler) httpRouteURL(ctx context.Context, app *webappv1.App, host string) (string, error) {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(httpRouteGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: app.Name, Namespace: app.Namespace}, route); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	parents, _, _ := unstructured.NestedSlice(route.Object, "status", "parents")
+	accepted := false
+	for _, p := range parents {
+		parent, _ := p.(map[string]interface{})
+		conditions, _, _ := unstructured.NestedSlice(parent, "conditions")
+		for _, c := range conditions {
+			condition, _ := c.(map[string]interface{})
+			if condition["type"] == "Accepted" && condition["status"] == string(metav1.ConditionTrue) {
+				accepted = true
+			}
+		}
+	}
+	if !accepted {
+		return "", nil
+	}
+
+	// The Gateway may live in another namespace, and is read without caching every Gateway.
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	ref := app.Spec.Expose.Gateway
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = app.Namespace
+	}
+	gateway := &unstructured.Unstructured{}
+	gateway.SetGroupVersionKind(gatewayGVK)
+	err := reader.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, gateway)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	scheme := "http"
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	for _, l := range listeners {
+		listener, _ := l.(map[string]interface{})
+		if ref.SectionName != "" && listener["name"] != ref.SectionName {
+			continue
+		}
+		if listener["protocol"] == "HTTPS" {
+			scheme = "https"
+		}
+	}
+	return exposeURL(scheme, host, exposePath(app)), nil
+}
+
+// exposeURL returns the URL of an exposed App. The root path is left out.
+func exposeURL(scheme, host, path string) string {
+	if path == defaultExposePath {
+		path = ""
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, host, path)
+}
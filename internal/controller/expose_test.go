@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Exposure", func() {
+	newApp := func(expose *webappv1.ExposeSpec) *webappv1.App {
+		return &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", UID: "web-uid"},
+			Spec:       webappv1.AppSpec{Image: "web:1.0", Port: 8080, Expose: expose},
+		}
+	}
+
+	It("should route the templated host to the Service through an Ingress and report its URL", func() {
+		app := newApp(&webappv1.ExposeSpec{Host: "{{ .Name }}.{{ .Namespace }}.apps.example.com", TLSSecretName: "web-tls", IngressClassName: ptr.To("nginx")})
+		r, c := newTestReconciler()
+
+		Expect(r.checkTemplates(app)).To(Succeed())
+		url, err := r.reconcileExpose(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(url).To(BeEmpty())
+
+		ingress := &networkingv1.Ingress{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-ingress", Namespace: "shop"}, ingress)).To(Succeed())
+		Expect(ingress.Spec.IngressClassName).To(Equal(ptr.To("nginx")))
+		Expect(ingress.Spec.Rules[0].Host).To(Equal("web.shop.apps.example.com"))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name).To(Equal("web-service"))
+		Expect(ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Number).To(Equal(int32(8080)))
+		Expect(ingress.Spec.TLS).To(ConsistOf(networkingv1.IngressTLS{Hosts: []string{"web.shop.apps.example.com"}, SecretName: "web-tls"}))
+
+		By("reporting the URL once the Ingress controller assigned an address")
+		ingress.Status.LoadBalancer.Ingress = []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.10"}}
+		Expect(c.Status().Update(ctx, ingress)).To(Succeed())
+		url, err = r.reconcileExpose(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(url).To(Equal("https://web.shop.apps.example.com"))
+	})
+
+	It("should switch to an HTTPRoute and report its URL once a Gateway accepted it", func() {
+		gateway := &unstructured.Unstructured{}
+		gateway.SetGroupVersionKind(gatewayGVK)
+		gateway.SetName("public")
+		gateway.SetNamespace("infra")
+		Expect(unstructured.SetNestedSlice(gateway.Object, []interface{}{
+			map[string]interface{}{"name": "http", "protocol": "HTTP", "port": int64(80)},
+			map[string]interface{}{"name": "https", "protocol": "HTTPS", "port": int64(443)},
+		}, "spec", "listeners")).To(Succeed())
+		r, c := newTestReconciler(gateway)
+
+		app := newApp(&webappv1.ExposeSpec{Host: "shop.example.com"})
+		_, err := r.reconcileExpose(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+
+		app.Spec.Expose = &webappv1.ExposeSpec{
+			Mode:    webappv1.ExposeModeHTTPRoute,
+			Host:    "shop.example.com",
+			Path:    "/api",
+			Gateway: &webappv1.GatewayReference{Name: "public", Namespace: "infra", SectionName: "https"},
+		}
+		url, err := r.reconcileExpose(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(url).To(BeEmpty())
+		Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "web-ingress", Namespace: "shop"}, &networkingv1.Ingress{}))).To(BeTrue())
+
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(httpRouteGVK)
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web", Namespace: "shop"}, route)).To(Succeed())
+		Expect(route.Object["spec"]).To(HaveKeyWithValue("hostnames", ConsistOf("shop.example.com")))
+		Expect(route.Object["spec"]).To(HaveKeyWithValue("parentRefs", ConsistOf(map[string]interface{}{"name": "public", "namespace": "infra", "sectionName": "https"})))
+
+		Expect(unstructured.SetNestedSlice(route.Object, []interface{}{map[string]interface{}{
+			"parentRef":  map[string]interface{}{"name": "public", "namespace": "infra"},
+			"conditions": []interface{}{map[string]interface{}{"type": "Accepted", "status": "True"}},
+		}}, "status", "parents")).To(Succeed())
+		Expect(c.Update(ctx, route)).To(Succeed())
+		url, err = r.reconcileExpose(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(url).To(Equal("https://shop.example.com/api"))
+
+		By("removing the route when the App is no longer exposed")
+		app.Spec.Expose = nil
+		Expect(r.reconcileExpose(ctx, app)).To(BeEmpty())
+		Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "web", Namespace: "shop"}, route))).To(BeTrue())
+	})
+
+	It("should refuse hosts that don't render to a host name", func() {
+		app := newApp(&webappv1.ExposeSpec{Host: "{{ .Labels.team }}.example.com"})
+		app.Labels = map[string]string{"team": "Web Shop"}
+		Expect((&AppReconciler{}).checkTemplates(app)).To(MatchError(ContainSubstring("spec.expose.host")))
+	})
+})
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/git"
+)
+
+const (
+	// gitCommitAnnotation records on the pod template the commit the App's Git configuration
+	// was read from, so a new commit rolls the pods out again.
+	gitCommitAnnotation = "webapp.example.com/config-commit"
+	// defaultGitInterval is used for Apps stored before the API server defaulted spec.configFrom.git.interval.
+	defaultGitInterval = time.Minute
+)
+
+// gitSnapshot is the configuration last pulled for an App's Git source.
+type gitSnapshot struct {
+	source    webappv1.GitConfigSource
+	snapshot  *git.Snapshot
+	checkedAt time.Time
+}
+
+// gitConfigFiles returns the files of the App's Git configuration source and records their
+// commit in the App's status. The source is only fetched again once its interval has passed
+// or it changed; in between, the files last pulled are returned.
+func (r *AppReconciler) gitConfigFiles(ctx context.Context, app *webappv1.App) (map[string]string, error) {
+	source := app.Spec.ConfigFrom.Git
+	key := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+	interval := defaultGitInterval
+	if source.Interval != nil {
+		interval = source.Interval.Duration
+	}
+
+	r.gitMu.Lock()
+	cached, ok := r.gitSnapshots[key]
+	r.gitMu.Unlock()
+	if !ok || !equality.Semantic.DeepEqual(cached.source, *source) || time.Since(cached.checkedAt) >= interval {
+		snapshot, err := r.fetchGitConfig(ctx, app)
+		if err != nil {
+			return nil, err
+		}
+		cached = gitSnapshot{source: *source.DeepCopy(), snapshot: snapshot, checkedAt: time.Now()}
+		r.gitMu.Lock()
+		if r.gitSnapshots == nil {
+			r.gitSnapshots = map[types.NamespacedName]gitSnapshot{}
+		}
+		r.gitSnapshots[key] = cached
+		r.gitMu.Unlock()
+	}
+
+	if status := app.Status.ConfigFrom; status == nil || status.Commit != cached.snapshot.Commit {
+		log.FromContext(ctx).Info("Pulled configuration from Git", "URL", source.URL, "Commit", cached.snapshot.Commit)
+		app.Status.ConfigFrom = &webappv1.ConfigSourceStatus{Commit: cached.snapshot.Commit, FetchedAt: metav1.Now()}
+	}
+
+	files := make(map[string]string, len(cached.snapshot.Files))
+	for name, content := range cached.snapshot.Files {
+		files[name] = string(content)
+	}
+	return files, nil
+}
+
+// gitConfigWait returns how long until the App's Git configuration source is due to be
+// fetched again, zero for Apps without one.
+func (r *AppReconciler) gitConfigWait(app *webappv1.App) time.Duration {
+	if app.Spec.ConfigFrom == nil || app.Spec.ConfigFrom.Git == nil {
+		return 0
+	}
+	interval := defaultGitInterval
+	if app.Spec.ConfigFrom.Git.Interval != nil {
+		interval = app.Spec.ConfigFrom.Git.Interval.Duration
+	}
+	r.gitMu.Lock()
+	cached, ok := r.gitSnapshots[types.NamespacedName{Name: app.Name, Namespace: app.Namespace}]
+	r.gitMu.Unlock()
+	if !ok {
+		return interval
+	}
+	return max(time.Until(cached.checkedAt.Add(interval)), time.Second)
+}
+
+// fetchGitConfig fetches the App's Git configuration source with the credentials of its Secret.
+func (r *AppReconciler) fetchGitConfig(ctx context.Context, app *webappv1.App) (*git.Snapshot, error) {
+	source := app.Spec.ConfigFrom.Git
+	var auth git.Auth
+	if ref := source.SecretRef; ref != nil {
+		// The cache only holds the controller's own Secrets.
+		reader := r.APIReader
+		if reader == nil {
+			reader = r.Client
+		}
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: app.Namespace}, secret); err != nil {
+			return nil, fmt.Errorf("getting Git credentials Secret %q: %w", ref.Name, err)
+		}
+		auth = git.Auth{
+			Username:    string(secret.Data["username"]),
+			Password:    string(secret.Data["password"]),
+			BearerToken: string(secret.Data["bearerToken"]),
+		}
+	}
+
+	fetcher := r.Git
+	if fetcher == nil {
+		fetcher = git.NewHTTPFetcher()
+	}
+	return fetcher.Fetch(ctx, git.Source{URL: source.URL, Ref: source.Ref, Path: source.Path, Auth: auth})
+}
+
+// forgetGitConfig drops the Git configuration pulled for a deleted App.
+func (r *AppReconciler) forgetGitConfig(key types.NamespacedName) {
+	r.gitMu.Lock()
+	defer r.gitMu.Unlock()
+	delete(r.gitSnapshots, key)
+}
+
+// gitConfigAnnotations returns the pod template annotation recording the commit of the
+// App's Git configuration.
+func gitConfigAnnotations(app *webappv1.App) map[string]string {
+	if app.Spec.ConfigFrom == nil || app.Status.ConfigFrom == nil {
+		return nil
+	}
+	return map[string]string{gitCommitAnnotation: app.Status.ConfigFrom.Commit}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/git"
+)
+
+// fakeGitFetcher serves a fixed snapshot and records the sources it was asked for.
+type fakeGitFetcher struct {
+	snapshot *git.Snapshot
+	fetched  []git.Source
+}
+
+func (f *fakeGitFetcher) Fetch(_ context.Context, source git.Source) (*git.Snapshot, error) {
+	f.fetched = append(f.fetched, source)
+	return f.snapshot, nil
+}
+
+var _ = Describe("Git configuration", func() {
+	It("should render pulled files below inline config and roll pods out on new commits", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Image:  "web:1.0",
+			Config: &webappv1.ConfigSpec{Data: map[string]string{"app.conf": "override"}},
+			ConfigFrom: &webappv1.ConfigSource{Git: &webappv1.GitConfigSource{
+				URL:       "https://git.example.com/config.git",
+				Ref:       "main",
+				Path:      "web",
+				SecretRef: &corev1.LocalObjectReference{Name: "git-credentials"},
+				Interval:  &metav1.Duration{Duration: time.Hour},
+			}},
+		})
+		credentials := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "git-credentials", Namespace: "default"},
+			Data:       map[string][]byte{"username": []byte("bot"), "password": []byte("secret")},
+		}
+		fetcher := &fakeGitFetcher{snapshot: &git.Snapshot{Commit: "c1", Files: map[string][]byte{"app.conf": []byte("pulled"), "extra.conf": []byte("extra")}}}
+		r, c := newTestReconciler(credentials)
+		r.Git = fetcher
+
+		Expect(r.reconcileConfigMap(ctx, app)).To(Succeed())
+		configMap := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-config", Namespace: "default"}, configMap)).To(Succeed())
+		Expect(configMap.Data).To(Equal(map[string]string{"app.conf": "override", "extra.conf": "extra"}))
+		Expect(fetcher.fetched).To(ConsistOf(git.Source{
+			URL: "https://git.example.com/config.git", Ref: "main", Path: "web",
+			Auth: git.Auth{Username: "bot", Password: "secret"},
+		}))
+		Expect(app.Status.ConfigFrom.Commit).To(Equal("c1"))
+		deployment := r.desiredDeployment(app, app.Spec.Image)
+		Expect(deployment.Spec.Template.Annotations).To(HaveKeyWithValue(gitCommitAnnotation, "c1"))
+		Expect(deployment.Spec.Template.Spec.Volumes).To(HaveLen(1))
+
+		// Within the interval, the files last pulled are reused.
+		Expect(r.reconcileConfigMap(ctx, app)).To(Succeed())
+		Expect(fetcher.fetched).To(HaveLen(1))
+
+		// A new commit is picked up once the interval has passed.
+		fetcher.snapshot = &git.Snapshot{Commit: "c2", Files: map[string][]byte{"extra.conf": []byte("changed")}}
+		app.Spec.ConfigFrom.Git.Interval = &metav1.Duration{}
+		Expect(r.reconcileConfigMap(ctx, app)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(configMap), configMap)).To(Succeed())
+		Expect(configMap.Data).To(HaveKeyWithValue("extra.conf", "changed"))
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Annotations).To(HaveKeyWithValue(gitCommitAnnotation, "c2"))
+	})
+})
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// conditionReady is True once the App's current spec is rolled out and all its pods are ready.
+	conditionReady = "Ready"
+	// conditionReconciling is True while a rollout of the App is in progress.
+	conditionReconciling = "Reconciling"
+	// conditionStalled is True when the App cannot make progress without a change to its spec.
+	conditionStalled = "Stalled"
+	// conditionAvailable is True while the App's workload serves with its minimum number of
+	// available pods, including during a rollout.
+	conditionAvailable = "Available"
+	// conditionProgressing is True while a rollout of the App is in progress. It mirrors
+	// Reconciling for tools that follow the Deployment's condition names.
+	conditionProgressing = "Progressing"
+	// conditionDegraded is True when the App is stalled or its pods can't be created.
+	conditionDegraded = "Degraded"
+	// progressDeadlineExceeded is the reason of the Progressing condition of a stuck Deployment.
+	progressDeadlineExceeded = "ProgressDeadlineExceeded"
+)
+
+// setRolloutConditions sets the health conditions of the App from the state of its
+// Deployment, nil when none was created. The Ready, Reconciling and Stalled conditions follow
+// kstatus semantics: Reconciling and Stalled are abnormal-true, and are only meaningful for
+// the generation recorded in status.observedGeneration. Available, Progressing and Degraded
+// follow the Deployment's, for `kubectl wait --for=condition=Available`.
+func setRolloutConditions(app *webappv1.App, deployment *appsv1.Deployment) {
+	reason, message, progressing, stalled := rolloutState(deployment)
+	setHealthConditions(app, reason, message, progressing, stalled)
+	status, reason, message := deploymentAvailability(deployment)
+	setCondition(app, conditionAvailable, status, reason, message)
+	setStrategyConditions(app)
+	if deployment == nil || stalled {
+		return
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentReplicaFailure && condition.Status == corev1.ConditionTrue {
+			setCondition(app, conditionDegraded, metav1.ConditionTrue, condition.Reason, condition.Message)
+		}
+	}
+}
+
+// setHealthConditions sets the Ready, Reconciling, Stalled, Progressing and Degraded conditions
+// of the App from the state of its workload, as returned by rolloutState or knativeState.
+func setHealthConditions(app *webappv1.App, reason, message string, progressing, stalled bool) {
+	set := func(conditionType string, status metav1.ConditionStatus) {
+		setCondition(app, conditionType, status, reason, message)
+	}
+
+	switch {
+	case stalled:
+		set(conditionReady, metav1.ConditionFalse)
+		set(conditionReconciling, metav1.ConditionFalse)
+		set(conditionStalled, metav1.ConditionTrue)
+		set(conditionProgressing, metav1.ConditionFalse)
+		set(conditionDegraded, metav1.ConditionTrue)
+	case progressing:
+		set(conditionReady, metav1.ConditionFalse)
+		set(conditionReconciling, metav1.ConditionTrue)
+		set(conditionStalled, metav1.ConditionFalse)
+		set(conditionProgressing, metav1.ConditionTrue)
+		set(conditionDegraded, metav1.ConditionFalse)
+	default:
+		set(conditionReady, metav1.ConditionTrue)
+		set(conditionReconciling, metav1.ConditionFalse)
+		set(conditionStalled, metav1.ConditionFalse)
+		set(conditionProgressing, metav1.ConditionFalse)
+		set(conditionDegraded, metav1.ConditionFalse)
+	}
+}
+
+// setStalledConditions marks the App as stalled on its current generation before its
+// workload is applied, leaving the availability of the workload already running as it was.
+func setStalledConditions(app *webappv1.App, reason, message string) {
+	for _, conditionType := range []string{conditionStalled, conditionDegraded} {
+		meta.SetStatusCondition(&app.Status.Conditions, metav1.Condition{Type: conditionType, Status: metav1.ConditionTrue, Reason: reason, Message: message, ObservedGeneration: app.Generation})
+	}
+	for _, conditionType := range []string{conditionReady, conditionProgressing} {
+		meta.SetStatusCondition(&app.Status.Conditions, metav1.Condition{Type: conditionType, Status: metav1.ConditionFalse, Reason: reason, Message: message, ObservedGeneration: app.Generation})
+	}
+}
+
+// setCondition sets a condition of the App for the generation in status.observedGeneration.
+func setCondition(app *webappv1.App, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&app.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: app.Status.ObservedGeneration,
+	})
+}
+
+// deploymentAvailability returns the Available condition of a Deployment, which is True while
+// it runs at least its replicas minus maxUnavailable, rollouts included.
+func deploymentAvailability(deployment *appsv1.Deployment) (status metav1.ConditionStatus, reason, message string) {
+	if deployment == nil {
+		return metav1.ConditionFalse, "ImageNotAdmitted", "No image has passed the App's supply-chain policies yet"
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable {
+			return metav1.ConditionStatus(condition.Status), condition.Reason, condition.Message
+		}
+	}
+	return metav1.ConditionUnknown, "AvailabilityPending", "Waiting for the Deployment controller to report availability"
+}
+
+// rolloutState describes the rollout of a Deployment the way `kubectl rollout status` does.
+func rolloutState(deployment *appsv1.Deployment) (reason, message string, progressing, stalled bool) {
+	if deployment == nil {
+		return "ImageNotAdmitted", "No image has passed the App's supply-chain policies yet", false, true
+	}
+
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	for _, condition := range status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse && condition.Reason == progressDeadlineExceeded {
+			return progressDeadlineExceeded, condition.Message, false, true
+		}
+	}
+	switch {
+	case deployment.Generation > status.ObservedGeneration:
+		return "RolloutPending", "Waiting for the Deployment controller to observe the latest spec", true, false
+	case status.UpdatedReplicas < desired:
+		return "RolloutInProgress", fmt.Sprintf("%d of %d replicas updated", status.UpdatedReplicas, desired), true, false
+	case status.Replicas > status.UpdatedReplicas:
+		return "RolloutInProgress", fmt.Sprintf("%d old replicas pending termination", status.Replicas-status.UpdatedReplicas), true, false
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return "RolloutInProgress", fmt.Sprintf("%d of %d updated replicas available", status.AvailableReplicas, status.UpdatedReplicas), true, false
+	}
+	return "RolloutComplete", fmt.Sprintf("%d of %d replicas ready", status.ReadyReplicas, desired), false, false
+}
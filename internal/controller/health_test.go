@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Rollout health", func() {
+	conditions := func(deployment *appsv1.Deployment) map[string]metav1.ConditionStatus {
+		app := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 2}}
+		app.Status.ObservedGeneration = 2
+		setRolloutConditions(app, deployment)
+		statuses := map[string]metav1.ConditionStatus{}
+		for _, condition := range app.Status.Conditions {
+			Expect(condition.ObservedGeneration).To(Equal(int64(2)))
+			statuses[condition.Type] = condition.Status
+		}
+		return statuses
+	}
+	deployment := func(generation int64, status appsv1.DeploymentStatus) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Generation: generation},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To(int32(2))},
+			Status:     status,
+		}
+	}
+
+	It("should follow kstatus conventions through a rollout", func() {
+		Expect(conditions(deployment(3, appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2}))).
+			To(Equal(map[string]metav1.ConditionStatus{
+				conditionReady: metav1.ConditionFalse, conditionReconciling: metav1.ConditionTrue, conditionStalled: metav1.ConditionFalse,
+				conditionAvailable: metav1.ConditionUnknown, conditionProgressing: metav1.ConditionTrue, conditionDegraded: metav1.ConditionFalse,
+			}))
+		Expect(conditions(deployment(3, appsv1.DeploymentStatus{ObservedGeneration: 3, Replicas: 3, UpdatedReplicas: 2, AvailableReplicas: 2}))).
+			To(HaveKeyWithValue(conditionReconciling, metav1.ConditionTrue))
+		Expect(conditions(deployment(3, appsv1.DeploymentStatus{ObservedGeneration: 3, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2, ReadyReplicas: 2, Conditions: []appsv1.DeploymentCondition{{
+			Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable",
+		}}}))).
+			To(Equal(map[string]metav1.ConditionStatus{
+				conditionReady: metav1.ConditionTrue, conditionReconciling: metav1.ConditionFalse, conditionStalled: metav1.ConditionFalse,
+				conditionAvailable: metav1.ConditionTrue, conditionProgressing: metav1.ConditionFalse, conditionDegraded: metav1.ConditionFalse,
+			}))
+	})
+
+	It("should stay available through a rollout and report pods that can't be created", func() {
+		rollingOut := deployment(3, appsv1.DeploymentStatus{ObservedGeneration: 3, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 2, Conditions: []appsv1.DeploymentCondition{
+			{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue, Reason: "MinimumReplicasAvailable"},
+			{Type: appsv1.DeploymentReplicaFailure, Status: corev1.ConditionTrue, Reason: "FailedCreate", Message: "exceeded quota"},
+		}})
+		statuses := conditions(rollingOut)
+		Expect(statuses).To(HaveKeyWithValue(conditionAvailable, metav1.ConditionTrue))
+		Expect(statuses).To(HaveKeyWithValue(conditionProgressing, metav1.ConditionTrue))
+		Expect(statuses).To(HaveKeyWithValue(conditionDegraded, metav1.ConditionTrue))
+		Expect(conditions(nil)).To(HaveKeyWithValue(conditionAvailable, metav1.ConditionFalse))
+	})
+
+	It("should mark Apps with an invalid spec degraded without touching availability", func() {
+		app := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Generation: 3}}
+		setCondition(app, conditionAvailable, metav1.ConditionTrue, "MinimumReplicasAvailable", "")
+		setStalledConditions(app, "InvalidTemplate", "template: unknown field")
+
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, conditionDegraded)).To(BeTrue())
+		Expect(meta.IsStatusConditionFalse(app.Status.Conditions, conditionProgressing)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, conditionAvailable)).To(BeTrue())
+		Expect(meta.FindStatusCondition(app.Status.Conditions, conditionDegraded).ObservedGeneration).To(Equal(int64(3)))
+	})
+
+	It("should report stalled rollouts", func() {
+		stuck := deployment(3, appsv1.DeploymentStatus{ObservedGeneration: 3, Replicas: 3, UpdatedReplicas: 1, Conditions: []appsv1.DeploymentCondition{{
+			Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: progressDeadlineExceeded,
+		}}})
+		Expect(conditions(stuck)).To(HaveKeyWithValue(conditionStalled, metav1.ConditionTrue))
+		Expect(conditions(nil)).To(HaveKeyWithValue(conditionStalled, metav1.ConditionTrue))
+	})
+
+	It("should write a new observed generation without waiting for the status interval", func() {
+		app := newTestApp(webappv1.AppSpec{})
+		r, c := newTestReconciler(app)
+		r.StatusInterval = time.Minute
+
+		for generation := int64(1); generation <= 2; generation++ {
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(app), app)).To(Succeed())
+			changed := app.DeepCopy()
+			changed.Status.ObservedGeneration = generation
+			Expect(r.updateStatus(ctx, app, changed)).To(BeZero())
+		}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(app), app)).To(Succeed())
+		Expect(app.Status.ObservedGeneration).To(Equal(int64(2)))
+	})
+})
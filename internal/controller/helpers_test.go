@@ -0,0 +1,76 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// newTestApp returns the App most specs reconcile: "web" in the default namespace.
+func newTestApp(spec webappv1.AppSpec) *webappv1.App {
+	return &webappv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec:       spec,
+	}
+}
+
+// newTestClient returns a fake client seeded with objs. Apps and AppPolicies among them
+// get a status subresource, as on a real API server.
+func newTestClient(objs ...client.Object) client.WithWatch {
+	builder := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...)
+	for _, obj := range objs {
+		switch obj.(type) {
+		case *webappv1.App, *webappv1.AppPolicy:
+			builder = builder.WithStatusSubresource(obj)
+		}
+	}
+	return builder.Build()
+}
+
+// newTestReconciler returns an AppReconciler backed by newTestClient(objs...).
+func newTestReconciler(objs ...client.Object) (*AppReconciler, client.WithWatch) {
+	c := newTestClient(objs...)
+	return &AppReconciler{Client: c, Scheme: c.Scheme()}, c
+}
+
+// newTestReplicaSet returns a Deployment the App controls and its ReplicaSet, whose pods
+// have the given pod-template-hash.
+func newTestReplicaSet(app *webappv1.App, deployment, hash string) (*appsv1.Deployment, *appsv1.ReplicaSet) {
+	owner := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deployment, Namespace: app.Namespace, UID: types.UID(deployment + "-uid")}}
+	if err := controllerutil.SetControllerReference(app, owner, scheme.Scheme); err != nil {
+		panic(err)
+	}
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name:      deployment + "-" + hash,
+		Namespace: app.Namespace,
+		UID:       types.UID(deployment + "-" + hash + "-uid"),
+		Labels:    map[string]string{"app": app.Name, appsv1.DefaultDeploymentUniqueLabelKey: hash},
+	}}
+	if err := controllerutil.SetControllerReference(owner, replicaSet, scheme.Scheme); err != nil {
+		panic(err)
+	}
+	return owner, replicaSet
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// pdbName returns the name of the PodDisruptionBudget of an App.
+func pdbName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-pdb", app.Name)
+}
+
+// highlyAvailable reports whether the App's pods run a Deployment with spec.highAvailability.
+func highlyAvailable(app *webappv1.App) bool {
+	return app.Spec.HighAvailability != nil && !isKnative(app)
+}
+
+// topologySpreadConstraints returns the constraints spreading the pods of each revision of
+// the App's Deployment across zones and nodes, nil for Apps without spec.highAvailability.
+func topologySpreadConstraints(app *webappv1.App) []corev1.TopologySpreadConstraint {
+	if !highlyAvailable(app) {
+		return nil
+	}
+	whenUnsatisfiable := app.Spec.HighAvailability.WhenUnsatisfiable
+	if whenUnsatisfiable == "" {
+		whenUnsatisfiable = corev1.ScheduleAnyway
+	}
+	var constraints []corev1.TopologySpreadConstraint
+	for _, key := range []string{corev1.LabelTopologyZone, corev1.LabelHostname} {
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       key,
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": app.Name}},
+			// Pods of the previous revision don't count while the Deployment rolls out.
+			MatchLabelKeys: []string{"pod-template-hash"},
+		})
+	}
+	return constraints
+}
+
+// podAffinity returns the affinity of the App's pods: the node affinity of platformAffinity
+// and, for Apps with spec.highAvailability, a preferred anti-affinity keeping the pods of each
+// revision on separate nodes alongside the spread constraints.
+func podAffinity(app *webappv1.App, image string) *corev1.Affinity {
+	affinity := platformAffinity(app, image)
+	if !highlyAvailable(app) {
+		return affinity
+	}
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+	affinity.PodAntiAffinity = &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+			Weight: 100,
+			PodAffinityTerm: corev1.PodAffinityTerm{
+				TopologyKey:    corev1.LabelHostname,
+				LabelSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"app": app.Name}},
+				MatchLabelKeys: []string{"pod-template-hash"},
+			},
+		}},
+	}
+	return affinity
+}
+
+// desiredPodDisruptionBudget returns the PodDisruptionBudget of the App's pods, those of a
+// canary or preview Deployment included, without owner.
+func desiredPodDisruptionBudget(app *webappv1.App) *policyv1.PodDisruptionBudget {
+	spec := app.Spec.HighAvailability
+	budget := policyv1.PodDisruptionBudgetSpec{
+		MinAvailable:   spec.MinAvailable,
+		MaxUnavailable: spec.MaxUnavailable,
+		Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": app.Name}},
+	}
+	if budget.MinAvailable == nil && budget.MaxUnavailable == nil {
+		budget.MaxUnavailable = ptr.To(intstr.FromInt32(1))
+	}
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pdbName(app),
+			Namespace:   app.Namespace,
+			Annotations: attributionAnnotations(app),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Spec: budget,
+	}
+}
+
+// reconcilePodDisruptionBudget creates or updates the PodDisruptionBudget of an App with
+// spec.highAvailability, and removes a previously generated one otherwise.
+func (r *AppReconciler) reconcilePodDisruptionBudget(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	found := &policyv1.PodDisruptionBudget{}
+	getErr := r.Get(ctx, types.NamespacedName{Name: pdbName(app), Namespace: app.Namespace}, found)
+
+	if !highlyAvailable(app) {
+		if errors.IsNotFound(getErr) {
+			return nil
+		}
+		if getErr != nil {
+			return getErr
+		}
+		if !metav1.IsControlledBy(found, app) {
+			return nil
+		}
+		log.Info("Deleting PodDisruptionBudget no longer used by App", "PodDisruptionBudget.Name", found.Name)
+		return r.deleteChild(ctx, app, found)
+	}
+
+	desired := desiredPodDisruptionBudget(app)
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	if errors.IsNotFound(getErr) {
+		log.Info("Creating a new PodDisruptionBudget", "PodDisruptionBudget.Namespace", desired.Namespace, "PodDisruptionBudget.Name", desired.Name)
+		return r.createChild(ctx, app, desired)
+	} else if getErr != nil {
+		return getErr
+	}
+	if !metav1.IsControlledBy(found, app) {
+		return fmt.Errorf("PodDisruptionBudget %q exists and is not owned by the App", found.Name)
+	}
+
+	updated := found.DeepCopy()
+	updated.Labels = mergeMaps(updated.Labels, desired.Labels)
+	updated.Spec.MinAvailable = desired.Spec.MinAvailable
+	updated.Spec.MaxUnavailable = desired.Spec.MaxUnavailable
+	updated.Spec.Selector = desired.Spec.Selector
+	syncAttribution(app, updated)
+	_, err := r.syncChild(ctx, r.Client, app, found, updated)
+	return err
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("High availability", func() {
+	It("should spread the App's pods and guard them with a PodDisruptionBudget", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+			Spec: webappv1.AppSpec{Image: "web:1.0", Port: 8080, Replicas: 3,
+				HighAvailability: &webappv1.HighAvailabilitySpec{}},
+		}
+		r, c := newTestReconciler()
+
+		Expect(r.reconcilePodDisruptionBudget(ctx, app)).To(Succeed())
+		pdb := &policyv1.PodDisruptionBudget{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-pdb", Namespace: "default"}, pdb)).To(Succeed())
+		Expect(metav1.IsControlledBy(pdb, app)).To(BeTrue())
+		Expect(pdb.Spec.MaxUnavailable).To(Equal(ptr.To(intstr.FromInt32(1))))
+		Expect(pdb.Spec.MinAvailable).To(BeNil())
+		Expect(pdb.Spec.Selector.MatchLabels).To(Equal(map[string]string{"app": "web"}))
+
+		constraints := r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.TopologySpreadConstraints
+		Expect(constraints).To(HaveLen(2))
+		Expect(constraints[0].TopologyKey).To(Equal("topology.kubernetes.io/zone"))
+		Expect(constraints[1].TopologyKey).To(Equal("kubernetes.io/hostname"))
+		for _, constraint := range constraints {
+			Expect(constraint.MaxSkew).To(Equal(int32(1)))
+			Expect(constraint.WhenUnsatisfiable).To(Equal(corev1.ScheduleAnyway))
+			Expect(constraint.LabelSelector.MatchLabels).To(Equal(map[string]string{"app": "web"}))
+			Expect(constraint.MatchLabelKeys).To(Equal([]string{"pod-template-hash"}))
+		}
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Affinity).To(Equal(&corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+					Weight: 100,
+					PodAffinityTerm: corev1.PodAffinityTerm{
+						TopologyKey:    "kubernetes.io/hostname",
+						LabelSelector:  &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+						MatchLabelKeys: []string{"pod-template-hash"},
+					},
+				}},
+			},
+		}))
+
+		By("keeping the node affinity of spec.platforms")
+		app.Spec.Platforms = []string{"arm64"}
+		affinity := r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Affinity
+		Expect(affinity.NodeAffinity).NotTo(BeNil())
+		Expect(affinity.PodAntiAffinity).NotTo(BeNil())
+		app.Spec.Platforms = nil
+
+		By("switching the budget to a minimum of available pods")
+		app.Spec.HighAvailability.MinAvailable = ptr.To(intstr.FromString("50%"))
+		app.Spec.HighAvailability.WhenUnsatisfiable = corev1.DoNotSchedule
+		Expect(r.reconcilePodDisruptionBudget(ctx, app)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(pdb), pdb)).To(Succeed())
+		Expect(pdb.Spec.MinAvailable).To(Equal(ptr.To(intstr.FromString("50%"))))
+		Expect(pdb.Spec.MaxUnavailable).To(BeNil())
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.TopologySpreadConstraints).To(
+			HaveEach(HaveField("WhenUnsatisfiable", corev1.DoNotSchedule)))
+
+		By("removing the budget and constraints without spec.highAvailability")
+		app.Spec.HighAvailability = nil
+		Expect(r.reconcilePodDisruptionBudget(ctx, app)).To(Succeed())
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(pdb), pdb))).To(BeTrue())
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.TopologySpreadConstraints).To(BeNil())
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Affinity).To(BeNil())
+	})
+
+	It("should leave PodDisruptionBudgets it doesn't own alone", func() {
+		pdb := &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "web-pdb", Namespace: "default"}}
+		r, c := newTestReconciler(pdb)
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+			Spec:       webappv1.AppSpec{Image: "web:1.0", Replicas: 2},
+		}
+
+		Expect(r.reconcilePodDisruptionBudget(ctx, app)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(pdb), pdb)).To(Succeed())
+		app.Spec.HighAvailability = &webappv1.HighAvailabilitySpec{}
+		Expect(r.reconcilePodDisruptionBudget(ctx, app)).To(MatchError(ContainSubstring("not owned by the App")))
+	})
+})
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// admittedImage returns the image the App's Deployment may run. That is spec.image,
+// unless the App's supply-chain policies (spec.imageScan, and spec.provenance or that of
+// the AppPolicies applying to the App) block it;
+// then the image currently deployed is kept, which is "" if the App has never been
+// rolled out. The deployed image is read through cluster, the client of the cluster the
+// App is delivered to. Check results are recorded in the App's status, and an image is
+// only checked once.
+func (r *AppReconciler) admittedImage(ctx context.Context, cluster client.Reader, app *webappv1.App) (string, error) {
+	log := log.FromContext(ctx)
+
+	requirements, err := r.provenanceRequirements(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	if app.Spec.ImageScan == nil && len(requirements) == 0 {
+		return app.Spec.Image, nil
+	}
+
+	deployed, err := deployedImage(ctx, cluster, app)
+	if err != nil {
+		return "", err
+	}
+	if deployed == app.Spec.Image {
+		// Already running; nothing new to gate.
+		return app.Spec.Image, nil
+	}
+
+	scanPassed, err := r.checkImageScan(ctx, app)
+	if err != nil {
+		return "", err
+	}
+	provenanceVerified, err := r.checkProvenance(ctx, app, requirements)
+	if err != nil {
+		return "", err
+	}
+	if scanPassed && provenanceVerified {
+		return app.Spec.Image, nil
+	}
+	log.Info("Blocking rollout of image failing the supply-chain policy", "Image", app.Spec.Image, "DeployedImage", deployed)
+	return deployed, nil
+}
+
+// checkImageScan scans spec.image unless it was scanned already, and reports whether
+// the App's scan policy lets it roll out.
+func (r *AppReconciler) checkImageScan(ctx context.Context, app *webappv1.App) (bool, error) {
+	log := log.FromContext(ctx)
+
+	policy := app.Spec.ImageScan
+	if policy == nil {
+		return true, nil
+	}
+
+	result := app.Status.ImageScan
+	if result == nil || result.Image != app.Spec.Image {
+		if r.Scanner == nil {
+			return false, fmt.Errorf("spec.imageScan is set but the controller has no image scanner configured")
+		}
+		summary, err := r.Scanner.Scan(ctx, app.Spec.Image)
+		if err != nil {
+			return false, err
+		}
+		severity := string(policy.Severity)
+		if severity == "" {
+			severity = "Critical"
+		}
+		result = &webappv1.ImageScanStatus{
+			Image:     app.Spec.Image,
+			ScannedAt: metav1.Now(),
+			Critical:  summary.Critical,
+			High:      summary.High,
+			Medium:    summary.Medium,
+			Low:       summary.Low,
+			Violation: summary.AtOrAbove(severity) > 0,
+		}
+		app.Status.ImageScan = result
+		log.Info("Scanned image", "Image", result.Image, "Critical", result.Critical, "High", result.High, "Violation", result.Violation)
+	}
+
+	if !result.Violation {
+		return true, nil
+	}
+	if policy.Action == webappv1.ImageScanActionWarn {
+		log.Info("Rolling out image violating the scan policy", "Image", app.Spec.Image)
+		return true, nil
+	}
+	return false, nil
+}
+
+// deployedImage returns the image of the App's existing Deployment, or "" if there is none.
+func deployedImage(ctx context.Context, cluster client.Reader, app *webappv1.App) (string, error) {
+	deployment := &appsv1.Deployment{}
+	err := cluster.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-deployment", app.Name), Namespace: app.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return appContainerImage(deployment), nil
+}
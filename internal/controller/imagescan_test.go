@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/scan"
+)
+
+// fakeScanner returns a fixed scan summary and counts how often it was called.
+type fakeScanner struct {
+	summary scan.Summary
+	scans   int
+}
+
+func (f *fakeScanner) Scan(_ context.Context, _ string) (*scan.Summary, error) {
+	f.scans++
+	summary := f.summary
+	return &summary, nil
+}
+
+var _ = Describe("Image scan gate", func() {
+	newApp := func(action webappv1.ImageScanAction) *webappv1.App {
+		return &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+			Spec: webappv1.AppSpec{
+				Image:     "shop:2.0",
+				ImageScan: &webappv1.ImageScanPolicy{Severity: "High", Action: action},
+			},
+		}
+	}
+	newReconciler := func(scanner scan.Scanner) *AppReconciler {
+		r, _ := newTestReconciler()
+		r.Scanner = scanner
+		return r
+	}
+
+	It("should admit images without violations and record the scan", func() {
+		scanner := &fakeScanner{summary: scan.Summary{Medium: 3}}
+		app := newApp(webappv1.ImageScanActionBlock)
+
+		r := newReconciler(scanner)
+		image, err := r.admittedImage(ctx, r.Client, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image).To(Equal("shop:2.0"))
+		Expect(app.Status.ImageScan.Image).To(Equal("shop:2.0"))
+		Expect(app.Status.ImageScan.Medium).To(Equal(int32(3)))
+		Expect(app.Status.ImageScan.Violation).To(BeFalse())
+	})
+
+	It("should block images violating the policy and not rescan them", func() {
+		scanner := &fakeScanner{summary: scan.Summary{High: 1}}
+		app := newApp(webappv1.ImageScanActionBlock)
+		r := newReconciler(scanner)
+
+		image, err := r.admittedImage(ctx, r.Client, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image).To(BeEmpty())
+		Expect(app.Status.ImageScan.Violation).To(BeTrue())
+
+		_, err = r.admittedImage(ctx, r.Client, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(scanner.scans).To(Equal(1))
+	})
+
+	It("should roll out violating images when the policy only warns", func() {
+		app := newApp(webappv1.ImageScanActionWarn)
+
+		r := newReconciler(&fakeScanner{summary: scan.Summary{Critical: 1}})
+		image, err := r.admittedImage(ctx, r.Client, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image).To(Equal("shop:2.0"))
+		Expect(app.Status.ImageScan.Violation).To(BeTrue())
+	})
+
+	It("should fail when a policy is set but no scanner is configured", func() {
+		r := newReconciler(nil)
+		_, err := r.admittedImage(ctx, r.Client, newApp(webappv1.ImageScanActionBlock))
+		Expect(err).To(HaveOccurred())
+	})
+})
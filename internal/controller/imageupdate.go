@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/registry"
+)
+
+// defaultImageUpdateInterval is how often a tracked tag is resolved when
+// spec.imageUpdate.interval is unset.
+const defaultImageUpdateInterval = 5 * time.Minute
+
+// imageUpdateInterval returns how often the tag of the App's image is resolved again, zero
+// when it is resolved once per image.
+func imageUpdateInterval(app *webappv1.App) time.Duration {
+	policy := app.Spec.ImageUpdate
+	if policy == nil || policy.Policy != webappv1.ImageUpdateTrackTag {
+		return 0
+	}
+	if policy.Interval != nil {
+		return policy.Interval.Duration
+	}
+	return defaultImageUpdateInterval
+}
+
+// pinImage resolves the tag of spec.image to the digest it points to, for Apps with
+// spec.imageUpdate, and pins spec.image to that digest for the rest of the reconciliation:
+// the image checks, the rollout and the workloads all see the pinned reference, so that a
+// moved tag goes through them like a new image. The change is not written back to the App.
+// A tag is resolved again once per image with the Pinned policy, and every interval with
+// TrackTag; the digest and when it was resolved are recorded in the App's status.
+func (r *AppReconciler) pinImage(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	if app.Spec.ImageUpdate == nil {
+		app.Status.ImageUpdate = nil
+		return nil
+	}
+
+	status := app.Status.ImageUpdate
+	interval := imageUpdateInterval(app)
+	if status == nil || status.Image != app.Spec.Image || (interval > 0 && time.Since(status.CheckedAt.Time) >= interval) {
+		auth, err := r.registryAuth(ctx, app, app.Spec.Image)
+		if err != nil {
+			return err
+		}
+		inspector := r.Registry
+		if inspector == nil {
+			inspector = registry.NewHTTPInspector()
+		}
+		digest, err := inspector.Digest(ctx, app.Spec.Image, auth)
+		if err != nil {
+			return err
+		}
+		if status != nil && status.Image == app.Spec.Image && status.Digest != digest {
+			log.Info("Image tag moved", "Image", app.Spec.Image, "Digest", digest, "PreviousDigest", status.Digest)
+		}
+		status = &webappv1.ImageUpdateStatus{Image: app.Spec.Image, Digest: digest, CheckedAt: metav1.Now()}
+		app.Status.ImageUpdate = status
+	}
+	app.Spec.Image = pinnedImage(app.Spec.Image, status.Digest)
+	return nil
+}
+
+// pinnedImage returns image, referenced by tag, pinned to digest. The tag is kept for
+// readers; the container runtime pulls by digest.
+func pinnedImage(image, digest string) string {
+	return image + "@" + digest
+}
+
+// imageUpdateWait returns how long until the tag of the App's image is due to be resolved
+// again, zero for Apps that don't track it.
+func imageUpdateWait(app *webappv1.App) time.Duration {
+	interval := imageUpdateInterval(app)
+	status := app.Status.ImageUpdate
+	if interval == 0 || status == nil {
+		return 0
+	}
+	return max(time.Until(status.CheckedAt.Add(interval)), time.Second)
+}
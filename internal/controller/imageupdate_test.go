@@ -0,0 +1,110 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Image updates", func() {
+	digest := func(b string) string { return "sha256:" + strings.Repeat(b, 64) }
+
+	It("should pin the image to the digest its tag points to once", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Image:       "ghcr.io/org/web:1.0",
+			Port:        8080,
+			ImageUpdate: &webappv1.ImageUpdateSpec{Policy: webappv1.ImageUpdatePinned},
+		})
+		r, c := newTestReconciler(app)
+		fakeRegistry := &fakeInspector{digest: digest("a")}
+		r.Registry = fakeRegistry
+		req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		deployment := &appsv1.Deployment{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-deployment", Namespace: "default"}, deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("ghcr.io/org/web:1.0@" + digest("a")))
+		Expect(c.Get(ctx, req.NamespacedName, app)).To(Succeed())
+		Expect(app.Spec.Image).To(Equal("ghcr.io/org/web:1.0"), "the pinned image is not written back")
+		Expect(app.Status.ImageUpdate.Image).To(Equal("ghcr.io/org/web:1.0"))
+		Expect(app.Status.ImageUpdate.Digest).To(Equal(digest("a")))
+
+		By("not resolving the tag again")
+		fakeRegistry.digest = digest("b")
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeRegistry.resolved).To(HaveLen(1))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("ghcr.io/org/web:1.0@" + digest("a")))
+
+		By("resolving a new image")
+		app.Spec.Image = "ghcr.io/org/web:1.1"
+		Expect(c.Update(ctx, app)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeRegistry.resolved).To(Equal([]string{"ghcr.io/org/web:1.0", "ghcr.io/org/web:1.1"}))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("ghcr.io/org/web:1.1@" + digest("b")))
+	})
+
+	It("should roll out the digest a tracked tag moves to", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Image: "ghcr.io/org/web:stable",
+			ImageUpdate: &webappv1.ImageUpdateSpec{
+				Policy:   webappv1.ImageUpdateTrackTag,
+				Interval: &metav1.Duration{Duration: 10 * time.Minute},
+			},
+		})
+		fakeRegistry := &fakeInspector{digest: digest("a")}
+		r := &AppReconciler{Registry: fakeRegistry}
+
+		Expect(r.pinImage(ctx, app)).To(Succeed())
+		Expect(app.Spec.Image).To(Equal("ghcr.io/org/web:stable@" + digest("a")))
+		Expect(imageUpdateWait(app)).To(BeNumerically("~", 10*time.Minute, time.Second))
+
+		By("resolving the tag again once the interval is over")
+		app.Spec.Image = "ghcr.io/org/web:stable"
+		app.Status.ImageUpdate.CheckedAt = metav1.NewTime(time.Now().Add(-11 * time.Minute))
+		fakeRegistry.digest = digest("b")
+		Expect(r.pinImage(ctx, app)).To(Succeed())
+		Expect(app.Spec.Image).To(Equal("ghcr.io/org/web:stable@" + digest("b")))
+		Expect(app.Status.ImageUpdate.Digest).To(Equal(digest("b")))
+		Expect(app.Status.ImageUpdate.CheckedAt.Time).To(BeTemporally("~", time.Now(), time.Second))
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Containers[0].Image).To(Equal("ghcr.io/org/web:stable@" + digest("b")))
+
+		By("dropping the status without spec.imageUpdate")
+		app.Spec.Image = "ghcr.io/org/web:stable"
+		app.Spec.ImageUpdate = nil
+		Expect(r.pinImage(ctx, app)).To(Succeed())
+		Expect(app.Spec.Image).To(Equal("ghcr.io/org/web:stable"))
+		Expect(app.Status.ImageUpdate).To(BeNil())
+		Expect(imageUpdateWait(app)).To(BeZero())
+	})
+})
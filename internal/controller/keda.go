@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// conditionScaledToZero reports whether an App scaling to zero currently runs no pods, so
+	// its next request waits for a cold start.
+	conditionScaledToZero = "ScaledToZero"
+	// defaultScaledownPeriod is used for Apps stored before the API server defaulted spec.scaling.scaledownPeriod.
+	defaultScaledownPeriod = 300
+)
+
+// httpScaledObjectGVK identifies the KEDA HTTP add-on's HTTPScaledObject. It is handled as
+// unstructured so the add-on is only required by Apps scaling to zero.
+var httpScaledObjectGVK = schema.GroupVersionKind{
+	Group:   "http.keda.sh",
+	Version: "v1alpha1",
+	Kind:    "HTTPScaledObject",
+}
+
+// scalesToZero reports whether the App's Deployment is scaled by the KEDA HTTP add-on.
+func scalesToZero(app *webappv1.App) bool {
+	return app.Spec.Scaling != nil && app.Spec.Scaling.ScaleToZero
+}
+
+// desiredHTTPScaledObject builds the HTTPScaledObject scaling the App's Deployment between zero
+// and spec.replicas pods with the requests its interceptor receives for the App's hosts.
+func desiredHTTPScaledObject(app *webappv1.App) *unstructured.Unstructured {
+	scaledownPeriod := int64(defaultScaledownPeriod)
+	if app.Spec.Scaling.ScaledownPeriod != nil {
+		scaledownPeriod = int64(*app.Spec.Scaling.ScaledownPeriod)
+	}
+	hosts := make([]interface{}, 0, len(app.Spec.Scaling.Hosts))
+	for _, host := range app.Spec.Scaling.Hosts {
+		hosts = append(hosts, host)
+	}
+
+	scaledObject := &unstructured.Unstructured{}
+	scaledObject.SetGroupVersionKind(httpScaledObjectGVK)
+	scaledObject.SetName(app.Name)
+	scaledObject.SetNamespace(app.Namespace)
+	scaledObject.SetLabels(map[string]string{
+		"app":        app.Name,
+		"controller": "app-controller",
+	})
+	scaledObject.Object["spec"] = map[string]interface{}{
+		"hosts": hosts,
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"name":       fmt.Sprintf("%s-deployment", app.Name),
+			"service":    fmt.Sprintf("%s-service", app.Name),
+			"port":       int64(app.Spec.Port),
+		},
+		"replicas": map[string]interface{}{
+			"min": int64(0),
+			"max": int64(specReplicas(app)),
+		},
+		"scaledownPeriod": scaledownPeriod,
+	}
+	return scaledObject
+}
+
+// reconcileHTTPScaledObject creates or updates the HTTPScaledObject of an App scaling to zero,
+// and removes a previously generated one when it no longer does.
+func (r *AppReconciler) reconcileHTTPScaledObject(ctx context.Context, app *webappv1.App) error {
+	var desired *unstructured.Unstructured
+	if scalesToZero(app) {
+		desired = desiredHTTPScaledObject(app)
+	}
+	return r.reconcileUnstructured(ctx, app, httpScaledObjectGVK, app.Name, desired)
+}
+
+// setScaledToZeroCondition reports whether an App scaling to zero runs no pods, from its
+// Deployment, nil when none was created. The condition is removed from other Apps.
+func setScaledToZeroCondition(app *webappv1.App, deployment *appsv1.Deployment) {
+	if !scalesToZero(app) || deployment == nil {
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionScaledToZero)
+		return
+	}
+	condition := metav1.Condition{
+		Type:               conditionScaledToZero,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Serving",
+		Message:            "Requests are served by running pods",
+		ObservedGeneration: app.Generation,
+	}
+	if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Idle"
+		condition.Message = "No pods run; the KEDA HTTP interceptor holds the next request until a pod has started"
+	}
+	meta.SetStatusCondition(&app.Status.Conditions, condition)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("HTTP scale to zero", func() {
+	It("should scale the Deployment through an HTTPScaledObject and leave its replicas to KEDA", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Image:    "web:1.0",
+			Replicas: 4,
+			Port:     8080,
+			Scaling:  &webappv1.ScalingSpec{ScaleToZero: true, Hosts: []string{"web.example.com"}},
+		})
+		r, c := newTestReconciler()
+
+		Expect(r.reconcileHTTPScaledObject(ctx, app)).To(Succeed())
+		scaledObject := &unstructured.Unstructured{}
+		scaledObject.SetGroupVersionKind(httpScaledObjectGVK)
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web", Namespace: "default"}, scaledObject)).To(Succeed())
+		Expect(scaledObject.Object["spec"]).To(HaveKeyWithValue("hosts", ConsistOf("web.example.com")))
+		Expect(scaledObject.Object["spec"]).To(HaveKeyWithValue("replicas", map[string]interface{}{"min": int64(0), "max": int64(4)}))
+		Expect(scaledObject.Object["spec"]).To(HaveKeyWithValue("scaledownPeriod", int64(300)))
+
+		deployment, err := r.reconcileDeployment(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		deployment.Spec.Replicas = ptr.To[int32](0)
+		Expect(c.Update(ctx, deployment)).To(Succeed())
+		deployment, err = r.reconcileDeployment(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*deployment.Spec.Replicas).To(BeZero())
+
+		setScaledToZeroCondition(app, deployment)
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, conditionScaledToZero)).To(BeTrue())
+
+		app.Spec.Scaling = nil
+		Expect(r.reconcileHTTPScaledObject(ctx, app)).To(Succeed())
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(scaledObject), scaledObject))).To(BeTrue())
+		setScaledToZeroCondition(app, deployment)
+		Expect(meta.FindStatusCondition(app.Status.Conditions, conditionScaledToZero)).To(BeNil())
+	})
+})
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// knativeServiceGVK identifies Knative Serving's Service. It is handled as unstructured so
+// Knative is only required by Apps of the Knative workload type.
+var knativeServiceGVK = schema.GroupVersionKind{
+	Group:   "serving.knative.dev",
+	Version: "v1",
+	Kind:    "Service",
+}
+
+// knativeRevisionGVK identifies Knative Serving's Revision, which counts the pods of a Service.
+var knativeRevisionGVK = schema.GroupVersionKind{
+	Group:   "serving.knative.dev",
+	Version: "v1",
+	Kind:    "Revision",
+}
+
+// isKnative reports whether the App runs as a Knative Service.
+func isKnative(app *webappv1.App) bool {
+	return app.Spec.WorkloadType == webappv1.WorkloadTypeKnative
+}
+
+// desiredKnativeService returns the Knative Service running the App's pods with the given
+// image, without owner. The pods are the ones of the App's Deployment, minus the pod security
+// context, which Knative only accepts behind a feature flag, and with the port named h2c
+// for HTTP/2 Apps. Scale bounds go into the autoscaling annotations of the revision template.
+func (r *AppReconciler) desiredKnativeService(app *webappv1.App, image string) (*unstructured.Unstructured, error) {
+	template := r.desiredDeployment(app, image).Spec.Template
+	template.Spec.SecurityContext = nil
+	if app.Spec.AppProtocol == webappv1.AppProtocolHTTP2 || app.Spec.AppProtocol == webappv1.AppProtocolGRPC {
+		// Knative only proxies HTTP/2 to a port named h2c.
+		template.Spec.Containers[0].Ports[0].Name = "h2c"
+	}
+	podSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&template.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := mergeMaps(template.Annotations, map[string]string{
+		"autoscaling.knative.dev/min-scale": "0",
+		"autoscaling.knative.dev/max-scale": strconv.Itoa(int(specReplicas(app))),
+	})
+	if knative := app.Spec.Knative; knative != nil {
+		if knative.MinScale != nil {
+			annotations["autoscaling.knative.dev/min-scale"] = strconv.Itoa(int(*knative.MinScale))
+		}
+		if knative.Target != nil {
+			annotations["autoscaling.knative.dev/target"] = strconv.Itoa(int(*knative.Target))
+		}
+		if knative.ContainerConcurrency != nil {
+			podSpec["containerConcurrency"] = *knative.ContainerConcurrency
+		}
+	}
+
+	service := &unstructured.Unstructured{}
+	service.SetGroupVersionKind(knativeServiceGVK)
+	service.SetName(app.Name)
+	service.SetNamespace(app.Namespace)
+	service.SetLabels(map[string]string{
+		"app":        app.Name,
+		"controller": "app-controller",
+	})
+	service.Object["spec"] = map[string]interface{}{
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels":      toInterfaceMap(template.Labels),
+				"annotations": toInterfaceMap(annotations),
+			},
+			"spec": podSpec,
+		},
+	}
+	return service, nil
+}
+
+// reconcileKnativeService creates or updates the Knative Service of an App of the Knative
+// workload type, and removes a previously generated one otherwise. It returns the Knative
+// Service as stored, nil when there is none, e.g. while no image has passed the App's
+// supply-chain policies.
+func (r *AppReconciler) reconcileKnativeService(ctx context.Context, app *webappv1.App, image string) (*unstructured.Unstructured, error) {
+	var desired *unstructured.Unstructured
+	if isKnative(app) && image != "" {
+		var err error
+		if desired, err = r.desiredKnativeService(app, image); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.reconcileUnstructured(ctx, app, knativeServiceGVK, app.Name, desired); err != nil {
+		return nil, err
+	}
+	if desired == nil {
+		return nil, nil
+	}
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(knativeServiceGVK)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(desired), found); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// deleteWorkload deletes the Deployments, Services, HorizontalPodAutoscaler and
+// PodDisruptionBudget of an App that switched to the Knative workload type.
+func (r *AppReconciler) deleteWorkload(ctx context.Context, app *webappv1.App) error {
+	return r.deleteChildren(ctx, app, append([]client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-deployment", app.Name)}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-service", app.Name)}},
+		&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: hpaName(app)}},
+		&policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: pdbName(app)}},
+	}, rolloutChildren(app)...)...)
+}
+
+// setKnativeStatus copies the URL, ready pods and readiness of the App's Knative Service,
+// nil when none was created, into the App's status.
+func (r *AppReconciler) setKnativeStatus(ctx context.Context, app *webappv1.App, service *unstructured.Unstructured) error {
+	if service == nil {
+		app.Status.URL = ""
+		app.Status.Replicas = 0
+		setHealthConditions(app, "ImageNotAdmitted", "No image has passed the App's supply-chain policies yet", false, true)
+		setCondition(app, conditionAvailable, metav1.ConditionFalse, "ImageNotAdmitted", "No image has passed the App's supply-chain policies yet")
+		return nil
+	}
+
+	app.Status.URL, _, _ = unstructured.NestedString(service.Object, "status", "url")
+	replicas, err := r.knativeReplicas(ctx, service)
+	if err != nil {
+		return err
+	}
+	app.Status.Replicas = replicas
+	reason, message, progressing, stalled := knativeState(service)
+	setHealthConditions(app, reason, message, progressing, stalled)
+	// Knative keeps routing to the latest ready revision while a newer one rolls out or fails.
+	if revision, _, _ := unstructured.NestedString(service.Object, "status", "latestReadyRevisionName"); revision != "" {
+		setCondition(app, conditionAvailable, metav1.ConditionTrue, "RevisionReady", fmt.Sprintf("Revision %s serves requests", revision))
+	} else {
+		setCondition(app, conditionAvailable, metav1.ConditionFalse, "NoReadyRevision", "No revision of the Knative Service is ready")
+	}
+	return nil
+}
+
+// knativeReplicas returns the number of pods of the latest ready revision of a Knative Service.
+func (r *AppReconciler) knativeReplicas(ctx context.Context, service *unstructured.Unstructured) (int32, error) {
+	name, _, _ := unstructured.NestedString(service.Object, "status", "latestReadyRevisionName")
+	if name == "" {
+		return 0, nil
+	}
+	revision := &unstructured.Unstructured{}
+	revision.SetGroupVersionKind(knativeRevisionGVK)
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: service.GetNamespace()}, revision)
+	if errors.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	replicas, _, _ := unstructured.NestedInt64(revision.Object, "status", "actualReplicas")
+	return int32(replicas), nil
+}
+
+// knativeState describes the rollout of a Knative Service from its Ready condition.
+func knativeState(service *unstructured.Unstructured) (reason, message string, progressing, stalled bool) {
+	observed, _, _ := unstructured.NestedInt64(service.Object, "status", "observedGeneration")
+	if service.GetGeneration() > observed {
+		return "RolloutPending", "Waiting for Knative to observe the latest spec", true, false
+	}
+	conditions, _, _ := unstructured.NestedSlice(service.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Ready" {
+			continue
+		}
+		reason, _ = condition["reason"].(string)
+		message, _ = condition["message"].(string)
+		switch condition["status"] {
+		case string(corev1.ConditionTrue):
+			return "RolloutComplete", "The Knative Service is ready", false, false
+		case string(corev1.ConditionFalse):
+			if reason == "" {
+				reason = "KnativeServiceFailed"
+			}
+			return reason, message, false, true
+		}
+		if reason == "" {
+			reason = "RolloutInProgress"
+		}
+		return reason, message, true, false
+	}
+	return "RolloutInProgress", "Waiting for the Knative Service to report readiness", true, false
+}
+
+// toInterfaceMap converts a string map for use in an unstructured object. It returns nil for an empty map.
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
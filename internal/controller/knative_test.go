@@ -0,0 +1,99 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Knative workloads", func() {
+	app := func() *webappv1.App {
+		return newTestApp(webappv1.AppSpec{
+			Image:        "web:1.0",
+			Replicas:     5,
+			Port:         8080,
+			WorkloadType: webappv1.WorkloadTypeKnative,
+			Knative:      &webappv1.KnativeSpec{MinScale: ptr.To[int32](1), ContainerConcurrency: ptr.To[int64](10)},
+		})
+	}
+
+	It("should render the App's pods into a Knative Service with its scale bounds", func() {
+		service, err := (&AppReconciler{}).desiredKnativeService(app(), "web:1.0")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(service.GetName()).To(Equal("web"))
+		annotations, _, _ := unstructured.NestedStringMap(service.Object, "spec", "template", "metadata", "annotations")
+		Expect(annotations).To(HaveKeyWithValue("autoscaling.knative.dev/min-scale", "1"))
+		Expect(annotations).To(HaveKeyWithValue("autoscaling.knative.dev/max-scale", "5"))
+		Expect(annotations).NotTo(HaveKey("autoscaling.knative.dev/target"))
+		concurrency, _, _ := unstructured.NestedInt64(service.Object, "spec", "template", "spec", "containerConcurrency")
+		Expect(concurrency).To(Equal(int64(10)))
+		containers, _, _ := unstructured.NestedSlice(service.Object, "spec", "template", "spec", "containers")
+		Expect(containers).To(HaveLen(1))
+		Expect(containers[0]).To(HaveKeyWithValue("image", "web:1.0"))
+		_, found, _ := unstructured.NestedMap(service.Object, "spec", "template", "spec", "securityContext")
+		Expect(found).To(BeFalse())
+	})
+
+	It("should replace the Deployment and report the Knative Service's URL and readiness", func() {
+		app := app()
+		local := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web-deployment", Namespace: "default"}}
+		Expect(controllerutil.SetControllerReference(app, local, scheme.Scheme)).To(Succeed())
+		r, c := newTestReconciler(local)
+
+		service, err := r.reconcileKnativeService(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.deleteWorkload(ctx, app)).To(Succeed())
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(local), &appsv1.Deployment{}))).To(BeTrue())
+
+		Expect(unstructured.SetNestedField(service.Object, map[string]interface{}{
+			"url":                     "https://web.default.example.com",
+			"latestReadyRevisionName": "web-00001",
+			"conditions":              []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+		}, "status")).To(Succeed())
+		revision := &unstructured.Unstructured{}
+		revision.SetGroupVersionKind(knativeRevisionGVK)
+		revision.SetName("web-00001")
+		revision.SetNamespace("default")
+		revision.Object["status"] = map[string]interface{}{"actualReplicas": int64(2)}
+		Expect(c.Create(ctx, revision)).To(Succeed())
+
+		Expect(r.setKnativeStatus(ctx, app, service)).To(Succeed())
+		Expect(app.Status.URL).To(Equal("https://web.default.example.com"))
+		Expect(app.Status.Replicas).To(Equal(int32(2)))
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, conditionReady)).To(BeTrue())
+
+		Expect(unstructured.SetNestedSlice(service.Object, []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "False", "reason": "RevisionFailed", "message": "image pull failed"},
+		}, "status", "conditions")).To(Succeed())
+		Expect(r.setKnativeStatus(ctx, app, service)).To(Succeed())
+		Expect(meta.FindStatusCondition(app.Status.Conditions, conditionStalled).Reason).To(Equal("RevisionFailed"))
+	})
+})
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/policy"
+)
+
+const (
+	// conditionMeshEnrolled reports whether the App's pods get the sidecar of their service mesh.
+	conditionMeshEnrolled = "MeshEnrolled"
+	// istioInjectLabel opts a pod in or out of Istio sidecar injection.
+	istioInjectLabel = "sidecar.istio.io/inject"
+	// istioNamespaceLabel set to "disabled" turns Istio injection off for a whole namespace.
+	istioNamespaceLabel = "istio-injection"
+	// linkerdInjectAnnotation opts a pod in or out of Linkerd proxy injection.
+	linkerdInjectAnnotation = "linkerd.io/inject"
+	// linkerdInboundAnnotation sets the default inbound policy of the Linkerd proxy.
+	linkerdInboundAnnotation = "config.linkerd.io/default-inbound-policy"
+	// linkerdNamespaceWebhooks set to "disabled" keeps Linkerd's injector away from a namespace.
+	linkerdNamespaceWebhooks = "config.linkerd.io/admission-webhooks"
+)
+
+// peerAuthenticationGVK identifies Istio's PeerAuthentication. It is handled as unstructured
+// so Istio is only required by Apps enrolled in it.
+var peerAuthenticationGVK = schema.GroupVersionKind{
+	Group:   "security.istio.io",
+	Version: "v1",
+	Kind:    "PeerAuthentication",
+}
+
+// meshInjection reports whether the App asks for sidecar injection. Apps stored before the
+// API server defaulted spec.mesh.enabled are enrolled.
+func meshInjection(app *webappv1.App) bool {
+	return app.Spec.Mesh != nil && (app.Spec.Mesh.Enabled == nil || *app.Spec.Mesh.Enabled)
+}
+
+// meshLabels returns the pod labels steering Istio sidecar injection.
+func meshLabels(app *webappv1.App) map[string]string {
+	if app.Spec.Mesh == nil || app.Spec.Mesh.Provider != webappv1.MeshProviderIstio {
+		return nil
+	}
+	return map[string]string{istioInjectLabel: fmt.Sprint(meshInjection(app))}
+}
+
+// meshAnnotations returns the pod annotations steering Linkerd proxy injection and its
+// inbound policy.
+func meshAnnotations(app *webappv1.App) map[string]string {
+	if app.Spec.Mesh == nil || app.Spec.Mesh.Provider != webappv1.MeshProviderLinkerd {
+		return nil
+	}
+	annotations := map[string]string{linkerdInjectAnnotation: "disabled"}
+	if meshInjection(app) {
+		annotations[linkerdInjectAnnotation] = "enabled"
+	}
+	switch app.Spec.Mesh.MTLSMode {
+	case webappv1.MeshMTLSModeStrict:
+		annotations[linkerdInboundAnnotation] = "all-authenticated"
+	case webappv1.MeshMTLSModePermissive:
+		annotations[linkerdInboundAnnotation] = "all-unauthenticated"
+	}
+	return annotations
+}
+
+// peerAuthenticationName returns the name of the PeerAuthentication generated for an App.
+func peerAuthenticationName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-mtls", app.Name)
+}
+
+// desiredPeerAuthentication builds the Istio PeerAuthentication applying the App's mTLS mode.
+func desiredPeerAuthentication(app *webappv1.App) *unstructured.Unstructured {
+	mode := "PERMISSIVE"
+	if app.Spec.Mesh.MTLSMode == webappv1.MeshMTLSModeStrict {
+		mode = "STRICT"
+	}
+	pa := &unstructured.Unstructured{}
+	pa.SetGroupVersionKind(peerAuthenticationGVK)
+	pa.SetName(peerAuthenticationName(app))
+	pa.SetNamespace(app.Namespace)
+	pa.SetLabels(map[string]string{
+		"app":        app.Name,
+		"controller": "app-controller",
+	})
+	pa.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"app": app.Name},
+		},
+		"mtls": map[string]interface{}{"mode": mode},
+	}
+	return pa
+}
+
+// reconcilePeerAuthentication creates or updates the PeerAuthentication of an Istio App
+// requesting an mTLS mode, and removes a previously generated one otherwise.
+func (r *AppReconciler) reconcilePeerAuthentication(ctx context.Context, app *webappv1.App) error {
+	var desired *unstructured.Unstructured
+	if meshInjection(app) && app.Spec.Mesh.Provider == webappv1.MeshProviderIstio && app.Spec.Mesh.MTLSMode != "" {
+		desired = desiredPeerAuthentication(app)
+	}
+	return r.reconcileUnstructured(ctx, app, peerAuthenticationGVK, peerAuthenticationName(app), desired)
+}
+
+// meshCondition reports whether the App's pods will get their mesh sidecar, checking that
+// the namespace does not opt out of injection. It returns nil when the App is not meshed.
+func (r *AppReconciler) meshCondition(ctx context.Context, app *webappv1.App) (*metav1.Condition, error) {
+	if app.Spec.Mesh == nil {
+		return nil, nil
+	}
+	condition := &metav1.Condition{
+		Type:               conditionMeshEnrolled,
+		Status:             metav1.ConditionTrue,
+		Reason:             "SidecarInjected",
+		Message:            fmt.Sprintf("Pods are enrolled in %s", app.Spec.Mesh.Provider),
+		ObservedGeneration: app.Generation,
+	}
+	if !meshInjection(app) {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InjectionDisabled"
+		condition.Message = "spec.mesh.enabled is false"
+		return condition, nil
+	}
+
+	// Only the namespace's labels matter, so a metadata-only read keeps full Namespace objects out of the cache.
+	namespace := &metav1.PartialObjectMetadata{}
+	namespace.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	if err := r.Get(ctx, types.NamespacedName{Name: app.Namespace}, namespace); err != nil {
+		return nil, err
+	}
+	blocked := false
+	switch app.Spec.Mesh.Provider {
+	case webappv1.MeshProviderIstio:
+		blocked = namespace.Labels[istioNamespaceLabel] == "disabled"
+	case webappv1.MeshProviderLinkerd:
+		blocked = namespace.Labels[linkerdNamespaceWebhooks] == "disabled"
+	}
+	if blocked {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NamespaceInjectionDisabled"
+		condition.Message = fmt.Sprintf("Pods will not join %s: the namespace opts out of sidecar injection", app.Spec.Mesh.Provider)
+	}
+	return condition, nil
+}
+
+// enqueueNamespaceApps maps a Namespace to its Apps depending on the namespace's labels: those
+// enrolled in a service mesh, whose MeshEnrolled condition depends on them, and those
+// referencing objects outside the namespace, granted by the AppPolicies selecting it.
+func (r *AppReconciler) enqueueNamespaceApps(ctx context.Context, obj client.Object) []reconcile.Request {
+	apps := &webappv1.AppList{}
+	if err := r.List(ctx, apps, client.InNamespace(obj.GetName())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list Apps of Namespace", "Namespace", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		if app.Spec.Mesh != nil || len(policy.CrossNamespaceReferences(&app)) > 0 {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+	return requests
+}
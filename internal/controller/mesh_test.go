@@ -0,0 +1,79 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Service mesh enrollment", func() {
+	It("should label Istio pods and request strict mTLS through a PeerAuthentication", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       webappv1.AppSpec{Mesh: &webappv1.MeshSpec{Provider: webappv1.MeshProviderIstio, MTLSMode: webappv1.MeshMTLSModeStrict}},
+		}
+
+		Expect(meshLabels(app)).To(Equal(map[string]string{"sidecar.istio.io/inject": "true"}))
+		Expect(meshAnnotations(app)).To(BeNil())
+		mode, _, _ := unstructured.NestedString(desiredPeerAuthentication(app).Object, "spec", "mtls", "mode")
+		Expect(mode).To(Equal("STRICT"))
+	})
+
+	It("should annotate Linkerd pods, including opted-out ones", func() {
+		disabled := false
+		app := &webappv1.App{Spec: webappv1.AppSpec{Mesh: &webappv1.MeshSpec{Provider: webappv1.MeshProviderLinkerd, Enabled: &disabled}}}
+
+		Expect(meshLabels(app)).To(BeNil())
+		Expect(meshAnnotations(app)).To(Equal(map[string]string{"linkerd.io/inject": "disabled"}))
+	})
+
+	It("should requeue the meshed Apps of a namespace whose labels change", func() {
+		meshed := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       webappv1.AppSpec{Mesh: &webappv1.MeshSpec{Provider: webappv1.MeshProviderLinkerd}},
+		}
+		plain := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "shop"}}
+		r, _ := newTestReconciler(meshed, plain)
+		namespace := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "shop"}}
+
+		requests := r.enqueueNamespaceApps(ctx, namespace)
+		Expect(requests).To(ConsistOf(reconcile.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "shop"}}))
+	})
+
+	It("should report namespaces opting out of injection", func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Labels: map[string]string{"istio-injection": "disabled"}}}
+		r, _ := newTestReconciler(namespace)
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "legacy"},
+			Spec:       webappv1.AppSpec{Mesh: &webappv1.MeshSpec{Provider: webappv1.MeshProviderIstio}},
+		}
+
+		condition, err := r.meshCondition(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal("NamespaceInjectionDisabled"))
+	})
+})
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var (
+	// reconcileTotal counts the reconciliations of each App by result, success or error.
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "app_controller_reconcile_total",
+		Help: "Total number of reconciliations per App and result.",
+	}, []string{"namespace", "name", "result"})
+
+	// readyReplicas is the number of ready pods of each App, as in its status.replicas.
+	readyReplicas = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "app_controller_ready_replicas",
+		Help: "Number of ready pods per App.",
+	}, []string{"namespace", "name"})
+
+	// timeToReady is how long Apps take to become Ready, from their creation or from when they
+	// stopped being Ready, e.g. because their spec changed.
+	timeToReady = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "app_controller_time_to_ready_seconds",
+		Help:    "Time Apps take to become Ready after being created or becoming unready.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12), // 1s to about 34m
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(reconcileTotal, readyReplicas, timeToReady)
+}
+
+// observeReconcile counts a reconciliation of an App.
+func observeReconcile(key types.NamespacedName, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	reconcileTotal.WithLabelValues(key.Namespace, key.Name, result).Inc()
+}
+
+// observeReadyReplicas records the ready pods of an App.
+func observeReadyReplicas(app *webappv1.App) {
+	readyReplicas.WithLabelValues(app.Namespace, app.Name).Set(float64(app.Status.Replicas))
+}
+
+// observeTimeToReady records how long an App took to become Ready when its status, just
+// written over original, made it so.
+func observeTimeToReady(original, app *webappv1.App) {
+	if meta.IsStatusConditionTrue(original.Status.Conditions, conditionReady) || !meta.IsStatusConditionTrue(app.Status.Conditions, conditionReady) {
+		return
+	}
+	since := app.CreationTimestamp.Time
+	if ready := meta.FindStatusCondition(original.Status.Conditions, conditionReady); ready != nil {
+		since = ready.LastTransitionTime.Time
+	}
+	timeToReady.Observe(time.Since(since).Seconds())
+}
+
+// forgetMetrics drops the series of a deleted App.
+func forgetMetrics(key types.NamespacedName) {
+	labels := prometheus.Labels{"namespace": key.Namespace, "name": key.Name}
+	reconcileTotal.DeletePartialMatch(labels)
+	readyReplicas.DeletePartialMatch(labels)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Metrics", func() {
+	It("should export reconcile outcomes, ready replicas and time to ready per App", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "metrics", Namespace: "default", CreationTimestamp: metav1.NewTime(time.Now().Add(-90 * time.Second))},
+			Spec:       webappv1.AppSpec{Image: "web:1.0", Port: 8080},
+		}
+		r, _ := newTestReconciler(app)
+		key := client.ObjectKeyFromObject(app)
+
+		// value reads a counter or gauge.
+		value := func(m prometheus.Metric) float64 {
+			metric := &dto.Metric{}
+			ExpectWithOffset(1, m.Write(metric)).To(Succeed())
+			return metric.GetCounter().GetValue() + metric.GetGauge().GetValue()
+		}
+		observeReconcile(key, nil)
+		observeReconcile(key, fmt.Errorf("boom"))
+		Expect(value(reconcileTotal.WithLabelValues("default", "metrics", "success"))).To(Equal(1.0))
+		Expect(value(reconcileTotal.WithLabelValues("default", "metrics", "error"))).To(Equal(1.0))
+
+		histogram := &dto.Metric{}
+		Expect(timeToReady.Write(histogram)).To(Succeed())
+		countBefore, sumBefore := histogram.GetHistogram().GetSampleCount(), histogram.GetHistogram().GetSampleSum()
+
+		original := app.DeepCopy()
+		app.Status.Replicas = 2
+		meta.SetStatusCondition(&app.Status.Conditions, metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "RolloutComplete"})
+		_, err := r.updateStatus(ctx, original, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value(readyReplicas.WithLabelValues("default", "metrics"))).To(Equal(2.0))
+		Expect(timeToReady.Write(histogram)).To(Succeed())
+		Expect(histogram.GetHistogram().GetSampleCount()).To(Equal(countBefore + 1))
+		Expect(histogram.GetHistogram().GetSampleSum()-sumBefore).To(BeNumerically("~", 90, 5), "measured from the App's creation")
+
+		forgetMetrics(key)
+		Expect(readyReplicas.DeleteLabelValues("default", "metrics")).To(BeFalse(), "the series of deleted Apps are dropped")
+	})
+})
@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// dependsOnIndex indexes Apps by the names of the Apps they depend on, so the Apps
+// allowed to call a given App can be listed without scanning the namespace.
+const dependsOnIndex = ".spec.dependsOn"
+
+// indexDependsOn is the field indexer function for dependsOnIndex.
+func indexDependsOn(obj client.Object) []string {
+	return obj.(*webappv1.App).Spec.DependsOn
+}
+
+// enqueueDependencies maps an App to the Apps it depends on, whose NetworkPolicies
+// must admit it.
+func enqueueDependencies(_ context.Context, obj client.Object) []reconcile.Request {
+	app := obj.(*webappv1.App)
+	requests := make([]reconcile.Request, 0, len(app.Spec.DependsOn))
+	for _, name := range app.Spec.DependsOn {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: app.Namespace}})
+	}
+	return requests
+}
+
+// defaultIngressNamespace is the namespace of the Ingress controller when the controller isn't
+// told otherwise: ingress-nginx's.
+const defaultIngressNamespace = "ingress-nginx"
+
+// networkPolicyName returns the name of the NetworkPolicy generated for an App.
+func networkPolicyName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-network-policy", app.Name)
+}
+
+// appPeer selects the pods of the named Apps.
+func appPeer(names ...string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "app",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   names,
+			}},
+		},
+	}
+}
+
+// tcpPort returns a NetworkPolicy port matching a TCP port number.
+func tcpPort(port int32) networkingv1.NetworkPolicyPort {
+	protocol := corev1.ProtocolTCP
+	p := intstr.FromInt32(port)
+	return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p}
+}
+
+// declaredPorts returns the NetworkPolicy ports of the App's pods: spec.port, the targets of
+// spec.service.ports and the ports of spec.containers and spec.sidecars.
+func declaredPorts(app *webappv1.App) []networkingv1.NetworkPolicyPort {
+	ports := []networkingv1.NetworkPolicyPort{tcpPort(app.Spec.Port)}
+	for _, port := range containerPorts(app)[1:] {
+		protocol := port.Protocol
+		p := intstr.FromInt32(port.ContainerPort)
+		ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p})
+	}
+	for _, container := range concat(app.Spec.Containers, app.Spec.Sidecars) {
+		for _, port := range container.Ports {
+			protocol := portProtocol(port)
+			p := intstr.FromInt32(port.ContainerPort)
+			ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: &p})
+		}
+	}
+	return ports
+}
+
+// namespacePeer selects the pods of the namespaces of spec.networkPolicy.fromNamespaces, or
+// of the App's own namespace when unset.
+func namespacePeer(app *webappv1.App) networkingv1.NetworkPolicyPeer {
+	if selector := app.Spec.NetworkPolicy.FromNamespaces; selector != nil {
+		return networkingv1.NetworkPolicyPeer{NamespaceSelector: selector.DeepCopy()}
+	}
+	return networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{}}
+}
+
+// namespaceNamePeer selects the pods of the named namespace.
+func namespaceNamePeer(namespace string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace},
+		},
+	}
+}
+
+// exposePeer selects the pods routing the App's spec.expose traffic to it, nil when it isn't
+// exposed: those of the Ingress controller's namespace, or of the Gateway's namespace, where
+// Gateway implementations run its proxies.
+func (r *AppReconciler) exposePeer(app *webappv1.App) *networkingv1.NetworkPolicyPeer {
+	var namespace string
+	switch exposeMode(app) {
+	case webappv1.ExposeModeIngress:
+		namespace = r.IngressNamespace
+		if namespace == "" {
+			namespace = defaultIngressNamespace
+		}
+	case webappv1.ExposeModeHTTPRoute:
+		namespace = app.Spec.Expose.Gateway.Namespace
+		if namespace == "" {
+			namespace = app.Namespace
+		}
+	default:
+		return nil
+	}
+	peer := namespaceNamePeer(namespace)
+	return &peer
+}
+
+// dnsEgressRule allows DNS lookups against the cluster DNS service.
+func dnsEgressRule() networkingv1.NetworkPolicyEgressRule {
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	port := intstr.FromInt32(53)
+	return networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"},
+			},
+			PodSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"k8s-app": "kube-dns"},
+			},
+		}},
+		Ports: []networkingv1.NetworkPolicyPort{
+			{Protocol: &udp, Port: &port},
+			{Protocol: &tcp, Port: &port},
+		},
+	}
+}
+
+// desiredNetworkPolicy builds the default-deny NetworkPolicy of an App with
+// spec.networkIsolation or spec.networkPolicy. dependents are the Apps depending on an
+// isolated App; dependencies are the Apps it depends on that exist, whose ports are opened
+// for egress. Egress is only restricted with spec.networkIsolation. The Ingress controller
+// or Gateway of an exposed App is always let in, so isolation doesn't cut off its route.
+func (r *AppReconciler) desiredNetworkPolicy(app *webappv1.App, dependents, dependencies []webappv1.App) *networkingv1.NetworkPolicy {
+	spec := networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": app.Name}},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+	}
+	if app.Spec.NetworkIsolation {
+		spec.PolicyTypes = append(spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		spec.Egress = []networkingv1.NetworkPolicyEgressRule{dnsEgressRule()}
+	}
+
+	if app.Spec.NetworkPolicy != nil {
+		spec.Ingress = append(spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{namespacePeer(app)},
+			Ports: declaredPorts(app),
+		})
+	}
+
+	if peer := r.exposePeer(app); peer != nil {
+		spec.Ingress = append(spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{*peer},
+			Ports: declaredPorts(app),
+		})
+	}
+
+	if len(dependents) > 0 {
+		names := make([]string, 0, len(dependents))
+		for _, dependent := range dependents {
+			names = append(names, dependent.Name)
+		}
+		// List order is not stable; sort to avoid rewriting an unchanged policy.
+		sort.Strings(names)
+		spec.Ingress = append(spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  []networkingv1.NetworkPolicyPeer{appPeer(names...)},
+			Ports: declaredPorts(app),
+		})
+	}
+
+	for _, dependency := range dependencies {
+		spec.Egress = append(spec.Egress, networkingv1.NetworkPolicyEgressRule{
+			To:    []networkingv1.NetworkPolicyPeer{appPeer(dependency.Name)},
+			Ports: declaredPorts(&dependency),
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        networkPolicyName(app),
+			Namespace:   app.Namespace,
+			Annotations: attributionAnnotations(app),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Spec: spec,
+	}
+}
+
+// appRelations returns the Apps depending on app and the existing Apps app depends on.
+func (r *AppReconciler) appRelations(ctx context.Context, app *webappv1.App) ([]webappv1.App, []webappv1.App, error) {
+	dependents := &webappv1.AppList{}
+	if err := r.List(ctx, dependents, client.InNamespace(app.Namespace), client.MatchingFields{dependsOnIndex: app.Name}); err != nil {
+		return nil, nil, err
+	}
+
+	dependencies := make([]webappv1.App, 0, len(app.Spec.DependsOn))
+	for _, name := range app.Spec.DependsOn {
+		dependency := webappv1.App{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, &dependency)
+		if errors.IsNotFound(err) {
+			// Opened up once the dependency is created and reconciled.
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		dependencies = append(dependencies, dependency)
+	}
+	return dependents.Items, dependencies, nil
+}
+
+// reconcileNetworkPolicy creates or updates the NetworkPolicy of an App using
+// spec.networkIsolation or spec.networkPolicy, and removes a previously generated one when
+// neither is set.
+func (r *AppReconciler) reconcileNetworkPolicy(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	found := &networkingv1.NetworkPolicy{}
+	getErr := r.Get(ctx, types.NamespacedName{Name: networkPolicyName(app), Namespace: app.Namespace}, found)
+
+	if !app.Spec.NetworkIsolation && app.Spec.NetworkPolicy == nil {
+		if errors.IsNotFound(getErr) {
+			return nil
+		}
+		if getErr != nil {
+			return getErr
+		}
+		if !metav1.IsControlledBy(found, app) {
+			return nil
+		}
+		log.Info("Deleting NetworkPolicy no longer used by App", "NetworkPolicy.Name", found.Name)
+		return r.deleteChild(ctx, app, found)
+	}
+
+	var dependents, dependencies []webappv1.App
+	var err error
+	if app.Spec.NetworkIsolation {
+		if dependents, dependencies, err = r.appRelations(ctx, app); err != nil {
+			return err
+		}
+	}
+	desired := r.desiredNetworkPolicy(app, dependents, dependencies)
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	if errors.IsNotFound(getErr) {
+		log.Info("Creating a new NetworkPolicy", "NetworkPolicy.Namespace", desired.Namespace, "NetworkPolicy.Name", desired.Name)
+		return r.createChild(ctx, app, desired)
+	} else if getErr != nil {
+		return getErr
+	}
+
+	updated := found.DeepCopy()
+	updated.Labels = mergeMaps(updated.Labels, desired.Labels)
+	updated.Spec = desired.Spec
+	syncAttribution(app, updated)
+	_, err = r.syncChild(ctx, r.Client, app, found, updated)
+	return err
+}
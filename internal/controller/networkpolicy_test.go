@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Network isolation", func() {
+	It("should allow ingress from dependents and egress to dependencies only", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "shop"},
+			Spec:       webappv1.AppSpec{Port: 8080, DependsOn: []string{"db"}, NetworkIsolation: true},
+		}
+		dependents := []webappv1.App{{ObjectMeta: metav1.ObjectMeta{Name: "web"}}, {ObjectMeta: metav1.ObjectMeta{Name: "api"}}}
+		dependencies := []webappv1.App{{ObjectMeta: metav1.ObjectMeta{Name: "db"}, Spec: webappv1.AppSpec{Port: 5432}}}
+
+		policy := (&AppReconciler{}).desiredNetworkPolicy(app, dependents, dependencies)
+		Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress))
+
+		Expect(policy.Spec.Ingress).To(HaveLen(1))
+		Expect(policy.Spec.Ingress[0].From[0].PodSelector.MatchExpressions[0].Values).To(Equal([]string{"api", "web"}))
+		Expect(policy.Spec.Ingress[0].Ports[0].Port.IntValue()).To(Equal(8080))
+
+		// DNS plus the single dependency.
+		Expect(policy.Spec.Egress).To(HaveLen(2))
+		Expect(policy.Spec.Egress[1].To[0].PodSelector.MatchExpressions[0].Values).To(Equal([]string{"db"}))
+		Expect(policy.Spec.Egress[1].Ports[0].Port.IntValue()).To(Equal(5432))
+	})
+
+	It("should let the Ingress controller or Gateway of an isolated, exposed App in on every declared port", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: webappv1.AppSpec{
+				Port:             8080,
+				Sidecars:         []webappv1.Container{{Name: "metrics", Image: "exporter:1.0", Ports: []webappv1.ContainerPort{{ContainerPort: 9090}}}},
+				Expose:           &webappv1.ExposeSpec{Host: "web.example.com"},
+				NetworkIsolation: true,
+			},
+		}
+		dependents := []webappv1.App{{ObjectMeta: metav1.ObjectMeta{Name: "api"}}}
+		r := &AppReconciler{IngressNamespace: "ingress-system"}
+
+		policy := r.desiredNetworkPolicy(app, dependents, nil)
+		Expect(policy.Spec.Ingress).To(HaveLen(2))
+		Expect(policy.Spec.Ingress[0].From).To(Equal([]networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress-system"},
+		}}}))
+		for _, rule := range policy.Spec.Ingress {
+			Expect(rule.Ports).To(HaveLen(2))
+			Expect(rule.Ports[0].Port.IntValue()).To(Equal(8080))
+			Expect(rule.Ports[1].Port.IntValue()).To(Equal(9090))
+		}
+
+		app.Spec.Expose = &webappv1.ExposeSpec{
+			Host:    "web.example.com",
+			Mode:    webappv1.ExposeModeHTTPRoute,
+			Gateway: &webappv1.GatewayReference{Name: "public", Namespace: "infra"},
+		}
+		policy = r.desiredNetworkPolicy(app, nil, nil)
+		Expect(policy.Spec.Ingress).To(HaveLen(1))
+		Expect(policy.Spec.Ingress[0].From[0].NamespaceSelector.MatchLabels).To(
+			HaveKeyWithValue("kubernetes.io/metadata.name", "infra"))
+	})
+
+	It("should deny all ingress when nothing depends on the App", func() {
+		app := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: "batch"}, Spec: webappv1.AppSpec{NetworkIsolation: true}}
+
+		Expect((&AppReconciler{}).desiredNetworkPolicy(app, nil, nil).Spec.Ingress).To(BeEmpty())
+	})
+
+	It("should only allow ingress on the declared ports from the selected namespaces", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: webappv1.AppSpec{
+				Port: 8080,
+				Service: &webappv1.ServiceSpec{Ports: []webappv1.ServicePortSpec{
+					{Name: "stream", Port: 9000, Protocol: corev1.ProtocolUDP},
+				}},
+				NetworkPolicy: &webappv1.NetworkPolicySpec{},
+			},
+		}
+
+		policy := (&AppReconciler{}).desiredNetworkPolicy(app, nil, nil)
+		Expect(policy.Spec.PolicyTypes).To(Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeIngress}))
+		Expect(policy.Spec.Egress).To(BeEmpty())
+		Expect(policy.Spec.Ingress).To(HaveLen(1))
+		Expect(policy.Spec.Ingress[0].From).To(Equal([]networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}))
+		Expect(policy.Spec.Ingress[0].Ports).To(HaveLen(2))
+		Expect(*policy.Spec.Ingress[0].Ports[1].Protocol).To(Equal(corev1.ProtocolUDP))
+		Expect(policy.Spec.Ingress[0].Ports[1].Port.IntValue()).To(Equal(9000))
+
+		ingress := &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ingress-nginx"}}
+		app.Spec.NetworkPolicy.FromNamespaces = ingress
+		app.Spec.NetworkIsolation = true
+		policy = (&AppReconciler{}).desiredNetworkPolicy(app, []webappv1.App{{ObjectMeta: metav1.ObjectMeta{Name: "api"}}}, nil)
+		Expect(policy.Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress))
+		Expect(policy.Spec.Ingress).To(HaveLen(2))
+		Expect(policy.Spec.Ingress[0].From[0].NamespaceSelector).To(Equal(ingress))
+	})
+})
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/registry"
+)
+
+// checkPlatforms reads the architectures spec.image is built for unless they were read
+// already, and fails when the image runs on none of the App's platforms.
+func (r *AppReconciler) checkPlatforms(ctx context.Context, app *webappv1.App) error {
+	if len(app.Spec.Platforms) == 0 {
+		app.Status.Platforms = nil
+		return nil
+	}
+
+	osName := corev1.Linux
+	if isWindows(app) {
+		osName = corev1.Windows
+	}
+	status := app.Status.Platforms
+	if status == nil || status.Image != app.Spec.Image {
+		auth, err := r.registryAuth(ctx, app, app.Spec.Image)
+		if err != nil {
+			return err
+		}
+		inspector := r.Registry
+		if inspector == nil {
+			inspector = registry.NewHTTPInspector()
+		}
+		platforms, err := inspector.Platforms(ctx, app.Spec.Image, auth)
+		if err != nil {
+			return err
+		}
+		status = &webappv1.PlatformsStatus{Image: app.Spec.Image, InspectedAt: metav1.Now()}
+		for _, platform := range platforms {
+			if platform.OS == string(osName) && !slices.Contains(status.Architectures, platform.Architecture) {
+				status.Architectures = append(status.Architectures, platform.Architecture)
+			}
+		}
+		slices.Sort(status.Architectures)
+		app.Status.Platforms = status
+		log.FromContext(ctx).Info("Inspected image platforms", "Image", status.Image, "Architectures", status.Architectures)
+	}
+
+	if len(platformArchitectures(app, app.Spec.Image)) == 0 {
+		return fmt.Errorf("image %s is built for none of spec.platforms, only for %s/[%s]",
+			app.Spec.Image, osName, strings.Join(status.Architectures, ", "))
+	}
+	return nil
+}
+
+// registryAuth returns the credentials of image's registry in the App's pull Secrets, or
+// else in the central pull Secret. The App's Secrets are read without the cache, which only
+// holds the ones the controller manages.
+func (r *AppReconciler) registryAuth(ctx context.Context, app *webappv1.App, image string) (registry.Auth, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	for _, ref := range app.Spec.ImagePullSecrets {
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: app.Namespace}, secret); err != nil {
+			return registry.Auth{}, err
+		}
+		config, ok := secret.Data[corev1.DockerConfigJsonKey]
+		if !ok {
+			continue
+		}
+		auth, err := registry.CredentialsFor(config, image)
+		if err != nil || auth != (registry.Auth{}) {
+			return auth, err
+		}
+	}
+
+	if r.PullSecret.Name == "" {
+		return registry.Auth{}, nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, r.PullSecret, secret); err != nil {
+		return registry.Auth{}, err
+	}
+	config, ok := secret.Data[corev1.DockerConfigJsonKey]
+	if !ok {
+		return registry.Auth{}, nil
+	}
+	return registry.CredentialsFor(config, image)
+}
+
+// platformArchitectures returns the architectures the App's pods may run on with image:
+// spec.platforms, narrowed to the ones the image is built for once it was inspected.
+func platformArchitectures(app *webappv1.App, image string) []string {
+	status := app.Status.Platforms
+	if status == nil || status.Image != image {
+		return app.Spec.Platforms
+	}
+	var architectures []string
+	for _, architecture := range app.Spec.Platforms {
+		if slices.Contains(status.Architectures, architecture) {
+			architectures = append(architectures, architecture)
+		}
+	}
+	return architectures
+}
+
+// platformAffinity returns the node affinity keeping the App's pods on nodes of the
+// architectures they may run on with image, nil for Apps without spec.platforms.
+func platformAffinity(app *webappv1.App, image string) *corev1.Affinity {
+	if len(app.Spec.Platforms) == 0 {
+		return nil
+	}
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      corev1.LabelArchStable,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   platformArchitectures(app, image),
+					}},
+				}},
+			},
+		},
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/registry"
+)
+
+// fakeInspector returns fixed image platforms and digests, and records the credentials it
+// was given and the images it resolved.
+type fakeInspector struct {
+	platforms []registry.Platform
+	digest    string
+	auth      []registry.Auth
+	resolved  []string
+}
+
+func (f *fakeInspector) Platforms(_ context.Context, _ string, auth registry.Auth) ([]registry.Platform, error) {
+	f.auth = append(f.auth, auth)
+	return f.platforms, nil
+}
+
+func (f *fakeInspector) Digest(_ context.Context, image string, auth registry.Auth) (string, error) {
+	f.auth = append(f.auth, auth)
+	f.resolved = append(f.resolved, image)
+	return f.digest, nil
+}
+
+var _ = Describe("Image platforms", func() {
+	newApp := func(platforms ...string) *webappv1.App {
+		return &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       webappv1.AppSpec{Image: "ghcr.io/org/web:1.0", Platforms: platforms},
+		}
+	}
+	inspector := func() *fakeInspector {
+		return &fakeInspector{platforms: []registry.Platform{
+			{OS: "linux", Architecture: "amd64"},
+			{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			{OS: "windows", Architecture: "arm"},
+		}}
+	}
+
+	It("should keep pods on the architectures both allowed and built for", func() {
+		pullSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "infra"},
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths": {"ghcr.io": {"username": "bot", "password": "token"}}}`)},
+		}
+		r, _ := newTestReconciler(pullSecret)
+		fakeRegistry := inspector()
+		r.Registry = fakeRegistry
+		r.PullSecret = client.ObjectKeyFromObject(pullSecret)
+		app := newApp("arm64", "arm")
+
+		Expect(r.checkPlatforms(ctx, app)).To(Succeed())
+		Expect(app.Status.Platforms.Architectures).To(Equal([]string{"amd64", "arm64"}))
+		Expect(fakeRegistry.auth).To(ConsistOf(registry.Auth{Username: "bot", Password: "token"}))
+
+		affinity := r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Affinity
+		Expect(affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions).To(ConsistOf(
+			corev1.NodeSelectorRequirement{Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"}}))
+
+		By("inspecting each image only once")
+		Expect(r.checkPlatforms(ctx, app)).To(Succeed())
+		Expect(fakeRegistry.auth).To(HaveLen(1))
+	})
+
+	It("should inspect private images with the App's pull secrets", func() {
+		pullSecrets := []client.Object{
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "docker-hub", Namespace: "default"}, Data: map[string][]byte{
+				corev1.DockerConfigJsonKey: []byte(`{"auths": {"https://index.docker.io/v1/": {"username": "hub", "password": "token"}}}`),
+			}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ghcr", Namespace: "default"}, Data: map[string][]byte{
+				corev1.DockerConfigJsonKey: []byte(`{"auths": {"ghcr.io": {"username": "app", "password": "secret"}}}`),
+			}},
+		}
+		r, _ := newTestReconciler(pullSecrets...)
+		fakeRegistry := inspector()
+		r.Registry = fakeRegistry
+		app := newApp("amd64")
+		app.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "docker-hub"}, {Name: "ghcr"}}
+
+		Expect(r.checkPlatforms(ctx, app)).To(Succeed())
+		Expect(fakeRegistry.auth).To(ConsistOf(registry.Auth{Username: "app", Password: "secret"}))
+	})
+
+	It("should refuse images built for none of the App's platforms", func() {
+		r := &AppReconciler{Registry: inspector()}
+		Expect(r.checkPlatforms(ctx, newApp("s390x"))).To(MatchError(ContainSubstring("only for linux/[amd64, arm64]")))
+	})
+
+	It("should leave Apps without platforms alone", func() {
+		app := newApp()
+		Expect((&AppReconciler{}).checkPlatforms(ctx, app)).To(Succeed())
+		Expect(app.Status.Platforms).To(BeNil())
+		Expect((&AppReconciler{}).desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Affinity).To(BeNil())
+	})
+})
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// Probe settings the API server defaults when left unset.
+const (
+	defaultProbePeriodSeconds    = 10
+	defaultProbeTimeoutSeconds   = 1
+	defaultProbeFailureThreshold = 3
+)
+
+const (
+	// defaultStartupFailureThreshold gives Apps 5 minutes to start with the default period.
+	defaultStartupFailureThreshold = 30
+	// defaultHTTPProbePath is the path of HTTP probes without one.
+	defaultHTTPProbePath = "/"
+)
+
+// livenessProbe returns the liveness probe of the app container, if any.
+func livenessProbe(app *webappv1.App) *corev1.Probe {
+	if app.Spec.Probes == nil {
+		return nil
+	}
+	return desiredProbe(app, app.Spec.Probes.Liveness)
+}
+
+// readinessProbe returns the readiness probe of the app container, if any.
+func readinessProbe(app *webappv1.App) *corev1.Probe {
+	if app.Spec.Probes == nil {
+		return nil
+	}
+	return desiredProbe(app, app.Spec.Probes.Readiness)
+}
+
+// startupProbe returns the startup probe of the app container, if any.
+func startupProbe(app *webappv1.App) *corev1.Probe {
+	if app.Spec.Probes == nil || app.Spec.Probes.Startup == nil {
+		return nil
+	}
+	probe := desiredProbe(app, app.Spec.Probes.Startup)
+	if app.Spec.Probes.Startup.FailureThreshold == 0 {
+		probe.FailureThreshold = defaultStartupFailureThreshold
+	}
+	return probe
+}
+
+// desiredProbe builds a container probe on the App's port: a gRPC health check, or an HTTP
+// GET request. Settings left unset get the API server's defaults, so a stored Deployment
+// compares equal to the desired one.
+func desiredProbe(app *webappv1.App, spec *webappv1.ProbeSpec) *corev1.Probe {
+	if spec == nil {
+		return nil
+	}
+	probe := &corev1.Probe{
+		ProbeHandler:        probeHandler(app, spec),
+		InitialDelaySeconds: spec.InitialDelaySeconds,
+		PeriodSeconds:       spec.PeriodSeconds,
+		TimeoutSeconds:      spec.TimeoutSeconds,
+		SuccessThreshold:    1,
+		FailureThreshold:    spec.FailureThreshold,
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = defaultProbePeriodSeconds
+	}
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = defaultProbeTimeoutSeconds
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = defaultProbeFailureThreshold
+	}
+	return probe
+}
+
+// probeHandler returns the check of a probe on the App's port.
+func probeHandler(app *webappv1.App, spec *webappv1.ProbeSpec) corev1.ProbeHandler {
+	if spec.GRPC != nil {
+		service := spec.GRPC.Service
+		return corev1.ProbeHandler{GRPC: &corev1.GRPCAction{Port: app.Spec.Port, Service: &service}}
+	}
+	path := defaultHTTPProbePath
+	if spec.HTTP != nil && spec.HTTP.Path != "" {
+		path = spec.HTTP.Path
+	}
+	scheme := corev1.URISchemeHTTP
+	if app.Spec.TLS != nil {
+		scheme = corev1.URISchemeHTTPS
+	}
+	return corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{
+		Path:   path,
+		Port:   intstr.FromInt32(app.Spec.Port),
+		Scheme: scheme,
+	}}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Resources and probes", func() {
+	newApp := func() *webappv1.App {
+		return newTestApp(webappv1.AppSpec{
+			Image: "web:1.0",
+			Port:  8080,
+			Resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+			Probes: &webappv1.ProbesSpec{
+				Liveness:  &webappv1.ProbeSpec{},
+				Readiness: &webappv1.ProbeSpec{HTTP: &webappv1.HTTPProbe{Path: "/ready"}, PeriodSeconds: 5},
+				Startup:   &webappv1.ProbeSpec{},
+			},
+		})
+	}
+
+	It("should set spec.resources under accelerators and applied recommendations", func() {
+		app := newApp()
+		app.Spec.Accelerators = &webappv1.AcceleratorSpec{
+			Resources: map[corev1.ResourceName]resource.Quantity{"nvidia.com/gpu": resource.MustParse("1")},
+		}
+		resources := containerResources(app)
+		Expect(resources.Requests.Cpu().String()).To(Equal("250m"))
+		Expect(resources.Limits.Memory().String()).To(Equal("512Mi"))
+		Expect(resources.Limits).To(HaveKey(corev1.ResourceName("nvidia.com/gpu")))
+
+		app.Spec.RightSizing = &webappv1.RightSizingSpec{AutoApply: true}
+		app.Status.Recommendations = &webappv1.ResourceRecommendations{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+		}
+		resources = containerResources(app)
+		Expect(resources.Requests.Cpu().String()).To(Equal("100m"))
+		Expect(resources.Requests.Memory().String()).To(Equal("256Mi"))
+		Expect(app.Spec.Resources.Requests.Cpu().String()).To(Equal("250m"), "the spec is left untouched")
+	})
+
+	It("should probe the App's port over HTTP by default", func() {
+		app := newApp()
+		container := (&AppReconciler{}).desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Containers[0]
+
+		Expect(container.LivenessProbe.HTTPGet).To(Equal(&corev1.HTTPGetAction{Path: "/", Port: intstr.FromInt32(8080), Scheme: corev1.URISchemeHTTP}))
+		Expect(container.ReadinessProbe.HTTPGet.Path).To(Equal("/ready"))
+		Expect(container.ReadinessProbe.PeriodSeconds).To(Equal(int32(5)))
+		Expect(container.StartupProbe.HTTPGet.Path).To(Equal("/"))
+		Expect(container.StartupProbe.FailureThreshold).To(Equal(int32(30)))
+		Expect(container.LivenessProbe.FailureThreshold).To(Equal(int32(3)))
+
+		app.Spec.TLS = &webappv1.TLSSpec{}
+		Expect(livenessProbe(app).HTTPGet.Scheme).To(Equal(corev1.URISchemeHTTPS))
+	})
+
+	It("should roll out resource changes", func() {
+		app := newApp()
+		r, _ := newTestReconciler()
+		Expect(r.reconcileDeployment(ctx, app, app.Spec.Image)).NotTo(BeNil())
+
+		app.Spec.Resources.Limits[corev1.ResourceMemory] = resource.MustParse("1Gi")
+		deployment, err := r.reconcileDeployment(ctx, app, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Resources.Limits.Memory().String()).To(Equal("1Gi"))
+	})
+})
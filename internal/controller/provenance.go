@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/policy"
+	"github.com/your-org/my-app-controller/internal/provenance"
+)
+
+// provenanceRequirement is a provenance policy an App's images must satisfy, and what
+// requires it: the App's spec.provenance or an AppPolicy.
+type provenanceRequirement struct {
+	policy *webappv1.ProvenancePolicy
+	source string
+}
+
+// provenanceRequirements returns the provenance policies applying to the App: its own
+// spec.provenance and those of the AppPolicies selecting its namespace.
+func (r *AppReconciler) provenanceRequirements(ctx context.Context, app *webappv1.App) ([]provenanceRequirement, error) {
+	var requirements []provenanceRequirement
+	if app.Spec.Provenance != nil {
+		requirements = append(requirements, provenanceRequirement{policy: app.Spec.Provenance, source: "spec.provenance"})
+	}
+	policies, err := policy.ForNamespace(ctx, r.Client, app.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, appPolicy := range policies {
+		if appPolicy.Spec.Provenance != nil {
+			requirements = append(requirements, provenanceRequirement{policy: appPolicy.Spec.Provenance, source: "AppPolicy " + appPolicy.Name})
+		}
+	}
+	return requirements, nil
+}
+
+// checkProvenance verifies the provenance attestation of spec.image unless it was
+// verified already, and reports whether it satisfies every one of the requirements.
+// Images without a verified attestation fail them; errors reaching the verifier are
+// returned so the rollout is retried. The attested builder and repository are kept in
+// the App's status, so requirements changed since are checked without verifying again.
+func (r *AppReconciler) checkProvenance(ctx context.Context, app *webappv1.App, requirements []provenanceRequirement) (bool, error) {
+	log := log.FromContext(ctx)
+
+	if len(requirements) == 0 {
+		return true, nil
+	}
+
+	result := app.Status.Provenance
+	if result == nil || result.Image != app.Spec.Image {
+		if r.Verifier == nil {
+			return false, fmt.Errorf("%s requires provenance but the controller has no provenance verifier configured", requirements[0].source)
+		}
+		result = &webappv1.ProvenanceStatus{Image: app.Spec.Image}
+		attestation, err := r.Verifier.Verify(ctx, app.Spec.Image)
+		switch {
+		case errors.Is(err, provenance.ErrNoAttestation):
+		case err != nil:
+			return false, err
+		default:
+			result.BuilderID = attestation.BuilderID
+			result.SourceRepository = attestation.SourceRepository
+		}
+		result.VerifiedAt = metav1.Now()
+		app.Status.Provenance = result
+		log.Info("Verified image provenance", "Image", result.Image, "BuilderID", result.BuilderID)
+	}
+
+	if result.BuilderID == "" {
+		result.Message = "The image has no verified provenance attestation"
+	} else {
+		result.Message = provenanceMismatch(requirements, &provenance.Attestation{
+			BuilderID:        result.BuilderID,
+			SourceRepository: result.SourceRepository,
+		})
+	}
+	result.Verified = result.Message == ""
+	return result.Verified, nil
+}
+
+// provenanceMismatch explains why an attestation does not satisfy one of the requirements,
+// or returns "" if it satisfies them all.
+func provenanceMismatch(requirements []provenanceRequirement, attestation *provenance.Attestation) string {
+	for _, requirement := range requirements {
+		policy := requirement.policy
+		if attestation.BuilderID != policy.BuilderID {
+			return fmt.Sprintf("The image was built by %q, not the builder %q trusted by %s", attestation.BuilderID, policy.BuilderID, requirement.source)
+		}
+		if policy.SourceRepository != "" && attestation.SourceRepository != policy.SourceRepository {
+			return fmt.Sprintf("The image was built from %q, not %q as required by %s", attestation.SourceRepository, policy.SourceRepository, requirement.source)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/provenance"
+)
+
+// fakeVerifier returns a fixed attestation, or ErrNoAttestation when it has none.
+type fakeVerifier struct {
+	attestation *provenance.Attestation
+}
+
+func (f *fakeVerifier) Verify(_ context.Context, _ string) (*provenance.Attestation, error) {
+	if f.attestation == nil {
+		return nil, provenance.ErrNoAttestation
+	}
+	return f.attestation, nil
+}
+
+var _ = Describe("Provenance gate", func() {
+	const builder = "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_container_slsa3.yml@refs/tags/v2.0.0"
+
+	admit := func(verifier provenance.Verifier) (string, *webappv1.App) {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+			Spec: webappv1.AppSpec{
+				Image:      "ghcr.io/acme/shop:2.0",
+				Provenance: &webappv1.ProvenancePolicy{BuilderID: builder, SourceRepository: "https://github.com/acme/shop"},
+			},
+		}
+		r, c := newTestReconciler()
+		r.Verifier = verifier
+		image, err := r.admittedImage(ctx, c, app)
+		Expect(err).NotTo(HaveOccurred())
+		return image, app
+	}
+
+	It("should admit images attested by the trusted builder and repository", func() {
+		image, app := admit(&fakeVerifier{attestation: &provenance.Attestation{BuilderID: builder, SourceRepository: "https://github.com/acme/shop"}})
+
+		Expect(image).To(Equal("ghcr.io/acme/shop:2.0"))
+		Expect(app.Status.Provenance.Verified).To(BeTrue())
+	})
+
+	It("should block images built from another repository", func() {
+		image, app := admit(&fakeVerifier{attestation: &provenance.Attestation{BuilderID: builder, SourceRepository: "https://github.com/evil/shop"}})
+
+		Expect(image).To(BeEmpty())
+		Expect(app.Status.Provenance.Verified).To(BeFalse())
+		Expect(app.Status.Provenance.Message).To(ContainSubstring("evil"))
+	})
+
+	It("should block images without a verified attestation", func() {
+		image, app := admit(&fakeVerifier{})
+
+		Expect(image).To(BeEmpty())
+		Expect(app.Status.Provenance.Verified).To(BeFalse())
+	})
+
+	It("should enforce the provenance requirements of AppPolicies as well as the App's", func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"environment": "production"}}}
+		appPolicy := &webappv1.AppPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "production"},
+			Spec: webappv1.AppPolicySpec{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+				Provenance:        &webappv1.ProvenancePolicy{BuilderID: builder, SourceRepository: "https://github.com/acme/platform"},
+			},
+		}
+		r, c := newTestReconciler(namespace, appPolicy)
+		r.Verifier = &fakeVerifier{attestation: &provenance.Attestation{BuilderID: builder, SourceRepository: "https://github.com/acme/shop"}}
+
+		By("blocking an image the App's own policy admits")
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default"},
+			Spec: webappv1.AppSpec{
+				Image:      "ghcr.io/acme/shop:2.0",
+				Provenance: &webappv1.ProvenancePolicy{BuilderID: builder, SourceRepository: "https://github.com/acme/shop"},
+			},
+		}
+		image, err := r.admittedImage(ctx, c, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image).To(BeEmpty())
+		Expect(app.Status.Provenance.Verified).To(BeFalse())
+		Expect(app.Status.Provenance.Message).To(ContainSubstring("AppPolicy production"))
+
+		By("gating Apps without a provenance policy of their own")
+		app.Spec.Provenance = nil
+		app.Status.Provenance = nil
+		image, err = r.admittedImage(ctx, c, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image).To(BeEmpty())
+		Expect(app.Status.Provenance).NotTo(BeNil())
+
+		By("admitting the image once the AppPolicy trusts its repository")
+		appPolicy.Spec.Provenance.SourceRepository = "https://github.com/acme/shop"
+		Expect(c.Update(ctx, appPolicy)).To(Succeed())
+		r.Verifier = nil
+		image, err = r.admittedImage(ctx, c, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(image).To(Equal("ghcr.io/acme/shop:2.0"))
+		Expect(app.Status.Provenance.Verified).To(BeTrue())
+	})
+})
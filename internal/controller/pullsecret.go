@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// imagePullSecrets returns the pull Secrets attached to the App's pods: the central registry
+// credential, unless the App is delivered to a target cluster it isn't replicated to, then
+// spec.imagePullSecrets.
+func (r *AppReconciler) imagePullSecrets(app *webappv1.App) []corev1.LocalObjectReference {
+	var refs []corev1.LocalObjectReference
+	if r.PullSecret.Name != "" && app.Spec.TargetCluster == nil {
+		refs = append(refs, corev1.LocalObjectReference{Name: r.PullSecret.Name})
+	}
+	for _, ref := range app.Spec.ImagePullSecrets {
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// reconcilePullSecret replicates the central registry credential into the App's namespace
+// under the same name. The copy is shared by all Apps of the namespace: each of them is
+// added as a (non-controller) owner, so it is garbage collected with the last App.
+// A Secret of that name not created by the controller is left alone.
+func (r *AppReconciler) reconcilePullSecret(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	if r.PullSecret.Name == "" || app.Namespace == r.PullSecret.Namespace {
+		return nil
+	}
+
+	source := &corev1.Secret{}
+	if err := r.Get(ctx, r.PullSecret, source); err != nil {
+		return err
+	}
+
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: r.PullSecret.Name, Namespace: app.Namespace}, found)
+	if errors.IsNotFound(err) {
+		desired := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.PullSecret.Name,
+				Namespace: app.Namespace,
+				Labels: map[string]string{
+					"controller": "app-controller",
+				},
+			},
+			Type: source.Type,
+			Data: source.Data,
+		}
+		if err := controllerutil.SetOwnerReference(app, desired, r.Scheme); err != nil {
+			return err
+		}
+		log.Info("Creating a new pull Secret", "Secret.Namespace", desired.Namespace, "Secret.Name", desired.Name)
+		err := r.Create(ctx, desired)
+		if errors.IsAlreadyExists(err) {
+			// The cache only holds labelled Secrets, so an unlabelled one of the same name is not seen.
+			log.V(1).Info("Pull Secret exists and is not managed by the controller", "Secret.Namespace", desired.Namespace, "Secret.Name", desired.Name)
+			return nil
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if found.Labels["controller"] != "app-controller" {
+		log.V(1).Info("Pull Secret exists and is not managed by the controller", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
+		return nil
+	}
+
+	updated := found.DeepCopy()
+	updated.Type = source.Type
+	updated.Data = maps.Clone(source.Data)
+	if err := controllerutil.SetOwnerReference(app, updated, r.Scheme); err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(found, updated) {
+		return nil
+	}
+	log.Info("Updating existing pull Secret", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
+	return r.Update(ctx, updated)
+}
+
+// enqueuePullSecretApps maps the central registry credential to every App outside its
+// namespace, and a copy of it to the Apps owning the copy, so copies follow the credential
+// and edits to them are reverted.
+func (r *AppReconciler) enqueuePullSecretApps(ctx context.Context, obj client.Object) []reconcile.Request {
+	if r.PullSecret.Name == "" || obj.GetName() != r.PullSecret.Name {
+		return nil
+	}
+	var requests []reconcile.Request
+	if obj.GetNamespace() != r.PullSecret.Namespace {
+		for _, owner := range obj.GetOwnerReferences() {
+			if owner.APIVersion == webappv1.GroupVersion.String() && owner.Kind == "App" {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: owner.Name, Namespace: obj.GetNamespace()}})
+			}
+		}
+		return requests
+	}
+	apps := &webappv1.AppList{}
+	if err := r.List(ctx, apps); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list Apps using pull Secret")
+		return nil
+	}
+	for _, app := range apps.Items {
+		if app.Namespace != r.PullSecret.Namespace {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+	return requests
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Private registries", func() {
+	It("should attach pull secrets to the pods and a ServiceAccount of their own", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "billing", Namespace: "shop", UID: "billing-uid"},
+			Spec: webappv1.AppSpec{
+				Image:            "registry.example.com/billing:1.0",
+				ImagePullPolicy:  corev1.PullAlways,
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				Sidecars:         []webappv1.Container{{Name: "proxy", Image: "registry.example.com/proxy:1.0"}},
+				ServiceAccount: &webappv1.ServiceAccountSpec{Annotations: map[string]string{
+					"eks.amazonaws.com/role-arn": "arn:aws:iam::123456789012:role/billing",
+				}},
+			},
+		}
+		r, c := newTestReconciler()
+
+		Expect(r.reconcileServiceAccount(ctx, app)).To(Succeed())
+		sa := &corev1.ServiceAccount{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "billing-serviceaccount", Namespace: "shop"}, sa)).To(Succeed())
+		Expect(sa.ImagePullSecrets).To(Equal([]corev1.LocalObjectReference{{Name: "registry"}}))
+		Expect(sa.Annotations).To(HaveKeyWithValue("eks.amazonaws.com/role-arn", "arn:aws:iam::123456789012:role/billing"))
+
+		pod := r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec
+		Expect(pod.ServiceAccountName).To(Equal("billing-serviceaccount"))
+		Expect(pod.ImagePullSecrets).To(Equal([]corev1.LocalObjectReference{{Name: "registry"}}))
+		Expect(pod.Containers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+		Expect(pod.InitContainers[0].ImagePullPolicy).To(Equal(corev1.PullAlways))
+
+		By("keeping the pull secrets and annotations others add")
+		sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: "billing-serviceaccount-dockercfg-x7k2p"})
+		sa.Annotations["openshift.io/internal-registry-pull-secret-ref"] = "billing-serviceaccount-dockercfg-x7k2p"
+		Expect(c.Update(ctx, sa)).To(Succeed())
+		app.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "mirror"}}
+		app.Spec.ServiceAccount.Annotations = nil
+		Expect(r.reconcileServiceAccount(ctx, app)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(sa), sa)).To(Succeed())
+		Expect(sa.ImagePullSecrets).To(Equal([]corev1.LocalObjectReference{{Name: "mirror"}, {Name: "billing-serviceaccount-dockercfg-x7k2p"}}))
+		Expect(sa.Annotations).NotTo(HaveKey("eks.amazonaws.com/role-arn"))
+		Expect(sa.Annotations).To(HaveKey("openshift.io/internal-registry-pull-secret-ref"))
+
+		By("removing the ServiceAccount")
+		app.Spec.ServiceAccount = nil
+		Expect(r.reconcileServiceAccount(ctx, app)).To(Succeed())
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(sa), sa))).To(BeTrue())
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.ServiceAccountName).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Central pull secret", func() {
+	It("should replicate the registry credential and add each App as an owner", func() {
+		source := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "app-system"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+		}
+		r, c := newTestReconciler(source)
+		r.PullSecret = types.NamespacedName{Namespace: "app-system", Name: "registry"}
+
+		for _, name := range []string{"web", "api"} {
+			app := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", UID: types.UID(name)}}
+			Expect(r.reconcilePullSecret(ctx, app)).To(Succeed())
+		}
+
+		replica := &corev1.Secret{}
+		Expect(c.Get(ctx, types.NamespacedName{Namespace: "shop", Name: "registry"}, replica)).To(Succeed())
+		Expect(replica.Type).To(Equal(corev1.SecretTypeDockerConfigJson))
+		Expect(replica.Data).To(Equal(source.Data))
+		Expect(replica.OwnerReferences).To(HaveLen(2))
+		app := &webappv1.App{Spec: webappv1.AppSpec{ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}, {Name: "private"}}}}
+		Expect(r.imagePullSecrets(app)).To(Equal([]corev1.LocalObjectReference{{Name: "registry"}, {Name: "private"}}))
+	})
+})
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// resyncPeriod is the mean time between two periodic reconciles of an App whose objects
+	// can't be watched: Apps delivered to a target cluster, or reading Secrets in their
+	// environment, which the cache doesn't hold.
+	resyncPeriod = 30 * time.Second
+	// progressPollMin and progressPollMax bound the backoff between two reconciles of an App
+	// whose rollout is in progress.
+	progressPollMin = 5 * time.Second
+	progressPollMax = 5 * time.Minute
+)
+
+// resyncAfter returns when to reconcile an App again, spread evenly over half to one and a
+// half resync periods so Apps reconciled together don't stay in lockstep.
+func resyncAfter() time.Duration {
+	return wait.Jitter(resyncPeriod/2, 2)
+}
+
+// newRateLimiter returns the rate limiter retrying Apps that fail to reconcile: after
+// baseDelay, doubling up to maxDelay while they keep failing, and at most 10 retries per
+// second overall, as controller-runtime's default limiter does. Zero delays keep its defaults.
+func newRateLimiter(baseDelay, maxDelay time.Duration) workqueue.TypedRateLimiter[reconcile.Request] {
+	if baseDelay <= 0 {
+		baseDelay = 5 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 1000 * time.Second
+	}
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](baseDelay, maxDelay),
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
+// nextReconcile returns when to reconcile an App again without an event, if ever: the App and
+// the objects it owns are watched, so only what no event announces is waited for. That is the
+// soonest of waits (a deferred status write, the end of a canary step's pause, ...), the next
+// pull of its Git configuration, resolution of its tracked image tag or sample of its usage,
+// the resync period for Apps whose objects can't be watched, and a growing backoff while its
+// rollout is in progress.
+func (r *AppReconciler) nextReconcile(app *webappv1.App, waits ...time.Duration) ctrl.Result {
+	key := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+	waits = append(waits, r.gitConfigWait(app), r.usageSampleWait(app), imageUpdateWait(app))
+	if app.Spec.TargetCluster != nil || readsSecrets(app) || gatewayBackendTLS(app) {
+		waits = append(waits, resyncAfter())
+	}
+	if rolloutInProgress(app) {
+		waits = append(waits, r.progressBackoff(key))
+	} else {
+		r.forgetProgress(key)
+	}
+
+	var requeueAfter time.Duration
+	for _, wait := range waits {
+		if wait > 0 && (requeueAfter == 0 || wait < requeueAfter) {
+			requeueAfter = wait
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}
+}
+
+// rolloutInProgress reports whether the App's workload is rolling out, or its canary or
+// blue/green rollout moving towards the next step. Paused and aborted rollouts wait for the
+// App to be annotated or changed.
+func rolloutInProgress(app *webappv1.App) bool {
+	if rollout := app.Status.Rollout; rollout != nil {
+		return rollout.Phase == webappv1.RolloutPhaseProgressing || rollout.Phase == webappv1.RolloutPhasePromoting
+	}
+	return meta.IsStatusConditionTrue(app.Status.Conditions, conditionReconciling)
+}
+
+// progressBackoff returns when to check the rollout of an App again: progressPollMin after it
+// started, doubling up to progressPollMax while it lasts.
+func (r *AppReconciler) progressBackoff(key types.NamespacedName) time.Duration {
+	r.progressMu.Lock()
+	defer r.progressMu.Unlock()
+	if r.progress == nil {
+		r.progress = workqueue.NewTypedItemExponentialFailureRateLimiter[types.NamespacedName](progressPollMin, progressPollMax)
+	}
+	return r.progress.When(key)
+}
+
+// forgetProgress resets the rollout backoff of an App once its rollout is over or it is deleted.
+func (r *AppReconciler) forgetProgress(key types.NamespacedName) {
+	r.progressMu.Lock()
+	defer r.progressMu.Unlock()
+	if r.progress != nil {
+		r.progress.Forget(key)
+	}
+}
+
+// newPriorityQueue returns the controller's workqueue. Event handlers already enqueue the
+// initial list and informer resyncs with handler.LowPriority, so only periodic requeues
+// are left to demote: a spec change or a new App is then reconciled ahead of thousands of
+// no-op rechecks.
+func newPriorityQueue(controllerName string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return lowPriorityRequeues{
+		PriorityQueue: priorityqueue.New(controllerName, func(o *priorityqueue.Opts[reconcile.Request]) {
+			o.Log = ctrl.Log.WithValues("controller", controllerName)
+			o.RateLimiter = rateLimiter
+		}),
+		spread: resyncPeriod,
+	}
+}
+
+// lowPriorityRequeues enqueues requests delayed by a Result.RequeueAfter with low priority.
+// An event for the same App raises the priority again.
+//
+// Low priority events (the initial list after a start or leader change, and informer
+// resyncs) are delayed by a random time within spread, so every App isn't reconciled at once.
+type lowPriorityRequeues struct {
+	priorityqueue.PriorityQueue[reconcile.Request]
+	spread time.Duration
+}
+
+// AddWithOpts implements priorityqueue.PriorityQueue. Retries after errors keep their priority.
+func (q lowPriorityRequeues) AddWithOpts(o priorityqueue.AddOpts, items ...reconcile.Request) {
+	switch {
+	case o.After > 0 && !o.RateLimited:
+		o.Priority = min(o.Priority, handler.LowPriority)
+	case o.After == 0 && !o.RateLimited && o.Priority <= handler.LowPriority && q.spread > 0:
+		for _, item := range items {
+			o.After = rand.N(q.spread)
+			q.PriorityQueue.AddWithOpts(o, item)
+		}
+		return
+	}
+	q.PriorityQueue.AddWithOpts(o, items...)
+}
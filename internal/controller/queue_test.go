@@ -0,0 +1,132 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Work queue", func() {
+	It("should reconcile changed Apps ahead of periodic requeues", func() {
+		q := newPriorityQueue("test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		defer q.ShutDown()
+		pq := q.(priorityqueue.PriorityQueue[reconcile.Request])
+		requeued := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "idle"}}
+		changed := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "changed"}}
+
+		pq.AddWithOpts(priorityqueue.AddOpts{After: time.Millisecond}, requeued)
+		Eventually(q.Len).Should(Equal(1))
+		pq.AddWithOpts(priorityqueue.AddOpts{}, changed)
+
+		item, priority, _ := pq.GetWithPriority()
+		Expect(item).To(Equal(changed))
+		Expect(priority).To(BeZero())
+		item, priority, _ = pq.GetWithPriority()
+		Expect(item).To(Equal(requeued))
+		Expect(priority).To(Equal(handler.LowPriority))
+	})
+
+	It("should spread low priority events over the resync period", func() {
+		q := lowPriorityRequeues{PriorityQueue: priorityqueue.New[reconcile.Request]("test"), spread: time.Hour}
+		defer q.ShutDown()
+		listed := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "listed"}}
+
+		q.AddWithOpts(priorityqueue.AddOpts{Priority: handler.LowPriority}, listed)
+		Consistently(q.Len, "50ms").Should(BeZero())
+
+		q.spread = 10 * time.Millisecond
+		q.AddWithOpts(priorityqueue.AddOpts{Priority: handler.LowPriority}, listed)
+		Eventually(q.Len).Should(Equal(1))
+	})
+
+	It("should jitter periodic requeues around the resync period", func() {
+		for range 100 {
+			Expect(resyncAfter()).To(BeNumerically(">=", resyncPeriod/2))
+			Expect(resyncAfter()).To(BeNumerically("<", resyncPeriod*3/2))
+		}
+	})
+
+	It("should only requeue Apps for what no watch announces", func() {
+		r := &AppReconciler{}
+		app := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: "settled", Namespace: "default"}}
+		Expect(r.nextReconcile(app)).To(Equal(reconcile.Result{}))
+		Expect(r.nextReconcile(app, 0, 2*time.Second, time.Second)).To(Equal(reconcile.Result{RequeueAfter: time.Second}))
+
+		// Secrets aren't cached, and target clusters aren't watched.
+		app.Spec.Env = []corev1.EnvVar{{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "token"}, Key: "token"},
+		}}}
+		Expect(r.nextReconcile(app).RequeueAfter).To(BeNumerically(">=", resyncPeriod/2))
+
+		// A rollout in progress is checked with a growing backoff, reset once it is over.
+		app.Spec.Env = nil
+		app.Status.Rollout = &webappv1.RolloutStatus{Phase: webappv1.RolloutPhaseProgressing}
+		Expect(r.nextReconcile(app).RequeueAfter).To(Equal(progressPollMin))
+		Expect(r.nextReconcile(app).RequeueAfter).To(Equal(2 * progressPollMin))
+		app.Status.Rollout.Phase = webappv1.RolloutPhasePaused
+		Expect(r.nextReconcile(app)).To(Equal(reconcile.Result{}))
+		app.Status.Rollout.Phase = webappv1.RolloutPhasePromoting
+		Expect(r.nextReconcile(app).RequeueAfter).To(Equal(progressPollMin))
+	})
+
+	It("should map the pods of an App's Deployments to the App", func() {
+		pod := func(app, replicaSet string) *metav1.PartialObjectMetadata {
+			return &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+				Name: replicaSet + "-x2v9k", Namespace: "default", Labels: map[string]string{"app": app},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: replicaSet, Controller: ptr.To(true)}},
+			}}
+		}
+		web := []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "web", Namespace: "default"}}}
+		Expect(enqueueAppForPod(ctx, pod("web", "web-deployment-7d4b9c"))).To(Equal(web))
+		Expect(enqueueAppForPod(ctx, pod("web", "web-canary-deployment-5f6c8"))).To(Equal(web))
+		Expect(enqueueAppForPod(ctx, pod("web", "web-preview-deployment-5f6c8"))).To(Equal(web))
+		Expect(enqueueAppForPod(ctx, pod("web", "frontend-7d4b9c"))).To(BeEmpty())
+		Expect(enqueueAppForPod(ctx, pod("", "web-deployment-7d4b9c"))).To(BeEmpty())
+	})
+
+	It("should enqueue the Apps whose environment reads a changed ConfigMap", func() {
+		reader := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "default"}, Spec: webappv1.AppSpec{
+			EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "shared"}}}},
+		}}
+		other := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"}}
+		r := &AppReconciler{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithIndex(&webappv1.App{}, envConfigMapIndex, indexEnvConfigMaps).WithObjects(reader, other).Build()}
+
+		shared := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "default"}}
+		Expect(r.enqueueEnvConfigMapApps(ctx, shared)).To(Equal([]reconcile.Request{
+			{NamespacedName: types.NamespacedName{Name: "reader", Namespace: "default"}},
+		}))
+		shared.Namespace = "elsewhere"
+		Expect(r.enqueueEnvConfigMapApps(ctx, shared)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/policy"
+)
+
+// checkReferences verifies that the App only references objects of its own namespace, and
+// objects outside it that the AppPolicies applying to the namespace grant. The webhook
+// checks the same at admission; this also covers Apps admitted while it was down, and Apps
+// whose grant was revoked since.
+func checkReferences(app *webappv1.App, policies []webappv1.AppPolicy) error {
+	if errs := policy.CheckReferences(app, policies); len(errs) > 0 {
+		return fmt.Errorf("invalid references of App %s/%s: %w", app.Namespace, app.Name, errs.ToAggregate())
+	}
+	return nil
+}
+
+// enqueueReferencingApps maps an AppPolicy to the Apps referencing objects outside their
+// namespace, whose references it may grant or stop granting, and to the Apps whose images
+// its provenance requirement may gate or stop gating.
+func (r *AppReconciler) enqueueReferencingApps(ctx context.Context, obj client.Object) []reconcile.Request {
+	appPolicy, _ := obj.(*webappv1.AppPolicy)
+	gatesProvenance := appPolicy != nil && appPolicy.Spec.Provenance != nil
+	apps := &webappv1.AppList{}
+	if err := r.List(ctx, apps); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list Apps")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, app := range apps.Items {
+		if len(policy.CrossNamespaceReferences(&app)) > 0 || gatesProvenance || app.Status.Provenance != nil {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+	return requests
+}
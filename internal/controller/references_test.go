@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Cross-namespace references", func() {
+	gatewayApp := func() *webappv1.App {
+		return &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"},
+			Spec: webappv1.AppSpec{Expose: &webappv1.ExposeSpec{
+				Mode:    webappv1.ExposeModeHTTPRoute,
+				Gateway: &webappv1.GatewayReference{Name: "public", Namespace: "infra"},
+			}},
+		}
+	}
+
+	It("should accept references to objects in the App's namespace", func() {
+		app := &webappv1.App{Spec: webappv1.AppSpec{
+			ServiceAccountName: "web",
+			DependsOn:          []string{"db", "cache"},
+			Config:             &webappv1.ConfigSpec{ConfigMapRef: &corev1.LocalObjectReference{Name: "web-settings"}},
+		}}
+
+		Expect(checkReferences(app, nil)).To(Succeed())
+	})
+
+	It("should reject references leaving the namespace without a grant", func() {
+		Expect(checkReferences(gatewayApp(), nil)).To(MatchError(ContainSubstring("spec.expose.gateway")))
+
+		grant := webappv1.AppPolicy{Spec: webappv1.AppPolicySpec{ReferenceGrants: []webappv1.AppPolicyReferenceGrant{
+			{Kind: webappv1.ReferenceKindGateway, Namespace: "infra", Name: "public"},
+		}}}
+		Expect(checkReferences(gatewayApp(), []webappv1.AppPolicy{grant})).To(Succeed())
+	})
+
+	It("should requeue the Apps referencing objects outside their namespace when AppPolicies change", func() {
+		local := &webappv1.App{ObjectMeta: metav1.ObjectMeta{Name: "batch", Namespace: "team-a"}}
+		r, _ := newTestReconciler(gatewayApp(), local)
+
+		requests := r.enqueueReferencingApps(ctx, &webappv1.AppPolicy{})
+		Expect(requests).To(ConsistOf(reconcile.Request{NamespacedName: types.NamespacedName{Name: "web", Namespace: "team-a"}}))
+	})
+})
@@ -0,0 +1,382 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// conditionTargetClusterReady reports whether an App was delivered to its target cluster.
+	conditionTargetClusterReady = "TargetClusterReady"
+	// remoteCleanupFinalizer holds back the deletion of an App until its objects are removed
+	// from its target cluster, where owner references cannot reach.
+	remoteCleanupFinalizer = "webapp.example.com/remote-cleanup"
+	// defaultKubeconfigKey is the Secret key holding a kubeconfig, as used by Cluster API.
+	defaultKubeconfigKey = "value"
+)
+
+// remoteClientKey identifies the version of a kubeconfig Secret a target cluster client is built from.
+type remoteClientKey struct {
+	secret          types.NamespacedName
+	resourceVersion string
+}
+
+// remoteClient is a client of a target cluster, and the Apps delivered with it.
+type remoteClient struct {
+	apps map[types.NamespacedName]bool
+	client.Client
+}
+
+// kubeconfigSecret returns the Secret and key holding the kubeconfig of the App's target cluster.
+func kubeconfigSecret(app *webappv1.App) (types.NamespacedName, string) {
+	target := app.Spec.TargetCluster
+	if ref := target.KubeconfigSecretRef; ref != nil {
+		key := ref.Key
+		if key == "" {
+			key = defaultKubeconfigKey
+		}
+		return types.NamespacedName{Name: ref.Name, Namespace: app.Namespace}, key
+	}
+	return types.NamespacedName{Name: fmt.Sprintf("%s-kubeconfig", target.ClusterName), Namespace: app.Namespace}, defaultKubeconfigKey
+}
+
+// targetClusterClient returns a client of the App's target cluster. Clients are shared by
+// the Apps using the same version of a kubeconfig Secret, and dropped once none uses it:
+// when the Secret changes, or its Apps are deleted or stop targeting the cluster.
+func (r *AppReconciler) targetClusterClient(ctx context.Context, app *webappv1.App) (client.Client, error) {
+	key, dataKey := kubeconfigSecret(app)
+
+	// The cache only holds the controller's own Secrets.
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, key, secret); err != nil {
+		return nil, fmt.Errorf("getting kubeconfig Secret %q: %w", key.Name, err)
+	}
+
+	appKey := client.ObjectKeyFromObject(app)
+	cacheKey := remoteClientKey{secret: key, resourceVersion: secret.ResourceVersion}
+	r.remoteMu.Lock()
+	defer r.remoteMu.Unlock()
+	r.releaseRemoteClients(appKey, cacheKey)
+	if cached, ok := r.remoteClients[cacheKey]; ok {
+		cached.apps[appKey] = true
+		return cached.Client, nil
+	}
+
+	kubeconfig := secret.Data[dataKey]
+	if len(kubeconfig) == 0 {
+		return nil, fmt.Errorf("kubeconfig Secret %q has no key %q", key.Name, dataKey)
+	}
+	c, err := r.newRemoteClient(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("building client from kubeconfig Secret %q: %w", key.Name, err)
+	}
+	if r.remoteClients == nil {
+		r.remoteClients = map[remoteClientKey]*remoteClient{}
+	}
+	r.remoteClients[cacheKey] = &remoteClient{apps: map[types.NamespacedName]bool{appKey: true}, Client: c}
+	return c, nil
+}
+
+// forgetTargetClusterClient releases the target cluster client of an App that is deleted or
+// no longer has a target cluster.
+func (r *AppReconciler) forgetTargetClusterClient(appKey types.NamespacedName) {
+	r.remoteMu.Lock()
+	defer r.remoteMu.Unlock()
+	r.releaseRemoteClients(appKey, remoteClientKey{})
+}
+
+// releaseRemoteClients removes an App from the target cluster clients other than keep, and
+// drops the clients no App uses anymore. remoteMu must be held.
+func (r *AppReconciler) releaseRemoteClients(appKey types.NamespacedName, keep remoteClientKey) {
+	for cacheKey, cached := range r.remoteClients {
+		if cacheKey == keep || !cached.apps[appKey] {
+			continue
+		}
+		delete(cached.apps, appKey)
+		if len(cached.apps) == 0 {
+			delete(r.remoteClients, cacheKey)
+		}
+	}
+}
+
+// newRemoteClient builds a client from a kubeconfig, with NewRemoteClient when set.
+func (r *AppReconciler) newRemoteClient(kubeconfig []byte) (client.Client, error) {
+	if r.NewRemoteClient != nil {
+		return r.NewRemoteClient(kubeconfig)
+	}
+	if err := checkEmbeddedCredentials(kubeconfig); err != nil {
+		return nil, err
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.New(config, client.Options{Scheme: r.Scheme})
+	if err != nil {
+		return nil, err
+	}
+	return client.WithFieldOwner(c, FieldManager), nil
+}
+
+// checkEmbeddedCredentials refuses kubeconfigs that would make the controller run a program
+// or read its own files: they are written by App owners, not by the controller's admins.
+func checkEmbeddedCredentials(kubeconfig []byte) error {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return err
+	}
+	for name, auth := range config.AuthInfos {
+		switch {
+		case auth.Exec != nil:
+			return fmt.Errorf("user %q uses an exec plugin", name)
+		case auth.AuthProvider != nil:
+			return fmt.Errorf("user %q uses an auth provider", name)
+		case auth.TokenFile != "" || auth.ClientCertificate != "" || auth.ClientKey != "":
+			return fmt.Errorf("user %q references credential files", name)
+		}
+	}
+	for name, cluster := range config.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return fmt.Errorf("cluster %q references a certificate authority file", name)
+		}
+	}
+	return nil
+}
+
+// reconcileRemote delivers an App with spec.targetCluster: its Deployment, Service and
+// configuration ConfigMap are applied to the target cluster, in a namespace of the same
+// name, and the App's status reflects the remote Deployment. Local copies left from
+// before the App had a target are removed.
+func (r *AppReconciler) reconcileRemote(ctx context.Context, original, app *webappv1.App) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	// 1. Hold back the App's deletion until its objects are removed from the target cluster.
+	if err := r.patchFinalizer(ctx, app, remoteCleanupFinalizer, true); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 2. Connect to the target cluster and apply the App's objects.
+	err := r.applyRemote(ctx, app)
+	condition := metav1.Condition{
+		Type:               conditionTargetClusterReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Applied",
+		Message:            "The App's objects are applied to its target cluster",
+		ObservedGeneration: app.Generation,
+	}
+	if err != nil {
+		log.Error(err, "Failed to deliver App to its target cluster")
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ApplyFailed"
+		condition.Message = err.Error()
+	}
+	meta.SetStatusCondition(&app.Status.Conditions, condition)
+	meta.RemoveStatusCondition(&app.Status.Conditions, conditionSecurityWarning)
+	meta.RemoveStatusCondition(&app.Status.Conditions, conditionMeshEnrolled)
+
+	// 3. Remove local objects created before the App had a target cluster.
+	if err == nil {
+		err = r.deleteLocalChildren(ctx, app)
+	}
+
+	// 4. Update the App's status; an error is returned once it is recorded.
+	statusWait, statusErr := r.updateStatus(ctx, original, app)
+	if err := utilerrors.NewAggregate([]error{err, statusErr}); err != nil {
+		return ctrl.Result{}, err
+	}
+	// The target cluster's objects aren't watched, so the App is resynced periodically.
+	return r.nextReconcile(app, statusWait), nil
+}
+
+// applyRemote applies the App's objects to its target cluster and copies the ready replicas
+// and rollout state of the remote Deployment into the App's status.
+func (r *AppReconciler) applyRemote(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	remote, err := r.targetClusterClient(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	image, err := r.admittedImage(ctx, remote, app)
+	if err != nil {
+		return err
+	}
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: app.Namespace}}
+	if err := remote.Get(ctx, client.ObjectKeyFromObject(namespace), namespace); errors.IsNotFound(err) {
+		log.Info("Creating namespace in target cluster", "Namespace", app.Namespace)
+		if err := remote.Create(ctx, namespace); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if err := r.applyRemoteConfigMap(ctx, remote, app); err != nil {
+		return fmt.Errorf("applying ConfigMap: %w", err)
+	}
+
+	desiredService := desiredService(app)
+	service := &corev1.Service{}
+	err = remote.Get(ctx, client.ObjectKeyFromObject(desiredService), service)
+	switch {
+	case errors.IsNotFound(err):
+		log.Info("Creating Service in target cluster", "Service.Namespace", desiredService.Namespace, "Service.Name", desiredService.Name)
+		if err := remote.Create(ctx, desiredService); err != nil {
+			return fmt.Errorf("creating Service: %w", err)
+		}
+	case err != nil:
+		return err
+	default:
+		updated := service.DeepCopy()
+		updated.Labels = mergeMaps(updated.Labels, desiredService.Labels)
+		syncServiceAnnotations(app, updated)
+		updated.Annotations = mergeMaps(updated.Annotations, attributionAnnotations(app))
+		updated.Spec = desiredService.Spec
+		if _, err := r.syncChild(ctx, remote, app, service, updated); err != nil {
+			return fmt.Errorf("updating Service: %w", err)
+		}
+	}
+
+	desiredDeployment := r.desiredDeployment(app, image)
+	deployment := &appsv1.Deployment{}
+	err = remote.Get(ctx, client.ObjectKeyFromObject(desiredDeployment), deployment)
+	switch {
+	case errors.IsNotFound(err) && image == "":
+		log.Info("Not creating Deployment until an image passes the supply-chain policies", "Image", app.Spec.Image)
+		deployment = nil
+	case errors.IsNotFound(err):
+		log.Info("Creating Deployment in target cluster", "Deployment.Namespace", desiredDeployment.Namespace, "Deployment.Name", desiredDeployment.Name)
+		if err := remote.Create(ctx, desiredDeployment); err != nil {
+			return fmt.Errorf("creating Deployment: %w", err)
+		}
+		deployment = desiredDeployment
+	case err != nil:
+		return err
+	default:
+		updated := desiredDeploymentUpdate(deployment, desiredDeployment)
+		updated.Annotations = mergeMaps(updated.Annotations, desiredDeployment.Annotations)
+		changed, err := r.syncChild(ctx, remote, app, deployment, updated)
+		if err != nil {
+			return fmt.Errorf("updating Deployment: %w", err)
+		}
+		if changed {
+			deployment = updated
+		}
+	}
+	if deployment != nil {
+		app.Status.Replicas = deployment.Status.ReadyReplicas
+	}
+	app.Status.ObservedGeneration = app.Generation
+	setRolloutConditions(app, deployment)
+	return nil
+}
+
+// applyRemoteConfigMap applies the App's configuration ConfigMap to its target cluster, or
+// removes it when spec.config and spec.configFrom are unset.
+func (r *AppReconciler) applyRemoteConfigMap(ctx context.Context, remote client.Client, app *webappv1.App) error {
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName(app), Namespace: app.Namespace}}
+	if !hasConfig(app) {
+		app.Status.ConfigFrom = nil
+		return client.IgnoreNotFound(remote.Delete(ctx, configMap))
+	}
+	data, err := r.renderConfigData(ctx, app)
+	if err != nil {
+		return err
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, remote, configMap, func() error {
+		configMap.Labels = mergeMaps(configMap.Labels, map[string]string{"app": app.Name, "controller": "app-controller"})
+		configMap.Annotations = mergeMaps(configMap.Annotations, attributionAnnotations(app))
+		configMap.Data = data
+		return nil
+	})
+	return err
+}
+
+// deleteLocalChildren deletes the Deployments, Services, ConfigMap, HorizontalPodAutoscaler,
+// Ingress and HTTPRoute the App controls in its own cluster.
+func (r *AppReconciler) deleteLocalChildren(ctx context.Context, app *webappv1.App) error {
+	err := r.deleteChildren(ctx, app, append([]client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-deployment", app.Name)}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-service", app.Name)}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName(app)}},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: ingressName(app)}},
+		&autoscalingv2.HorizontalPodAutoscaler{ObjectMeta: metav1.ObjectMeta{Name: hpaName(app)}},
+	}, rolloutChildren(app)...)...)
+	if err != nil {
+		return err
+	}
+	return r.reconcileUnstructured(ctx, app, httpRouteGVK, app.Name, nil)
+}
+
+// deleteChildren deletes the given objects of the App's namespace, by name, if the App controls them.
+func (r *AppReconciler) deleteChildren(ctx context.Context, app *webappv1.App, objs ...client.Object) error {
+	for _, obj := range objs {
+		err := r.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: app.Namespace}, obj)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(obj, app) {
+			continue
+		}
+		log.FromContext(ctx).Info("Deleting object no longer used by App", "Name", obj.GetName())
+		if err := r.deleteChild(ctx, app, obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// finalizeRemote removes a deleted App's objects from its target cluster, then releases the App.
+// An App whose kubeconfig Secret is gone is released as is: its target cannot be reached anymore.
+func (r *AppReconciler) finalizeRemote(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(app, remoteCleanupFinalizer) {
+		return nil
+	}
+	remote, err := r.targetClusterClient(ctx, app)
+	if errors.IsNotFound(err) {
+		log.Info("Kubeconfig Secret is gone, leaving objects in target cluster", "Error", err.Error())
+		r.forgetTargetClusterClient(client.ObjectKeyFromObject(app))
+		return r.patchFinalizer(ctx, app, remoteCleanupFinalizer, false)
+	} else if err != nil {
+		return err
+	}
+	for _, obj := range []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-deployment", app.Name), Namespace: app.Namespace}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-service", app.Name), Namespace: app.Namespace}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: configMapName(app), Namespace: app.Namespace}},
+	} {
+		if err := client.IgnoreNotFound(remote.Delete(ctx, obj)); err != nil {
+			return err
+		}
+	}
+	log.Info("Deleted App's objects from its target cluster")
+	r.forgetTargetClusterClient(client.ObjectKeyFromObject(app))
+	return r.patchFinalizer(ctx, app, remoteCleanupFinalizer, false)
+}
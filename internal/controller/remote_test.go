@@ -0,0 +1,155 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Target clusters", func() {
+	It("should deliver the App to its target cluster and clean it up on deletion", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Image:         "web:1.0",
+			Replicas:      2,
+			Port:          8080,
+			TargetCluster: &webappv1.TargetCluster{ClusterName: "spoke"},
+		})
+		kubeconfig := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "spoke-kubeconfig", Namespace: "default"},
+			Data:       map[string][]byte{"value": []byte("kubeconfig")},
+		}
+		local := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web-deployment", Namespace: "default"}}
+		Expect(controllerutil.SetControllerReference(app, local, scheme.Scheme)).To(Succeed())
+		r, hub := newTestReconciler(app, kubeconfig, local)
+		spoke := newTestClient()
+		r.NewRemoteClient = func(data []byte) (client.Client, error) {
+			Expect(string(data)).To(Equal("kubeconfig"))
+			return spoke, nil
+		}
+		req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(spoke.Get(ctx, types.NamespacedName{Name: "default"}, &corev1.Namespace{})).To(Succeed())
+		remote := &appsv1.Deployment{}
+		Expect(spoke.Get(ctx, client.ObjectKeyFromObject(local), remote)).To(Succeed())
+		Expect(remote.OwnerReferences).To(BeEmpty())
+		Expect(remote.Spec.Template.Spec.Containers[0].Image).To(Equal("web:1.0"))
+		Expect(spoke.Get(ctx, types.NamespacedName{Name: "web-service", Namespace: "default"}, &corev1.Service{})).To(Succeed())
+		Expect(errors.IsNotFound(hub.Get(ctx, client.ObjectKeyFromObject(local), &appsv1.Deployment{}))).To(BeTrue())
+
+		Expect(hub.Get(ctx, req.NamespacedName, app)).To(Succeed())
+		Expect(app.Finalizers).To(ContainElement(remoteCleanupFinalizer))
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, conditionTargetClusterReady)).To(BeTrue())
+
+		remote.Status.ReadyReplicas = 2
+		Expect(spoke.Status().Update(ctx, remote)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hub.Get(ctx, req.NamespacedName, app)).To(Succeed())
+		Expect(app.Status.Replicas).To(Equal(int32(2)))
+
+		Expect(hub.Delete(ctx, app)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(errors.IsNotFound(spoke.Get(ctx, client.ObjectKeyFromObject(remote), &appsv1.Deployment{}))).To(BeTrue())
+		Expect(errors.IsNotFound(hub.Get(ctx, req.NamespacedName, app))).To(BeTrue())
+		Expect(r.remoteClients).To(BeEmpty())
+	})
+
+	It("should drop target cluster clients once no App uses them", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Image:         "web:1.0",
+			Port:          8080,
+			TargetCluster: &webappv1.TargetCluster{ClusterName: "spoke"},
+		})
+		kubeconfig := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "spoke-kubeconfig", Namespace: "default"},
+			Data:       map[string][]byte{"value": []byte("kubeconfig")},
+		}
+		r, hub := newTestReconciler(app, kubeconfig)
+		built := 0
+		r.NewRemoteClient = func([]byte) (client.Client, error) {
+			built++
+			return newTestClient(), nil
+		}
+		req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+		By("reusing the client while the kubeconfig Secret is unchanged")
+		for range 2 {
+			_, err := r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(built).To(Equal(1))
+		Expect(r.remoteClients).To(HaveLen(1))
+
+		By("replacing the client when the kubeconfig Secret changes")
+		kubeconfig.Data["value"] = []byte("rotated")
+		Expect(hub.Update(ctx, kubeconfig)).To(Succeed())
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(built).To(Equal(2))
+		Expect(r.remoteClients).To(HaveLen(1))
+		for cacheKey := range r.remoteClients {
+			Expect(cacheKey.resourceVersion).To(Equal(kubeconfig.ResourceVersion))
+		}
+
+		By("dropping the client when the App stops targeting the cluster")
+		Expect(hub.Get(ctx, req.NamespacedName, app)).To(Succeed())
+		app.Spec.TargetCluster = nil
+		Expect(hub.Update(ctx, app)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.remoteClients).To(BeEmpty())
+	})
+
+	It("should refuse kubeconfigs running programs or reading files of the controller", func() {
+		kubeconfig := func(user string) []byte {
+			return []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: spoke
+  cluster:
+    server: https://spoke.example.com
+contexts:
+- name: spoke
+  context: {cluster: spoke, user: spoke}
+current-context: spoke
+users:
+- name: spoke
+  user: ` + user + "\n")
+		}
+		Expect(checkEmbeddedCredentials(kubeconfig("{token: secret}"))).To(Succeed())
+		Expect(checkEmbeddedCredentials(kubeconfig("{exec: {apiVersion: client.authentication.k8s.io/v1, command: sh}}"))).NotTo(Succeed())
+		Expect(checkEmbeddedCredentials(kubeconfig("{tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token}"))).NotTo(Succeed())
+	})
+})
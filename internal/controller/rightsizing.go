@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// podMetricsPageSize is the number of pod metrics read per request.
+	podMetricsPageSize = 500
+	// usageSampleInterval is the minimum time between two usage samples of an App.
+	usageSampleInterval = time.Minute
+	// minUsageSamples is the number of samples needed before resources are recommended.
+	minUsageSamples = 10
+	// defaultRightSizingWindow is used for Apps stored before the API server defaulted spec.rightSizing.window.
+	defaultRightSizingWindow = 24 * time.Hour
+	// recommendationMargin is the headroom added to the observed usage.
+	recommendationMargin = 1.15
+	// memoryLimitFactor is the memory limit recommended relative to peak usage.
+	memoryLimitFactor = 1.5
+	// recommendationTolerance is the relative change below which a recommendation is kept,
+	// so auto-applied recommendations don't roll the pods out for noise.
+	recommendationTolerance = 0.1
+)
+
+// podMetricsListGVK identifies the pod metrics served by metrics-server. They are read
+// without the cache: the metrics API can't be watched.
+var podMetricsListGVK = schema.GroupVersionKind{
+	Group:   "metrics.k8s.io",
+	Version: "v1beta1",
+	Kind:    "PodMetricsList",
+}
+
+// usageSample is the highest CPU and memory usage among an App's pods at one point in time.
+type usageSample struct {
+	at          time.Time
+	milliCPU    int64
+	memoryBytes int64
+}
+
+// recommendResources samples the usage of the App's pods, at most once per
+// usageSampleInterval, and recommends requests and limits from the samples of its window in
+// the App's status. Samples are kept in memory, so recommendations start over when the
+// controller restarts.
+func (r *AppReconciler) recommendResources(ctx context.Context, app *webappv1.App) error {
+	key := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+	if app.Spec.RightSizing == nil {
+		app.Status.Recommendations = nil
+		r.forgetUsage(key)
+		return nil
+	}
+	window := defaultRightSizingWindow
+	if app.Spec.RightSizing.Window != nil {
+		window = app.Spec.RightSizing.Window.Duration
+	}
+
+	r.usageMu.Lock()
+	samples := r.usage[key]
+	r.usageMu.Unlock()
+	if len(samples) > 0 && time.Since(samples[len(samples)-1].at) < usageSampleInterval {
+		return nil
+	}
+
+	sample, ok, err := r.sampleUsage(ctx, app)
+	if meta.IsNoMatchError(err) {
+		app.Status.Recommendations = &webappv1.ResourceRecommendations{Message: "The metrics API is not available; install metrics-server"}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if ok {
+		samples = append(samples, sample)
+	}
+	samples = slices.DeleteFunc(samples, func(s usageSample) bool { return time.Since(s.at) > window })
+	r.usageMu.Lock()
+	if r.usage == nil {
+		r.usage = map[types.NamespacedName][]usageSample{}
+	}
+	r.usage[key] = samples
+	r.usageMu.Unlock()
+
+	app.Status.Recommendations = recommendation(app.Status.Recommendations, samples)
+	return nil
+}
+
+// usageSampleWait returns how long until the usage of the App's pods is due to be sampled
+// again, zero for Apps without spec.rightSizing.
+func (r *AppReconciler) usageSampleWait(app *webappv1.App) time.Duration {
+	if app.Spec.RightSizing == nil {
+		return 0
+	}
+	r.usageMu.Lock()
+	samples := r.usage[types.NamespacedName{Name: app.Name, Namespace: app.Namespace}]
+	r.usageMu.Unlock()
+	if len(samples) == 0 {
+		return usageSampleInterval
+	}
+	return max(time.Until(samples[len(samples)-1].at.Add(usageSampleInterval)), time.Second)
+}
+
+// sampleUsage returns the highest usage of the app container among the App's pods, and
+// whether any pod reported metrics. Pod metrics carry the labels of their pod, so the pods
+// of the App's ReplicaSets are selected by their pod-template-hash: other workloads may
+// share the app label.
+func (r *AppReconciler) sampleUsage(ctx context.Context, app *webappv1.App) (usageSample, bool, error) {
+	sample := usageSample{at: time.Now()}
+	replicaSets, err := r.appReplicaSets(ctx, app)
+	if err != nil || len(replicaSets) == 0 {
+		return sample, false, err
+	}
+	var hashes []string
+	for _, replicaSet := range replicaSets {
+		if hash := replicaSet.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" {
+			hashes = append(hashes, hash)
+		}
+	}
+	if len(hashes) == 0 {
+		return sample, false, nil
+	}
+	hashRequirement, err := labels.NewRequirement(appsv1.DefaultDeploymentUniqueLabelKey, selection.In, hashes)
+	if err != nil {
+		return sample, false, err
+	}
+	selector := labels.SelectorFromSet(labels.Set{"app": app.Name}).Add(*hashRequirement)
+
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	found := false
+	for continueToken := ""; ; {
+		podMetrics := &unstructured.UnstructuredList{}
+		podMetrics.SetGroupVersionKind(podMetricsListGVK)
+		if err := reader.List(ctx, podMetrics, client.InNamespace(app.Namespace), client.MatchingLabelsSelector{Selector: selector},
+			client.Limit(podMetricsPageSize), client.Continue(continueToken)); err != nil {
+			return usageSample{}, false, err
+		}
+		for _, pod := range podMetrics.Items {
+			if sampleContainerUsage(app, pod, &sample) {
+				found = true
+			}
+		}
+		if continueToken = podMetrics.GetContinue(); continueToken == "" {
+			return sample, found, nil
+		}
+	}
+}
+
+// sampleContainerUsage raises the sample to the usage of the app container in a pod's metrics,
+// and reports whether they had it.
+func sampleContainerUsage(app *webappv1.App, pod unstructured.Unstructured, sample *usageSample) bool {
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok || container["name"] != containerName(app) {
+			continue
+		}
+		usage, _, _ := unstructured.NestedStringMap(container, "usage")
+		cpu, err := resource.ParseQuantity(usage["cpu"])
+		if err != nil {
+			continue
+		}
+		memory, err := resource.ParseQuantity(usage["memory"])
+		if err != nil {
+			continue
+		}
+		sample.milliCPU = max(sample.milliCPU, cpu.MilliValue())
+		sample.memoryBytes = max(sample.memoryBytes, memory.Value())
+		return true
+	}
+	return false
+}
+
+// recommendation derives requests and limits from usage samples. The previous
+// recommendation is kept while the new one is within recommendationTolerance of it.
+func recommendation(previous *webappv1.ResourceRecommendations, samples []usageSample) *webappv1.ResourceRecommendations {
+	if len(samples) < minUsageSamples {
+		return &webappv1.ResourceRecommendations{
+			Samples: int32(len(samples)),
+			Message: "Collecting usage samples",
+		}
+	}
+
+	cpu := make([]int64, 0, len(samples))
+	var peakMemory int64
+	for _, s := range samples {
+		cpu = append(cpu, s.milliCPU)
+		peakMemory = max(peakMemory, s.memoryBytes)
+	}
+	slices.Sort(cpu)
+	p90 := cpu[int(math.Ceil(0.9*float64(len(cpu))))-1]
+
+	const mebibyte = 1 << 20
+	milliCPU := max(int64(math.Ceil(float64(p90)*recommendationMargin)), 1)
+	memory := int64(math.Ceil(float64(peakMemory)*recommendationMargin/mebibyte)) * mebibyte
+	memoryLimit := int64(math.Ceil(float64(peakMemory)*memoryLimitFactor/mebibyte)) * mebibyte
+
+	if previous != nil && previous.Requests != nil &&
+		withinTolerance(previous.Requests.Cpu().MilliValue(), milliCPU) &&
+		withinTolerance(previous.Requests.Memory().Value(), memory) {
+		kept := previous.DeepCopy()
+		kept.Samples = int32(len(samples))
+		return kept
+	}
+	return &webappv1.ResourceRecommendations{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    *resource.NewMilliQuantity(milliCPU, resource.DecimalSI),
+			corev1.ResourceMemory: *resource.NewQuantity(memory, resource.BinarySI),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: *resource.NewQuantity(memoryLimit, resource.BinarySI),
+		},
+		Samples:   int32(len(samples)),
+		UpdatedAt: metav1.Now(),
+	}
+}
+
+// withinTolerance reports whether current differs from previous by at most recommendationTolerance.
+func withinTolerance(previous, current int64) bool {
+	return math.Abs(float64(current-previous)) <= recommendationTolerance*float64(previous)
+}
+
+// forgetUsage drops the usage samples of an App.
+func (r *AppReconciler) forgetUsage(key types.NamespacedName) {
+	r.usageMu.Lock()
+	defer r.usageMu.Unlock()
+	delete(r.usage, key)
+}
+
+// containerResources returns the resources of the app container: spec.resources, its
+// accelerators, and the recommended CPU and memory when the App auto-applies recommendations.
+func containerResources(app *webappv1.App) corev1.ResourceRequirements {
+	var resources corev1.ResourceRequirements
+	if app.Spec.Resources != nil {
+		resources = *app.Spec.Resources.DeepCopy()
+	}
+	accelerators := acceleratorResources(app)
+	resources.Requests = mergeResources(resources.Requests, accelerators.Requests)
+	resources.Limits = mergeResources(resources.Limits, accelerators.Limits)
+
+	recommended := app.Status.Recommendations
+	if app.Spec.RightSizing == nil || !app.Spec.RightSizing.AutoApply || recommended == nil || recommended.Requests == nil {
+		return resources
+	}
+	resources.Requests = mergeResources(resources.Requests, recommended.Requests)
+	resources.Limits = mergeResources(resources.Limits, recommended.Limits)
+	return resources
+}
+
+// mergeResources sets the quantities of over on a copy of base. It returns nil if both are empty.
+func mergeResources(base, over corev1.ResourceList) corev1.ResourceList {
+	if len(base) == 0 && len(over) == 0 {
+		return nil
+	}
+	merged := base.DeepCopy()
+	if merged == nil {
+		merged = corev1.ResourceList{}
+	}
+	for name, quantity := range over {
+		merged[name] = quantity
+	}
+	return merged
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Resource recommendations", func() {
+	podMetrics := func(name, hash, cpu, memory string) *unstructured.Unstructured {
+		metrics := &unstructured.Unstructured{Object: map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": appContainerName, "usage": map[string]interface{}{"cpu": cpu, "memory": memory}},
+				map[string]interface{}{"name": "istio-proxy", "usage": map[string]interface{}{"cpu": "900m", "memory": "4Gi"}},
+			},
+		}}
+		metrics.SetGroupVersionKind(schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetrics"})
+		metrics.SetName(name)
+		metrics.SetNamespace("default")
+		metrics.SetLabels(map[string]string{"app": "web", appsv1.DefaultDeploymentUniqueLabelKey: hash})
+		return metrics
+	}
+	samples := func(n int, milliCPU, memoryBytes int64) []usageSample {
+		var out []usageSample
+		for i := range n {
+			out = append(out, usageSample{at: time.Now(), milliCPU: milliCPU + int64(i), memoryBytes: memoryBytes})
+		}
+		return out
+	}
+
+	It("should sample the highest usage of the app container among the App's pods", func() {
+		app := newTestApp(webappv1.AppSpec{RightSizing: &webappv1.RightSizingSpec{}})
+		stable, stableReplicaSet := newTestReplicaSet(app, "web-deployment", "abc")
+		canary, canaryReplicaSet := newTestReplicaSet(app, "web-canary-deployment", "def")
+		r, _ := newTestReconciler(stable, stableReplicaSet, canary, canaryReplicaSet,
+			podMetrics("web-deployment-abc-1", "abc", "120m", "200Mi"),
+			podMetrics("web-canary-deployment-def-1", "def", "80m", "300Mi"),
+			// A pod of another workload sharing the app label.
+			podMetrics("web-migrate-1", "xyz", "4", "8Gi"))
+
+		Expect(r.recommendResources(ctx, app)).To(Succeed())
+		sampled := r.usage[types.NamespacedName{Name: "web", Namespace: "default"}]
+		Expect(sampled).To(HaveLen(1))
+		Expect(sampled[0].milliCPU).To(Equal(int64(120)))
+		Expect(sampled[0].memoryBytes).To(Equal(int64(300 << 20)))
+		Expect(app.Status.Recommendations.Samples).To(Equal(int32(1)))
+		Expect(app.Status.Recommendations.Requests).To(BeNil())
+
+		By("not sampling again within the sample interval")
+		Expect(r.recommendResources(ctx, app)).To(Succeed())
+		Expect(r.usage[types.NamespacedName{Name: "web", Namespace: "default"}]).To(HaveLen(1))
+	})
+
+	It("should recommend the 90th percentile of CPU and the peak memory with a margin", func() {
+		recommended := recommendation(nil, samples(10, 100, 400<<20))
+
+		Expect(recommended.Requests.Cpu().MilliValue()).To(Equal(int64(125)))     // 108m * 1.15
+		Expect(recommended.Requests.Memory().Value()).To(Equal(int64(460 << 20))) // 400Mi * 1.15
+		Expect(recommended.Limits.Memory().Value()).To(Equal(int64(600 << 20)))   // 400Mi * 1.5
+		Expect(recommendation(nil, samples(3, 100, 400<<20)).Requests).To(BeNil())
+	})
+
+	It("should keep a recommendation while usage stays close to it", func() {
+		previous := recommendation(nil, samples(10, 100, 400<<20))
+
+		Expect(recommendation(previous, samples(20, 100, 410<<20)).Requests).To(Equal(previous.Requests))
+		Expect(recommendation(previous, samples(20, 200, 400<<20)).Requests.Cpu().MilliValue()).To(Equal(int64(250)))
+	})
+
+	It("should only set recommended resources on the container when auto-applied", func() {
+		app := &webappv1.App{Spec: webappv1.AppSpec{
+			RightSizing: &webappv1.RightSizingSpec{},
+			Accelerators: &webappv1.AcceleratorSpec{
+				Resources: map[corev1.ResourceName]resource.Quantity{"nvidia.com/gpu": resource.MustParse("1")},
+			},
+		}}
+		app.Status.Recommendations = recommendation(nil, samples(10, 100, 400<<20))
+		Expect(containerResources(app).Requests).NotTo(HaveKey(corev1.ResourceCPU))
+
+		app.Spec.RightSizing.AutoApply = true
+		resources := containerResources(app)
+		Expect(resources.Requests).To(HaveKey(corev1.ResourceCPU))
+		Expect(resources.Requests).To(HaveKey(corev1.ResourceName("nvidia.com/gpu")))
+		Expect(resources.Limits.Memory().Value()).To(Equal(int64(600 << 20)))
+	})
+})
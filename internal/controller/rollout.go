@@ -0,0 +1,464 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// trackLabel tells the pods of an App's stable Deployment apart from those of its canary or
+	// preview Deployment. All of them keep the App's app label, so policies selecting it apply.
+	trackLabel = "webapp.example.com/track"
+	// trackStable, trackCanary and trackPreview are the values of trackLabel.
+	trackStable  = "stable"
+	trackCanary  = "canary"
+	trackPreview = "preview"
+)
+
+const (
+	// conditionRolloutPaused is True while a canary or blue/green rollout waits to be promoted.
+	conditionRolloutPaused = "RolloutPaused"
+	// conditionRolloutAborted is True once a canary or blue/green rollout was aborted.
+	conditionRolloutAborted = "RolloutAborted"
+)
+
+// rolloutStrategy returns the strategy the App rolls out new images with.
+func rolloutStrategy(app *webappv1.App) webappv1.StrategyType {
+	if app.Spec.Strategy == nil || app.Spec.Strategy.Type == "" || isKnative(app) || app.Spec.TargetCluster != nil {
+		return webappv1.RollingUpdateStrategy
+	}
+	return app.Spec.Strategy.Type
+}
+
+// rolloutTrack returns the track of the second Deployment of the App's strategy, "" when it
+// has none.
+func rolloutTrack(app *webappv1.App) string {
+	switch rolloutStrategy(app) {
+	case webappv1.CanaryStrategy:
+		return trackCanary
+	case webappv1.BlueGreenStrategy:
+		return trackPreview
+	}
+	return ""
+}
+
+// rolloutChildren returns the canary and preview Deployments and Services an App may own,
+// without namespace.
+func rolloutChildren(app *webappv1.App) []client.Object {
+	var objs []client.Object
+	for _, track := range []string{trackCanary, trackPreview} {
+		objs = append(objs,
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s-deployment", app.Name, track)}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s-service", app.Name, track)}},
+		)
+	}
+	return objs
+}
+
+// appReplicaSets returns the ReplicaSets controlled by the App's stable, canary and preview
+// Deployments, matched by UID rather than by label, which other workloads may share. They are
+// read without the cache, which doesn't hold ReplicaSets.
+func (r *AppReconciler) appReplicaSets(ctx context.Context, app *webappv1.App) ([]appsv1.ReplicaSet, error) {
+	deployments := map[types.UID]bool{}
+	for _, name := range []string{fmt.Sprintf("%s-deployment", app.Name), fmt.Sprintf("%s-%s-deployment", app.Name, trackCanary), fmt.Sprintf("%s-%s-deployment", app.Name, trackPreview)} {
+		deployment := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, deployment)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if metav1.IsControlledBy(deployment, app) {
+			deployments[deployment.UID] = true
+		}
+	}
+	if len(deployments) == 0 {
+		return nil, nil
+	}
+
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	replicaSets := &appsv1.ReplicaSetList{}
+	if err := reader.List(ctx, replicaSets, client.InNamespace(app.Namespace), client.MatchingLabels{"app": app.Name}); err != nil {
+		return nil, err
+	}
+	var owned []appsv1.ReplicaSet
+	for _, replicaSet := range replicaSets.Items {
+		if owner := metav1.GetControllerOf(&replicaSet); owner != nil && deployments[owner.UID] {
+			owned = append(owned, replicaSet)
+		}
+	}
+	return owned, nil
+}
+
+// stableTrackLabels returns the labels of the pods of the App's stable Deployment. Only
+// blue/green Apps label them, for their Service to select either Deployment.
+func stableTrackLabels(app *webappv1.App) map[string]string {
+	if rolloutStrategy(app) != webappv1.BlueGreenStrategy {
+		return nil
+	}
+	return map[string]string{trackLabel: trackStable}
+}
+
+// serviceSelector returns the selector of the App's Service. It selects all the App's pods,
+// so a canary takes requests in proportion to its replicas, except during a blue/green
+// rollout, where it selects the stable pods until the preview is promoted.
+func serviceSelector(app *webappv1.App) map[string]string {
+	selector := map[string]string{"app": app.Name}
+	if rollout := app.Status.Rollout; rollout != nil && rolloutStrategy(app) == webappv1.BlueGreenStrategy {
+		selector[trackLabel] = trackStable
+		if rollout.Phase == webappv1.RolloutPhasePromoting {
+			selector[trackLabel] = trackPreview
+		}
+	}
+	return selector
+}
+
+// stableReplicas returns the number of pods of the App's stable Deployment: the App's
+// replicas, less those a canary step moved to the new image. The replicas of autoscaled Apps
+// are left to their HorizontalPodAutoscaler.
+func stableReplicas(app *webappv1.App) int32 {
+	replicas := specReplicas(app)
+	rollout := app.Status.Rollout
+	if rollout == nil || rolloutStrategy(app) != webappv1.CanaryStrategy || autoscaled(app) ||
+		rollout.Phase == webappv1.RolloutPhasePromoting || rollout.Phase == webappv1.RolloutPhaseAborted {
+		return replicas
+	}
+	return max(replicas-rollout.Replicas, 0)
+}
+
+// canarySteps returns the steps of the App's canary rollout. Without steps, a single pod
+// runs the new image until the rollout is promoted.
+func canarySteps(app *webappv1.App) []webappv1.CanaryStep {
+	if canary := app.Spec.Strategy.Canary; canary != nil && len(canary.Steps) > 0 {
+		return canary.Steps
+	}
+	return []webappv1.CanaryStep{{}}
+}
+
+// canaryReplicas returns the number of pods running the new image at the given weight out of
+// total: at least one, and no more than total.
+func canaryReplicas(total, weight int32) int32 {
+	replicas := (total*weight + 99) / 100
+	return min(max(replicas, 1), max(total, 1))
+}
+
+// reconcileRollout moves the canary or blue/green rollout of the App's admitted image along,
+// recording it in status.rollout, and returns the image the stable Deployment runs: the new
+// image once promoted, the one it runs until then. It acts on the webapp.example.com/rollout
+// annotation, and returns how long until a canary step's pause is over.
+func (r *AppReconciler) reconcileRollout(ctx context.Context, app *webappv1.App, image string) (string, time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	if rolloutTrack(app) == "" || image == "" {
+		app.Status.Rollout = nil
+		return image, 0, nil
+	}
+	stable := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-deployment", app.Name), Namespace: app.Namespace}, stable)
+	if errors.IsNotFound(err) {
+		// The first image has nothing to be compared with.
+		app.Status.Rollout = nil
+		return image, 0, nil
+	} else if err != nil {
+		return "", 0, err
+	}
+	stableImage := appContainerImage(stable)
+
+	rollout := app.Status.Rollout
+	if rollout != nil && rollout.Phase == webappv1.RolloutPhasePromoting && rollout.Image == image {
+		// The second Deployment keeps serving until the stable one runs the new image.
+		if _, _, progressing, _ := rolloutState(stable); stableImage == image && !progressing {
+			log.Info("Promoted new image", "Image", image)
+			app.Status.Rollout = nil
+		}
+		return image, 0, nil
+	}
+	if image == stableImage {
+		app.Status.Rollout = nil
+		return image, 0, nil
+	}
+	if rollout == nil || rollout.Image != image {
+		if rolloutStrategy(app) == webappv1.BlueGreenStrategy && !stableLabeled(stable) {
+			// The Service only selects the stable pods once they all carry their track.
+			log.Info("Waiting for the stable pods to be labeled before starting the rollout", "Image", image)
+			app.Status.Rollout = nil
+			return stableImage, 0, nil
+		}
+		log.Info("Starting rollout of new image", "Strategy", rolloutStrategy(app), "Image", image, "StableImage", stableImage)
+		rollout = &webappv1.RolloutStatus{Image: image, StableImage: stableImage, Phase: webappv1.RolloutPhaseProgressing}
+	}
+	app.Status.Rollout = rollout
+	if rollout.Phase == webappv1.RolloutPhaseAborted {
+		return stableImage, 0, nil
+	}
+
+	action := app.Annotations[webappv1.RolloutAnnotation]
+	if action == webappv1.RolloutAbort {
+		if err := r.removeRolloutAnnotation(ctx, app); err != nil {
+			return "", 0, err
+		}
+		log.Info("Aborting rollout of new image", "Image", image)
+		rollout.Phase = webappv1.RolloutPhaseAborted
+		rollout.Replicas = 0
+		rollout.StepStartedAt = nil
+		rollout.Message = fmt.Sprintf("The rollout was aborted; %s keeps running until spec.image changes", stableImage)
+		return stableImage, 0, nil
+	}
+	promote := action == webappv1.RolloutPromote
+
+	total := specReplicas(app)
+	if autoscaled(app) && stable.Spec.Replicas != nil {
+		total = *stable.Spec.Replicas
+	}
+	ready, err := r.rolloutReady(ctx, app, rollout)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if rolloutStrategy(app) == webappv1.BlueGreenStrategy {
+		rollout.Replicas = total
+		switch {
+		case promote && ready:
+			// Promoting before the preview is available would leave the Service without pods;
+			// the annotation is acted upon once it is.
+			if err := r.removeRolloutAnnotation(ctx, app); err != nil {
+				return "", 0, err
+			}
+			return startPromotion(ctx, app, image)
+		case ready:
+			rollout.Phase = webappv1.RolloutPhasePaused
+			rollout.Message = fmt.Sprintf("The preview is ready; annotate the App with %s=%s to switch to it", webappv1.RolloutAnnotation, webappv1.RolloutPromote)
+		default:
+			rollout.Phase = webappv1.RolloutPhaseProgressing
+			rollout.Message = fmt.Sprintf("Waiting for %d preview pods to be available", total)
+		}
+		return stableImage, 0, nil
+	}
+
+	steps := canarySteps(app)
+	rollout.Step = min(rollout.Step, int32(len(steps)-1))
+	var wait time.Duration
+	if step := steps[rollout.Step]; !promote && ready && step.Pause != nil {
+		if rollout.StepStartedAt == nil {
+			rollout.StepStartedAt = ptr.To(metav1.Now())
+		}
+		wait = time.Until(rollout.StepStartedAt.Add(step.Pause.Duration))
+		promote = wait <= 0
+	}
+	if promote {
+		if action == webappv1.RolloutPromote {
+			if err := r.removeRolloutAnnotation(ctx, app); err != nil {
+				return "", 0, err
+			}
+		}
+		// The weights only grow: the next step starts from the current canary pods.
+		ready = false
+		wait = 0
+		rollout.Step++
+		rollout.StepStartedAt = nil
+		if int(rollout.Step) == len(steps) {
+			return startPromotion(ctx, app, image)
+		}
+		log.Info("Starting canary step", "Step", rollout.Step, "Weight", steps[rollout.Step].Weight)
+	}
+	step := steps[rollout.Step]
+	rollout.Weight = step.Weight
+	rollout.Replicas = canaryReplicas(total, step.Weight)
+	switch {
+	case !ready:
+		rollout.Phase = webappv1.RolloutPhaseProgressing
+		rollout.Message = fmt.Sprintf("Step %d of %d: waiting for %d canary pods to be available", rollout.Step+1, len(steps), rollout.Replicas)
+	case step.Pause != nil:
+		rollout.Phase = webappv1.RolloutPhaseProgressing
+		rollout.Message = fmt.Sprintf("Step %d of %d: %d canary pods available, next step at %s", rollout.Step+1, len(steps), rollout.Replicas, rollout.StepStartedAt.Add(step.Pause.Duration).UTC().Format(time.RFC3339))
+	default:
+		rollout.Phase = webappv1.RolloutPhasePaused
+		rollout.Message = fmt.Sprintf("Step %d of %d: %d canary pods available; annotate the App with %s=%s to continue", rollout.Step+1, len(steps), rollout.Replicas, webappv1.RolloutAnnotation, webappv1.RolloutPromote)
+	}
+	return stableImage, wait, nil
+}
+
+// startPromotion hands the App's new image over to its stable Deployment.
+func startPromotion(ctx context.Context, app *webappv1.App, image string) (string, time.Duration, error) {
+	log.FromContext(ctx).Info("Promoting new image to the stable Deployment", "Image", image)
+	rollout := app.Status.Rollout
+	rollout.Phase = webappv1.RolloutPhasePromoting
+	rollout.StepStartedAt = nil
+	rollout.Message = fmt.Sprintf("Rolling out %s to the stable Deployment", image)
+	return image, 0, nil
+}
+
+// rolloutReady reports whether the second Deployment of the App's rollout runs all its pods,
+// as many as the rollout asks for, with the new image.
+func (r *AppReconciler) rolloutReady(ctx context.Context, app *webappv1.App, rollout *webappv1.RolloutStatus) (bool, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s-deployment", app.Name, rolloutTrack(app)), Namespace: app.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if appContainerImage(deployment) != rollout.Image || ptr.Deref(deployment.Spec.Replicas, 1) != rollout.Replicas {
+		return false, nil
+	}
+	_, _, progressing, stalled := rolloutState(deployment)
+	return !progressing && !stalled, nil
+}
+
+// stableLabeled reports whether all pods of the stable Deployment carry their track label.
+func stableLabeled(stable *appsv1.Deployment) bool {
+	return stable.Spec.Template.Labels[trackLabel] == trackStable &&
+		stable.Status.ObservedGeneration >= stable.Generation &&
+		stable.Status.Replicas == stable.Status.UpdatedReplicas
+}
+
+// removeRolloutAnnotation removes the webapp.example.com/rollout annotation once acted upon.
+// A copy of the App is patched, so the status computed so far isn't replaced by the stored one.
+func (r *AppReconciler) removeRolloutAnnotation(ctx context.Context, app *webappv1.App) error {
+	patched := app.DeepCopy()
+	delete(patched.Annotations, webappv1.RolloutAnnotation)
+	if err := r.Patch(ctx, patched, client.MergeFrom(app)); err != nil {
+		return err
+	}
+	delete(app.Annotations, webappv1.RolloutAnnotation)
+	return nil
+}
+
+// reconcileRolloutWorkload creates or updates the canary or preview Deployment and Service
+// of an App's rollout, and removes them once the rollout is over.
+func (r *AppReconciler) reconcileRolloutWorkload(ctx context.Context, app *webappv1.App) error {
+	var deployment *appsv1.Deployment
+	var service *corev1.Service
+	if rollout := app.Status.Rollout; rollout != nil && rollout.Phase != webappv1.RolloutPhaseAborted {
+		track := rolloutTrack(app)
+		deployment = r.desiredDeployment(app, rollout.Image)
+		deployment.Name = fmt.Sprintf("%s-%s-deployment", app.Name, track)
+		deployment.Spec.Replicas = ptr.To(rollout.Replicas)
+		deployment.Spec.Selector.MatchLabels[trackLabel] = track
+		deployment.Spec.Template.Labels[trackLabel] = track
+
+		// The track's Service is only reached from inside the cluster, whatever the App's type.
+		service = desiredService(app)
+		service.Name = fmt.Sprintf("%s-%s-service", app.Name, track)
+		service.Annotations = attributionAnnotations(app)
+		service.Spec.Selector = map[string]string{"app": app.Name, trackLabel: track}
+		service.Spec.Type = corev1.ServiceTypeClusterIP
+		service.Spec.ExternalTrafficPolicy = ""
+	}
+
+	var stale []client.Object
+	for _, obj := range rolloutChildren(app) {
+		switch {
+		case deployment != nil && obj.GetName() == deployment.Name:
+			if err := r.applyRolloutDeployment(ctx, app, deployment); err != nil {
+				return err
+			}
+		case service != nil && obj.GetName() == service.Name:
+			if err := r.applyRolloutService(ctx, app, service); err != nil {
+				return err
+			}
+		default:
+			stale = append(stale, obj)
+		}
+	}
+	return r.deleteChildren(ctx, app, stale...)
+}
+
+// applyRolloutDeployment creates or updates the canary or preview Deployment of an App.
+func (r *AppReconciler) applyRolloutDeployment(ctx context.Context, app *webappv1.App, desired *appsv1.Deployment) error {
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return err
+	}
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), found)
+	if errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating a new Deployment", "Deployment.Namespace", desired.Namespace, "Deployment.Name", desired.Name)
+		return r.createChild(ctx, app, desired)
+	} else if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(found, app) {
+		return fmt.Errorf("Deployment %q exists and is not owned by the App", found.Name)
+	}
+	updated := desiredDeploymentUpdate(found, desired)
+	syncAttribution(app, updated)
+	_, err = r.syncChild(ctx, r.Client, app, found, updated)
+	return err
+}
+
+// applyRolloutService creates or updates the Service reaching the canary or preview pods of an App.
+func (r *AppReconciler) applyRolloutService(ctx context.Context, app *webappv1.App, desired *corev1.Service) error {
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return err
+	}
+	found := &corev1.Service{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), found)
+	if errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating a new Service", "Service.Namespace", desired.Namespace, "Service.Name", desired.Name)
+		return r.createChild(ctx, app, desired)
+	} else if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(found, app) {
+		return fmt.Errorf("Service %q exists and is not owned by the App", found.Name)
+	}
+	updated := found.DeepCopy()
+	updated.Labels = mergeMaps(updated.Labels, desired.Labels)
+	updated.Spec = desired.Spec
+	syncAttribution(app, updated)
+	_, err = r.syncChild(ctx, r.Client, app, found, updated)
+	return err
+}
+
+// setStrategyConditions reports a canary or blue/green rollout in the App's conditions. An
+// App rolling out a new image is Reconciling until the image is promoted, and Stalled once
+// its rollout is aborted, as only a new image makes progress then.
+func setStrategyConditions(app *webappv1.App) {
+	rollout := app.Status.Rollout
+	if rollout == nil {
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionRolloutPaused)
+		meta.RemoveStatusCondition(&app.Status.Conditions, conditionRolloutAborted)
+		return
+	}
+	paused, aborted := metav1.ConditionFalse, metav1.ConditionFalse
+	if rollout.Phase == webappv1.RolloutPhasePaused {
+		paused = metav1.ConditionTrue
+	}
+	if rollout.Phase == webappv1.RolloutPhaseAborted {
+		aborted = metav1.ConditionTrue
+	}
+	reason := "Rollout" + string(rollout.Phase)
+	setCondition(app, conditionRolloutPaused, paused, reason, rollout.Message)
+	setCondition(app, conditionRolloutAborted, aborted, reason, rollout.Message)
+	if meta.IsStatusConditionTrue(app.Status.Conditions, conditionStalled) {
+		// A stalled stable Deployment is the more pressing problem.
+		return
+	}
+	setHealthConditions(app, reason, rollout.Message, rollout.Phase != webappv1.RolloutPhaseAborted, rollout.Phase == webappv1.RolloutPhaseAborted)
+}
+
+// appContainerImage returns the image of the app container of a Deployment, which comes
+// first whatever its name.
+func appContainerImage(deployment *appsv1.Deployment) string {
+	if containers := deployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+		return containers[0].Image
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Progressive rollouts", func() {
+	newApp := func(strategy webappv1.StrategySpec) *webappv1.App {
+		return &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       webappv1.AppSpec{Image: "web:1.0", Replicas: 4, Port: 8080, Strategy: &strategy},
+		}
+	}
+	// rolledOut reports the Deployment's pods as all updated and available.
+	rolledOut := func(c client.Client, name string) {
+		deployment := &appsv1.Deployment{}
+		ExpectWithOffset(1, c.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, deployment)).To(Succeed())
+		replicas := *deployment.Spec.Replicas
+		deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: deployment.Generation, Replicas: replicas, UpdatedReplicas: replicas, ReadyReplicas: replicas, AvailableReplicas: replicas}
+		ExpectWithOffset(1, c.Status().Update(ctx, deployment)).To(Succeed())
+	}
+	// reconcile runs the rollout and applies the workload with the stable image it returns.
+	reconcile := func(r *AppReconciler, app *webappv1.App) (string, time.Duration) {
+		image, wait, err := r.reconcileRollout(ctx, app, app.Spec.Image)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		_, err = r.reconcileDeployment(ctx, app, image)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		ExpectWithOffset(1, r.reconcileService(ctx, app)).To(Succeed())
+		ExpectWithOffset(1, r.reconcileRolloutWorkload(ctx, app)).To(Succeed())
+		return image, wait
+	}
+	// annotate sets the rollout annotation, keeping the in-memory status the update drops.
+	annotate := func(c client.Client, app *webappv1.App, action string) {
+		status := app.Status
+		app.Annotations = map[string]string{webappv1.RolloutAnnotation: action}
+		ExpectWithOffset(1, c.Update(ctx, app)).To(Succeed())
+		app.Status = status
+	}
+
+	It("should move a canary through its steps and promote it", func() {
+		app := newApp(webappv1.StrategySpec{Type: webappv1.CanaryStrategy, Canary: &webappv1.CanaryStrategySpec{Steps: []webappv1.CanaryStep{
+			{Weight: 25, Pause: &metav1.Duration{Duration: 10 * time.Minute}},
+			{Weight: 50},
+		}}})
+		r, c := newTestReconciler(app)
+		Expect(reconcile(r, app)).To(Equal("web:1.0"))
+		Expect(app.Status.Rollout).To(BeNil(), "the first image is rolled out as is")
+		rolledOut(c, "web-deployment")
+
+		app.Spec.Image = "web:2.0"
+		Expect(reconcile(r, app)).To(Equal("web:1.0"))
+		Expect(app.Status.Rollout).To(HaveField("Phase", webappv1.RolloutPhaseProgressing))
+		Expect(app.Status.Rollout.Replicas).To(Equal(int32(1)))
+		stable, canary := &appsv1.Deployment{}, &appsv1.Deployment{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-deployment", Namespace: "default"}, stable)).To(Succeed())
+		Expect(*stable.Spec.Replicas).To(Equal(int32(3)))
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-canary-deployment", Namespace: "default"}, canary)).To(Succeed())
+		Expect(*canary.Spec.Replicas).To(Equal(int32(1)))
+		Expect(canary.Spec.Template.Spec.Containers[0].Image).To(Equal("web:2.0"))
+		Expect(canary.Spec.Selector.MatchLabels).To(HaveKeyWithValue("webapp.example.com/track", "canary"))
+		service := &corev1.Service{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-canary-service", Namespace: "default"}, service)).To(Succeed())
+		Expect(service.Spec.Selector).To(HaveKeyWithValue("webapp.example.com/track", "canary"))
+
+		rolledOut(c, "web-canary-deployment")
+		_, wait := reconcile(r, app)
+		Expect(wait).To(BeNumerically("~", 10*time.Minute, time.Minute))
+		Expect(app.Status.Rollout.StepStartedAt).NotTo(BeNil())
+
+		app.Status.Rollout.StepStartedAt = ptr.To(metav1.NewTime(time.Now().Add(-11 * time.Minute)))
+		Expect(reconcile(r, app)).To(Equal("web:1.0"))
+		Expect(app.Status.Rollout).To(HaveField("Step", int32(1)))
+		Expect(app.Status.Rollout.Replicas).To(Equal(int32(2)))
+		rolledOut(c, "web-canary-deployment")
+		reconcile(r, app)
+		Expect(app.Status.Rollout.Phase).To(Equal(webappv1.RolloutPhasePaused))
+		setStrategyConditions(app)
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, "RolloutPaused")).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, "Reconciling")).To(BeTrue())
+
+		annotate(c, app, webappv1.RolloutPromote)
+		Expect(reconcile(r, app)).To(Equal("web:2.0"))
+		Expect(app.Status.Rollout.Phase).To(Equal(webappv1.RolloutPhasePromoting))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(app), app)).To(Succeed())
+		Expect(app.Annotations).NotTo(HaveKey(webappv1.RolloutAnnotation), "the annotation is removed once acted upon")
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(stable), stable)).To(Succeed())
+		Expect(*stable.Spec.Replicas).To(Equal(int32(4)))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(canary), canary)).To(Succeed(), "the canary serves until the stable pods are replaced")
+
+		rolledOut(c, "web-deployment")
+		reconcile(r, app)
+		Expect(app.Status.Rollout).To(BeNil())
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(canary), canary))).To(BeTrue())
+		Expect(errors.IsNotFound(c.Get(ctx, client.ObjectKeyFromObject(service), service))).To(BeTrue())
+	})
+
+	It("should switch a blue/green App's Service to its preview once promoted", func() {
+		app := newApp(webappv1.StrategySpec{Type: webappv1.BlueGreenStrategy})
+		r, c := newTestReconciler(app)
+		reconcile(r, app)
+		rolledOut(c, "web-deployment")
+
+		app.Spec.Image = "web:2.0"
+		Expect(reconcile(r, app)).To(Equal("web:1.0"))
+		service := &corev1.Service{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-service", Namespace: "default"}, service)).To(Succeed())
+		Expect(service.Spec.Selector).To(HaveKeyWithValue("webapp.example.com/track", "stable"))
+		preview := &appsv1.Deployment{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-preview-deployment", Namespace: "default"}, preview)).To(Succeed())
+		Expect(*preview.Spec.Replicas).To(Equal(int32(4)))
+
+		annotate(c, app, webappv1.RolloutPromote)
+		reconcile(r, app)
+		Expect(app.Status.Rollout.Phase).To(Equal(webappv1.RolloutPhaseProgressing), "an unavailable preview isn't switched to")
+		Expect(app.Annotations).To(HaveKey(webappv1.RolloutAnnotation))
+
+		rolledOut(c, "web-preview-deployment")
+		Expect(reconcile(r, app)).To(Equal("web:2.0"))
+		Expect(app.Status.Rollout.Phase).To(Equal(webappv1.RolloutPhasePromoting))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(service), service)).To(Succeed())
+		Expect(service.Spec.Selector).To(HaveKeyWithValue("webapp.example.com/track", "preview"))
+
+		rolledOut(c, "web-deployment")
+		reconcile(r, app)
+		Expect(app.Status.Rollout).To(BeNil())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(service), service)).To(Succeed())
+		Expect(service.Spec.Selector).To(Equal(map[string]string{"app": "web"}))
+	})
+
+	It("should scale the new image down and keep the stable one when aborted", func() {
+		app := newApp(webappv1.StrategySpec{Type: webappv1.CanaryStrategy})
+		r, c := newTestReconciler(app)
+		reconcile(r, app)
+		rolledOut(c, "web-deployment")
+		app.Spec.Image = "web:2.0"
+		reconcile(r, app)
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-canary-deployment", Namespace: "default"}, &appsv1.Deployment{})).To(Succeed())
+
+		annotate(c, app, webappv1.RolloutAbort)
+		Expect(reconcile(r, app)).To(Equal("web:1.0"))
+		Expect(app.Status.Rollout.Phase).To(Equal(webappv1.RolloutPhaseAborted))
+		Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "web-canary-deployment", Namespace: "default"}, &appsv1.Deployment{}))).To(BeTrue())
+		stable := &appsv1.Deployment{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "web-deployment", Namespace: "default"}, stable)).To(Succeed())
+		Expect(*stable.Spec.Replicas).To(Equal(int32(4)))
+		setStrategyConditions(app)
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, "RolloutAborted")).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, "Stalled")).To(BeTrue())
+
+		app.Spec.Image = "web:3.0"
+		reconcile(r, app)
+		Expect(app.Status.Rollout).To(HaveField("Image", "web:3.0"))
+		Expect(app.Status.Rollout.Phase).To(Equal(webappv1.RolloutPhaseProgressing), "a new image starts over")
+	})
+})
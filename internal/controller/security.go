@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// appArmorAnnotationPrefix is the beta annotation used to select an AppArmor profile
+// on clusters that predate the securityContext.appArmorProfile field.
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// appArmorFieldMinVersion is the first Kubernetes version serving securityContext.appArmorProfile.
+var appArmorFieldMinVersion = version.MajorMinor(1, 30)
+
+// SupportsAppArmorField reports whether a cluster running the given server version
+// (e.g. "v1.29.4") understands securityContext.appArmorProfile.
+func SupportsAppArmorField(serverVersion string) (bool, error) {
+	v, err := version.ParseGeneric(serverVersion)
+	if err != nil {
+		return false, err
+	}
+	return v.AtLeast(appArmorFieldMinVersion), nil
+}
+
+// windowsTaintKey is the taint key conventionally put on Windows nodes, so that only pods
+// tolerating it are scheduled there.
+const windowsTaintKey = "os"
+
+// isWindows reports whether the App runs Windows containers.
+func isWindows(app *webappv1.App) bool {
+	return app.Spec.OS == corev1.Windows
+}
+
+// podOS returns the operating system declared for the App's pods. Linux pods leave it unset,
+// as they did before the field existed.
+func podOS(app *webappv1.App) *corev1.PodOS {
+	if !isWindows(app) {
+		return nil
+	}
+	return &corev1.PodOS{Name: corev1.Windows}
+}
+
+// osNodeSelector schedules Windows Apps on Windows nodes.
+func osNodeSelector(app *webappv1.App) map[string]string {
+	if !isWindows(app) {
+		return nil
+	}
+	return map[string]string{corev1.LabelOSStable: string(corev1.Windows)}
+}
+
+// osTolerations lets Windows Apps onto Windows nodes tainted os=windows:NoSchedule.
+func osTolerations(app *webappv1.App) []corev1.Toleration {
+	if !isWindows(app) {
+		return nil
+	}
+	return []corev1.Toleration{{
+		Key:      windowsTaintKey,
+		Operator: corev1.TolerationOpEqual,
+		Value:    string(corev1.Windows),
+		Effect:   corev1.TaintEffectNoSchedule,
+	}}
+}
+
+// podSecurityContext builds the pod-level security context for an App.
+// Pods always get a seccomp profile: the one from the spec, or RuntimeDefault when
+// the App was stored before the API server started defaulting the field.
+// The AppArmor profile is left out when legacyAppArmor is set, as it is carried by
+// an annotation instead (see appArmorAnnotations). Windows pods get neither, as the
+// API server rejects Linux-only settings on them.
+func podSecurityContext(app *webappv1.App, legacyAppArmor bool) *corev1.PodSecurityContext {
+	if isWindows(app) {
+		return nil
+	}
+	profile := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	if app.Spec.Security != nil && app.Spec.Security.SeccompProfile != nil {
+		profile = seccompProfile(app.Spec.Security.SeccompProfile)
+	}
+	sc := &corev1.PodSecurityContext{SeccompProfile: profile}
+	if !legacyAppArmor && app.Spec.Security != nil && app.Spec.Security.AppArmorProfile != nil {
+		sc.AppArmorProfile = appArmorProfile(app.Spec.Security.AppArmorProfile)
+	}
+	return sc
+}
+
+// appArmorAnnotations returns the pod annotations selecting the App's AppArmor profile
+// on clusters older than Kubernetes 1.30. It returns nil when the field can be used directly.
+// The beta annotation is per container, so one is set for each container of the pod
+// template: the app container, spec.containers, spec.sidecars and spec.initContainers.
+// Containers injected by admission webhooks, such as mesh proxies and the Vault agent,
+// are not in the template and the API server rejects annotations naming them; they
+// run under the container runtime's default profile.
+func appArmorAnnotations(app *webappv1.App, legacyAppArmor bool) map[string]string {
+	if !legacyAppArmor || isWindows(app) || app.Spec.Security == nil || app.Spec.Security.AppArmorProfile == nil {
+		return nil
+	}
+	p := app.Spec.Security.AppArmorProfile
+	value := "runtime/default"
+	if p.Type == corev1.AppArmorProfileTypeLocalhost && p.LocalhostProfile != nil {
+		value = "localhost/" + *p.LocalhostProfile
+	}
+	annotations := map[string]string{appArmorAnnotationPrefix + containerName(app): value}
+	for _, container := range concat(app.Spec.Containers, app.Spec.Sidecars, app.Spec.InitContainers) {
+		annotations[appArmorAnnotationPrefix+container.Name] = value
+	}
+	return annotations
+}
+
+// automountServiceAccountToken decides whether the App's pods get an API token mounted.
+// An explicit setting always wins; otherwise only Apps that name their own
+// ServiceAccount, or log in to Vault through the agent injector, are assumed to need it.
+func automountServiceAccountToken(app *webappv1.App) *bool {
+	automount := app.Spec.ServiceAccountName != "" || vaultMode(app) == webappv1.VaultModeAgentInjector
+	if app.Spec.AutomountServiceAccountToken != nil {
+		automount = *app.Spec.AutomountServiceAccountToken
+	}
+	return &automount
+}
+
+// containerSecurityContext builds the security context of the app container.
+// It returns nil when nothing is overridden, so the container inherits the pod settings.
+func containerSecurityContext(app *webappv1.App) *corev1.SecurityContext {
+	if isWindows(app) || app.Spec.Security == nil || app.Spec.Security.ContainerSeccompProfile == nil {
+		return nil
+	}
+	return &corev1.SecurityContext{SeccompProfile: seccompProfile(app.Spec.Security.ContainerSeccompProfile)}
+}
+
+// seccompProfile converts the App API seccomp profile into its core/v1 counterpart.
+func seccompProfile(p *webappv1.SeccompProfile) *corev1.SeccompProfile {
+	out := &corev1.SeccompProfile{Type: p.Type}
+	if p.Type == corev1.SeccompProfileTypeLocalhost && p.LocalhostProfile != nil {
+		profile := *p.LocalhostProfile
+		out.LocalhostProfile = &profile
+	}
+	return out
+}
+
+// appArmorProfile converts the App API AppArmor profile into its core/v1 counterpart.
+func appArmorProfile(p *webappv1.AppArmorProfile) *corev1.AppArmorProfile {
+	out := &corev1.AppArmorProfile{Type: p.Type}
+	if p.Type == corev1.AppArmorProfileTypeLocalhost && p.LocalhostProfile != nil {
+		profile := *p.LocalhostProfile
+		out.LocalhostProfile = &profile
+	}
+	return out
+}
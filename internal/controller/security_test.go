@@ -0,0 +1,120 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Pod security contexts", func() {
+	It("should default the pod seccomp profile to RuntimeDefault", func() {
+		app := &webappv1.App{}
+
+		Expect(podSecurityContext(app, false).SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+		Expect(containerSecurityContext(app)).To(BeNil())
+	})
+
+	It("should apply a Localhost profile to the container only", func() {
+		profile := "profiles/app.json"
+		app := &webappv1.App{Spec: webappv1.AppSpec{Security: &webappv1.SecuritySpec{
+			ContainerSeccompProfile: &webappv1.SeccompProfile{
+				Type:             corev1.SeccompProfileTypeLocalhost,
+				LocalhostProfile: &profile,
+			},
+		}}}
+
+		Expect(podSecurityContext(app, false).SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+		sc := containerSecurityContext(app)
+		Expect(sc).NotTo(BeNil())
+		Expect(sc.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeLocalhost))
+		Expect(*sc.SeccompProfile.LocalhostProfile).To(Equal(profile))
+	})
+})
+
+var _ = Describe("AppArmor profiles", func() {
+	app := &webappv1.App{Spec: webappv1.AppSpec{Security: &webappv1.SecuritySpec{
+		AppArmorProfile: &webappv1.AppArmorProfile{Type: corev1.AppArmorProfileTypeRuntimeDefault},
+	}}}
+
+	It("should use the securityContext field on current clusters", func() {
+		Expect(podSecurityContext(app, false).AppArmorProfile).NotTo(BeNil())
+		Expect(appArmorAnnotations(app, false)).To(BeEmpty())
+	})
+
+	It("should fall back to the beta annotation on clusters older than 1.30", func() {
+		Expect(podSecurityContext(app, true).AppArmorProfile).To(BeNil())
+		Expect(appArmorAnnotations(app, true)).To(HaveKeyWithValue(
+			"container.apparmor.security.beta.kubernetes.io/app-container", "runtime/default"))
+	})
+
+	It("should annotate every container of the pod template", func() {
+		app := app.DeepCopy()
+		app.Spec.Security.AppArmorProfile = &webappv1.AppArmorProfile{Type: corev1.AppArmorProfileTypeLocalhost, LocalhostProfile: ptr.To("k8s-web")}
+		app.Spec.Sidecars = []webappv1.Container{{Name: "proxy", Image: "envoy:1.30"}}
+		app.Spec.InitContainers = []webappv1.Container{{Name: "migrate", Image: "web-migrate:1.0"}}
+		app.Spec.Containers = []webappv1.Container{{Name: "worker", Image: "web-worker:1.0"}}
+
+		pod := (&AppReconciler{LegacyAppArmor: true}).desiredDeployment(app, "web:1.0").Spec.Template
+		Expect(pod.Annotations).To(HaveKeyWithValue(
+			"container.apparmor.security.beta.kubernetes.io/app-container", "localhost/k8s-web"))
+		for _, container := range concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+			Expect(pod.Annotations).To(HaveKeyWithValue(
+				"container.apparmor.security.beta.kubernetes.io/"+container.Name, "localhost/k8s-web"))
+		}
+	})
+
+	It("should detect field support from the server version", func() {
+		Expect(SupportsAppArmorField("v1.29.4")).To(BeFalse())
+		Expect(SupportsAppArmorField("v1.30.0-gke.1")).To(BeTrue())
+	})
+})
+
+var _ = Describe("Windows Apps", func() {
+	It("should schedule on Windows nodes without Linux-only security settings", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "iis", Namespace: "default"},
+			Spec: webappv1.AppSpec{
+				Image:    "mcr.microsoft.com/windows/servercore/iis",
+				OS:       corev1.Windows,
+				Security: &webappv1.SecuritySpec{SeccompProfile: &webappv1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}},
+			},
+		}
+		pod := (&AppReconciler{}).desiredDeployment(app, app.Spec.Image).Spec.Template.Spec
+
+		Expect(pod.OS).To(Equal(&corev1.PodOS{Name: corev1.Windows}))
+		Expect(pod.NodeSelector).To(Equal(map[string]string{"kubernetes.io/os": "windows"}))
+		Expect(pod.Tolerations).To(ConsistOf(corev1.Toleration{Key: "os", Operator: corev1.TolerationOpEqual, Value: "windows", Effect: corev1.TaintEffectNoSchedule}))
+		Expect(pod.SecurityContext).To(BeNil())
+		Expect(pod.Containers[0].SecurityContext).To(BeNil())
+	})
+
+	It("should leave Linux pods as they were", func() {
+		pod := (&AppReconciler{}).desiredDeployment(&webappv1.App{}, "web:1.0").Spec.Template.Spec
+
+		Expect(pod.OS).To(BeNil())
+		Expect(pod.NodeSelector).To(BeNil())
+		Expect(pod.Tolerations).To(BeNil())
+		Expect(pod.SecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+	})
+})
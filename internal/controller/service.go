@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// primaryPortName names spec.port on the Service and the app container when the App
+	// declares further ports, as every port of a multi-port Service must be named.
+	primaryPortName = "app"
+	// serviceAnnotationsAnnotation lists the keys of the Service annotations set from
+	// spec.service.annotations, so those removed from the App are removed from the Service.
+	serviceAnnotationsAnnotation = "webapp.example.com/service-annotations"
+)
+
+// serviceType returns the type of the App's Service, ClusterIP by default.
+func serviceType(app *webappv1.App) corev1.ServiceType {
+	if app.Spec.Service == nil || app.Spec.Service.Type == "" {
+		return corev1.ServiceTypeClusterIP
+	}
+	return app.Spec.Service.Type
+}
+
+// serviceAnnotations returns the annotations of spec.service, with the list of their keys.
+func serviceAnnotations(app *webappv1.App) map[string]string {
+	if app.Spec.Service == nil {
+		return nil
+	}
+	return trackedAnnotations(app.Spec.Service.Annotations, serviceAnnotationsAnnotation)
+}
+
+// syncServiceAnnotations writes the annotations of spec.service over a stored Service and
+// removes those the App no longer sets. Annotations added by others, such as a cloud
+// provider's load balancer controller, are kept.
+func syncServiceAnnotations(app *webappv1.App, service *corev1.Service) {
+	var annotations map[string]string
+	if app.Spec.Service != nil {
+		annotations = app.Spec.Service.Annotations
+	}
+	syncTrackedAnnotations(service, serviceAnnotationsAnnotation, annotations)
+}
+
+// extraPorts returns the ports of spec.service.ports.
+func extraPorts(app *webappv1.App) []webappv1.ServicePortSpec {
+	if app.Spec.Service == nil {
+		return nil
+	}
+	return app.Spec.Service.Ports
+}
+
+// portTarget returns the container port and protocol of a port of spec.service.ports.
+func portTarget(port webappv1.ServicePortSpec) (int32, corev1.Protocol) {
+	target, protocol := port.TargetPort, port.Protocol
+	if target == 0 {
+		target = port.Port
+	}
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	return target, protocol
+}
+
+// servicePorts returns the ports of the App's Service: spec.port, then spec.service.ports,
+// then the ports of spec.containers and spec.sidecars. spec.port is only named alongside
+// further ports, so single-port Services are unchanged.
+func servicePorts(app *webappv1.App) []corev1.ServicePort {
+	primary := corev1.ServicePort{
+		Protocol:    corev1.ProtocolTCP,
+		AppProtocol: servicePortAppProtocol(app), // "https" when the App serves TLS itself, else its protocol
+		Port:        app.Spec.Port,
+		TargetPort:  intstr.FromInt32(app.Spec.Port), // Target the container port
+	}
+	var ports []corev1.ServicePort
+	for _, port := range extraPorts(app) {
+		target, protocol := portTarget(port)
+		ports = append(ports, corev1.ServicePort{
+			Name:        port.Name,
+			Protocol:    protocol,
+			AppProtocol: port.AppProtocol,
+			Port:        port.Port,
+			TargetPort:  intstr.FromInt32(target),
+		})
+	}
+	ports = append(ports, containerServicePorts(app)...)
+	if len(ports) == 0 {
+		return []corev1.ServicePort{primary}
+	}
+	primary.Name = primaryPortName
+	return append([]corev1.ServicePort{primary}, ports...)
+}
+
+// containerPorts returns the ports of the app container: spec.port, then the targets of
+// spec.service.ports. Several Service ports may share a container port, which is listed once.
+func containerPorts(app *webappv1.App) []corev1.ContainerPort {
+	primary := corev1.ContainerPort{ContainerPort: app.Spec.Port}
+	extra := extraPorts(app)
+	if len(extra) == 0 {
+		return []corev1.ContainerPort{primary}
+	}
+	primary.Name = primaryPortName
+	ports := []corev1.ContainerPort{primary}
+	for _, port := range extra {
+		target, protocol := portTarget(port)
+		if slices.ContainsFunc(ports, func(p corev1.ContainerPort) bool {
+			return p.ContainerPort == target && (p.Protocol == protocol || p.Protocol == "" && protocol == corev1.ProtocolTCP)
+		}) {
+			continue
+		}
+		ports = append(ports, corev1.ContainerPort{Name: port.Name, ContainerPort: target, Protocol: protocol})
+	}
+	return ports
+}
+
+// serviceIPFamilies returns the IP families and IP family policy of the App's Service, nil
+// to leave them to the cluster's defaults. Two families require both unless a policy says otherwise.
+func serviceIPFamilies(app *webappv1.App) ([]corev1.IPFamily, *corev1.IPFamilyPolicy) {
+	if app.Spec.Service == nil {
+		return nil, nil
+	}
+	policy := app.Spec.Service.IPFamilyPolicy
+	if policy == nil && len(app.Spec.Service.IPFamilies) == 2 {
+		requireDualStack := corev1.IPFamilyPolicyRequireDualStack
+		policy = &requireDualStack
+	}
+	return app.Spec.Service.IPFamilies, policy
+}
+
+// servicePortAppProtocol returns the appProtocol of the App's Service port, which tells
+// Ingress controllers and meshes how to speak to the backend: https when the App serves TLS,
+// otherwise the App's declared protocol. TLS wins, as a mesh can't parse the encrypted stream.
+func servicePortAppProtocol(app *webappv1.App) *string {
+	protocol := string(app.Spec.AppProtocol)
+	if app.Spec.TLS != nil {
+		protocol = "https"
+	}
+	if protocol == "" {
+		return nil
+	}
+	return &protocol
+}
+
+// internalTrafficPolicy returns the internal traffic policy of the App's Service, nil for the default.
+func internalTrafficPolicy(app *webappv1.App) *corev1.ServiceInternalTrafficPolicy {
+	if app.Spec.Service == nil {
+		return nil
+	}
+	return app.Spec.Service.InternalTrafficPolicy
+}
+
+// externalTrafficPolicy returns the external traffic policy of the App's Service of the given
+// type. The API server rejects one on Services not reachable from outside the cluster.
+func externalTrafficPolicy(app *webappv1.App, serviceType corev1.ServiceType) corev1.ServiceExternalTrafficPolicy {
+	if app.Spec.Service == nil || (serviceType != corev1.ServiceTypeNodePort && serviceType != corev1.ServiceTypeLoadBalancer) {
+		return ""
+	}
+	return app.Spec.Service.ExternalTrafficPolicy
+}
+
+// sessionAffinity returns the session affinity of the App's Service and its configuration.
+func sessionAffinity(app *webappv1.App) (corev1.ServiceAffinity, *corev1.SessionAffinityConfig) {
+	if app.Spec.Service == nil || app.Spec.Service.SessionAffinity != corev1.ServiceAffinityClientIP {
+		return "", nil
+	}
+	var config *corev1.SessionAffinityConfig
+	if timeout := app.Spec.Service.SessionAffinityTimeoutSeconds; timeout != nil {
+		config = &corev1.SessionAffinityConfig{ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: timeout}}
+	}
+	return corev1.ServiceAffinityClientIP, config
+}
+
+// checkIPFamilies verifies that the cluster allocates Service IPs of the families the App's
+// Service requires, so it isn't rejected by the API server on every reconcile. The families
+// are read from the cluster's ServiceCIDRs; clusters predating them are not checked.
+func (r *AppReconciler) checkIPFamilies(ctx context.Context, app *webappv1.App) error {
+	families, policy := serviceIPFamilies(app)
+	required := slices.Clone(families)
+	if policy != nil && *policy == corev1.IPFamilyPolicyRequireDualStack {
+		required = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	cidrs := &networkingv1.ServiceCIDRList{}
+	if err := r.List(ctx, cidrs); meta.IsNoMatchError(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	available := map[corev1.IPFamily]bool{}
+	for _, serviceCIDR := range cidrs.Items {
+		if !serviceCIDR.DeletionTimestamp.IsZero() {
+			continue
+		}
+		for _, cidr := range serviceCIDR.Spec.CIDRs {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				continue
+			}
+			if prefix.Addr().Is4() {
+				available[corev1.IPv4Protocol] = true
+			} else {
+				available[corev1.IPv6Protocol] = true
+			}
+		}
+	}
+	if len(available) == 0 {
+		// Nothing to compare with, e.g. while the API server creates the default ServiceCIDR.
+		return nil
+	}
+	for _, family := range required {
+		if !available[family] {
+			return fmt.Errorf("the cluster has no %s Service CIDR for spec.service", family)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Service IP families", func() {
+	dualStack := newTestApp(webappv1.AppSpec{
+		Port:    8080,
+		Service: &webappv1.ServiceSpec{IPFamilies: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}},
+	})
+	serviceCIDR := func(cidrs ...string) *networkingv1.ServiceCIDR {
+		return &networkingv1.ServiceCIDR{ObjectMeta: metav1.ObjectMeta{Name: "kubernetes"}, Spec: networkingv1.ServiceCIDRSpec{CIDRs: cidrs}}
+	}
+
+	It("should request the App's IP families and require both of two", func() {
+		spec := desiredService(dualStack).Spec
+		Expect(spec.IPFamilies).To(Equal([]corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}))
+		Expect(spec.IPFamilyPolicy).To(Equal(ptr.To(corev1.IPFamilyPolicyRequireDualStack)))
+	})
+
+	It("should refuse families the cluster has no Service CIDR for", func() {
+		r, _ := newTestReconciler(serviceCIDR("10.96.0.0/12"))
+		Expect(r.checkIPFamilies(ctx, dualStack)).To(MatchError(ContainSubstring("no IPv6 Service CIDR")))
+		Expect(r.checkIPFamilies(ctx, &webappv1.App{})).To(Succeed())
+
+		r, _ = newTestReconciler(serviceCIDR("10.96.0.0/12", "fd00:10:96::/112"))
+		Expect(r.checkIPFamilies(ctx, dualStack)).To(Succeed())
+	})
+})
+
+var _ = Describe("Service traffic options", func() {
+	It("should set the App's traffic policy and session affinity", func() {
+		app := &webappv1.App{Spec: webappv1.AppSpec{Port: 8080, Service: &webappv1.ServiceSpec{
+			InternalTrafficPolicy:         ptr.To(corev1.ServiceInternalTrafficPolicyLocal),
+			ExternalTrafficPolicy:         corev1.ServiceExternalTrafficPolicyLocal,
+			SessionAffinity:               corev1.ServiceAffinityClientIP,
+			SessionAffinityTimeoutSeconds: ptr.To[int32](600),
+		}}}
+		spec := desiredService(app).Spec
+
+		Expect(spec.InternalTrafficPolicy).To(Equal(ptr.To(corev1.ServiceInternalTrafficPolicyLocal)))
+		Expect(spec.ExternalTrafficPolicy).To(BeEmpty(), "a ClusterIP Service takes no external traffic policy")
+		Expect(spec.SessionAffinity).To(Equal(corev1.ServiceAffinityClientIP))
+		Expect(*spec.SessionAffinityConfig.ClientIP.TimeoutSeconds).To(Equal(int32(600)))
+	})
+
+	It("should expose further named ports on a LoadBalancer Service", func() {
+		app := &webappv1.App{Spec: webappv1.AppSpec{Port: 8080, Service: &webappv1.ServiceSpec{
+			Type:        corev1.ServiceTypeLoadBalancer,
+			Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+			Ports: []webappv1.ServicePortSpec{
+				{Name: "metrics", Port: 9090},
+				{Name: "syslog", Port: 514, TargetPort: 5514, Protocol: corev1.ProtocolUDP},
+				{Name: "metrics-alt", Port: 9091, TargetPort: 9090},
+			},
+		}}}
+		service := desiredService(app)
+
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+		Expect(service.Annotations).To(HaveKeyWithValue("service.beta.kubernetes.io/aws-load-balancer-type", "nlb"))
+		Expect(service.Spec.Ports).To(HaveLen(4))
+		Expect(service.Spec.Ports[0].Name).To(Equal(primaryPortName))
+		Expect(service.Spec.Ports[2].Protocol).To(Equal(corev1.ProtocolUDP))
+		Expect(service.Spec.Ports[2].TargetPort.IntValue()).To(Equal(5514))
+
+		// Service ports sharing a container port declare it once.
+		ports := (&AppReconciler{}).desiredDeployment(app, "web:1.0").Spec.Template.Spec.Containers[0].Ports
+		Expect(ports).To(Equal([]corev1.ContainerPort{
+			{Name: primaryPortName, ContainerPort: 8080},
+			{Name: "metrics", ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+			{Name: "syslog", ContainerPort: 5514, Protocol: corev1.ProtocolUDP},
+		}))
+	})
+
+	It("should remove the Service annotations the App no longer sets", func() {
+		app := &webappv1.App{Spec: webappv1.AppSpec{Service: &webappv1.ServiceSpec{
+			Annotations: map[string]string{"a": "1", "b": "2"},
+		}}}
+		service := desiredService(app)
+		service.Annotations["cloud-provider/status"] = "provisioned"
+
+		app.Spec.Service.Annotations = map[string]string{"b": "3"}
+		syncServiceAnnotations(app, service)
+		Expect(service.Annotations).To(Equal(map[string]string{
+			"b": "3", "cloud-provider/status": "provisioned", serviceAnnotationsAnnotation: "b",
+		}))
+
+		app.Spec.Service = nil
+		syncServiceAnnotations(app, service)
+		Expect(service.Annotations).To(Equal(map[string]string{"cloud-provider/status": "provisioned"}))
+	})
+})
+
+var _ = Describe("gRPC Apps", func() {
+	app := &webappv1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "greeter", Namespace: "default"},
+		Spec: webappv1.AppSpec{
+			Image:       "greeter:1.0",
+			Port:        50051,
+			AppProtocol: webappv1.AppProtocolGRPC,
+			Probes: &webappv1.ProbesSpec{
+				Liveness:  &webappv1.ProbeSpec{GRPC: &webappv1.GRPCProbe{}},
+				Readiness: &webappv1.ProbeSpec{GRPC: &webappv1.GRPCProbe{Service: "greeter.v1.Greeter"}, PeriodSeconds: 5},
+			},
+		},
+	}
+
+	It("should declare the protocol on the Service port, unless the App serves TLS", func() {
+		Expect(desiredService(app).Spec.Ports[0].AppProtocol).To(Equal(ptr.To("grpc")))
+
+		withTLS := app.DeepCopy()
+		withTLS.Spec.TLS = &webappv1.TLSSpec{}
+		Expect(desiredService(withTLS).Spec.Ports[0].AppProtocol).To(Equal(ptr.To("https")))
+		Expect(desiredService(&webappv1.App{}).Spec.Ports[0].AppProtocol).To(BeNil())
+	})
+
+	It("should probe the container with the gRPC health checking protocol", func() {
+		container := (&AppReconciler{}).desiredDeployment(app, app.Spec.Image).Spec.Template.Spec.Containers[0]
+
+		Expect(container.LivenessProbe.GRPC).To(Equal(&corev1.GRPCAction{Port: 50051, Service: ptr.To("")}))
+		Expect(container.LivenessProbe.PeriodSeconds).To(Equal(int32(10)))
+		Expect(container.ReadinessProbe.GRPC.Service).To(Equal(ptr.To("greeter.v1.Greeter")))
+		Expect(container.ReadinessProbe.PeriodSeconds).To(Equal(int32(5)))
+		Expect(container.ReadinessProbe.FailureThreshold).To(Equal(int32(3)))
+	})
+
+	It("should roll out probe changes", func() {
+		r, _ := newTestReconciler()
+		Expect(r.reconcileDeployment(ctx, app, app.Spec.Image)).NotTo(BeNil())
+
+		changed := app.DeepCopy()
+		changed.Spec.Probes.Readiness.FailureThreshold = 6
+		deployment, err := r.reconcileDeployment(ctx, changed, app.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deployment.Spec.Template.Spec.Containers[0].ReadinessProbe.FailureThreshold).To(Equal(int32(6)))
+	})
+
+	It("should name the port h2c for Knative", func() {
+		knative := app.DeepCopy()
+		knative.Spec.WorkloadType = webappv1.WorkloadTypeKnative
+		service, err := (&AppReconciler{}).desiredKnativeService(knative, knative.Spec.Image)
+		Expect(err).NotTo(HaveOccurred())
+		containers, _, _ := unstructured.NestedSlice(service.Object, "spec", "template", "spec", "containers")
+		Expect(containers[0]).To(HaveKeyWithValue("ports", ConsistOf(HaveKeyWithValue("name", "h2c"))))
+	})
+})
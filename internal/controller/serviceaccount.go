@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// conditionSecurityWarning is set on Apps whose pods run with more API access than they should.
+	conditionSecurityWarning = "SecurityWarning"
+	// clusterAdminRole is the built-in ClusterRole granting full access to the cluster.
+	clusterAdminRole = "cluster-admin"
+	// defaultServiceAccount is the ServiceAccount pods run as when none is named.
+	defaultServiceAccount = "default"
+	// serviceAccountAnnotationsAnnotation lists the keys of the ServiceAccount annotations set
+	// from spec.serviceAccount.annotations, so those removed from the App are removed from it.
+	serviceAccountAnnotationsAnnotation = "webapp.example.com/serviceaccount-annotations"
+	// pullSecretsAnnotation lists the pull Secrets the controller attached to an App's
+	// ServiceAccount, so those the App no longer uses are detached from it.
+	pullSecretsAnnotation = "webapp.example.com/pull-secrets"
+)
+
+// ownServiceAccount reports whether the App's pods run as a ServiceAccount generated for
+// them, for spec.serviceAccount or spec.cloudIdentity.
+func ownServiceAccount(app *webappv1.App) bool {
+	return app.Spec.ServiceAccount != nil || app.Spec.CloudIdentity != nil
+}
+
+// serviceAccountName returns the name of the ServiceAccount generated for an App.
+func serviceAccountName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-serviceaccount", app.Name)
+}
+
+// podServiceAccountName returns the ServiceAccount the App's pods run as: the one generated
+// for them, or spec.serviceAccountName.
+func podServiceAccountName(app *webappv1.App) string {
+	if ownServiceAccount(app) {
+		return serviceAccountName(app)
+	}
+	return app.Spec.ServiceAccountName
+}
+
+// serviceAccountAnnotations returns the annotations of spec.serviceAccount, with the list of their keys.
+func serviceAccountAnnotations(app *webappv1.App) map[string]string {
+	if app.Spec.ServiceAccount == nil {
+		return nil
+	}
+	return trackedAnnotations(app.Spec.ServiceAccount.Annotations, serviceAccountAnnotationsAnnotation)
+}
+
+// pullSecretsAnnotations returns the annotation listing the pull Secrets attached to an App's
+// ServiceAccount, nil when there are none.
+func pullSecretsAnnotations(pullSecrets []corev1.LocalObjectReference) map[string]string {
+	if len(pullSecrets) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(pullSecrets))
+	for _, ref := range pullSecrets {
+		names = append(names, ref.Name)
+	}
+	return map[string]string{pullSecretsAnnotation: strings.Join(names, ",")}
+}
+
+// desiredServiceAccount returns the ServiceAccount generated for the App, without owner.
+func (r *AppReconciler) desiredServiceAccount(app *webappv1.App) *corev1.ServiceAccount {
+	pullSecrets := r.imagePullSecrets(app)
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceAccountName(app),
+			Namespace: app.Namespace,
+			Annotations: mergeMaps(attributionAnnotations(app), serviceAccountAnnotations(app),
+				cloudIdentityAnnotations(app), pullSecretsAnnotations(pullSecrets)),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		ImagePullSecrets: pullSecrets,
+	}
+}
+
+// syncPullSecrets attaches the App's pull Secrets to a stored ServiceAccount, and detaches
+// those the controller attached before that the App no longer uses. Pull Secrets attached
+// by others, such as the dockercfg Secret OpenShift adds to every ServiceAccount, are kept.
+func syncPullSecrets(sa *corev1.ServiceAccount, pullSecrets []corev1.LocalObjectReference) {
+	previous := strings.Split(sa.Annotations[pullSecretsAnnotation], ",")
+	others := slices.DeleteFunc(slices.Clone(sa.ImagePullSecrets), func(ref corev1.LocalObjectReference) bool {
+		return slices.Contains(previous, ref.Name) || slices.Contains(pullSecrets, ref)
+	})
+	sa.ImagePullSecrets = concat(pullSecrets, others)
+	delete(sa.Annotations, pullSecretsAnnotation)
+	sa.Annotations = mergeMaps(sa.Annotations, pullSecretsAnnotations(pullSecrets))
+}
+
+// reconcileServiceAccount creates or updates the ServiceAccount generated for an App with
+// spec.serviceAccount or a cloud identity, and removes a previously generated one when the
+// App no longer has either. A ServiceAccount of that name not created for the App is an
+// error, as its pods would otherwise run with someone else's identity.
+func (r *AppReconciler) reconcileServiceAccount(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	if !ownServiceAccount(app) {
+		return r.deleteChildren(ctx, app, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName(app)}})
+	}
+
+	desired := r.desiredServiceAccount(app)
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return err
+	}
+	found := &corev1.ServiceAccount{}
+	err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, found)
+	if errors.IsNotFound(err) {
+		log.Info("Creating a new ServiceAccount", "ServiceAccount.Namespace", desired.Namespace, "ServiceAccount.Name", desired.Name)
+		err = r.createChild(ctx, app, desired)
+		if errors.IsAlreadyExists(err) {
+			// The cache only holds labelled ServiceAccounts, so an unlabelled one of the same name is not seen.
+			return fmt.Errorf("ServiceAccount %q exists and is not managed by the controller", desired.Name)
+		}
+		return err
+	} else if err != nil {
+		return err
+	}
+	if !metav1.IsControlledBy(found, app) {
+		return fmt.Errorf("ServiceAccount %q exists and is not owned by the App", desired.Name)
+	}
+
+	updated := found.DeepCopy()
+	updated.Labels = mergeMaps(updated.Labels, desired.Labels)
+	for _, key := range []string{awsRoleARNAnnotation, gcpServiceAccountAnnotation, azureClientIDAnnotation, azureTenantIDAnnotation} {
+		delete(updated.Annotations, key)
+	}
+	var annotations map[string]string
+	if app.Spec.ServiceAccount != nil {
+		annotations = app.Spec.ServiceAccount.Annotations
+	}
+	syncTrackedAnnotations(updated, serviceAccountAnnotationsAnnotation, annotations)
+	updated.Annotations = mergeMaps(updated.Annotations, attributionAnnotations(app), cloudIdentityAnnotations(app))
+	syncPullSecrets(updated, desired.ImagePullSecrets)
+	if equality.Semantic.DeepEqual(found, updated) {
+		return nil
+	}
+	log.Info("Updating existing ServiceAccount", "ServiceAccount.Namespace", found.Namespace, "ServiceAccount.Name", found.Name)
+	return r.updateChild(ctx, app, updated)
+}
+
+// serviceAccountCondition reports whether the App's pods run as a ServiceAccount that
+// defeats least privilege: one bound to cluster-admin, or the namespace's shared default
+// ServiceAccount with its token mounted.
+func (r *AppReconciler) serviceAccountCondition(ctx context.Context, app *webappv1.App) (metav1.Condition, error) {
+	condition := metav1.Condition{
+		Type:               conditionSecurityWarning,
+		Status:             metav1.ConditionFalse,
+		Reason:             "LeastPrivilege",
+		Message:            "The App's ServiceAccount is not known to be over-privileged",
+		ObservedGeneration: app.Generation,
+	}
+
+	name := podServiceAccountName(app)
+	if name == "" {
+		name = defaultServiceAccount
+	}
+
+	bound, err := r.boundToClusterAdmin(ctx, app.Namespace, name)
+	if err != nil {
+		return condition, err
+	}
+	switch {
+	case bound:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ClusterAdminServiceAccount"
+		condition.Message = fmt.Sprintf("ServiceAccount %q is bound to %s; grant it only the permissions the App needs", name, clusterAdminRole)
+	case name == defaultServiceAccount && *automountServiceAccountToken(app):
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DefaultServiceAccount"
+		condition.Message = "Pods mount a token of the shared default ServiceAccount; set spec.serviceAccountName to a dedicated ServiceAccount"
+	}
+	return condition, nil
+}
+
+// boundToClusterAdmin reports whether the ServiceAccount is granted the cluster-admin
+// ClusterRole, cluster-wide or within its namespace.
+func (r *AppReconciler) boundToClusterAdmin(ctx context.Context, namespace, name string) (bool, error) {
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := r.List(ctx, clusterRoleBindings); err != nil {
+		return false, err
+	}
+	for _, binding := range clusterRoleBindings.Items {
+		if grantsClusterAdmin(binding.RoleRef, binding.Subjects, namespace, name) {
+			return true, nil
+		}
+	}
+
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := r.List(ctx, roleBindings, client.InNamespace(namespace)); err != nil {
+		return false, err
+	}
+	for _, binding := range roleBindings.Items {
+		if grantsClusterAdmin(binding.RoleRef, binding.Subjects, namespace, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// grantsClusterAdmin reports whether a binding grants cluster-admin to the given ServiceAccount.
+func grantsClusterAdmin(roleRef rbacv1.RoleRef, subjects []rbacv1.Subject, namespace, name string) bool {
+	if roleRef.Kind != "ClusterRole" || roleRef.Name != clusterAdminRole {
+		return false
+	}
+	for _, subject := range subjects {
+		if subject.Kind == rbacv1.ServiceAccountKind && subject.Namespace == namespace && subject.Name == name {
+			return true
+		}
+	}
+	return false
+}
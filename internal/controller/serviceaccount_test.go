@@ -0,0 +1,88 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("ServiceAccount token automounting", func() {
+	It("should not mount a token for Apps using the default ServiceAccount", func() {
+		Expect(*automountServiceAccountToken(&webappv1.App{})).To(BeFalse())
+	})
+
+	It("should mount a token for Apps with their own ServiceAccount", func() {
+		app := &webappv1.App{Spec: webappv1.AppSpec{ServiceAccountName: "api-reader"}}
+		Expect(*automountServiceAccountToken(app)).To(BeTrue())
+	})
+
+	It("should honour an explicit override", func() {
+		automount := true
+		app := &webappv1.App{Spec: webappv1.AppSpec{AutomountServiceAccountToken: &automount}}
+		Expect(*automountServiceAccountToken(app)).To(BeTrue())
+	})
+})
+
+var _ = Describe("ServiceAccount privilege warnings", func() {
+	conditionFor := func(app *webappv1.App, objs ...client.Object) metav1.Condition {
+		r, _ := newTestReconciler(objs...)
+		condition, err := r.serviceAccountCondition(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		return condition
+	}
+
+	It("should not warn about the default ServiceAccount when no token is mounted", func() {
+		app := newTestApp(webappv1.AppSpec{})
+
+		Expect(conditionFor(app).Status).To(Equal(metav1.ConditionFalse))
+	})
+
+	It("should warn when pods mount a default ServiceAccount token", func() {
+		automount := true
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       webappv1.AppSpec{AutomountServiceAccountToken: &automount},
+		}
+
+		condition := conditionFor(app)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("DefaultServiceAccount"))
+	})
+
+	It("should warn when the ServiceAccount is bound to cluster-admin", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       webappv1.AppSpec{ServiceAccountName: "web"},
+		}
+		binding := &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-admin"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "shop", Name: "web"}},
+		}
+
+		condition := conditionFor(app, binding)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal("ClusterAdminServiceAccount"))
+	})
+})
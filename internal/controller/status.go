@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// updateStatus writes the App's status, if it changed since original was read, with
+// patchStatus. Changes to ready replicas and conditions are debounced: they are written at
+// most once per StatusInterval per App, so pods flapping during a rollout cost one write per
+// interval. A deferred write is reported by a non-zero wait, after which the App should be
+// reconciled again; status is derived state, so the next reconcile recomputes it.
+// Image check results are always written immediately, so images are not checked twice, and
+// so are a new Git configuration commit and a new observed generation, so GitOps tools
+// waiting on them learn about a rollout at once, debug session changes, which a user is
+// waiting on, and the state of a canary or blue/green rollout, which the next reconcile moves on from.
+func (r *AppReconciler) updateStatus(ctx context.Context, original, app *webappv1.App) (time.Duration, error) {
+	log := log.FromContext(ctx)
+
+	observeReadyReplicas(app)
+	if equality.Semantic.DeepEqual(original.Status, app.Status) {
+		return 0, nil
+	}
+
+	key := types.NamespacedName{Name: app.Name, Namespace: app.Namespace}
+	imageChecked := !equality.Semantic.DeepEqual(original.Status.ImageScan, app.Status.ImageScan) ||
+		!equality.Semantic.DeepEqual(original.Status.Provenance, app.Status.Provenance) ||
+		!equality.Semantic.DeepEqual(original.Status.Platforms, app.Status.Platforms) ||
+		!equality.Semantic.DeepEqual(original.Status.ImageUpdate, app.Status.ImageUpdate)
+	configPulled := !equality.Semantic.DeepEqual(original.Status.ConfigFrom, app.Status.ConfigFrom)
+	specObserved := original.Status.ObservedGeneration != app.Status.ObservedGeneration
+	debugChanged := !equality.Semantic.DeepEqual(original.Status.Debug, app.Status.Debug)
+	rolloutChanged := !equality.Semantic.DeepEqual(original.Status.Rollout, app.Status.Rollout)
+	if wait := r.statusWriteDelay(key); wait > 0 && !imageChecked && !configPulled && !specObserved && !debugChanged && !rolloutChanged {
+		log.V(1).Info("Deferring App status update", "After", wait)
+		return wait, nil
+	}
+
+	if err := r.patchStatus(ctx, original, app); err != nil {
+		return 0, err
+	}
+	r.statusWritten(key)
+	observeTimeToReady(original, app)
+	log.Info("App status updated", "Replicas", app.Status.Replicas)
+	return 0, nil
+}
+
+// patchStatus writes the changes from original to the App's status as a merge patch guarded
+// by the resourceVersion of original. Reconcile and the status worker both write the status,
+// and a merge patch replaces the conditions as a whole, so an unguarded patch computed from a
+// stale read would drop the conditions the other wrote in between. On a conflict, the changes
+// are replayed onto the latest App, see rebaseStatus, and app is left with the status written.
+func (r *AppReconciler) patchStatus(ctx context.Context, original, app *webappv1.App) error {
+	desired := app.Status.DeepCopy()
+	err := r.Status().Patch(ctx, app, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{}))
+	if !errors.IsConflict(err) {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		latest := &webappv1.App{}
+		if err := r.Get(ctx, client.ObjectKeyFromObject(app), latest); err != nil {
+			return err
+		}
+		rebased := latest.DeepCopy()
+		if err := rebaseStatus(&rebased.Status, &original.Status, desired); err != nil {
+			return err
+		}
+		if !equality.Semantic.DeepEqual(latest.Status, rebased.Status) {
+			if err := r.Status().Patch(ctx, rebased, client.MergeFromWithOptions(latest, client.MergeFromWithOptimisticLock{})); err != nil {
+				return err
+			}
+		}
+		app.Status = rebased.Status
+		return nil
+	})
+}
+
+// rebaseStatus replays onto latest the changes a writer made from original to desired: the
+// status fields it changed take its value, and the conditions it set or removed are set or
+// removed, leaving the other fields and conditions as latest has them.
+func rebaseStatus(latest, original, desired *webappv1.AppStatus) error {
+	for _, c := range desired.Conditions {
+		if previous := meta.FindStatusCondition(original.Conditions, c.Type); previous == nil || !equality.Semantic.DeepEqual(*previous, c) {
+			replaceCondition(&latest.Conditions, c)
+		}
+	}
+	for _, c := range original.Conditions {
+		if meta.FindStatusCondition(desired.Conditions, c.Type) == nil {
+			meta.RemoveStatusCondition(&latest.Conditions, c.Type)
+		}
+	}
+
+	// The other fields are compared as JSON, so new status fields are covered too.
+	converter := runtime.DefaultUnstructuredConverter
+	latestFields, err := converter.ToUnstructured(latest)
+	if err != nil {
+		return err
+	}
+	originalFields, err := converter.ToUnstructured(original)
+	if err != nil {
+		return err
+	}
+	desiredFields, err := converter.ToUnstructured(desired)
+	if err != nil {
+		return err
+	}
+	for _, fields := range []map[string]any{originalFields, desiredFields} {
+		for name := range fields {
+			if name == "conditions" || equality.Semantic.DeepEqual(originalFields[name], desiredFields[name]) {
+				continue
+			}
+			if value, ok := desiredFields[name]; ok {
+				latestFields[name] = value
+			} else {
+				delete(latestFields, name)
+			}
+		}
+	}
+	*latest = webappv1.AppStatus{}
+	return converter.FromUnstructured(latestFields, latest)
+}
+
+// replaceCondition sets a condition as is, replacing the condition of its type if there is one.
+func replaceCondition(conditions *[]metav1.Condition, condition metav1.Condition) {
+	for i := range *conditions {
+		if (*conditions)[i].Type == condition.Type {
+			(*conditions)[i] = condition
+			return
+		}
+	}
+	*conditions = append(*conditions, condition)
+}
+
+// statusWriteDelay returns how long the status of an App must wait before it is written again.
+func (r *AppReconciler) statusWriteDelay(key types.NamespacedName) time.Duration {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	last, ok := r.lastStatusWrite[key]
+	if !ok {
+		return 0
+	}
+	return time.Until(last.Add(r.StatusInterval))
+}
+
+// statusWritten records that the status of an App was just written.
+func (r *AppReconciler) statusWritten(key types.NamespacedName) {
+	if r.StatusInterval <= 0 {
+		return
+	}
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	if r.lastStatusWrite == nil {
+		r.lastStatusWrite = map[types.NamespacedName]time.Time{}
+	}
+	r.lastStatusWrite[key] = time.Now()
+}
+
+// forgetStatus drops the write history of a deleted App.
+func (r *AppReconciler) forgetStatus(key types.NamespacedName) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	delete(r.lastStatusWrite, key)
+}
+
+// reconcileStatus is the status worker: it copies the ready replicas and rollout state of an
+// App's Deployment into the App's status. It runs as its own controller, triggered by Deployment status
+// changes, so pods becoming ready during a rollout don't re-run the full apply path of
+// Reconcile, which in turn ignores Deployment status changes.
+func (r *AppReconciler) reconcileStatus(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	app := &webappv1.App{}
+	if err := r.Get(ctx, req.NamespacedName, app); err != nil {
+		if errors.IsNotFound(err) {
+			r.forgetStatus(req.NamespacedName)
+			forgetMetrics(req.NamespacedName)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if app.Spec.TargetCluster != nil || isKnative(app) {
+		// Reconcile reads the ready replicas from the target cluster or the Knative Revision.
+		return ctrl.Result{}, nil
+	}
+	original := app.DeepCopy()
+
+	// The Deployment controller already counts ready pods; a Deployment not created yet has none.
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-deployment", app.Name), Namespace: app.Namespace}, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	app.Status.Replicas = deployment.Status.ReadyReplicas
+	if err == nil {
+		setRolloutConditions(app, deployment)
+		setScaledToZeroCondition(app, deployment)
+	}
+
+	wait, err := r.updateStatus(ctx, original, app)
+	return ctrl.Result{RequeueAfter: wait}, err
+}
+
+// deploymentStatusChanged passes Deployment updates changing its status, which tracks
+// ready pods and rollout progress.
+var deploymentStatusChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		old, okOld := e.ObjectOld.(*appsv1.Deployment)
+		updated, okNew := e.ObjectNew.(*appsv1.Deployment)
+		return okOld && okNew && !equality.Semantic.DeepEqual(old.Status, updated.Status)
+	},
+}
+
+// specChanged passes updates of a child resource's spec, labels or annotations, leaving
+// status changes to the status worker.
+var specChanged = predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{})
@@ -0,0 +1,124 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Status updates", func() {
+	It("should debounce replica changes but write image check results immediately", func() {
+		app := newTestApp(webappv1.AppSpec{})
+		r, c := newTestReconciler(app)
+		r.StatusInterval = time.Minute
+
+		update := func(mutate func(*webappv1.App)) time.Duration {
+			current := &webappv1.App{}
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(app), current)).To(Succeed())
+			changed := current.DeepCopy()
+			mutate(changed)
+			wait, err := r.updateStatus(ctx, current, changed)
+			Expect(err).NotTo(HaveOccurred())
+			return wait
+		}
+		stored := func() webappv1.AppStatus {
+			current := &webappv1.App{}
+			Expect(c.Get(ctx, client.ObjectKeyFromObject(app), current)).To(Succeed())
+			return current.Status
+		}
+
+		Expect(update(func(a *webappv1.App) { a.Status.Replicas = 1 })).To(BeZero())
+		Expect(stored().Replicas).To(Equal(int32(1)))
+
+		Expect(update(func(a *webappv1.App) { a.Status.Replicas = 2 })).To(BeNumerically(">", 0))
+		Expect(stored().Replicas).To(Equal(int32(1)))
+
+		Expect(update(func(a *webappv1.App) {
+			a.Status.Replicas = 2
+			a.Status.ImageScan = &webappv1.ImageScanStatus{Image: "web:2.0"}
+		})).To(BeZero())
+		Expect(stored().Replicas).To(Equal(int32(2)))
+	})
+
+	It("should not lose the status changes of interleaved writers", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 2},
+			Status: webappv1.AppStatus{ObservedGeneration: 1, Conditions: []metav1.Condition{
+				{Type: conditionScaledToZero, Status: metav1.ConditionTrue, Reason: "NoReplicas"},
+			}},
+		}
+		r, c := newTestReconciler(app)
+
+		// Reconcile and the status worker both read the App before either writes.
+		reconcilerRead, workerRead := &webappv1.App{}, &webappv1.App{}
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(app), reconcilerRead)).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(app), workerRead)).To(Succeed())
+
+		reconciled := reconcilerRead.DeepCopy()
+		reconciled.Status.ObservedGeneration = 2
+		meta.SetStatusCondition(&reconciled.Status.Conditions, metav1.Condition{Type: "Ready", Status: metav1.ConditionFalse, Reason: "Progressing"})
+		_, err := r.updateStatus(ctx, reconcilerRead, reconciled)
+		Expect(err).NotTo(HaveOccurred())
+
+		worked := workerRead.DeepCopy()
+		worked.Status.Replicas = 2
+		meta.RemoveStatusCondition(&worked.Status.Conditions, conditionScaledToZero)
+		meta.SetStatusCondition(&worked.Status.Conditions, metav1.Condition{Type: "Available", Status: metav1.ConditionTrue, Reason: "MinimumReplicasAvailable"})
+		_, err = r.updateStatus(ctx, workerRead, worked)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(app), app)).To(Succeed())
+		Expect(app.Status.ObservedGeneration).To(Equal(int64(2)))
+		Expect(app.Status.Replicas).To(Equal(int32(2)))
+		Expect(app.Status.Conditions).To(ConsistOf(
+			HaveField("Type", "Ready"),
+			HaveField("Type", "Available"),
+		), "the stale writer keeps the other's conditions and still removes its own")
+		Expect(worked.Status).To(Equal(app.Status), "the writer is left with the status written")
+	})
+
+	It("should copy the Deployment's ready replicas in the status worker", func() {
+		app := newTestApp(webappv1.AppSpec{})
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-deployment", Namespace: "default"},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 2},
+		}
+		r, c := newTestReconciler(app, deployment)
+
+		_, err := r.reconcileStatus(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(app)})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(app), app)).To(Succeed())
+		Expect(app.Status.Replicas).To(Equal(int32(2)))
+
+		ready := deployment.DeepCopy()
+		ready.Status.ReadyReplicas = 3
+		Expect(deploymentStatusChanged.Update(event.UpdateEvent{ObjectOld: deployment, ObjectNew: ready})).To(BeTrue())
+		Expect(specChanged.Update(event.UpdateEvent{ObjectOld: deployment, ObjectNew: ready})).To(BeFalse())
+	})
+})
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// storageVolumeName is the name of the pod volume backed by the App's PersistentVolumeClaim.
+const storageVolumeName = "data"
+
+// claimName returns the name of the App's PersistentVolumeClaim.
+func claimName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-data", app.Name)
+}
+
+// storageAccessModes returns the access modes of the App's volume, ReadWriteOnce by default.
+func storageAccessModes(app *webappv1.App) []corev1.PersistentVolumeAccessMode {
+	if len(app.Spec.Storage.AccessModes) == 0 {
+		return []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	return app.Spec.Storage.AccessModes
+}
+
+// storageReadOnly reports whether the App's volume can only be mounted read-only.
+func storageReadOnly(app *webappv1.App) bool {
+	for _, mode := range storageAccessModes(app) {
+		if mode != corev1.ReadOnlyMany {
+			return false
+		}
+	}
+	return true
+}
+
+// singleNodeStorage reports whether the App's volume can only be mounted on one node at a time.
+func singleNodeStorage(app *webappv1.App) bool {
+	if app.Spec.Storage == nil {
+		return false
+	}
+	for _, mode := range storageAccessModes(app) {
+		if mode == corev1.ReadWriteMany || mode == corev1.ReadOnlyMany {
+			return false
+		}
+	}
+	return true
+}
+
+// deploymentStrategy returns the strategy of the App's Deployment: Recreate when its volume is
+// single-node, as the pods of a rolling update would wait for the volume of the pods they
+// replace, and the server's default rolling update otherwise.
+func deploymentStrategy(app *webappv1.App) appsv1.DeploymentStrategy {
+	if singleNodeStorage(app) {
+		return appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+	return appsv1.DeploymentStrategy{}
+}
+
+// storageVolumes returns the volume backed by the App's PersistentVolumeClaim, with its mount.
+func storageVolumes(app *webappv1.App) ([]corev1.Volume, []corev1.VolumeMount) {
+	if app.Spec.Storage == nil {
+		return nil, nil
+	}
+	volume := corev1.Volume{
+		Name: storageVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: claimName(app),
+				ReadOnly:  storageReadOnly(app),
+			},
+		},
+	}
+	mount := corev1.VolumeMount{Name: storageVolumeName, MountPath: app.Spec.Storage.MountPath, ReadOnly: storageReadOnly(app)}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}
+}
+
+// desiredPersistentVolumeClaim returns the PersistentVolumeClaim of the App's spec.storage,
+// without owner.
+func desiredPersistentVolumeClaim(app *webappv1.App) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        claimName(app),
+			Namespace:   app.Namespace,
+			Annotations: attributionAnnotations(app),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      storageAccessModes(app),
+			StorageClassName: app.Spec.Storage.StorageClassName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: app.Spec.Storage.Size},
+			},
+		},
+	}
+}
+
+// reconcilePersistentVolumeClaim creates the PersistentVolumeClaim of an App with
+// spec.storage, or expands it when spec.storage.size grows; the rest of a claim's spec is
+// immutable. It returns the claim as last written or read, nil for Apps without storage. A
+// claim left by an App that no longer has storage is kept with its data until the App is
+// deleted. A claim of that name not created for the App is an error, as its pods would
+// otherwise read and write someone else's data.
+func (r *AppReconciler) reconcilePersistentVolumeClaim(ctx context.Context, app *webappv1.App) (*corev1.PersistentVolumeClaim, error) {
+	log := log.FromContext(ctx)
+
+	if app.Spec.Storage == nil {
+		return nil, nil
+	}
+
+	desired := desiredPersistentVolumeClaim(app)
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return nil, err
+	}
+	found := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, found)
+	if errors.IsNotFound(err) {
+		log.Info("Creating a new PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", desired.Namespace, "PersistentVolumeClaim.Name", desired.Name)
+		err = r.createChild(ctx, app, desired)
+		if errors.IsAlreadyExists(err) {
+			// The cache only holds labelled claims, so an unlabelled one of the same name is not seen.
+			return nil, fmt.Errorf("PersistentVolumeClaim %q exists and is not managed by the controller", desired.Name)
+		} else if err != nil {
+			return nil, err
+		}
+		return desired, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !metav1.IsControlledBy(found, app) {
+		return nil, fmt.Errorf("PersistentVolumeClaim %q exists and is not owned by the App", desired.Name)
+	}
+
+	updated := found.DeepCopy()
+	updated.Labels = mergeMaps(updated.Labels, desired.Labels)
+	if app.Spec.Storage.Size.Cmp(found.Spec.Resources.Requests[corev1.ResourceStorage]) > 0 {
+		updated.Spec.Resources.Requests = updated.Spec.Resources.Requests.DeepCopy()
+		updated.Spec.Resources.Requests[corev1.ResourceStorage] = app.Spec.Storage.Size
+	}
+	syncAttribution(app, updated)
+	if equality.Semantic.DeepEqual(found, updated) {
+		return found, nil
+	}
+	log.Info("Updating existing PersistentVolumeClaim", "PersistentVolumeClaim.Namespace", found.Namespace, "PersistentVolumeClaim.Name", found.Name)
+	if err := r.updateChild(ctx, app, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// storageStatus returns the state of the App's PersistentVolumeClaim, nil when there is none.
+func storageStatus(claim *corev1.PersistentVolumeClaim) *webappv1.StorageStatus {
+	if claim == nil {
+		return nil
+	}
+	status := &webappv1.StorageStatus{ClaimName: claim.Name, Phase: claim.Status.Phase}
+	if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok {
+		status.Capacity = &capacity
+	}
+	return status
+}
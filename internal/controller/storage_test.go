@@ -0,0 +1,75 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Persistent storage", func() {
+	It("should create, mount and expand the App's PersistentVolumeClaim and report its phase", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+			Spec: webappv1.AppSpec{Image: "web:1.0", Port: 8080, Storage: &webappv1.StorageSpec{
+				Size: resource.MustParse("1Gi"), StorageClassName: ptr.To("fast"), MountPath: "/var/lib/web",
+			}},
+		}
+		r, c := newTestReconciler()
+
+		claim, err := r.reconcilePersistentVolumeClaim(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(claim.Name).To(Equal("web-data"))
+		Expect(claim.Spec.AccessModes).To(Equal([]corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}))
+		Expect(claim.Spec.StorageClassName).To(Equal(ptr.To("fast")))
+		Expect(metav1.IsControlledBy(claim, app)).To(BeTrue())
+		Expect(storageStatus(claim)).To(Equal(&webappv1.StorageStatus{ClaimName: "web-data"}))
+
+		deployment := r.desiredDeployment(app, app.Spec.Image)
+		Expect(deployment.Spec.Strategy.Type).To(Equal(appsv1.RecreateDeploymentStrategyType), "pods don't wait for a single-node volume")
+		Expect(deployment.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("PersistentVolumeClaim.ClaimName", "web-data")))
+		Expect(deployment.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "data", MountPath: "/var/lib/web"}))
+
+		By("expanding the claim without ever shrinking it")
+		app.Spec.Storage.Size = resource.MustParse("5Gi")
+		claim, err = r.reconcilePersistentVolumeClaim(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal("5Gi"))
+		app.Spec.Storage.Size = resource.MustParse("2Gi")
+		claim, err = r.reconcilePersistentVolumeClaim(ctx, app)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(claim.Spec.Resources.Requests.Storage().String()).To(Equal("5Gi"))
+
+		claim.Status = corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound, Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")}}
+		Expect(storageStatus(claim)).To(Equal(&webappv1.StorageStatus{ClaimName: "web-data", Phase: corev1.ClaimBound, Capacity: ptr.To(resource.MustParse("5Gi"))}))
+
+		By("keeping the claim and its data when the App no longer mounts it")
+		app.Spec.Storage = nil
+		Expect(r.reconcilePersistentVolumeClaim(ctx, app)).To(BeNil())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(claim), &corev1.PersistentVolumeClaim{})).To(Succeed())
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Strategy.Type).To(BeEmpty())
+	})
+})
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// conditionSuspended reports whether the controller leaves the App's objects as they are,
+// per spec.suspend.
+const conditionSuspended = "Suspended"
+
+// reconcileSuspended reports an App with spec.suspend without creating, updating or deleting
+// any of its objects. The status worker keeps its ready replicas and rollout conditions up to
+// date (see reconcileStatus), while status.observedGeneration stays at the last generation
+// applied.
+func (r *AppReconciler) reconcileSuspended(ctx context.Context, original, app *webappv1.App) (ctrl.Result, error) {
+	meta.SetStatusCondition(&app.Status.Conditions, metav1.Condition{
+		Type:               conditionSuspended,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Suspended",
+		Message:            "The App's objects are not updated while spec.suspend is set",
+		ObservedGeneration: app.Generation,
+	})
+	statusWait, err := r.updateStatus(ctx, original, app)
+	return ctrl.Result{RequeueAfter: statusWait}, err
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Suspended Apps", func() {
+	It("should leave the objects of a suspended App as they are while updating its status", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid", Generation: 2},
+			Spec:       webappv1.AppSpec{Image: "web:2.0", Port: 8080, Suspend: true},
+		}
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-deployment", Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "web:1.0"}},
+			}}},
+		}
+		Expect(controllerutil.SetControllerReference(app, deployment, scheme.Scheme)).To(Succeed())
+		r, c := newTestReconciler(app, deployment)
+		req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(app)}
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, req.NamespacedName, app)).To(Succeed())
+		Expect(app.Finalizers).To(ContainElement(appCleanupFinalizer))
+		Expect(meta.IsStatusConditionTrue(app.Status.Conditions, conditionSuspended)).To(BeTrue())
+		Expect(app.Status.ObservedGeneration).To(BeZero())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("web:1.0"))
+		Expect(errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "web-service", Namespace: "default"}, &corev1.Service{}))).To(BeTrue())
+
+		deployment.Status.ReadyReplicas = 1
+		Expect(c.Status().Update(ctx, deployment)).To(Succeed())
+		_, err = r.reconcileStatus(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, req.NamespacedName, app)).To(Succeed())
+		Expect(app.Status.Replicas).To(Equal(int32(1)))
+
+		By("resuming the App")
+		app.Spec.Suspend = false
+		Expect(c.Update(ctx, app)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, req.NamespacedName, app)).To(Succeed())
+		Expect(meta.FindStatusCondition(app.Status.Conditions, conditionSuspended)).To(BeNil())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(deployment), deployment)).To(Succeed())
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal("web:2.0"))
+	})
+})
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// templateVariables are the built-in variables of templated spec values.
+type templateVariables struct {
+	// Name and Namespace of the App.
+	Name, Namespace string
+	// Labels of the App.
+	Labels map[string]string
+	// ClusterName is the name of the cluster the controller runs in, from its configuration.
+	ClusterName string
+}
+
+// renderTemplate resolves a templated spec value. Values without an action are returned as is.
+func (r *AppReconciler) renderTemplate(app *webappv1.App, value string) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+	tmpl, err := template.New("").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	err = tmpl.Execute(&out, templateVariables{
+		Name:        app.Name,
+		Namespace:   app.Namespace,
+		Labels:      app.Labels,
+		ClusterName: r.ClusterName,
+	})
+	return out.String(), err
+}
+
+// checkTemplates verifies that every templated spec value of the App resolves, so rendering
+// objects from the spec cannot fail later on.
+func (r *AppReconciler) checkTemplates(app *webappv1.App) error {
+	var invalid []string
+	for _, key := range slices.Sorted(maps.Keys(app.Spec.PodAnnotations)) {
+		if _, err := r.renderTemplate(app, app.Spec.PodAnnotations[key]); err != nil {
+			invalid = append(invalid, fmt.Sprintf("spec.podAnnotations[%s]: %v", key, err))
+		}
+	}
+	for _, v := range app.Spec.Env {
+		if _, err := r.renderTemplate(app, v.Value); err != nil {
+			invalid = append(invalid, fmt.Sprintf("spec.env[%s]: %v", v.Name, err))
+		}
+	}
+	if app.Spec.Expose != nil {
+		host, err := r.renderTemplate(app, app.Spec.Expose.Host)
+		if err == nil {
+			if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+				err = fmt.Errorf("%q is not a valid host name: %s", host, strings.Join(errs, ", "))
+			}
+		}
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("spec.expose.host: %v", err))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid templates: %s", strings.Join(invalid, "; "))
+	}
+	return nil
+}
+
+// podAnnotations returns the App's spec.podAnnotations with their templates resolved. Values
+// failing to resolve are left out; checkTemplates reports them before anything is applied.
+func (r *AppReconciler) podAnnotations(app *webappv1.App) map[string]string {
+	var annotations map[string]string
+	for key, value := range app.Spec.PodAnnotations {
+		rendered, err := r.renderTemplate(app, value)
+		if err != nil {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[key] = rendered
+	}
+	return annotations
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Templated spec values", func() {
+	It("should resolve built-in variables in pod annotations", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Labels: map[string]string{"team": "checkout"}},
+			Spec: webappv1.AppSpec{
+				Image: "web:1.0",
+				PodAnnotations: map[string]string{
+					"example.com/dashboard": "https://grafana.{{ .ClusterName }}.example.com/d/{{ .Namespace }}-{{ .Name }}",
+					"example.com/owner":     `{{ index .Labels "team" }}`,
+					"example.com/static":    "plain",
+				},
+			},
+		}
+		r := &AppReconciler{ClusterName: "eu-1"}
+
+		Expect(r.checkTemplates(app)).To(Succeed())
+		Expect(r.desiredDeployment(app, app.Spec.Image).Spec.Template.Annotations).To(Equal(map[string]string{
+			"example.com/dashboard": "https://grafana.eu-1.example.com/d/shop-web",
+			"example.com/owner":     "checkout",
+			"example.com/static":    "plain",
+		}))
+	})
+
+	It("should report templates that don't resolve", func() {
+		app := &webappv1.App{Spec: webappv1.AppSpec{PodAnnotations: map[string]string{
+			"a": "{{ .Region }}",
+			"b": "{{ .Name",
+		}}}
+
+		err := (&AppReconciler{}).checkTemplates(app)
+		Expect(err).To(MatchError(ContainSubstring("spec.podAnnotations[a]")))
+		Expect(err).To(MatchError(ContainSubstring("spec.podAnnotations[b]")))
+	})
+})
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// tlsVolumeName is the name of the volume carrying the App's serving certificate.
+	tlsVolumeName = "tls"
+	// defaultTLSMountPath is used for Apps stored before the API server defaulted spec.tls.mountPath.
+	defaultTLSMountPath = "/etc/tls"
+	// caCertKey is the key of the issuing CA's certificate, in cert-manager Secrets and in
+	// the ConfigMap Gateways validate the App's certificate with.
+	caCertKey = "ca.crt"
+)
+
+// certificateGVK identifies cert-manager's Certificate. It is handled as unstructured so
+// cert-manager is only required by Apps that use spec.tls.
+var certificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// backendTLSPolicyGVK identifies the Gateway API BackendTLSPolicy. It is handled as
+// unstructured so the Gateway API CRDs are only required by Apps exposed through an HTTPRoute.
+var backendTLSPolicyGVK = schema.GroupVersionKind{
+	Group:   "gateway.networking.k8s.io",
+	Version: "v1",
+	Kind:    "BackendTLSPolicy",
+}
+
+// tlsSecretName returns the name of the Secret cert-manager stores the App's certificate in.
+// The Certificate itself uses the same name.
+func tlsSecretName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-tls", app.Name)
+}
+
+// serviceDNSNames returns the in-cluster DNS names of the App's Service.
+func serviceDNSNames(app *webappv1.App) []string {
+	service := fmt.Sprintf("%s-service", app.Name)
+	return []string{
+		service,
+		fmt.Sprintf("%s.%s", service, app.Namespace),
+		fmt.Sprintf("%s.%s.svc", service, app.Namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", service, app.Namespace),
+	}
+}
+
+// tlsVolumes returns the volume and mount exposing the App's serving certificate.
+func tlsVolumes(app *webappv1.App) ([]corev1.Volume, []corev1.VolumeMount) {
+	if app.Spec.TLS == nil {
+		return nil, nil
+	}
+	mountPath := app.Spec.TLS.MountPath
+	if mountPath == "" {
+		mountPath = defaultTLSMountPath
+	}
+	// Set the default mode explicitly so the template matches what the API server stores.
+	defaultMode := corev1.SecretVolumeSourceDefaultMode
+	volumes := []corev1.Volume{{
+		Name: tlsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  tlsSecretName(app),
+				DefaultMode: &defaultMode,
+			},
+		},
+	}}
+	mounts := []corev1.VolumeMount{{
+		Name:      tlsVolumeName,
+		MountPath: mountPath,
+		ReadOnly:  true,
+	}}
+	return volumes, mounts
+}
+
+// desiredCertificate builds the cert-manager Certificate for the App's Service.
+func desiredCertificate(app *webappv1.App) *unstructured.Unstructured {
+	kind := app.Spec.TLS.IssuerRef.Kind
+	if kind == "" {
+		kind = "Issuer"
+	}
+	dnsNames := make([]interface{}, 0, 4)
+	for _, name := range serviceDNSNames(app) {
+		dnsNames = append(dnsNames, name)
+	}
+
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(certificateGVK)
+	cert.SetName(tlsSecretName(app))
+	cert.SetNamespace(app.Namespace)
+	cert.SetLabels(map[string]string{
+		"app":        app.Name,
+		"controller": "app-controller",
+	})
+	cert.Object["spec"] = map[string]interface{}{
+		"secretName": tlsSecretName(app),
+		"dnsNames":   dnsNames,
+		"issuerRef": map[string]interface{}{
+			"name":  app.Spec.TLS.IssuerRef.Name,
+			"kind":  kind,
+			"group": certificateGVK.Group,
+		},
+	}
+	return cert
+}
+
+// reconcileCertificate creates or updates the serving Certificate of an App using spec.tls,
+// and removes a previously generated one when TLS is turned off.
+func (r *AppReconciler) reconcileCertificate(ctx context.Context, app *webappv1.App) error {
+	var desired *unstructured.Unstructured
+	if app.Spec.TLS != nil {
+		desired = desiredCertificate(app)
+	}
+	return r.reconcileUnstructured(ctx, app, certificateGVK, tlsSecretName(app), desired)
+}
+
+// gatewayBackendTLS reports whether Gateways reach the App's pods over TLS, as the App
+// serves TLS and is exposed through an HTTPRoute.
+func gatewayBackendTLS(app *webappv1.App) bool {
+	return app.Spec.TLS != nil && exposeMode(app) == webappv1.ExposeModeHTTPRoute
+}
+
+// backendCAName returns the name of the ConfigMap holding the CA certificate Gateways
+// validate the App's serving certificate with. The BackendTLSPolicy uses the same name.
+func backendCAName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-backend-ca", app.Name)
+}
+
+// desiredBackendTLSPolicy builds the BackendTLSPolicy telling Gateways to speak TLS to the
+// App's Service, checking its certificate against the issuing CA and the Service's DNS name.
+func desiredBackendTLSPolicy(app *webappv1.App) *unstructured.Unstructured {
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(backendTLSPolicyGVK)
+	policy.SetName(backendCAName(app))
+	policy.SetNamespace(app.Namespace)
+	policy.SetLabels(map[string]string{
+		"app":        app.Name,
+		"controller": "app-controller",
+	})
+	policy.Object["spec"] = map[string]interface{}{
+		"targetRefs": []interface{}{map[string]interface{}{
+			"group": "",
+			"kind":  "Service",
+			"name":  fmt.Sprintf("%s-service", app.Name),
+		}},
+		"validation": map[string]interface{}{
+			"caCertificateRefs": []interface{}{map[string]interface{}{
+				"group": "",
+				"kind":  "ConfigMap",
+				"name":  backendCAName(app),
+			}},
+			"hostname": fmt.Sprintf("%s-service.%s.svc", app.Name, app.Namespace),
+		},
+	}
+	return policy
+}
+
+// reconcileBackendTLS creates or updates the BackendTLSPolicy of an App serving TLS behind a
+// Gateway, and the ConfigMap it trusts, and removes them otherwise. The CA certificate is
+// copied from the Secret cert-manager issues the serving certificate to, read without the
+// cache; until it is issued the policy's reference stays unresolved and Gateways don't route
+// to the App rather than send it plaintext.
+func (r *AppReconciler) reconcileBackendTLS(ctx context.Context, app *webappv1.App) error {
+	var policy *unstructured.Unstructured
+	if gatewayBackendTLS(app) {
+		policy = desiredBackendTLSPolicy(app)
+	}
+	if err := r.reconcileUnstructured(ctx, app, backendTLSPolicyGVK, backendCAName(app), policy); err != nil {
+		return err
+	}
+	return r.reconcileBackendCA(ctx, app)
+}
+
+// reconcileBackendCA copies the CA certificate of the App's serving certificate into the
+// ConfigMap its BackendTLSPolicy trusts, and removes the ConfigMap when no policy needs it.
+func (r *AppReconciler) reconcileBackendCA(ctx context.Context, app *webappv1.App) error {
+	log := log.FromContext(ctx)
+
+	found := &corev1.ConfigMap{}
+	getErr := r.Get(ctx, types.NamespacedName{Name: backendCAName(app), Namespace: app.Namespace}, found)
+
+	var ca string
+	if gatewayBackendTLS(app) {
+		reader := r.APIReader
+		if reader == nil {
+			reader = r.Client
+		}
+		secret := &corev1.Secret{}
+		err := reader.Get(ctx, types.NamespacedName{Name: tlsSecretName(app), Namespace: app.Namespace}, secret)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		ca = string(secret.Data[caCertKey])
+	}
+
+	if ca == "" {
+		if errors.IsNotFound(getErr) {
+			return nil
+		}
+		if getErr != nil {
+			return getErr
+		}
+		// Keep the CA of an earlier certificate while the Secret is reissued.
+		if gatewayBackendTLS(app) || !metav1.IsControlledBy(found, app) {
+			return nil
+		}
+		log.Info("Deleting ConfigMap no longer used by App", "ConfigMap.Name", found.Name)
+		return r.deleteChild(ctx, app, found)
+	}
+
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        backendCAName(app),
+			Namespace:   app.Namespace,
+			Annotations: attributionAnnotations(app),
+			Labels: map[string]string{
+				"app":        app.Name,
+				"controller": "app-controller",
+			},
+		},
+		Data: map[string]string{caCertKey: ca},
+	}
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	if errors.IsNotFound(getErr) {
+		log.Info("Creating a new ConfigMap", "ConfigMap.Namespace", desired.Namespace, "ConfigMap.Name", desired.Name)
+		return r.createChild(ctx, app, desired)
+	} else if getErr != nil {
+		return getErr
+	}
+	if !metav1.IsControlledBy(found, app) {
+		return fmt.Errorf("ConfigMap %q exists and is not owned by the App", found.Name)
+	}
+
+	if syncAttribution(app, found) || !equality.Semantic.DeepEqual(found.Data, desired.Data) {
+		log.Info("Updating existing ConfigMap", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+		found.Data = desired.Data
+		return r.updateChild(ctx, app, found)
+	}
+	return nil
+}
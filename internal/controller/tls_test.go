@@ -0,0 +1,109 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Backend TLS", func() {
+	It("should request a certificate for the Service and mount it", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: webappv1.AppSpec{TLS: &webappv1.TLSSpec{
+				IssuerRef: webappv1.IssuerReference{Name: "internal-ca", Kind: "ClusterIssuer"},
+			}},
+		}
+
+		cert := desiredCertificate(app)
+		Expect(cert.GetName()).To(Equal("web-tls"))
+		dnsNames, _, _ := unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+		Expect(dnsNames).To(ContainElements("web-service", "web-service.shop.svc"))
+		kind, _, _ := unstructured.NestedString(cert.Object, "spec", "issuerRef", "kind")
+		Expect(kind).To(Equal("ClusterIssuer"))
+
+		volumes, mounts := tlsVolumes(app)
+		Expect(volumes[0].Secret.SecretName).To(Equal("web-tls"))
+		Expect(mounts[0].MountPath).To(Equal("/etc/tls"))
+		Expect(*servicePortAppProtocol(app)).To(Equal("https"))
+	})
+
+	It("should have Ingress controllers speak TLS to an exposed App", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Port:   8443,
+			TLS:    &webappv1.TLSSpec{IssuerRef: webappv1.IssuerReference{Name: "internal-ca"}},
+			Expose: &webappv1.ExposeSpec{Host: "web.example.com"},
+		})
+		Expect(desiredIngress(app, "web.example.com").Annotations).To(
+			HaveKeyWithValue("nginx.ingress.kubernetes.io/backend-protocol", "HTTPS"))
+
+		app.Spec.AppProtocol = webappv1.AppProtocolGRPC
+		Expect(desiredIngress(app, "web.example.com").Annotations).To(
+			HaveKeyWithValue("nginx.ingress.kubernetes.io/backend-protocol", "GRPCS"))
+
+		app.Spec.TLS = nil
+		Expect(desiredIngress(app, "web.example.com").Annotations).NotTo(HaveKey("nginx.ingress.kubernetes.io/backend-protocol"))
+	})
+
+	It("should have Gateways speak TLS to an exposed App and trust its issuer", func() {
+		app := newTestApp(webappv1.AppSpec{
+			Port: 8443,
+			TLS:  &webappv1.TLSSpec{IssuerRef: webappv1.IssuerReference{Name: "internal-ca"}},
+			Expose: &webappv1.ExposeSpec{
+				Host:    "web.example.com",
+				Mode:    webappv1.ExposeModeHTTPRoute,
+				Gateway: &webappv1.GatewayReference{Name: "public"},
+			},
+		})
+		r, c := newTestReconciler(app)
+		policyKey := types.NamespacedName{Name: "web-backend-ca", Namespace: "default"}
+
+		Expect(r.reconcileBackendTLS(ctx, app)).To(Succeed())
+		policy := &unstructured.Unstructured{}
+		policy.SetGroupVersionKind(backendTLSPolicyGVK)
+		Expect(c.Get(ctx, policyKey, policy)).To(Succeed())
+		target, _, _ := unstructured.NestedSlice(policy.Object, "spec", "targetRefs")
+		Expect(target).To(ConsistOf(HaveKeyWithValue("name", "web-service")))
+		hostname, _, _ := unstructured.NestedString(policy.Object, "spec", "validation", "hostname")
+		Expect(hostname).To(Equal("web-service.default.svc"))
+		Expect(c.Get(ctx, policyKey, &corev1.ConfigMap{})).NotTo(Succeed(), "the CA is copied once the certificate is issued")
+
+		issued := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "default"},
+			Data:       map[string][]byte{"ca.crt": []byte("-----BEGIN CERTIFICATE-----")},
+		}
+		Expect(c.Create(ctx, issued)).To(Succeed())
+		Expect(r.reconcileBackendTLS(ctx, app)).To(Succeed())
+		ca := &corev1.ConfigMap{}
+		Expect(c.Get(ctx, policyKey, ca)).To(Succeed())
+		Expect(ca.Data).To(HaveKeyWithValue("ca.crt", "-----BEGIN CERTIFICATE-----"))
+		Expect(metav1.IsControlledBy(ca, app)).To(BeTrue())
+
+		app.Spec.TLS = nil
+		Expect(r.reconcileBackendTLS(ctx, app)).To(Succeed())
+		Expect(c.Get(ctx, policyKey, policy)).NotTo(Succeed())
+		Expect(c.Get(ctx, policyKey, ca)).NotTo(Succeed())
+	})
+})
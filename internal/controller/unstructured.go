@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// reconcileUnstructured creates or updates an App-owned object of a kind provided by a
+// third-party CRD (cert-manager, the Secrets Store CSI driver, ...), syncing its labels and spec.
+// A nil desired object means the App no longer needs the object named name, and a copy
+// previously created by the controller is deleted. Kinds whose CRD is not installed are
+// only an error when the object is actually wanted.
+func (r *AppReconciler) reconcileUnstructured(ctx context.Context, app *webappv1.App, gvk schema.GroupVersionKind, name string, desired *unstructured.Unstructured) error {
+	log := log.FromContext(ctx)
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(gvk)
+	getErr := r.Get(ctx, types.NamespacedName{Name: name, Namespace: app.Namespace}, found)
+
+	if desired == nil {
+		// Nothing to clean up if the object, or its CRD, is absent.
+		if errors.IsNotFound(getErr) || meta.IsNoMatchError(getErr) {
+			return nil
+		}
+		if getErr != nil {
+			return getErr
+		}
+		if !metav1.IsControlledBy(found, app) {
+			return nil
+		}
+		log.Info("Deleting "+gvk.Kind+" no longer used by App", gvk.Kind+".Name", name)
+		return r.deleteChild(ctx, app, found)
+	}
+
+	desired.SetAnnotations(mergeMaps(desired.GetAnnotations(), attributionAnnotations(app)))
+	if err := ctrl.SetControllerReference(app, desired, r.Scheme); err != nil {
+		return err
+	}
+
+	if errors.IsNotFound(getErr) {
+		log.Info("Creating a new "+gvk.Kind, gvk.Kind+".Namespace", desired.GetNamespace(), gvk.Kind+".Name", desired.GetName())
+		return r.createChild(ctx, app, desired)
+	} else if getErr != nil {
+		return getErr
+	}
+
+	updated := found.DeepCopy()
+	updated.SetLabels(mergeMaps(updated.GetLabels(), desired.GetLabels()))
+	updated.Object["spec"] = desired.Object["spec"]
+	syncAttribution(app, updated)
+	_, err := r.syncChild(ctx, r.Client, app, found, updated)
+	return err
+}
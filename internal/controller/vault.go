@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+const (
+	// vaultSecretsPath is where Vault secrets are rendered in the app container.
+	// It matches the default of the Vault Agent injector so both modes look the same to the app.
+	vaultSecretsPath = "/vault/secrets"
+	// vaultVolumeName is the name of the CSI volume carrying Vault secrets.
+	vaultVolumeName = "vault-secrets"
+	// secretsStoreCSIDriver is the name of the Secrets Store CSI driver.
+	secretsStoreCSIDriver = "secrets-store.csi.k8s.io"
+)
+
+// secretProviderClassGVK identifies the Secrets Store CSI driver's SecretProviderClass.
+// The object is handled as unstructured so the driver's CRDs are only needed when CSI mode is used.
+var secretProviderClassGVK = schema.GroupVersionKind{
+	Group:   "secrets-store.csi.x-k8s.io",
+	Version: "v1",
+	Kind:    "SecretProviderClass",
+}
+
+// vaultMode returns the configured Vault delivery mode, or "" when Vault is not used.
+func vaultMode(app *webappv1.App) webappv1.VaultMode {
+	if app.Spec.Vault == nil {
+		return ""
+	}
+	if app.Spec.Vault.Mode == "" {
+		return webappv1.VaultModeAgentInjector
+	}
+	return app.Spec.Vault.Mode
+}
+
+// secretProviderClassName returns the name of the SecretProviderClass generated for an App.
+func secretProviderClassName(app *webappv1.App) string {
+	return fmt.Sprintf("%s-vault", app.Name)
+}
+
+// vaultAnnotations returns the Vault Agent injector annotations for the App's pods.
+func vaultAnnotations(app *webappv1.App) map[string]string {
+	if vaultMode(app) != webappv1.VaultModeAgentInjector {
+		return nil
+	}
+	annotations := map[string]string{
+		"vault.hashicorp.com/agent-inject": "true",
+		"vault.hashicorp.com/role":         app.Spec.Vault.Role,
+	}
+	for _, secret := range app.Spec.Vault.Secrets {
+		annotations["vault.hashicorp.com/agent-inject-secret-"+secret.Name] = secret.Path
+		if secret.Template != "" {
+			annotations["vault.hashicorp.com/agent-inject-template-"+secret.Name] = secret.Template
+		}
+	}
+	return annotations
+}
+
+// vaultVolumes returns the CSI volume and matching mount used in CSI mode.
+func vaultVolumes(app *webappv1.App) ([]corev1.Volume, []corev1.VolumeMount) {
+	if vaultMode(app) != webappv1.VaultModeCSI {
+		return nil, nil
+	}
+	readOnly := true
+	volumes := []corev1.Volume{{
+		Name: vaultVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			CSI: &corev1.CSIVolumeSource{
+				Driver:           secretsStoreCSIDriver,
+				ReadOnly:         &readOnly,
+				VolumeAttributes: map[string]string{"secretProviderClass": secretProviderClassName(app)},
+			},
+		},
+	}}
+	mounts := []corev1.VolumeMount{{
+		Name:      vaultVolumeName,
+		MountPath: vaultSecretsPath,
+		ReadOnly:  true,
+	}}
+	return volumes, mounts
+}
+
+// desiredSecretProviderClass builds the SecretProviderClass describing the App's Vault secrets.
+func desiredSecretProviderClass(app *webappv1.App) (*unstructured.Unstructured, error) {
+	objects := make([]map[string]string, 0, len(app.Spec.Vault.Secrets))
+	for _, secret := range app.Spec.Vault.Secrets {
+		object := map[string]string{
+			"objectName": secret.Name,
+			"secretPath": secret.Path,
+		}
+		if secret.Key != "" {
+			object["secretKey"] = secret.Key
+		}
+		objects = append(objects, object)
+	}
+	// The Vault provider expects its objects as a YAML document embedded in a string parameter.
+	objectsYAML, err := yaml.Marshal(objects)
+	if err != nil {
+		return nil, err
+	}
+
+	spc := &unstructured.Unstructured{}
+	spc.SetGroupVersionKind(secretProviderClassGVK)
+	spc.SetName(secretProviderClassName(app))
+	spc.SetNamespace(app.Namespace)
+	spc.SetLabels(map[string]string{
+		"app":        app.Name,
+		"controller": "app-controller",
+	})
+	spc.Object["spec"] = map[string]interface{}{
+		"provider": "vault",
+		"parameters": map[string]interface{}{
+			"vaultAddress": app.Spec.Vault.Address,
+			"roleName":     app.Spec.Vault.Role,
+			"objects":      string(objectsYAML),
+		},
+	}
+	return spc, nil
+}
+
+// reconcileSecretProviderClass creates or updates the SecretProviderClass of an App in
+// Vault CSI mode, and removes a previously generated one when CSI mode is no longer used.
+func (r *AppReconciler) reconcileSecretProviderClass(ctx context.Context, app *webappv1.App) error {
+	var desired *unstructured.Unstructured
+	if vaultMode(app) == webappv1.VaultModeCSI {
+		var err error
+		if desired, err = desiredSecretProviderClass(app); err != nil {
+			return err
+		}
+	}
+	return r.reconcileUnstructured(ctx, app, secretProviderClassGVK, secretProviderClassName(app), desired)
+}
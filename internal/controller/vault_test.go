@@ -0,0 +1,71 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Vault integration", func() {
+	It("should render agent injector annotations and mount the API token", func() {
+		app := &webappv1.App{Spec: webappv1.AppSpec{Vault: &webappv1.VaultSpec{
+			Role:    "web",
+			Secrets: []webappv1.VaultSecret{{Name: "db", Path: "secret/data/web/db", Template: "{{ .Data.data.password }}"}},
+		}}}
+
+		annotations := vaultAnnotations(app)
+		Expect(annotations).To(HaveKeyWithValue("vault.hashicorp.com/agent-inject", "true"))
+		Expect(annotations).To(HaveKeyWithValue("vault.hashicorp.com/role", "web"))
+		Expect(annotations).To(HaveKeyWithValue("vault.hashicorp.com/agent-inject-secret-db", "secret/data/web/db"))
+		Expect(annotations).To(HaveKey("vault.hashicorp.com/agent-inject-template-db"))
+		Expect(*automountServiceAccountToken(app)).To(BeTrue())
+
+		volumes, mounts := vaultVolumes(app)
+		Expect(volumes).To(BeEmpty())
+		Expect(mounts).To(BeEmpty())
+	})
+
+	It("should mount a SecretProviderClass volume in CSI mode", func() {
+		app := &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: webappv1.AppSpec{Vault: &webappv1.VaultSpec{
+				Mode:    webappv1.VaultModeCSI,
+				Role:    "web",
+				Address: "https://vault.example.com",
+				Secrets: []webappv1.VaultSecret{{Name: "db", Path: "secret/data/web/db", Key: "password"}},
+			}},
+		}
+
+		Expect(vaultAnnotations(app)).To(BeEmpty())
+		volumes, mounts := vaultVolumes(app)
+		Expect(volumes).To(HaveLen(1))
+		Expect(volumes[0].CSI.VolumeAttributes).To(HaveKeyWithValue("secretProviderClass", "web-vault"))
+		Expect(mounts[0].MountPath).To(Equal("/vault/secrets"))
+
+		spc, err := desiredSecretProviderClass(app)
+		Expect(err).NotTo(HaveOccurred())
+		objects, _, _ := unstructured.NestedString(spc.Object, "spec", "parameters", "objects")
+		Expect(objects).To(ContainSubstring("secretPath: secret/data/web/db"))
+		Expect(objects).To(ContainSubstring("secretKey: password"))
+	})
+})
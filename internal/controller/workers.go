@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller/priorityqueue"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// workerScaleInterval is how often the number of reconcile workers is adjusted.
+	workerScaleInterval = 15 * time.Second
+	// targetQueueLatency is how long Apps may wait in the work queue before more workers are added.
+	targetQueueLatency = time.Second
+)
+
+// workerScaler bounds how many of the controller's reconcile workers run at once, between
+// min and max. controller-runtime starts a fixed number of workers, so max of them are
+// started and the ones above the current limit wait in the Get of the work queue returned by
+// newQueue, before taking an App off it. The limit doubles while Apps queue up for longer
+// than targetQueueLatency, and shrinks by one once the queue is idle.
+type workerScaler struct {
+	name     string // Name of the controller, as in its work queue metrics
+	min, max int
+
+	mu       sync.Mutex
+	queue    workqueue.TypedRateLimitingInterface[reconcile.Request] // Set once the controller starts
+	limit    int
+	active   int
+	shutdown bool          // Set once the queue shuts down
+	wake     chan struct{} // Closed whenever a worker may proceed
+
+	waitSum, waitCount float64 // Queue latency totals at the last adjustment
+}
+
+// newWorkerScaler returns a workerScaler starting with min workers.
+func newWorkerScaler(name string, minWorkers, maxWorkers int) *workerScaler {
+	return &workerScaler{name: name, min: minWorkers, max: maxWorkers, limit: minWorkers, wake: make(chan struct{})}
+}
+
+// newQueue wraps newPriorityQueue to hold workers above the limit back, and keeps the
+// controller's work queue, whose depth drives the number of workers.
+func (s *workerScaler) newQueue(controllerName string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	queue := gatedQueue{
+		PriorityQueue: newPriorityQueue(controllerName, rateLimiter).(priorityqueue.PriorityQueue[reconcile.Request]),
+		workers:       s,
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = queue
+	return queue
+}
+
+// acquire blocks until a worker may take an App off the queue. It returns false once the
+// queue shuts down.
+func (s *workerScaler) acquire() bool {
+	for {
+		s.mu.Lock()
+		if s.shutdown {
+			s.mu.Unlock()
+			return false
+		}
+		if s.active < s.limit {
+			s.active++
+			s.mu.Unlock()
+			return true
+		}
+		wake := s.wake
+		s.mu.Unlock()
+		<-wake
+	}
+}
+
+// release ends a reconcile started by acquire.
+func (s *workerScaler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	s.notify()
+}
+
+// stop lets the workers waiting in acquire return.
+func (s *workerScaler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shutdown = true
+	s.notify()
+}
+
+// notify wakes up waiting workers. s.mu must be held.
+func (s *workerScaler) notify() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// Start implements manager.Runnable. It adjusts the limit until ctx is done.
+func (s *workerScaler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(workerScaleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.mu.Lock()
+			queue := s.queue
+			s.mu.Unlock()
+			if queue != nil {
+				s.scale(queue.Len(), s.queueLatency())
+			}
+		}
+	}
+}
+
+// scale adjusts the limit to the queue depth and the mean time Apps waited in the queue
+// since the last adjustment.
+func (s *workerScaler) scale(depth int, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	limit := s.limit
+	switch {
+	case depth > limit && latency > targetQueueLatency:
+		limit = min(s.max, limit*2)
+	case depth == 0 && latency < targetQueueLatency/4:
+		limit = max(s.min, limit-1)
+	}
+	if limit == s.limit {
+		return
+	}
+	logf.Log.WithName("workers").Info("Adjusting reconcile workers", "controller", s.name, "from", s.limit, "to", limit, "queueDepth", depth, "queueLatency", latency)
+	s.limit = limit
+	s.notify()
+}
+
+// gatedQueue is the work queue of a controller with a workerScaler. Workers above the limit
+// wait before taking an App off the queue rather than after, so the App keeps its place by
+// priority, and the time it waits is counted in the queue's metrics, which drive the limit.
+type gatedQueue struct {
+	priorityqueue.PriorityQueue[reconcile.Request]
+	workers *workerScaler
+}
+
+// Get implements workqueue.Interface.
+func (q gatedQueue) Get() (reconcile.Request, bool) {
+	item, _, shutdown := q.GetWithPriority()
+	return item, shutdown
+}
+
+// GetWithPriority implements priorityqueue.PriorityQueue. It waits for the worker to be
+// allowed to reconcile; Done ends the reconcile.
+func (q gatedQueue) GetWithPriority() (reconcile.Request, int, bool) {
+	if !q.workers.acquire() {
+		return reconcile.Request{}, 0, true
+	}
+	item, priority, shutdown := q.PriorityQueue.GetWithPriority()
+	if shutdown {
+		q.workers.release()
+	}
+	return item, priority, shutdown
+}
+
+// Done implements workqueue.Interface.
+func (q gatedQueue) Done(item reconcile.Request) {
+	q.PriorityQueue.Done(item)
+	q.workers.release()
+}
+
+// ShutDown implements workqueue.Interface.
+func (q gatedQueue) ShutDown() {
+	q.PriorityQueue.ShutDown()
+	q.workers.stop()
+}
+
+// ShutDownWithDrain implements workqueue.Interface.
+func (q gatedQueue) ShutDownWithDrain() {
+	q.PriorityQueue.ShutDownWithDrain()
+	q.workers.stop()
+}
+
+// queueLatency returns the mean time Apps waited in the work queue since the last call,
+// from the controller's work queue metrics.
+func (s *workerScaler) queueLatency() time.Duration {
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		return 0
+	}
+	var sum, count float64
+	for _, family := range families {
+		if family.GetName() != "workqueue_queue_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == s.name {
+					sum += metric.GetHistogram().GetSampleSum()
+					count += float64(metric.GetHistogram().GetSampleCount())
+				}
+			}
+		}
+	}
+	deltaSum, deltaCount := sum-s.waitSum, count-s.waitCount
+	s.waitSum, s.waitCount = sum, count
+	if deltaCount <= 0 {
+		return 0
+	}
+	return time.Duration(deltaSum / deltaCount * float64(time.Second))
+}
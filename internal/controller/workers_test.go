@@ -0,0 +1,86 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("Worker scaling", func() {
+	It("should add workers while Apps wait and remove them once the queue is idle", func() {
+		s := newWorkerScaler("test", 1, 6)
+
+		s.scale(10, 2*time.Second)
+		Expect(s.limit).To(Equal(2))
+		s.scale(10, 2*time.Second)
+		s.scale(10, 2*time.Second)
+		Expect(s.limit).To(Equal(6))
+
+		// Apps waiting briefly, or a short queue, keep the current workers.
+		s.scale(10, 100*time.Millisecond)
+		s.scale(3, 2*time.Second)
+		Expect(s.limit).To(Equal(6))
+
+		for range 10 {
+			s.scale(0, 0)
+		}
+		Expect(s.limit).To(Equal(1))
+	})
+
+	It("should hold back workers above the limit before they take an App off the queue", func() {
+		s := newWorkerScaler("test", 1, 2)
+		q := s.newQueue("worker-scaler-test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+		first := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "first"}}
+		second := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "second"}}
+		q.Add(first)
+		Eventually(q.Len).Should(Equal(1))
+		q.Add(second)
+		Eventually(q.Len).Should(Equal(2))
+
+		item, shutdown := q.Get()
+		Expect(shutdown).To(BeFalse())
+		Expect(item).To(Equal(first))
+
+		// get takes the next App off the queue from another worker.
+		get := func() <-chan bool {
+			got := make(chan bool, 1)
+			go func() {
+				_, shutdown := q.Get()
+				got <- shutdown
+			}()
+			return got
+		}
+		got := get()
+		Consistently(got, "50ms").ShouldNot(Receive())
+		Expect(q.Len()).To(Equal(1), "the held back App stays in the queue")
+
+		s.scale(5, 2*time.Second)
+		Eventually(got).Should(Receive(BeFalse()))
+		Expect(q.Len()).To(BeZero())
+
+		got = get()
+		Consistently(got, "50ms").ShouldNot(Receive(), "both workers are reconciling")
+		q.ShutDown()
+		Eventually(got).Should(Receive(BeTrue()))
+	})
+})
@@ -0,0 +1,371 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package git fetches a directory of a Git repository over the smart HTTP protocol
+// (version 2). Only the files at the tip of a ref are transferred, with a shallow fetch,
+// so no Git binary or local clone is needed.
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultMaxPackSize bounds the packfile of a single fetch.
+const defaultMaxPackSize = 64 << 20
+
+// commitPattern matches a full commit ID, used as a ref as is.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Auth holds the credentials of a repository: basic auth with Username and Password, or
+// a BearerToken.
+type Auth struct {
+	Username    string
+	Password    string
+	BearerToken string
+}
+
+// Source identifies a directory of a repository.
+type Source struct {
+	// URL of the repository.
+	URL string
+	// Ref is a branch, a tag or a full commit ID. The default branch is used when empty.
+	Ref string
+	// Path is the directory, relative to the root of the repository.
+	Path string
+	// Auth holds the credentials of the repository, if any.
+	Auth Auth
+}
+
+// Snapshot is the content of a directory at a commit.
+type Snapshot struct {
+	// Commit is the ID of the commit the files were read from.
+	Commit string
+	// Files maps the names of the regular files of the directory to their content.
+	// Subdirectories, symlinks and submodules are left out.
+	Files map[string][]byte
+}
+
+// Fetcher fetches directories of Git repositories.
+type Fetcher interface {
+	Fetch(ctx context.Context, source Source) (*Snapshot, error)
+}
+
+// HTTPFetcher fetches over the smart HTTP protocol.
+type HTTPFetcher struct {
+	Client *http.Client
+	// MaxPackSize bounds the size of the packfile transferred by a fetch.
+	MaxPackSize int64
+}
+
+// NewHTTPFetcher returns an HTTPFetcher with a bounded request timeout.
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{Client: &http.Client{Timeout: 2 * time.Minute}, MaxPackSize: defaultMaxPackSize}
+}
+
+// Fetch implements Fetcher.
+func (f *HTTPFetcher) Fetch(ctx context.Context, source Source) (*Snapshot, error) {
+	if err := f.checkProtocol(ctx, source); err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", source.URL, err)
+	}
+	commit, err := f.resolve(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: resolving %q: %w", source.URL, source.Ref, err)
+	}
+	objects, err := f.fetchPack(ctx, source, commit)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s at %s: %w", source.URL, commit, err)
+	}
+	files, err := objects.directory(commit, source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s at %s: %w", source.URL, commit, err)
+	}
+	return &Snapshot{Commit: commit, Files: files}, nil
+}
+
+// checkProtocol makes sure the server speaks protocol version 2.
+func (f *HTTPFetcher) checkProtocol(ctx context.Context, source Source) error {
+	resp, err := f.do(ctx, source, http.MethodGet, "/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	r := newPktReader(io.LimitReader(resp.Body, 1<<20))
+	for {
+		line, err := r.readLine()
+		if err == io.EOF {
+			return fmt.Errorf("server does not support Git protocol version 2")
+		} else if err != nil {
+			return err
+		}
+		if string(bytes.TrimSpace(line)) == "version 2" {
+			return nil
+		}
+	}
+}
+
+// resolve returns the commit a ref points to.
+func (f *HTTPFetcher) resolve(ctx context.Context, source Source) (string, error) {
+	ref := source.Ref
+	if commitPattern.MatchString(ref) {
+		return ref, nil
+	}
+
+	var req pktWriter
+	req.line("command=ls-refs\n")
+	req.delim()
+	req.line("peel\n")
+	wanted := []string{"HEAD"}
+	if ref != "" {
+		wanted = []string{"refs/heads/" + ref, "refs/tags/" + ref}
+	}
+	for _, name := range wanted {
+		req.line("ref-prefix " + name + "\n")
+	}
+	req.flush()
+
+	resp, err := f.do(ctx, source, http.MethodPost, "/git-upload-pack", req.Bytes())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	refs := map[string]string{}
+	r := newPktReader(io.LimitReader(resp.Body, 1<<20))
+	for {
+		line, err := r.readLine()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		// <oid> <name>[ peeled:<oid>]
+		fields := strings.Fields(string(line))
+		if len(fields) < 2 {
+			continue
+		}
+		oid := fields[0]
+		for _, attr := range fields[2:] {
+			if peeled, ok := strings.CutPrefix(attr, "peeled:"); ok {
+				oid = peeled
+			}
+		}
+		refs[fields[1]] = oid
+	}
+	for _, name := range wanted {
+		if oid, ok := refs[name]; ok {
+			return oid, nil
+		}
+	}
+	return "", fmt.Errorf("no such branch or tag")
+}
+
+// fetchPack fetches the objects of a commit, without its history.
+func (f *HTTPFetcher) fetchPack(ctx context.Context, source Source, commit string) (objectStore, error) {
+	var req pktWriter
+	req.line("command=fetch\n")
+	req.delim()
+	req.line("no-progress\n")
+	req.line("ofs-delta\n")
+	req.line("deepen 1\n")
+	req.line("want " + commit + "\n")
+	req.line("done\n")
+	req.flush()
+
+	resp, err := f.do(ctx, source, http.MethodPost, "/git-upload-pack", req.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	maxSize := f.MaxPackSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxPackSize
+	}
+	var pack bytes.Buffer
+	inPack := false
+	r := newPktReader(resp.Body)
+	for {
+		line, err := r.readLine()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if !inPack {
+			inPack = string(line) == "packfile\n"
+			continue
+		}
+		// Side-band: 1 carries the packfile, 2 progress and 3 a fatal error.
+		if len(line) == 0 {
+			continue
+		}
+		switch line[0] {
+		case 1:
+			if int64(pack.Len()+len(line)-1) > maxSize {
+				return nil, fmt.Errorf("packfile exceeds %d bytes", maxSize)
+			}
+			pack.Write(line[1:])
+		case 3:
+			return nil, fmt.Errorf("server error: %s", bytes.TrimSpace(line[1:]))
+		}
+	}
+	if !inPack {
+		return nil, fmt.Errorf("server sent no packfile")
+	}
+	return readPack(pack.Bytes())
+}
+
+// do sends a request to the repository's smart HTTP endpoint.
+func (f *HTTPFetcher) do(ctx context.Context, source Source, method, endpoint string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(source.URL, "/")+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Git-Protocol", "version=2")
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/x-git-upload-pack-request")
+		req.Header.Set("Accept", "application/x-git-upload-pack-result")
+	}
+	switch auth := source.Auth; {
+	case auth.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+auth.BearerToken)
+	case auth.Username != "" || auth.Password != "":
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close() //nolint:errcheck
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("server returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+// directory returns the regular files of a directory of a commit's tree.
+func (s objectStore) directory(commit, dir string) (map[string][]byte, error) {
+	tree, err := s.commitTree(commit)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(path.Clean("/"+dir), "/") {
+		if name == "" {
+			continue
+		}
+		entries, err := s.tree(tree)
+		if err != nil {
+			return nil, err
+		}
+		entry, ok := entries[name]
+		if !ok || entry.mode != modeTree {
+			return nil, fmt.Errorf("directory %q not found", dir)
+		}
+		tree = entry.oid
+	}
+
+	entries, err := s.tree(tree)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for name, entry := range entries {
+		if entry.mode != modeFile && entry.mode != modeExecutable {
+			continue
+		}
+		blob, ok := s[entry.oid]
+		if !ok || blob.kind != objBlob {
+			return nil, fmt.Errorf("file %q missing from packfile", name)
+		}
+		files[name] = blob.data
+	}
+	return files, nil
+}
+
+// commitTree returns the ID of a commit's tree, following annotated tags.
+func (s objectStore) commitTree(oid string) (string, error) {
+	for range 10 {
+		obj, ok := s[oid]
+		if !ok {
+			return "", fmt.Errorf("object %s missing from packfile", oid)
+		}
+		header, _, _ := bytes.Cut(obj.data, []byte("\n"))
+		switch obj.kind {
+		case objCommit:
+			if tree, ok := bytes.CutPrefix(header, []byte("tree ")); ok {
+				return string(tree), nil
+			}
+			return "", fmt.Errorf("commit %s has no tree", oid)
+		case objTag:
+			target, ok := bytes.CutPrefix(header, []byte("object "))
+			if !ok {
+				return "", fmt.Errorf("tag %s has no object", oid)
+			}
+			oid = string(target)
+		default:
+			return "", fmt.Errorf("object %s is not a commit", oid)
+		}
+	}
+	return "", fmt.Errorf("too many nested tags")
+}
+
+// Modes of tree entries.
+const (
+	modeTree       = "40000"
+	modeFile       = "100644"
+	modeExecutable = "100755"
+)
+
+// treeEntry is an entry of a tree object.
+type treeEntry struct {
+	mode string
+	oid  string
+}
+
+// tree parses a tree object: entries of "<mode> <name>\0<20-byte oid>".
+func (s objectStore) tree(oid string) (map[string]treeEntry, error) {
+	obj, ok := s[oid]
+	if !ok || obj.kind != objTree {
+		return nil, fmt.Errorf("tree %s missing from packfile", oid)
+	}
+	entries := map[string]treeEntry{}
+	data := obj.data
+	for len(data) > 0 {
+		header, rest, ok := bytes.Cut(data, []byte{0})
+		if !ok || len(rest) < 20 {
+			return nil, fmt.Errorf("tree %s is malformed", oid)
+		}
+		mode, name, ok := bytes.Cut(header, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("tree %s is malformed", oid)
+		}
+		entries[string(name)] = treeEntry{mode: string(mode), oid: hex.EncodeToString(rest[:20])}
+		data = rest[20:]
+	}
+	return entries, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"context"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPFetcher", func() {
+	var (
+		repo   string
+		server *httptest.Server
+	)
+
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		Expect(err).NotTo(HaveOccurred(), string(out))
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(repo, name), []byte(content), 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		gitPath, err := exec.LookPath("git")
+		if err != nil {
+			Skip("git is not installed")
+		}
+		root := GinkgoT().TempDir()
+		repo = filepath.Join(root, "config.git")
+		Expect(os.Mkdir(repo, 0o755)).To(Succeed())
+		run("init", "--quiet", "--initial-branch=main")
+
+		// Similar files, so the packfile holds deltas.
+		settings := strings.Repeat("key = value\n", 200)
+		write("README.md", "docs")
+		write("prod/app.conf", settings+"mode = prod\n")
+		write("prod/extra.conf", settings+"mode = extra\n")
+		write("prod/nested/ignored.conf", "ignored")
+		run("add", ".")
+		run("commit", "--quiet", "-m", "first")
+		run("tag", "-a", "v1", "-m", "v1")
+		write("prod/app.conf", settings+"mode = prod\nreplicas = 3\n")
+		run("commit", "--quiet", "-am", "second")
+
+		server = httptest.NewServer(&cgi.Handler{
+			Path: gitPath,
+			Args: []string{"http-backend"},
+			Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+		})
+		DeferCleanup(server.Close)
+	})
+
+	fetch := func(ref, dir string) (*Snapshot, error) {
+		return NewHTTPFetcher().Fetch(context.Background(), Source{URL: server.URL + "/config.git", Ref: ref, Path: dir})
+	}
+
+	It("should fetch the files of a directory at the tip of a branch", func() {
+		snapshot, err := fetch("main", "prod")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Commit).To(Equal(run("rev-parse", "main")))
+		Expect(snapshot.Files).To(HaveLen(2))
+		Expect(string(snapshot.Files["app.conf"])).To(HaveSuffix("replicas = 3\n"))
+		Expect(string(snapshot.Files["extra.conf"])).To(HaveSuffix("mode = extra\n"))
+	})
+
+	It("should resolve annotated tags, commit IDs and the default branch", func() {
+		snapshot, err := fetch("v1", "/prod/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Commit).To(Equal(run("rev-parse", "v1^{commit}")))
+		Expect(string(snapshot.Files["app.conf"])).To(HaveSuffix("mode = prod\n"))
+
+		first := run("rev-parse", "main~1")
+		snapshot, err = fetch(first, "prod")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Commit).To(Equal(first))
+
+		snapshot, err = fetch("", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshot.Files).To(HaveKeyWithValue("README.md", []byte("docs")))
+	})
+
+	It("should report missing refs and directories", func() {
+		_, err := fetch("develop", "prod")
+		Expect(err).To(MatchError(ContainSubstring("no such branch or tag")))
+		_, err = fetch("main", "staging")
+		Expect(err).To(MatchError(ContainSubstring(`directory "staging" not found`)))
+	})
+
+	It("should send credentials", func() {
+		var authorization string
+		authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			http.Error(w, "denied", http.StatusForbidden)
+		}))
+		defer authServer.Close()
+
+		_, err := NewHTTPFetcher().Fetch(context.Background(), Source{URL: authServer.URL, Auth: Auth{BearerToken: "token"}})
+		Expect(err).To(MatchError(ContainSubstring("denied")))
+		Expect(authorization).To(Equal("Bearer token"))
+	})
+})
@@ -0,0 +1,341 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// pktWriter builds a request of pkt-lines.
+type pktWriter struct {
+	bytes.Buffer
+}
+
+// line writes a data pkt-line.
+func (w *pktWriter) line(s string) {
+	fmt.Fprintf(w, "%04x%s", len(s)+4, s)
+}
+
+// delim writes a delimiter packet, ending a command's capabilities.
+func (w *pktWriter) delim() {
+	w.WriteString("0001")
+}
+
+// flush writes a flush packet, ending a request.
+func (w *pktWriter) flush() {
+	w.WriteString("0000")
+}
+
+// pktReader reads the data pkt-lines of a response.
+type pktReader struct {
+	r *bufio.Reader
+}
+
+func newPktReader(r io.Reader) *pktReader {
+	return &pktReader{r: bufio.NewReader(r)}
+}
+
+// readLine returns the next data pkt-line, skipping flush, delimiter and response-end
+// packets. It returns io.EOF at the end of the response.
+func (p *pktReader) readLine() ([]byte, error) {
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(p.r, size[:]); err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseUint(string(size[:]), 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("malformed pkt-line length %q", size[:])
+		}
+		if n < 4 {
+			continue
+		}
+		line := make([]byte, n-4)
+		if _, err := io.ReadFull(p.r, line); err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(line, []byte("ERR ")) {
+			return nil, fmt.Errorf("server error: %s", bytes.TrimSpace(line[4:]))
+		}
+		return line, nil
+	}
+}
+
+// Object types of a packfile.
+const (
+	objCommit   = 1
+	objTree     = 2
+	objBlob     = 3
+	objTag      = 4
+	objOfsDelta = 6
+	objRefDelta = 7
+)
+
+// Bounds of a packfile, which is read in memory: the inflated size of each object, of all
+// of them together, and the length of delta chains. A few compressed bytes can inflate to
+// gigabytes, and deltas can copy ranges of their base many times over.
+const (
+	maxObjectSize   = 16 << 20
+	maxInflatedSize = 256 << 20
+	// maxDeltaDepth is the deepest delta chain Git itself writes (pack.depth).
+	maxDeltaDepth = 4095
+)
+
+// object is a Git object read from a packfile.
+type object struct {
+	kind int
+	data []byte
+}
+
+// objectStore holds the objects of a packfile by ID.
+type objectStore map[string]object
+
+// packEntry is an object of a packfile before deltas are resolved.
+type packEntry struct {
+	offset int64
+	kind   int
+	size   uint64 // Inflated size, from the entry's header
+	data   []byte
+	base   string // ID of the base of a ref delta
+	baseAt int64  // Offset of the base of an offset delta
+}
+
+// readPack reads a version 2 packfile, resolving its deltas. Thin packs are not supported.
+// Packfiles exceeding the bounds above are refused.
+func readPack(pack []byte) (objectStore, error) {
+	if len(pack) < 12 || string(pack[:4]) != "PACK" || binary.BigEndian.Uint32(pack[4:8]) != 2 {
+		return nil, fmt.Errorf("not a version 2 packfile")
+	}
+	count := binary.BigEndian.Uint32(pack[8:12])
+	// Every entry takes at least a byte of header and the two bytes of a zlib header.
+	if uint64(count) > uint64(len(pack)-12)/3 {
+		return nil, fmt.Errorf("packfile of %d bytes cannot hold %d objects", len(pack), count)
+	}
+
+	r := bytes.NewReader(pack)
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+	entries := make([]packEntry, 0, count)
+	var inflated uint64
+	for range count {
+		entry, err := readPackEntry(r, int64(len(pack))-int64(r.Len()))
+		if err != nil {
+			return nil, err
+		}
+		if inflated += entry.size; inflated > maxInflatedSize {
+			return nil, fmt.Errorf("packfile inflates to more than %d bytes", maxInflatedSize)
+		}
+		entries = append(entries, entry)
+	}
+
+	store := objectStore{}
+	byOffset := map[int64]object{}
+	for depth, pending := 0, entries; len(pending) > 0; depth++ {
+		if depth > maxDeltaDepth {
+			return nil, fmt.Errorf("packfile has delta chains deeper than %d", maxDeltaDepth)
+		}
+		var unresolved []packEntry
+		for _, entry := range pending {
+			obj := object{kind: entry.kind, data: entry.data}
+			if entry.kind == objOfsDelta || entry.kind == objRefDelta {
+				base, ok := byOffset[entry.baseAt]
+				if entry.kind == objRefDelta {
+					base, ok = store[entry.base]
+				}
+				if !ok {
+					unresolved = append(unresolved, entry)
+					continue
+				}
+				data, err := applyDelta(base.data, entry.data)
+				if err != nil {
+					return nil, err
+				}
+				if inflated += uint64(len(data)); inflated > maxInflatedSize {
+					return nil, fmt.Errorf("packfile inflates to more than %d bytes", maxInflatedSize)
+				}
+				obj = object{kind: base.kind, data: data}
+			}
+			byOffset[entry.offset] = obj
+			store[objectID(obj)] = obj
+		}
+		if len(unresolved) == len(pending) {
+			return nil, fmt.Errorf("%d deltas have no base in the packfile", len(unresolved))
+		}
+		pending = unresolved
+	}
+	return store, nil
+}
+
+// readPackEntry reads the object starting at offset.
+func readPackEntry(r *bytes.Reader, offset int64) (packEntry, error) {
+	entry := packEntry{offset: offset}
+	c, err := r.ReadByte()
+	if err != nil {
+		return entry, err
+	}
+	entry.kind = int(c>>4) & 7
+	entry.size = uint64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = r.ReadByte(); err != nil {
+			return entry, err
+		}
+		if shift > 28 {
+			return entry, fmt.Errorf("object at offset %d is too large", offset)
+		}
+		entry.size |= uint64(c&0x7f) << shift
+	}
+	if entry.size > maxObjectSize {
+		return entry, fmt.Errorf("object at offset %d has %d bytes, more than %d", offset, entry.size, maxObjectSize)
+	}
+
+	switch entry.kind {
+	case objCommit, objTree, objBlob, objTag:
+	case objOfsDelta:
+		c, err := r.ReadByte()
+		if err != nil {
+			return entry, err
+		}
+		distance := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return entry, err
+			}
+			if distance >= offset {
+				return entry, fmt.Errorf("delta at offset %d has its base before the packfile", offset)
+			}
+			distance = (distance+1)<<7 | int64(c&0x7f)
+		}
+		if distance <= 0 || distance > offset {
+			return entry, fmt.Errorf("delta at offset %d has its base outside the packfile", offset)
+		}
+		entry.baseAt = offset - distance
+	case objRefDelta:
+		var base [20]byte
+		if _, err := io.ReadFull(r, base[:]); err != nil {
+			return entry, err
+		}
+		entry.base = hex.EncodeToString(base[:])
+	default:
+		return entry, fmt.Errorf("unknown object type %d at offset %d", entry.kind, offset)
+	}
+
+	// bytes.Reader is an io.ByteReader, so zlib consumes exactly the compressed object.
+	z, err := zlib.NewReader(r)
+	if err != nil {
+		return entry, err
+	}
+	// One byte more than the header announces tells an object inflating beyond it.
+	if entry.data, err = io.ReadAll(io.LimitReader(z, int64(entry.size)+1)); err != nil {
+		return entry, err
+	}
+	if uint64(len(entry.data)) != entry.size {
+		return entry, fmt.Errorf("object at offset %d inflates to %d bytes, not %d", offset, len(entry.data), entry.size)
+	}
+	return entry, z.Close()
+}
+
+// objectID returns the ID of an object.
+func objectID(obj object) string {
+	kind := map[int]string{objCommit: "commit", objTree: "tree", objBlob: "blob", objTag: "tag"}[obj.kind]
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", kind, len(obj.data))
+	h.Write(obj.data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// applyDelta rebuilds an object from its base and a delta: the sizes of both, followed by
+// instructions copying ranges of the base or inserting new data.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	r := bytes.NewReader(delta)
+	baseSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != uint64(len(base)) {
+		return nil, fmt.Errorf("delta base has %d bytes, expected %d", len(base), baseSize)
+	}
+	if size > maxObjectSize {
+		return nil, fmt.Errorf("delta produces %d bytes, more than %d", size, maxObjectSize)
+	}
+
+	out := make([]byte, 0, size)
+	for {
+		op, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch {
+		case op&0x80 != 0:
+			var offset, n uint32
+			for i := range 7 {
+				if op&(1<<i) == 0 {
+					continue
+				}
+				b, err := r.ReadByte()
+				if err != nil {
+					return nil, err
+				}
+				if i < 4 {
+					offset |= uint32(b) << (8 * i)
+				} else {
+					n |= uint32(b) << (8 * (i - 4))
+				}
+			}
+			if n == 0 {
+				n = 0x10000
+			}
+			if uint64(offset)+uint64(n) > uint64(len(base)) {
+				return nil, fmt.Errorf("delta copies beyond its base")
+			}
+			if uint64(len(out))+uint64(n) > size {
+				return nil, fmt.Errorf("delta produces more than %d bytes", size)
+			}
+			out = append(out, base[offset:offset+n]...)
+		case op != 0:
+			if uint64(len(out))+uint64(op) > size {
+				return nil, fmt.Errorf("delta produces more than %d bytes", size)
+			}
+			chunk := make([]byte, op)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return nil, err
+			}
+			out = append(out, chunk...)
+		default:
+			return nil, fmt.Errorf("invalid delta instruction")
+		}
+	}
+	if uint64(len(out)) != size {
+		return nil, fmt.Errorf("delta produced %d bytes, expected %d", len(out), size)
+	}
+	return out, nil
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// packObject encodes an entry of a packfile: its type and size header, the prefix of a
+// delta, then data compressed with zlib.
+func packObject(kind int, size int, prefix, data []byte) []byte {
+	var entry bytes.Buffer
+	c := byte(kind<<4) | byte(size&0x0f)
+	for size >>= 4; size > 0; size >>= 7 {
+		entry.WriteByte(c | 0x80)
+		c = byte(size & 0x7f)
+	}
+	entry.WriteByte(c)
+	entry.Write(prefix)
+	z := zlib.NewWriter(&entry)
+	_, _ = z.Write(data)
+	_ = z.Close()
+	return entry.Bytes()
+}
+
+// buildPack encodes a version 2 packfile of the given entries.
+func buildPack(entries ...[]byte) []byte {
+	pack := []byte("PACK")
+	pack = binary.BigEndian.AppendUint32(pack, 2)
+	pack = binary.BigEndian.AppendUint32(pack, uint32(len(entries)))
+	for _, entry := range entries {
+		pack = append(pack, entry...)
+	}
+	return pack
+}
+
+// buildDelta encodes a delta copying all of base, then inserting suffix.
+func buildDelta(base []byte, suffix string) []byte {
+	delta := binary.AppendUvarint(nil, uint64(len(base)))
+	delta = binary.AppendUvarint(delta, uint64(len(base)+len(suffix)))
+	delta = append(delta, 0x80|0x10, byte(len(base))) // Copy len(base) bytes from offset 0.
+	delta = append(delta, byte(len(suffix)))
+	return append(delta, suffix...)
+}
+
+// samplePack holds a blob and an offset delta rebuilding another blob from it.
+func samplePack() []byte {
+	base := []byte("key = value\n")
+	first := packObject(objBlob, len(base), nil, base)
+	delta := buildDelta(base, "mode = prod\n")
+	return buildPack(first, packObject(objOfsDelta, len(delta), []byte{byte(len(first))}, delta))
+}
+
+var _ = Describe("readPack", func() {
+	It("should resolve offset deltas", func() {
+		store, err := readPack(samplePack())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store).To(HaveLen(2))
+		var blobs []string
+		for _, obj := range store {
+			blobs = append(blobs, string(obj.data))
+		}
+		Expect(blobs).To(ConsistOf("key = value\n", "key = value\nmode = prod\n"))
+	})
+
+	It("should refuse objects inflating beyond their header", func() {
+		data := bytes.Repeat([]byte{0}, 1<<16)
+		_, err := readPack(buildPack(packObject(objBlob, 16, nil, data)))
+		Expect(err).To(MatchError(ContainSubstring("inflates to 17 bytes, not 16")))
+	})
+
+	It("should refuse objects larger than the bound", func() {
+		_, err := readPack(buildPack(packObject(objBlob, maxObjectSize+1, nil, nil)))
+		Expect(err).To(MatchError(ContainSubstring("more than")))
+	})
+
+	It("should refuse more objects than the packfile can hold", func() {
+		pack := samplePack()
+		binary.BigEndian.PutUint32(pack[8:12], 1<<30)
+		_, err := readPack(pack)
+		Expect(err).To(MatchError(ContainSubstring("cannot hold")))
+	})
+
+	It("should refuse offset deltas based outside the packfile", func() {
+		delta := buildDelta([]byte("base"), "")
+		_, err := readPack(buildPack(packObject(objOfsDelta, len(delta), []byte{0x7f}, delta)))
+		Expect(err).To(MatchError(ContainSubstring("outside the packfile")))
+	})
+})
+
+var _ = Describe("applyDelta", func() {
+	It("should refuse deltas producing more than they declare or than the bound", func() {
+		base := []byte("base")
+		delta := buildDelta(base, "suffix")
+		delta[1] = byte(len(base)) // Declares the copy only.
+		_, err := applyDelta(base, delta)
+		Expect(err).To(MatchError(ContainSubstring("more than 4 bytes")))
+
+		delta = binary.AppendUvarint(binary.AppendUvarint(nil, uint64(len(base))), maxObjectSize+1)
+		_, err = applyDelta(base, delta)
+		Expect(err).To(MatchError(ContainSubstring("more than")))
+	})
+})
+
+func FuzzReadPack(f *testing.F) {
+	f.Add(samplePack())
+	f.Add(buildPack(packObject(objTree, 0, nil, nil)))
+	f.Fuzz(func(t *testing.T, pack []byte) {
+		store, err := readPack(pack)
+		if err != nil {
+			return
+		}
+		for _, obj := range store {
+			if len(obj.data) > maxObjectSize {
+				t.Fatalf("object of %d bytes exceeds the bound", len(obj.data))
+			}
+		}
+	})
+}
+
+func FuzzApplyDelta(f *testing.F) {
+	base := []byte("key = value\n")
+	f.Add(base, buildDelta(base, "mode = prod\n"))
+	f.Add(base, []byte{12, 4, 0x91, 4, 4})
+	f.Fuzz(func(t *testing.T, base, delta []byte) {
+		out, err := applyDelta(base, delta)
+		if err == nil && len(out) > maxObjectSize {
+			t.Fatalf("delta produced %d bytes, beyond the bound", len(out))
+		}
+	})
+}
+
+func FuzzReadLine(f *testing.F) {
+	var req pktWriter
+	req.line("command=ls-refs\n")
+	req.delim()
+	req.line("peel\n")
+	req.flush()
+	f.Add(req.Bytes())
+	f.Add([]byte("0008ERR x"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := newPktReader(bytes.NewReader(data))
+		for {
+			line, err := r.readLine()
+			if err != nil {
+				return
+			}
+			if len(line) > 0xffff-4 {
+				t.Fatalf("pkt-line of %d bytes", len(line))
+			}
+		}
+	})
+}
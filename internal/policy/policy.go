@@ -0,0 +1,178 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy enforces AppPolicies on Apps. Rules are evaluated in the CEL environment of
+// ValidatingAdmissionPolicy expressions, so the operator's webhook and the admission
+// policies generated from an AppPolicy accept the same Apps, and references leaving an
+// App's namespace are checked against the policies' grants.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apiserver/pkg/cel/environment"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// env is the environment rules are compiled in: the base environment of the API server,
+// with the variables of ValidatingAdmissionPolicy expressions that rules may use.
+var env = func() *cel.Env {
+	envSet, err := environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true).Extend(
+		environment.VersionedOptions{
+			IntroducedVersion: version.MajorMinor(1, 0),
+			EnvOptions: []cel.EnvOption{
+				cel.Variable("object", cel.DynType),
+				cel.Variable("oldObject", cel.DynType),
+			},
+		})
+	if err != nil {
+		panic(err)
+	}
+	compiled, err := envSet.Env(environment.NewExpressions)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}()
+
+// Rules are the compiled rules of an AppPolicy.
+type Rules struct {
+	policy string
+	rules  []rule
+}
+
+type rule struct {
+	webappv1.AppPolicyRule
+	program cel.Program
+}
+
+// Compile compiles the rules of an AppPolicy. The error names every rule that doesn't compile
+// or doesn't return a bool, and reports an invalid namespace selector.
+func Compile(appPolicy *webappv1.AppPolicy) (*Rules, error) {
+	compiled := &Rules{policy: appPolicy.Name}
+	var invalid []string
+	if selector := appPolicy.Spec.NamespaceSelector; selector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(selector); err != nil {
+			invalid = append(invalid, fmt.Sprintf("namespaceSelector: %v", err))
+		}
+	}
+	for _, r := range appPolicy.Spec.Rules {
+		ast, issues := env.Compile(r.Expression)
+		if issues.Err() != nil {
+			invalid = append(invalid, fmt.Sprintf("rule %s: %v", r.Name, issues.Err()))
+			continue
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			invalid = append(invalid, fmt.Sprintf("rule %s: must return a bool, not %s", r.Name, ast.OutputType()))
+			continue
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("rule %s: %v", r.Name, err))
+			continue
+		}
+		compiled.rules = append(compiled.rules, rule{AppPolicyRule: r, program: program})
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("AppPolicy %s: %s", appPolicy.Name, strings.Join(invalid, "; "))
+	}
+	return compiled, nil
+}
+
+// Validate evaluates the rules against an App and its previous version, nil on creation. It
+// returns the message of every rule the App doesn't satisfy; rules failing to evaluate, such
+// as those reading a field the App doesn't set, aren't satisfied, as an admission policy
+// failing closed would reject the App too.
+func (r *Rules) Validate(app, old *webappv1.App) ([]string, error) {
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(app)
+	if err != nil {
+		return nil, err
+	}
+	vars := map[string]any{"object": object, "oldObject": nil}
+	if old != nil {
+		if vars["oldObject"], err = runtime.DefaultUnstructuredConverter.ToUnstructured(old); err != nil {
+			return nil, err
+		}
+	}
+
+	var violations []string
+	for _, rule := range r.rules {
+		out, _, err := rule.program.Eval(vars)
+		switch {
+		case err != nil:
+			violations = append(violations, fmt.Sprintf("AppPolicy %s: rule %s failed: %v", r.policy, rule.Name, err))
+		case out.Value() != true:
+			violations = append(violations, Message(r.policy, rule.AppPolicyRule))
+		}
+	}
+	return violations, nil
+}
+
+// Message returns the reason an App not satisfying a rule of an AppPolicy is rejected with.
+func Message(policyName string, r webappv1.AppPolicyRule) string {
+	if r.Message != "" {
+		return fmt.Sprintf("AppPolicy %s: %s", policyName, r.Message)
+	}
+	return fmt.Sprintf("AppPolicy %s: rule %s is not satisfied", policyName, r.Name)
+}
+
+// Selects reports whether an AppPolicy applies to the Apps of a namespace with the given
+// labels. A policy with an invalid namespace selector selects no namespace.
+func Selects(appPolicy *webappv1.AppPolicy, namespaceLabels map[string]string) bool {
+	if appPolicy.Spec.NamespaceSelector == nil {
+		return true
+	}
+	selector, err := metav1.LabelSelectorAsSelector(appPolicy.Spec.NamespaceSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(namespaceLabels))
+}
+
+// ForNamespace returns the AppPolicies applying to the Apps of a namespace.
+func ForNamespace(ctx context.Context, c client.Reader, namespace string) ([]webappv1.AppPolicy, error) {
+	policies := &webappv1.AppPolicyList{}
+	if err := c.List(ctx, policies); err != nil {
+		return nil, err
+	}
+	if len(policies.Items) == 0 {
+		return nil, nil
+	}
+	// Only the namespace's labels matter, so a metadata-only read keeps full Namespace objects out of the cache.
+	ns := &metav1.PartialObjectMetadata{}
+	ns.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, err
+	}
+	var selected []webappv1.AppPolicy
+	for _, appPolicy := range policies.Items {
+		if Selects(&appPolicy, ns.Labels) {
+			selected = append(selected, appPolicy)
+		}
+	}
+	return selected, nil
+}
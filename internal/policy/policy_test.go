@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("Rules", func() {
+	var app *webappv1.App
+
+	BeforeEach(func() {
+		app = &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       webappv1.AppSpec{Image: "nginx:1.27", Replicas: 1, Port: 80},
+		}
+	})
+
+	// newPolicy returns an AppPolicy named production with the given rules.
+	newPolicy := func(rules ...webappv1.AppPolicyRule) *webappv1.AppPolicy {
+		return &webappv1.AppPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "production"},
+			Spec:       webappv1.AppPolicySpec{Rules: rules},
+		}
+	}
+
+	It("should report the rules an App doesn't satisfy", func() {
+		rules, err := Compile(newPolicy(
+			webappv1.AppPolicyRule{Name: "replicated", Expression: "object.spec.replicas >= 2", Message: "production Apps run at least two replicas"},
+			webappv1.AppPolicyRule{Name: "pinned", Expression: "!object.spec.image.endsWith(':latest')"},
+			webappv1.AppPolicyRule{Name: "port", Expression: "object.spec.port != 22"},
+		))
+		Expect(err).NotTo(HaveOccurred())
+
+		app.Spec.Image = "nginx:latest"
+		violations, err := rules.Validate(app, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(violations).To(ConsistOf(
+			"AppPolicy production: production Apps run at least two replicas",
+			"AppPolicy production: rule pinned is not satisfied",
+		))
+
+		app.Spec.Image, app.Spec.Replicas = "nginx:1.27", 3
+		Expect(rules.Validate(app, nil)).To(BeEmpty())
+	})
+
+	It("should give rules the previous App on updates only", func() {
+		rules, err := Compile(newPolicy(webappv1.AppPolicyRule{
+			Name:       "no-scale-down",
+			Expression: "oldObject == null || object.spec.replicas >= oldObject.spec.replicas",
+		}))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(rules.Validate(app, nil)).To(BeEmpty())
+		old := app.DeepCopy()
+		old.Spec.Replicas = 3
+		Expect(rules.Validate(app, old)).To(ConsistOf("AppPolicy production: rule no-scale-down is not satisfied"))
+	})
+
+	It("should not admit Apps a rule fails to evaluate on", func() {
+		rules, err := Compile(newPolicy(webappv1.AppPolicyRule{Name: "limited", Expression: "object.spec.resources.limits.memory != ''"}))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(rules.Validate(app, nil)).To(ConsistOf(ContainSubstring("AppPolicy production: rule limited failed")))
+	})
+
+	It("should have the Kubernetes CEL libraries", func() {
+		rules, err := Compile(newPolicy(webappv1.AppPolicyRule{Name: "registry", Expression: "object.spec.image.matches('^[a-z]+:[0-9.]+$')"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rules.Validate(app, nil)).To(BeEmpty())
+	})
+
+	It("should reject rules that don't compile or return a bool", func() {
+		_, err := Compile(newPolicy(
+			webappv1.AppPolicyRule{Name: "broken", Expression: "object.spec.replicas >="},
+			webappv1.AppPolicyRule{Name: "count", Expression: "1 + 1"},
+			webappv1.AppPolicyRule{Name: "fine", Expression: "true"},
+		))
+		Expect(err).To(MatchError(ContainSubstring("rule broken")))
+		Expect(err).To(MatchError(ContainSubstring("rule count: must return a bool")))
+		Expect(err).NotTo(MatchError(ContainSubstring("rule fine")))
+	})
+
+	It("should select namespaces by label", func() {
+		appPolicy := newPolicy()
+		Expect(Selects(appPolicy, nil)).To(BeTrue())
+
+		appPolicy.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}}
+		Expect(Selects(appPolicy, map[string]string{"environment": "production"})).To(BeTrue())
+		Expect(Selects(appPolicy, map[string]string{"environment": "staging"})).To(BeFalse())
+
+		appPolicy.Spec.NamespaceSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Near"}}
+		Expect(Selects(appPolicy, map[string]string{"environment": "production"})).To(BeFalse())
+		_, err := Compile(appPolicy)
+		Expect(err).To(MatchError(ContainSubstring("namespaceSelector")))
+	})
+})
@@ -0,0 +1,175 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+// Reference is a reference of an App to an object outside its namespace.
+type Reference struct {
+	// Path is the field holding the reference.
+	Path *field.Path
+	// Kind is one of the kinds an AppPolicy grants references to.
+	Kind string
+	// Namespace is the namespace of the object, empty for cluster-scoped objects.
+	Namespace string
+	// Name is the name of the object.
+	Name string
+}
+
+// String returns the object a reference points to, as kind namespace/name.
+func (r Reference) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// CrossNamespaceReferences returns the references of an App leaving its namespace: to a
+// ClusterIssuer in spec.tls.issuerRef, and to a Gateway of another namespace in
+// spec.expose.gateway. Every other reference of the App is resolved in its namespace.
+func CrossNamespaceReferences(app *webappv1.App) []Reference {
+	var refs []Reference
+	specPath := field.NewPath("spec")
+	if tls := app.Spec.TLS; tls != nil && tls.IssuerRef.Kind == webappv1.ReferenceKindClusterIssuer {
+		refs = append(refs, Reference{
+			Path: specPath.Child("tls", "issuerRef"),
+			Kind: webappv1.ReferenceKindClusterIssuer,
+			Name: tls.IssuerRef.Name,
+		})
+	}
+	if expose := app.Spec.Expose; expose != nil && expose.Gateway != nil {
+		if namespace := expose.Gateway.Namespace; namespace != "" && namespace != app.Namespace {
+			refs = append(refs, Reference{
+				Path:      specPath.Child("expose", "gateway"),
+				Kind:      webappv1.ReferenceKindGateway,
+				Namespace: namespace,
+				Name:      expose.Gateway.Name,
+			})
+		}
+	}
+	return refs
+}
+
+// Granted reports whether one of the AppPolicies grants a reference.
+func Granted(ref Reference, policies []webappv1.AppPolicy) bool {
+	for _, appPolicy := range policies {
+		for _, grant := range appPolicy.Spec.ReferenceGrants {
+			if grant.Kind == ref.Kind && grant.Namespace == ref.Namespace && (grant.Name == "" || grant.Name == ref.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckReferences returns the errors of the references of an App: local references, in the
+// App's spec and in each of its containers, must be object names, as the controller resolves
+// them in the App's namespace, and references leaving the namespace must be granted by one
+// of the AppPolicies applying to it.
+func CheckReferences(app *webappv1.App, policies []webappv1.AppPolicy) field.ErrorList {
+	var errs field.ErrorList
+	check := func(path *field.Path, name string) {
+		if name == "" {
+			return
+		}
+		if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+			errs = append(errs, field.Invalid(path, name, fmt.Sprintf("must name an object in namespace %s: %s", app.Namespace, strings.Join(msgs, ", "))))
+		}
+	}
+
+	spec := &app.Spec
+	specPath := field.NewPath("spec")
+	check(specPath.Child("serviceAccountName"), spec.ServiceAccountName)
+	for i, name := range spec.DependsOn {
+		check(specPath.Child("dependsOn").Index(i), name)
+	}
+	for i, secret := range spec.ImagePullSecrets {
+		check(specPath.Child("imagePullSecrets").Index(i).Child("name"), secret.Name)
+	}
+	if spec.Config != nil && spec.Config.ConfigMapRef != nil {
+		check(specPath.Child("config", "configMapRef", "name"), spec.Config.ConfigMapRef.Name)
+	}
+	if spec.ConfigFrom != nil && spec.ConfigFrom.Git != nil && spec.ConfigFrom.Git.SecretRef != nil {
+		check(specPath.Child("configFrom", "git", "secretRef", "name"), spec.ConfigFrom.Git.SecretRef.Name)
+	}
+	checkEnv(check, specPath, spec.Env, spec.EnvFrom)
+	for _, list := range []struct {
+		path       *field.Path
+		containers []webappv1.Container
+	}{
+		{specPath.Child("containers"), spec.Containers},
+		{specPath.Child("initContainers"), spec.InitContainers},
+		{specPath.Child("sidecars"), spec.Sidecars},
+	} {
+		for i, container := range list.containers {
+			checkEnv(check, list.path.Index(i), container.Env, container.EnvFrom)
+		}
+	}
+	if spec.TLS != nil {
+		check(specPath.Child("tls", "issuerRef", "name"), spec.TLS.IssuerRef.Name)
+	}
+	if spec.Expose != nil {
+		check(specPath.Child("expose", "tlsSecretName"), spec.Expose.TLSSecretName)
+	}
+	if target := spec.TargetCluster; target != nil {
+		if target.KubeconfigSecretRef != nil {
+			check(specPath.Child("targetCluster", "kubeconfigSecretRef", "name"), target.KubeconfigSecretRef.Name)
+		}
+		check(specPath.Child("targetCluster", "clusterName"), target.ClusterName)
+	}
+
+	for _, ref := range CrossNamespaceReferences(app) {
+		if !Granted(ref, policies) {
+			errs = append(errs, field.Forbidden(ref.Path,
+				fmt.Sprintf("references %s outside namespace %s, which no AppPolicy grants", ref, app.Namespace)))
+		}
+	}
+	return errs
+}
+
+// checkEnv checks the ConfigMaps and Secrets the environment of a container reads.
+func checkEnv(check func(*field.Path, string), path *field.Path, env []corev1.EnvVar, envFrom []corev1.EnvFromSource) {
+	for _, v := range env {
+		if v.ValueFrom == nil {
+			continue
+		}
+		valuePath := path.Child("env").Key(v.Name).Child("valueFrom")
+		if v.ValueFrom.ConfigMapKeyRef != nil {
+			check(valuePath.Child("configMapKeyRef", "name"), v.ValueFrom.ConfigMapKeyRef.Name)
+		}
+		if v.ValueFrom.SecretKeyRef != nil {
+			check(valuePath.Child("secretKeyRef", "name"), v.ValueFrom.SecretKeyRef.Name)
+		}
+	}
+	for i, source := range envFrom {
+		if source.ConfigMapRef != nil {
+			check(path.Child("envFrom").Index(i).Child("configMapRef", "name"), source.ConfigMapRef.Name)
+		}
+		if source.SecretRef != nil {
+			check(path.Child("envFrom").Index(i).Child("secretRef", "name"), source.SecretRef.Name)
+		}
+	}
+}
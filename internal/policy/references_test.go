@@ -0,0 +1,128 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+)
+
+var _ = Describe("References", func() {
+	var app *webappv1.App
+
+	BeforeEach(func() {
+		app = &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"},
+			Spec: webappv1.AppSpec{
+				Image: "nginx:1.27",
+				TLS:   &webappv1.TLSSpec{IssuerRef: webappv1.IssuerReference{Name: "letsencrypt", Kind: webappv1.ReferenceKindClusterIssuer}},
+				Expose: &webappv1.ExposeSpec{
+					Mode:    webappv1.ExposeModeHTTPRoute,
+					Gateway: &webappv1.GatewayReference{Name: "public", Namespace: "infra"},
+				},
+			},
+		}
+	})
+
+	// grants returns an AppPolicy with the given reference grants.
+	grants := func(grants ...webappv1.AppPolicyReferenceGrant) []webappv1.AppPolicy {
+		return []webappv1.AppPolicy{{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared-infra"},
+			Spec:       webappv1.AppPolicySpec{ReferenceGrants: grants},
+		}}
+	}
+
+	It("should find references to ClusterIssuers and Gateways of other namespaces", func() {
+		Expect(CrossNamespaceReferences(app)).To(ConsistOf(
+			HaveField("String()", "ClusterIssuer letsencrypt"),
+			HaveField("String()", "Gateway infra/public"),
+		))
+
+		app.Spec.TLS.IssuerRef.Kind = "Issuer"
+		app.Spec.Expose.Gateway.Namespace = "team-a"
+		Expect(CrossNamespaceReferences(app)).To(BeEmpty())
+	})
+
+	It("should reject references leaving the namespace without a grant", func() {
+		errs := CheckReferences(app, nil)
+		Expect(errs).To(HaveLen(2))
+		Expect(errs.ToAggregate()).To(MatchError(ContainSubstring("spec.tls.issuerRef: Forbidden: references ClusterIssuer letsencrypt outside namespace team-a")))
+		Expect(errs.ToAggregate()).To(MatchError(ContainSubstring("spec.expose.gateway: Forbidden: references Gateway infra/public")))
+	})
+
+	It("should accept references an AppPolicy grants", func() {
+		Expect(CheckReferences(app, grants(
+			webappv1.AppPolicyReferenceGrant{Kind: webappv1.ReferenceKindClusterIssuer, Name: "letsencrypt"},
+			webappv1.AppPolicyReferenceGrant{Kind: webappv1.ReferenceKindGateway, Namespace: "infra"},
+		))).To(BeEmpty())
+	})
+
+	It("should only grant the named object of the kind and namespace", func() {
+		errs := CheckReferences(app, grants(
+			webappv1.AppPolicyReferenceGrant{Kind: webappv1.ReferenceKindClusterIssuer, Name: "internal-ca"},
+			webappv1.AppPolicyReferenceGrant{Kind: webappv1.ReferenceKindGateway, Namespace: "edge"},
+		))
+		Expect(errs).To(HaveLen(2))
+	})
+
+	It("should check the local references of every container list", func() {
+		app.Spec.TLS, app.Spec.Expose = nil, nil
+		secretRef := func(name string) *corev1.EnvVarSource {
+			return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: "token"}}
+		}
+		app.Spec.Env = []corev1.EnvVar{{Name: "TOKEN", ValueFrom: secretRef("team-b/token")}}
+		app.Spec.Containers = []webappv1.Container{{Name: "worker", Image: "worker:1.0", EnvFrom: []corev1.EnvFromSource{
+			{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "team-b/settings"}}},
+		}}}
+		app.Spec.InitContainers = []webappv1.Container{{Name: "migrate", Image: "migrate:1.0", Env: []corev1.EnvVar{{Name: "TOKEN", ValueFrom: secretRef("Token")}}}}
+		app.Spec.Sidecars = []webappv1.Container{{Name: "proxy", Image: "proxy:1.0", Env: []corev1.EnvVar{{Name: "TOKEN", ValueFrom: secretRef("proxy-token")}}}}
+
+		errs := CheckReferences(app, nil)
+		Expect(errs).To(HaveLen(3))
+		Expect(errs.ToAggregate()).To(MatchError(ContainSubstring("spec.env[TOKEN].valueFrom.secretKeyRef.name")))
+		Expect(errs.ToAggregate()).To(MatchError(ContainSubstring("spec.containers[0].envFrom[0].configMapRef.name")))
+		Expect(errs.ToAggregate()).To(MatchError(ContainSubstring("spec.initContainers[0].env[TOKEN].valueFrom.secretKeyRef.name")))
+	})
+
+	It("should read the AppPolicies selecting a namespace", func() {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(webappv1.AddToScheme(s)).To(Succeed())
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"tenant": "a"}}}
+		everywhere := &webappv1.AppPolicy{ObjectMeta: metav1.ObjectMeta{Name: "everywhere"}}
+		tenantA := &webappv1.AppPolicy{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a"}, Spec: webappv1.AppPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "a"}},
+		}}
+		tenantB := &webappv1.AppPolicy{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b"}, Spec: webappv1.AppPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tenant": "b"}},
+		}}
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(namespace, everywhere, tenantA, tenantB).Build()
+
+		policies, err := ForNamespace(context.Background(), c, "team-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policies).To(ConsistOf(HaveField("Name", "everywhere"), HaveField("Name", "tenant-a")))
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package provenance checks the SLSA provenance attested for container images before
+// they are rolled out.
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrNoAttestation is returned when an image has no provenance attestation with a valid signature.
+var ErrNoAttestation = errors.New("no verified provenance attestation")
+
+// Attestation holds the facts of a verified SLSA provenance attestation that
+// policies are checked against.
+type Attestation struct {
+	// BuilderID is the identity of the builder that produced the image.
+	BuilderID string `json:"builderID"`
+	// SourceRepository is the repository the image was built from.
+	SourceRepository string `json:"sourceRepository"`
+}
+
+// Verifier verifies the signed in-toto provenance attestation of container images.
+type Verifier interface {
+	Verify(ctx context.Context, image string) (*Attestation, error)
+}
+
+// HTTPVerifier delegates signature verification to a verification service over HTTP.
+// It POSTs {"image": "<reference>"} to URL and expects the Attestation of the verified
+// provenance in response, or 404 Not Found when there is none, so cosign or a policy
+// engine can be put behind it.
+type HTTPVerifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPVerifier returns an HTTPVerifier for url with a bounded request timeout.
+func NewHTTPVerifier(url string) *HTTPVerifier {
+	return &HTTPVerifier{URL: url, Client: &http.Client{Timeout: time.Minute}}
+}
+
+// Verify implements Verifier.
+func (v *HTTPVerifier) Verify(ctx context.Context, image string) (*Attestation, error) {
+	body, err := json.Marshal(map[string]string{"image": image})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("verifying %s: %w", image, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("verifying %s: %w", image, ErrNoAttestation)
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("verifying %s: verifier returned %s: %s", image, resp.Status, bytes.TrimSpace(msg))
+	}
+	attestation := &Attestation{}
+	if err := json.NewDecoder(resp.Body).Decode(attestation); err != nil {
+		return nil, fmt.Errorf("verifying %s: decoding attestation: %w", image, err)
+	}
+	return attestation, nil
+}
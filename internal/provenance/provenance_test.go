@@ -0,0 +1,68 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPVerifier", func() {
+	It("should post the image and decode the attestation", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			var req map[string]string
+			Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+			Expect(req).To(HaveKeyWithValue("image", "ghcr.io/acme/shop:1.0"))
+			_, _ = w.Write([]byte(`{"builderID": "https://github.com/actions/runner", "sourceRepository": "https://github.com/acme/shop"}`))
+		}))
+		defer server.Close()
+
+		attestation, err := NewHTTPVerifier(server.URL).Verify(context.Background(), "ghcr.io/acme/shop:1.0")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*attestation).To(Equal(Attestation{
+			BuilderID:        "https://github.com/actions/runner",
+			SourceRepository: "https://github.com/acme/shop",
+		}))
+	})
+
+	It("should report images without a verified attestation", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.NotFound(w, nil)
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPVerifier(server.URL).Verify(context.Background(), "unsigned:latest")
+		Expect(err).To(MatchError(ErrNoAttestation))
+	})
+
+	It("should surface verifier errors", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "registry unavailable", http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPVerifier(server.URL).Verify(context.Background(), "shop:1.0")
+		Expect(err).To(MatchError(ContainSubstring("registry unavailable")))
+		Expect(err).NotTo(MatchError(ErrNoAttestation))
+	})
+})
@@ -0,0 +1,418 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry reads the platforms a container image is built for and the digest its
+// tag points to from its manifest, with the OCI distribution API.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Manifest media types, most specific first in the Accept header.
+const (
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// maxManifestSize bounds the manifests and image configs read from a registry.
+const maxManifestSize = 4 << 20
+
+// Platform is an operating system and CPU architecture an image runs on.
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Auth holds the credentials of a registry. Anonymous access is used when empty.
+type Auth struct {
+	Username string
+	Password string
+}
+
+// Reference is a parsed image reference.
+type Reference struct {
+	// Registry is the host of the registry, e.g. registry-1.docker.io.
+	Registry string
+	// Repository is the path of the image in the registry, e.g. library/nginx.
+	Repository string
+	// Reference is the digest of the image if pinned, its tag otherwise.
+	Reference string
+}
+
+// ParseReference parses an image reference the way the container runtime does: images
+// without registry come from Docker Hub, and images without tag or digest are latest.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{Registry: "registry-1.docker.io", Reference: "latest"}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Reference = name[:i], name[i+1:]
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Reference = name[:i], name[i+1:]
+	}
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		ref.Registry, name = name[:i], name[i+1:]
+		if ref.Registry == "docker.io" || ref.Registry == "index.docker.io" {
+			ref.Registry = "registry-1.docker.io"
+		}
+	}
+	if ref.Registry == "registry-1.docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" || ref.Reference == "" {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// referencePattern is the grammar of image references of the OCI distribution
+// specification: an optional registry host and port, lowercase path components, then an
+// optional tag and digest.
+var referencePattern = func() *regexp.Regexp {
+	const (
+		hostLabel = `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])`
+		host      = hostLabel + `(?:\.` + hostLabel + `)*(?::[0-9]+)?`
+		component = `[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*`
+		tag       = `[\w][\w.-]{0,127}`
+	)
+	return regexp.MustCompile(`^(?:` + host + `/)?` + component + `(?:/` + component + `)*(?::` + tag + `)?(?:@` + digestPattern + `)?$`)
+}()
+
+// digestPattern is the grammar of content digests, e.g. sha256:<hex>.
+const digestPattern = `[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}`
+
+// digestRegexp matches a whole content digest.
+var digestRegexp = regexp.MustCompile(`^` + digestPattern + `$`)
+
+// maxNameLength is the longest repository name, registry included, references may have.
+const maxNameLength = 255
+
+// ValidateReference verifies that image is a well-formed image reference, e.g.
+// ghcr.io/org/app:1.0 or nginx@sha256:<digest>. ParseReference is more lenient, as the
+// references it is given were already accepted by the API server.
+func ValidateReference(image string) error {
+	if !referencePattern.MatchString(image) {
+		return fmt.Errorf("invalid image reference %q: expected [registry/]repository[:tag][@digest] with a lowercase repository", image)
+	}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	if len(name) > maxNameLength {
+		return fmt.Errorf("invalid image reference %q: the repository name exceeds %d characters", image, maxNameLength)
+	}
+	return nil
+}
+
+// Inspector reads the platforms of container images and the digests their tags point to.
+type Inspector interface {
+	Platforms(ctx context.Context, image string, auth Auth) ([]Platform, error)
+	Digest(ctx context.Context, image string, auth Auth) (string, error)
+}
+
+// HTTPInspector implements Inspector over HTTPS, authenticating with basic auth or the
+// registry's token service.
+type HTTPInspector struct {
+	Client *http.Client
+}
+
+// NewHTTPInspector returns an HTTPInspector with a bounded request timeout.
+func NewHTTPInspector() *HTTPInspector {
+	return &HTTPInspector{Client: &http.Client{Timeout: time.Minute}}
+}
+
+// Platforms implements Inspector. It returns the platforms of a multi-platform image's index,
+// or the single platform recorded in the config of any other image.
+func (i *HTTPInspector) Platforms(ctx context.Context, image string, auth Auth) ([]Platform, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	s := &session{client: i.Client, ref: ref, auth: auth}
+
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+		Manifests []struct {
+			Platform *Platform `json:"platform"`
+		} `json:"manifests"`
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+	}
+	if err := s.get(ctx, "manifests/"+ref.Reference, manifestAccept, &manifest); err != nil {
+		return nil, fmt.Errorf("inspecting %s: %w", image, err)
+	}
+
+	if manifest.Manifests != nil {
+		var platforms []Platform
+		for _, m := range manifest.Manifests {
+			// Attestation manifests are recorded with an unknown platform.
+			if m.Platform != nil && m.Platform.OS != "unknown" && m.Platform.Architecture != "unknown" {
+				platforms = append(platforms, *m.Platform)
+			}
+		}
+		return platforms, nil
+	}
+	if manifest.Config.Digest == "" {
+		return nil, fmt.Errorf("inspecting %s: unsupported manifest type %q", image, manifest.MediaType)
+	}
+	platform := Platform{}
+	if err := s.get(ctx, "blobs/"+manifest.Config.Digest, "*/*", &platform); err != nil {
+		return nil, fmt.Errorf("inspecting %s: reading image config: %w", image, err)
+	}
+	return []Platform{platform}, nil
+}
+
+// Digest implements Inspector. It returns the digest of the manifest image's tag points to,
+// the index of a multi-platform image, as the registry reports it for a HEAD request. Images
+// pinned to a digest already are returned as they are, without reaching the registry.
+func (i *HTTPInspector) Digest(ctx context.Context, image string, auth Auth) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if digestRegexp.MatchString(ref.Reference) {
+		return ref.Reference, nil
+	}
+	s := &session{client: i.Client, ref: ref, auth: auth}
+
+	resp, err := s.send(ctx, http.MethodHead, "manifests/"+ref.Reference, manifestAccept)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", image, err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving %s: registry returned %s", image, resp.Status)
+	}
+	if digest := resp.Header.Get("Docker-Content-Digest"); digest != "" {
+		if !digestRegexp.MatchString(digest) {
+			return "", fmt.Errorf("resolving %s: registry returned invalid digest %q", image, digest)
+		}
+		return digest, nil
+	}
+
+	// The header is optional; the digest is then that of the manifest as served.
+	if resp, err = s.send(ctx, http.MethodGet, "manifests/"+ref.Reference, manifestAccept); err != nil {
+		return "", fmt.Errorf("resolving %s: %w", image, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	manifest, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", image, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolving %s: registry returned %s: %s", image, resp.Status, bytes.TrimSpace(manifest))
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)), nil
+}
+
+// manifestAccept is the Accept header of manifest requests.
+var manifestAccept = strings.Join([]string{mediaTypeOCIIndex, mediaTypeDockerManifestList, mediaTypeOCIManifest, mediaTypeDockerManifest}, ", ")
+
+// session reads the objects of a repository, keeping the token it was granted.
+type session struct {
+	client *http.Client
+	ref    Reference
+	auth   Auth
+	token  string
+}
+
+// get decodes the JSON object at path of the repository, authenticating when challenged.
+func (s *session) get(ctx context.Context, path, accept string, out interface{}) error {
+	resp, err := s.send(ctx, http.MethodGet, path, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// send requests path of the repository, authenticating when challenged.
+func (s *session) send(ctx context.Context, method, path, accept string) (*http.Response, error) {
+	resp, err := s.do(ctx, method, path, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && s.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close() //nolint:errcheck
+		if err := s.authenticate(ctx, challenge); err != nil {
+			return nil, err
+		}
+		return s.do(ctx, method, path, accept)
+	}
+	return resp, nil
+}
+
+// do requests path of the repository with the session's credentials.
+func (s *session) do(ctx context.Context, method, path, accept string) (*http.Response, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/%s", s.ref.Registry, s.ref.Repository, path)
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	switch {
+	case s.token != "":
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	case s.auth.Username != "":
+		req.SetBasicAuth(s.auth.Username, s.auth.Password)
+	}
+	return s.client.Do(req)
+}
+
+// authenticate obtains a pull token from the token service named by a Bearer challenge.
+func (s *session) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("registry requires %s authentication", scheme)
+	}
+	attrs := parseChallenge(params)
+	realm, err := url.Parse(attrs["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid token realm %q", attrs["realm"])
+	}
+	query := realm.Query()
+	if service := attrs["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", s.ref.Repository))
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if s.auth.Username != "" {
+		req.SetBasicAuth(s.auth.Username, s.auth.Password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("token service returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("decoding token: %w", err)
+	}
+	s.token = token.Token
+	if s.token == "" {
+		s.token = token.AccessToken
+	}
+	if s.token == "" {
+		return fmt.Errorf("token service returned no token")
+	}
+	return nil
+}
+
+// parseChallenge parses the comma-separated key="value" parameters of a WWW-Authenticate challenge.
+func parseChallenge(params string) map[string]string {
+	attrs := map[string]string{}
+	for params != "" {
+		key, rest, ok := strings.Cut(strings.TrimLeft(params, ", "), "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		attrs[strings.ToLower(strings.TrimSpace(key))] = value
+		params = rest
+	}
+	return attrs
+}
+
+// CredentialsFor returns the credentials of the registry of image from a
+// .dockerconfigjson pull Secret, anonymous access when it has none.
+func CredentialsFor(dockerConfigJSON []byte, image string) (Auth, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return Auth{}, err
+	}
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(dockerConfigJSON, &config); err != nil {
+		return Auth{}, fmt.Errorf("decoding docker config: %w", err)
+	}
+	for server, entry := range config.Auths {
+		if registryHost(server) != ref.Registry {
+			continue
+		}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return Auth{}, fmt.Errorf("decoding credentials of %s: %w", server, err)
+			}
+			entry.Username, entry.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		return Auth{Username: entry.Username, Password: entry.Password}, nil
+	}
+	return Auth{}, nil
+}
+
+// registryHost returns the registry host of a docker config server entry, which may be a URL.
+func registryHost(server string) string {
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		server = u.Host
+	}
+	server, _, _ = strings.Cut(server, "/")
+	switch server {
+	case "docker.io", "index.docker.io":
+		return "registry-1.docker.io"
+	}
+	return server
+}
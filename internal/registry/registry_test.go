@@ -0,0 +1,166 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseReference", func() {
+	It("should resolve Docker Hub images and tags", func() {
+		Expect(ParseReference("nginx")).To(Equal(Reference{Registry: "registry-1.docker.io", Repository: "library/nginx", Reference: "latest"}))
+		Expect(ParseReference("bitnami/redis:7.2")).To(Equal(Reference{Registry: "registry-1.docker.io", Repository: "bitnami/redis", Reference: "7.2"}))
+	})
+
+	It("should keep the registry host and digest", func() {
+		Expect(ParseReference("localhost:5000/team/web@sha256:abc")).To(Equal(Reference{Registry: "localhost:5000", Repository: "team/web", Reference: "sha256:abc"}))
+		Expect(ParseReference("ghcr.io/org/app:1.0")).To(Equal(Reference{Registry: "ghcr.io", Repository: "org/app", Reference: "1.0"}))
+	})
+})
+
+var _ = Describe("ValidateReference", func() {
+	It("should accept references with registry, tag and digest", func() {
+		Expect(ValidateReference("nginx")).To(Succeed())
+		Expect(ValidateReference("localhost:5000/team/web:v1.2_rc-1")).To(Succeed())
+		Expect(ValidateReference("ghcr.io/org/app@sha256:" + strings.Repeat("ab", 32))).To(Succeed())
+	})
+
+	It("should reject malformed references", func() {
+		for _, image := range []string{"", "Nginx", "nginx:", "nginx:1.0:2", "ghcr.io//app", "nginx@sha256:abc", "nginx latest", strings.Repeat("a", 256)} {
+			Expect(ValidateReference(image)).NotTo(Succeed(), image)
+		}
+	})
+})
+
+var _ = Describe("HTTPInspector", func() {
+	var (
+		server    *httptest.Server
+		inspector *HTTPInspector
+		image     string
+	)
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			if user, password, _ := r.BasicAuth(); user != "robot" || password != "secret" {
+				http.Error(w, "denied", http.StatusUnauthorized)
+				return
+			}
+			Expect(r.URL.Query().Get("scope")).To(Equal("repository:team/web:pull"))
+			_, _ = w.Write([]byte(`{"token": "pull-token"}`))
+		})
+		mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer pull-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Path {
+			case "/v2/team/web/manifests/multi":
+				w.Header().Set("Content-Type", mediaTypeOCIIndex)
+				_, _ = w.Write([]byte(`{"mediaType": "` + mediaTypeOCIIndex + `", "manifests": [
+					{"platform": {"os": "linux", "architecture": "amd64"}},
+					{"platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+					{"platform": {"os": "unknown", "architecture": "unknown"}}]}`))
+			case "/v2/team/web/manifests/single":
+				_, _ = w.Write([]byte(`{"mediaType": "` + mediaTypeDockerManifest + `", "config": {"digest": "sha256:cfg"}}`))
+			case "/v2/team/web/manifests/stable":
+				w.Header().Set("Docker-Content-Digest", "sha256:"+strings.Repeat("ab", 32))
+				if r.Method == http.MethodGet {
+					_, _ = w.Write([]byte(`{}`))
+				}
+			case "/v2/team/web/manifests/legacy":
+				if r.Method == http.MethodGet {
+					_, _ = w.Write([]byte(`{}`))
+				}
+			case "/v2/team/web/blobs/sha256:cfg":
+				_, _ = w.Write([]byte(`{"os": "linux", "architecture": "arm64", "rootfs": {}}`))
+			default:
+				http.NotFound(w, r)
+			}
+		})
+		server = httptest.NewTLSServer(mux)
+		inspector = &HTTPInspector{Client: server.Client()}
+		image = strings.TrimPrefix(server.URL, "https://") + "/team/web"
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should list the platforms of a multi-platform image", func() {
+		platforms, err := inspector.Platforms(context.Background(), image+":multi", Auth{Username: "robot", Password: "secret"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(platforms).To(Equal([]Platform{
+			{OS: "linux", Architecture: "amd64"},
+			{OS: "linux", Architecture: "arm64", Variant: "v8"},
+		}))
+	})
+
+	It("should read the platform of a single-platform image from its config", func() {
+		platforms, err := inspector.Platforms(context.Background(), image+":single", Auth{Username: "robot", Password: "secret"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(platforms).To(Equal([]Platform{{OS: "linux", Architecture: "arm64"}}))
+	})
+
+	It("should resolve a tag to the digest the registry reports", func() {
+		digest, err := inspector.Digest(context.Background(), image+":stable", Auth{Username: "robot", Password: "secret"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("sha256:" + strings.Repeat("ab", 32)))
+	})
+
+	It("should hash the manifest of a registry not reporting digests", func() {
+		digest, err := inspector.Digest(context.Background(), image+":legacy", Auth{Username: "robot", Password: "secret"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"))
+	})
+
+	It("should not resolve images pinned to a digest", func() {
+		pinned := "sha256:" + strings.Repeat("cd", 32)
+		Expect(inspector.Digest(context.Background(), image+":missing@"+pinned, Auth{})).To(Equal(pinned))
+	})
+
+	It("should fail to resolve missing tags", func() {
+		_, err := inspector.Digest(context.Background(), image+":missing", Auth{Username: "robot", Password: "secret"})
+		Expect(err).To(MatchError(ContainSubstring("404")))
+	})
+
+	It("should surface authentication failures", func() {
+		_, err := inspector.Platforms(context.Background(), image+":multi", Auth{})
+		Expect(err).To(MatchError(ContainSubstring("401")))
+	})
+})
+
+var _ = Describe("CredentialsFor", func() {
+	It("should pick the credentials of the image's registry", func() {
+		config := []byte(`{"auths": {
+			"https://index.docker.io/v1/": {"auth": "aHViOnB3"},
+			"ghcr.io": {"username": "bot", "password": "token"}}}`)
+
+		Expect(CredentialsFor(config, "ghcr.io/org/app:1.0")).To(Equal(Auth{Username: "bot", Password: "token"}))
+		Expect(CredentialsFor(config, "nginx")).To(Equal(Auth{Username: "hub", Password: "pw"}))
+		Expect(CredentialsFor(config, "quay.io/org/app")).To(Equal(Auth{}))
+	})
+})
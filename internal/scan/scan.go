@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scan queries a vulnerability scanner for container images before they are rolled out.
+package scan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Summary summarises the vulnerabilities found in an image, by severity.
+type Summary struct {
+	Critical int32 `json:"critical"`
+	High     int32 `json:"high"`
+	Medium   int32 `json:"medium"`
+	Low      int32 `json:"low"`
+}
+
+// AtOrAbove returns the number of vulnerabilities of the given severity or worse.
+// Severity is one of Critical, High, Medium or Low.
+func (s *Summary) AtOrAbove(severity string) int32 {
+	switch severity {
+	case "Low":
+		return s.Critical + s.High + s.Medium + s.Low
+	case "Medium":
+		return s.Critical + s.High + s.Medium
+	case "High":
+		return s.Critical + s.High
+	default:
+		return s.Critical
+	}
+}
+
+// Scanner scans container images for known vulnerabilities.
+type Scanner interface {
+	Scan(ctx context.Context, image string) (*Summary, error)
+}
+
+// HTTPScanner asks a scanner service for a summary over HTTP. It POSTs
+// {"image": "<reference>"} to URL and expects a JSON Summary in response, a protocol
+// small enough to put in front of Trivy, Grype or a registry's built-in scanner.
+type HTTPScanner struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPScanner returns an HTTPScanner for url with a bounded request timeout.
+func NewHTTPScanner(url string) *HTTPScanner {
+	return &HTTPScanner{URL: url, Client: &http.Client{Timeout: 2 * time.Minute}}
+}
+
+// Scan implements Scanner.
+func (s *HTTPScanner) Scan(ctx context.Context, image string) (*Summary, error) {
+	body, err := json.Marshal(map[string]string{"image": image})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", image, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("scanning %s: scanner returned %s: %s", image, resp.Status, bytes.TrimSpace(msg))
+	}
+	summary := &Summary{}
+	if err := json.NewDecoder(resp.Body).Decode(summary); err != nil {
+		return nil, fmt.Errorf("scanning %s: decoding summary: %w", image, err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scan
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTTPScanner", func() {
+	It("should post the image and decode the summary", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+			var req map[string]string
+			Expect(json.NewDecoder(r.Body).Decode(&req)).To(Succeed())
+			Expect(req).To(HaveKeyWithValue("image", "nginx:1.27"))
+			_, _ = w.Write([]byte(`{"critical": 1, "high": 2, "medium": 3, "low": 4}`))
+		}))
+		defer server.Close()
+
+		summary, err := NewHTTPScanner(server.URL).Scan(context.Background(), "nginx:1.27")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*summary).To(Equal(Summary{Critical: 1, High: 2, Medium: 3, Low: 4}))
+	})
+
+	It("should surface scanner errors", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "image not found", http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPScanner(server.URL).Scan(context.Background(), "missing:latest")
+		Expect(err).To(MatchError(ContainSubstring("image not found")))
+	})
+})
+
+var _ = Describe("Summary", func() {
+	It("should count vulnerabilities at or above a severity", func() {
+		summary := &Summary{Critical: 1, High: 2, Medium: 3, Low: 4}
+
+		Expect(summary.AtOrAbove("Critical")).To(Equal(int32(1)))
+		Expect(summary.AtOrAbove("High")).To(Equal(int32(3)))
+		Expect(summary.AtOrAbove("Low")).To(Equal(int32(10)))
+	})
+})
@@ -0,0 +1,350 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sops decrypts SOPS-encrypted YAML and JSON documents with age keys,
+// so encrypted configuration can be kept in Git and only decrypted in-cluster.
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// metadataKey is the top-level key holding SOPS metadata in an encrypted document.
+const metadataKey = "sops"
+
+// encryptedValue matches a value encrypted by SOPS, e.g.
+// ENC[AES256_GCM,data:...,iv:...,tag:...,type:str].
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// Decryptor decrypts SOPS documents whose data key is encrypted to one of its age identities.
+type Decryptor struct {
+	identities []age.Identity
+}
+
+// NewDecryptor returns a Decryptor using the given age identities.
+func NewDecryptor(identities ...age.Identity) *Decryptor {
+	return &Decryptor{identities: identities}
+}
+
+// NewDecryptorFromKeyFile returns a Decryptor using the age identities in keyFile,
+// in the same format as SOPS_AGE_KEY_FILE (typically a mounted Secret).
+func NewDecryptorFromKeyFile(keyFile string) (*Decryptor, error) {
+	f, err := os.Open(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing age identities from %s: %w", keyFile, err)
+	}
+	return NewDecryptor(identities...), nil
+}
+
+// IsEncrypted reports whether data is a SOPS-encrypted YAML or JSON document.
+func IsEncrypted(data []byte) bool {
+	if !bytes.Contains(data, []byte("ENC[AES256_GCM,")) {
+		return false
+	}
+	doc, err := parse(data)
+	if err != nil {
+		return false
+	}
+	return lookup(doc.Content[0], metadataKey) != nil
+}
+
+// Decrypt returns the plaintext form of a SOPS-encrypted document, without its SOPS metadata.
+// JSON documents are returned as JSON, anything else as YAML.
+//
+// Each value is authenticated by its own AES-GCM tag, and the document as a whole by
+// the MAC in its metadata, so values removed, added or reordered are detected too.
+func (d *Decryptor) Decrypt(data []byte) ([]byte, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	metadata := lookup(doc.Content[0], metadataKey)
+	if metadata == nil {
+		return nil, errors.New("document has no sops metadata")
+	}
+
+	key, err := d.dataKey(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	root := doc.Content[0]
+	content := make([]*yaml.Node, 0, len(root.Content))
+	mac := &macWriter{hash: sha512.New()}
+	if onlyEncrypted := lookup(metadata, "mac_only_encrypted"); onlyEncrypted != nil {
+		mac.onlyEncrypted = onlyEncrypted.Value == "true"
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		if k.Value == metadataKey {
+			continue
+		}
+		if err := decryptNode(v, []string{k.Value}, key, mac); err != nil {
+			return nil, err
+		}
+		content = append(content, k, v)
+	}
+	root.Content = content
+	if err := verifyMAC(metadata, key, mac.hash); err != nil {
+		return nil, err
+	}
+
+	if isJSON(data) {
+		var out interface{}
+		if err := root.Decode(&out); err != nil {
+			return nil, err
+		}
+		return json.MarshalIndent(out, "", "  ")
+	}
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// dataKey recovers the document's data key from the age recipients in its metadata.
+func (d *Decryptor) dataKey(metadata *yaml.Node) ([]byte, error) {
+	recipients := lookup(metadata, "age")
+	if recipients == nil || recipients.Kind != yaml.SequenceNode || len(recipients.Content) == 0 {
+		return nil, errors.New("document has no age recipients; only age-encrypted data keys are supported")
+	}
+	var lastErr error
+	for _, recipient := range recipients.Content {
+		enc := lookup(recipient, "enc")
+		if enc == nil {
+			continue
+		}
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(enc.Value)), d.identities...)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return io.ReadAll(r)
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no age recipient has an encrypted data key")
+	}
+	return nil, fmt.Errorf("decrypting data key: %w", lastErr)
+}
+
+// macWriter accumulates the cleartext values of a document into its MAC, the way
+// SOPS does: in document order, with every value formatted as by Python's str().
+type macWriter struct {
+	hash hash.Hash
+	// onlyEncrypted leaves unencrypted values out, for documents encrypted with
+	// --mac-only-encrypted.
+	onlyEncrypted bool
+}
+
+// write adds a cleartext value to the MAC.
+func (w *macWriter) write(value []byte, encrypted bool) {
+	if encrypted || !w.onlyEncrypted {
+		w.hash.Write(value) //nolint:errcheck // hash.Hash never returns an error
+	}
+}
+
+// verifyMAC checks the MAC of the document's cleartext values, written to sum,
+// against the MAC in its metadata. SOPS encrypts the MAC with the data key, bound
+// to the document's last modification time.
+func verifyMAC(metadata *yaml.Node, key []byte, sum hash.Hash) error {
+	mac, lastModified := lookup(metadata, "mac"), lookup(metadata, "lastmodified")
+	if mac == nil || lastModified == nil {
+		return errors.New("document has no MAC")
+	}
+	want, _, err := decryptValue(mac.Value, lastModified.Value, key)
+	if err != nil {
+		return fmt.Errorf("decrypting MAC: %w", err)
+	}
+	got := fmt.Sprintf("%X", sum.Sum(nil))
+	if subtle.ConstantTimeCompare([]byte(got), want) != 1 {
+		return errors.New("document MAC does not match its values; it was modified without the data key")
+	}
+	return nil
+}
+
+// decryptNode decrypts all encrypted scalars below node in place and adds every value
+// to mac. path holds the mapping keys leading to node; SOPS binds every value to its
+// path as additional data.
+func decryptNode(node *yaml.Node, path []string, key []byte, mac *macWriter) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := decryptNode(node.Content[i+1], append(path, node.Content[i].Value), key, mac); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		// List items share the path of the list itself.
+		for _, item := range node.Content {
+			if err := decryptNode(item, path, key, mac); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return decryptScalar(node, strings.Join(path, ":")+":", key, mac)
+	}
+	return nil
+}
+
+// decryptScalar replaces an encrypted scalar with its plaintext value. Unencrypted
+// scalars (e.g. keys matching the document's unencrypted suffix) are left untouched.
+func decryptScalar(node *yaml.Node, additionalData string, key []byte, mac *macWriter) error {
+	if !encryptedValue.MatchString(node.Value) {
+		value, err := scalarBytes(node)
+		if err != nil {
+			return fmt.Errorf("reading value at %q: %w", additionalData, err)
+		}
+		mac.write(value, false)
+		return nil
+	}
+	plaintext, typ, err := decryptValue(node.Value, additionalData, key)
+	if err != nil {
+		return err
+	}
+	if typ != "comment" {
+		mac.write(plaintext, true)
+	}
+
+	node.Value = string(plaintext)
+	node.Style = 0
+	switch typ {
+	case "int":
+		node.Tag = "!!int"
+	case "float":
+		node.Tag = "!!float"
+	case "bool":
+		node.Tag = "!!bool"
+	default:
+		node.Tag = "!!str"
+	}
+	return nil
+}
+
+// decryptValue decrypts a value in the ENC[AES256_GCM,...] format bound to additionalData,
+// returning its plaintext and type.
+func decryptValue(value, additionalData string, key []byte) ([]byte, string, error) {
+	m := encryptedValue.FindStringSubmatch(value)
+	if m == nil {
+		return nil, "", fmt.Errorf("value at %q is not encrypted", additionalData)
+	}
+	var parts [3][]byte
+	for i, s := range m[1:4] {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, "", fmt.Errorf("decoding encrypted value at %q: %w", additionalData, err)
+		}
+		parts[i] = b
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, "", fmt.Errorf("decrypting value at %q: %w", additionalData, err)
+	}
+	return plaintext, m[4], nil
+}
+
+// scalarBytes formats an unencrypted scalar the way SOPS does when computing the MAC.
+// Nulls contribute nothing.
+func scalarBytes(node *yaml.Node) ([]byte, error) {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case bool:
+		if v {
+			return []byte("True"), nil
+		}
+		return []byte("False"), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	default:
+		return []byte(fmt.Sprint(v)), nil
+	}
+}
+
+// parse parses a YAML or JSON document whose root must be a mapping.
+func parse(data []byte) (*yaml.Node, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("document root is not a mapping")
+	}
+	return doc, nil
+}
+
+// lookup returns the value of key in a mapping node, or nil.
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// isJSON reports whether data looks like a JSON object rather than YAML.
+func isJSON(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// encryptValue encrypts a value the way SOPS does, binding it to its path.
+func encryptValue(key []byte, value, typ, path string) string {
+	block, err := aes.NewCipher(key)
+	Expect(err).NotTo(HaveOccurred())
+	iv := make([]byte, 32)
+	_, err = rand.Read(iv)
+	Expect(err).NotTo(HaveOccurred())
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	Expect(err).NotTo(HaveOccurred())
+	sealed := gcm.Seal(nil, iv, []byte(value), []byte(path))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data), base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag), typ)
+}
+
+// lastModified is the modification time the test documents' MAC is bound to.
+const lastModified = "2025-06-01T12:00:00Z"
+
+// encryptMAC returns the encrypted MAC of a document with the given cleartext values.
+func encryptMAC(key []byte, values ...string) string {
+	sum := sha512.New()
+	for _, value := range values {
+		sum.Write([]byte(value))
+	}
+	return encryptValue(key, fmt.Sprintf("%X", sum.Sum(nil)), "str", lastModified)
+}
+
+// encryptDataKey encrypts a data key to an age recipient as an armored age file.
+func encryptDataKey(key []byte, recipient age.Recipient) string {
+	buf := &bytes.Buffer{}
+	aw := armor.NewWriter(buf)
+	w, err := age.Encrypt(aw, recipient)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = w.Write(key)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(w.Close()).To(Succeed())
+	Expect(aw.Close()).To(Succeed())
+	return buf.String()
+}
+
+// indent indents every line of s by n spaces.
+func indent(s string, n int) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n"+pad)
+}
+
+var _ = Describe("Decryptor", func() {
+	var (
+		identity *age.X25519Identity
+		key      []byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		identity, err = age.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+		key = make([]byte, 32)
+		_, err = rand.Read(key)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	encryptedYAML := func() []byte {
+		return []byte(fmt.Sprintf(`database:
+  password: %s
+  port: %s
+  host_unencrypted: db.internal
+tags:
+  - %s
+sops:
+  age:
+    - recipient: %s
+      enc: |
+%s
+  lastmodified: "%s"
+  mac: %s
+  version: 3.9.0
+`,
+			encryptValue(key, "s3cr3t", "str", "database:password:"),
+			encryptValue(key, "5432", "int", "database:port:"),
+			encryptValue(key, "blue", "str", "tags:"),
+			identity.Recipient(), indent(encryptDataKey(key, identity.Recipient()), 8),
+			lastModified, encryptMAC(key, "s3cr3t", "5432", "db.internal", "blue")))
+	}
+
+	It("should detect SOPS documents", func() {
+		Expect(IsEncrypted(encryptedYAML())).To(BeTrue())
+		Expect(IsEncrypted([]byte("database:\n  password: plain\n"))).To(BeFalse())
+		Expect(IsEncrypted([]byte("not: [valid"))).To(BeFalse())
+	})
+
+	It("should decrypt a YAML document and drop the sops metadata", func() {
+		out, err := NewDecryptor(identity).Decrypt(encryptedYAML())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(out)).To(Equal(`database:
+  password: s3cr3t
+  port: 5432
+  host_unencrypted: db.internal
+tags:
+  - blue
+`))
+	})
+
+	It("should decrypt a JSON document to JSON", func() {
+		doc := fmt.Sprintf(`{"token": %q, "sops": {"age": [{"recipient": %q, "enc": %q}], "lastmodified": %q, "mac": %q}}`,
+			encryptValue(key, "abc", "str", "token:"), identity.Recipient(), encryptDataKey(key, identity.Recipient()),
+			lastModified, encryptMAC(key, "abc"))
+
+		out, err := NewDecryptor(identity).Decrypt([]byte(doc))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(MatchJSON(`{"token": "abc"}`))
+	})
+
+	It("should fail without a matching age identity", func() {
+		other, err := age.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = NewDecryptor(other).Decrypt(encryptedYAML())
+		Expect(err).To(MatchError(ContainSubstring("decrypting data key")))
+	})
+
+	It("should reject values moved to another path", func() {
+		doc := strings.Replace(string(encryptedYAML()), "password:", "passwd:", 1)
+
+		_, err := NewDecryptor(identity).Decrypt([]byte(doc))
+		Expect(err).To(MatchError(ContainSubstring("database:passwd:")))
+	})
+
+	It("should reject documents with values removed or changed", func() {
+		lines := strings.Split(string(encryptedYAML()), "\n")
+		Expect(lines[2]).To(HavePrefix("  port: ENC["))
+		removed := strings.Join(append(lines[:2:2], lines[3:]...), "\n")
+
+		_, err := NewDecryptor(identity).Decrypt([]byte(removed))
+		Expect(err).To(MatchError(ContainSubstring("MAC does not match")))
+
+		changed := strings.Replace(string(encryptedYAML()), "db.internal", "db.attacker.example", 1)
+		_, err = NewDecryptor(identity).Decrypt([]byte(changed))
+		Expect(err).To(MatchError(ContainSubstring("MAC does not match")))
+	})
+
+	It("should reject documents without a MAC", func() {
+		doc := strings.Replace(string(encryptedYAML()), "  mac: ", "  unused: ", 1)
+
+		_, err := NewDecryptor(identity).Decrypt([]byte(doc))
+		Expect(err).To(MatchError(ContainSubstring("document has no MAC")))
+	})
+})
@@ -0,0 +1,332 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	"github.com/your-org/my-app-controller/internal/policy"
+	"github.com/your-org/my-app-controller/internal/registry"
+)
+
+const (
+	// defaultPort is the port of Apps created without one.
+	defaultPort = 8080
+	// defaultReplicas is the number of pods of Apps created without replicas nor autoscaling.
+	defaultReplicas = 1
+)
+
+// log is for logging in this package.
+var applog = logf.Log.WithName("app-resource")
+
+// SetupAppWebhookWithManager registers the webhook for App in the manager.
+func SetupAppWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&webappv1.App{}).
+		WithDefaulter(&AppCustomDefaulter{}).
+		WithValidator(&AppCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-webapp-example-com-v1-app,mutating=true,failurePolicy=fail,sideEffects=None,groups=webapp.example.com,resources=apps,verbs=create;update,versions=v1,name=mapp-v1.kb.io,admissionReviewVersions=v1
+
+// AppCustomDefaulter struct is responsible for setting default values on the custom resource of the
+// Kind App when those are created or updated.
+type AppCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &AppCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind App.
+// It defaults the port, replicas and seccomp profile of the App, and records the user
+// creating the App, or changing its spec, in the last-modified-by annotation. Updates leaving
+// the spec untouched, defaults aside, keep the previous value, so the annotation cannot be
+// set by hand.
+func (d *AppCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	app, ok := obj.(*webappv1.App)
+	if !ok {
+		return fmt.Errorf("expected an App object but got %T", obj)
+	}
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	var old *webappv1.App
+	if req.Operation == admissionv1.Update {
+		old = &webappv1.App{}
+		if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return fmt.Errorf("decoding the previous App: %w", err)
+		}
+	}
+	defaultSpec(&app.Spec, old)
+
+	actor := req.UserInfo.Username
+	if old != nil && equality.Semantic.DeepEqual(defaultedSpec(old), app.Spec) {
+		actor = old.Annotations[webappv1.LastModifiedByAnnotation]
+	}
+
+	if actor == "" {
+		delete(app.Annotations, webappv1.LastModifiedByAnnotation)
+		return nil
+	}
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+	if app.Annotations[webappv1.LastModifiedByAnnotation] != actor {
+		applog.V(1).Info("Recording App change", "name", app.GetName(), "namespace", app.GetNamespace(), "user", actor)
+	}
+	app.Annotations[webappv1.LastModifiedByAnnotation] = actor
+	return nil
+}
+
+// defaultSpec sets the port, replicas and pod seccomp profile of an App left without them.
+// The seccomp profile defaults to RuntimeDefault, as the CRD schema does for Apps that set
+// spec.security; Apps stored before either default existed get it on their next update.
+// Replicas are only defaulted without autoscaling. An update adding autoscaling to an App
+// drops replicas it leaves unchanged, typically the default of an earlier version that a
+// client-side apply doesn't know to remove; replicas changed along with it are rejected by
+// the validator.
+func defaultSpec(spec *webappv1.AppSpec, old *webappv1.App) {
+	if spec.Port == 0 {
+		spec.Port = defaultPort
+	}
+	if spec.Security == nil {
+		spec.Security = &webappv1.SecuritySpec{}
+	}
+	if spec.Security.SeccompProfile == nil {
+		spec.Security.SeccompProfile = &webappv1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+	if spec.Autoscaling != nil {
+		if old != nil && old.Spec.Autoscaling == nil && spec.Replicas == old.Spec.Replicas {
+			spec.Replicas = 0
+		}
+		return
+	}
+	if spec.Replicas == 0 {
+		spec.Replicas = defaultReplicas
+	}
+}
+
+// defaultedSpec returns the spec of a previous App with the defaults of defaultSpec, so that
+// an update doesn't count as a change of spec for the defaults alone.
+func defaultedSpec(old *webappv1.App) webappv1.AppSpec {
+	spec := *old.Spec.DeepCopy()
+	defaultSpec(&spec, nil)
+	return spec
+}
+
+// +kubebuilder:webhook:path=/validate-webapp-example-com-v1-app,mutating=false,failurePolicy=fail,sideEffects=None,groups=webapp.example.com,resources=apps,verbs=create;update,versions=v1,name=vapp-v1.kb.io,admissionReviewVersions=v1
+
+// AppCustomValidator struct is responsible for validating the App resource when it is
+// created or updated. It covers what the CRD schema can't express, and rules the schema
+// applies too, so Apps are rejected with the same message either way, and enforces the
+// AppPolicies selecting the App's namespace: their rules, and their grants of references
+// leaving it.
+type AppCustomValidator struct {
+	// Client reads AppPolicies and the labels of namespaces.
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &AppCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type App.
+func (v *AppCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	app, ok := obj.(*webappv1.App)
+	if !ok {
+		return nil, fmt.Errorf("expected an App object but got %T", obj)
+	}
+	applog.V(1).Info("Validation for App upon creation", "name", app.GetName())
+	policies, err := policy.ForNamespace(ctx, v.Client, app.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	errs := append(validateSpec(&app.Spec), policy.CheckReferences(app, policies)...)
+	if err := invalid(app, errs); err != nil {
+		return nil, err
+	}
+	return nil, validateRules(app, nil, policies)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type App.
+func (v *AppCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	app, ok := newObj.(*webappv1.App)
+	if !ok {
+		return nil, fmt.Errorf("expected an App object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*webappv1.App)
+	if !ok {
+		return nil, fmt.Errorf("expected an App object for the oldObj but got %T", oldObj)
+	}
+	applog.V(1).Info("Validation for App upon update", "name", app.GetName())
+
+	policies, err := policy.ForNamespace(ctx, v.Client, app.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	errs := append(validateSpec(&app.Spec), policy.CheckReferences(app, policies)...)
+	// Objects delivered to a target cluster are only removed from it when the App is deleted,
+	// so moving an App to another cluster, or back to its own, would leave them running.
+	if old.Spec.TargetCluster != nil && !equality.Semantic.DeepEqual(old.Spec.TargetCluster, app.Spec.TargetCluster) {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "targetCluster"),
+			"is immutable once set; delete and recreate the App to deliver it elsewhere"))
+	}
+	// Volumes can be expanded but never shrunk.
+	if old.Spec.Storage != nil && app.Spec.Storage != nil && app.Spec.Storage.Size.Cmp(old.Spec.Storage.Size) < 0 {
+		errs = append(errs, field.Forbidden(field.NewPath("spec", "storage", "size"),
+			fmt.Sprintf("can't be less than the current size of %s", old.Spec.Storage.Size.String())))
+	}
+	if err := invalid(app, errs); err != nil {
+		return nil, err
+	}
+	return nil, validateRules(app, old, policies)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type App.
+func (v *AppCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateRules returns a Forbidden error listing the rules of the AppPolicies applying to the
+// App that it doesn't satisfy. Policies whose rules don't compile reject every App they
+// apply to, as their admission policies would.
+func validateRules(app, old *webappv1.App, policies []webappv1.AppPolicy) error {
+	var violations []string
+	for i := range policies {
+		rules, err := policy.Compile(&policies[i])
+		if err != nil {
+			violations = append(violations, err.Error())
+			continue
+		}
+		unsatisfied, err := rules.Validate(app, old)
+		if err != nil {
+			return err
+		}
+		violations = append(violations, unsatisfied...)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return apierrors.NewForbidden(webappv1.GroupVersion.WithResource("apps").GroupResource(), app.Name,
+		errors.New(strings.Join(violations, "; ")))
+}
+
+// validateSpec returns the errors of an App's spec that don't depend on its previous version.
+func validateSpec(spec *webappv1.AppSpec) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	if err := registry.ValidateReference(spec.Image); err != nil {
+		errs = append(errs, field.Invalid(specPath.Child("image"), spec.Image, err.Error()))
+	}
+	if spec.Resources != nil {
+		for name, request := range spec.Resources.Requests {
+			if limit, ok := spec.Resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+				errs = append(errs, field.Invalid(specPath.Child("resources", "requests").Key(string(name)), request.String(),
+					fmt.Sprintf("must be less than or equal to the %s limit of %s", name, limit.String())))
+			}
+		}
+	}
+	if spec.Autoscaling != nil && spec.Replicas != 0 {
+		errs = append(errs, field.Forbidden(specPath.Child("replicas"),
+			"can't be set together with autoscaling, which sets the number of pods"))
+	}
+	if spec.Storage != nil && spec.Strategy != nil && spec.Strategy.Type != webappv1.RollingUpdateStrategy && !sharedAccess(spec.Storage) {
+		errs = append(errs, field.Forbidden(specPath.Child("storage", "accessModes"),
+			fmt.Sprintf("must include ReadWriteMany or ReadOnlyMany with the %s strategy, whose Deployments mount the volume together", spec.Strategy.Type)))
+	}
+	return append(errs, validateContainers(spec, specPath)...)
+}
+
+// validateContainers returns the errors of spec.containers, spec.initContainers and
+// spec.sidecars: their images must be valid references, and their names, like the names of
+// the ports the App's Service gets from them, must be unique across the pod.
+func validateContainers(spec *webappv1.AppSpec, specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	containerName := spec.ContainerName
+	if containerName == "" {
+		containerName = "app-container"
+	}
+	names := map[string]bool{containerName: true}
+	portNames := map[string]bool{"app": true}
+	if spec.Service != nil {
+		for _, port := range spec.Service.Ports {
+			portNames[port.Name] = true
+		}
+	}
+	for _, list := range []struct {
+		path       *field.Path
+		containers []webappv1.Container
+	}{
+		{specPath.Child("containers"), spec.Containers},
+		{specPath.Child("initContainers"), spec.InitContainers},
+		{specPath.Child("sidecars"), spec.Sidecars},
+	} {
+		for i, container := range list.containers {
+			path := list.path.Index(i)
+			if names[container.Name] {
+				errs = append(errs, field.Duplicate(path.Child("name"), container.Name))
+			}
+			names[container.Name] = true
+			if err := registry.ValidateReference(container.Image); err != nil {
+				errs = append(errs, field.Invalid(path.Child("image"), container.Image, err.Error()))
+			}
+			for j, port := range container.Ports {
+				if port.Name == "" {
+					continue
+				}
+				if portNames[port.Name] {
+					errs = append(errs, field.Duplicate(path.Child("ports").Index(j).Child("name"), port.Name))
+				}
+				portNames[port.Name] = true
+			}
+		}
+	}
+	return errs
+}
+
+// sharedAccess reports whether the volume of spec.storage can be mounted on several nodes.
+func sharedAccess(storage *webappv1.StorageSpec) bool {
+	for _, mode := range storage.AccessModes {
+		if mode == corev1.ReadWriteMany || mode == corev1.ReadOnlyMany {
+			return true
+		}
+	}
+	return false
+}
+
+// invalid returns an Invalid error for the App listing errs, nil when there are none.
+func invalid(app *webappv1.App, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(webappv1.GroupVersion.WithKind("App").GroupKind(), app.Name, errs)
+}
@@ -0,0 +1,413 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	webappv2 "github.com/your-org/my-app-controller/api/v2"
+)
+
+var _ = Describe("App Webhook", func() {
+	var (
+		obj       *webappv1.App
+		oldObj    *webappv1.App
+		defaulter AppCustomDefaulter
+		validator AppCustomValidator
+	)
+
+	BeforeEach(func() {
+		obj = &webappv1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       webappv1.AppSpec{Image: "nginx:1.27", Replicas: 2, Port: 80},
+		}
+		oldObj = obj.DeepCopy()
+		oldObj.Annotations = map[string]string{webappv1.LastModifiedByAnnotation: "alice"}
+		defaulter = AppCustomDefaulter{}
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"environment": "production"}}}
+		validator = AppCustomValidator{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(namespace).Build()}
+	})
+
+	// admit runs the defaulter for a request made by user.
+	admit := func(operation admissionv1.Operation, user string) error {
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			UserInfo:  authenticationv1.UserInfo{Username: user},
+		}}
+		if operation == admissionv1.Update {
+			raw, err := json.Marshal(oldObj)
+			Expect(err).NotTo(HaveOccurred())
+			req.OldObject = runtime.RawExtension{Raw: raw}
+		}
+		return defaulter.Default(admission.NewContextWithRequest(ctx, req), obj)
+	}
+
+	Context("When creating or updating App under Defaulting Webhook", func() {
+		It("Should record the user creating the App", func() {
+			Expect(admit(admissionv1.Create, "alice")).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(webappv1.LastModifiedByAnnotation, "alice"))
+		})
+
+		It("Should record the user changing the spec", func() {
+			obj.Spec.Image = "nginx:1.28"
+			Expect(admit(admissionv1.Update, "system:serviceaccount:ci:deployer")).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(webappv1.LastModifiedByAnnotation, "system:serviceaccount:ci:deployer"))
+		})
+
+		It("Should keep the previous user when the spec is unchanged", func() {
+			obj.Annotations = map[string]string{webappv1.LastModifiedByAnnotation: "mallory"}
+			Expect(admit(admissionv1.Update, "bob")).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(webappv1.LastModifiedByAnnotation, "alice"))
+		})
+
+		It("Should default the port and replicas", func() {
+			obj.Spec.Port, obj.Spec.Replicas = 0, 0
+			Expect(admit(admissionv1.Create, "alice")).To(Succeed())
+			Expect(obj.Spec.Port).To(Equal(int32(8080)))
+			Expect(obj.Spec.Replicas).To(Equal(int32(1)))
+		})
+
+		It("Should default the seccomp profile to RuntimeDefault", func() {
+			Expect(admit(admissionv1.Create, "alice")).To(Succeed())
+			Expect(obj.Spec.Security).NotTo(BeNil())
+			Expect(obj.Spec.Security.SeccompProfile).To(Equal(&webappv1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}))
+		})
+
+		It("Should keep a seccomp profile set by the user", func() {
+			localhost := &webappv1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: ptr.To("profiles/web.json")}
+			obj.Spec.Security = &webappv1.SecuritySpec{SeccompProfile: localhost.DeepCopy()}
+			Expect(admit(admissionv1.Create, "alice")).To(Succeed())
+			Expect(obj.Spec.Security.SeccompProfile).To(Equal(localhost))
+		})
+
+		It("Should leave replicas to autoscaling", func() {
+			obj.Spec.Replicas = 0
+			obj.Spec.Autoscaling = &webappv1.AutoscalingSpec{MaxReplicas: 5}
+			Expect(admit(admissionv1.Create, "alice")).To(Succeed())
+			Expect(obj.Spec.Replicas).To(BeZero())
+		})
+
+		It("Should drop the replicas left over when autoscaling is added", func() {
+			obj.Spec.Autoscaling = &webappv1.AutoscalingSpec{MaxReplicas: 5}
+			Expect(admit(admissionv1.Update, "bob")).To(Succeed())
+			Expect(obj.Spec.Replicas).To(BeZero())
+		})
+	})
+
+	Context("When creating or updating App under Validating Webhook", func() {
+		It("Should admit a valid App", func() {
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeEmpty())
+		})
+
+		It("Should reject a malformed image reference", func() {
+			obj.Spec.Image = "Nginx:1.27"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("spec.image")))
+		})
+
+		It("Should reject requests above their limit", func() {
+			obj.Spec.Resources = &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.resources.requests[memory]")))
+		})
+
+		It("Should reject replicas set together with autoscaling", func() {
+			obj.Spec.Autoscaling = &webappv1.AutoscalingSpec{MaxReplicas: 5}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.replicas")))
+		})
+
+		It("Should reject changes to the target cluster", func() {
+			oldObj.Spec.TargetCluster = &webappv1.TargetCluster{ClusterName: "east"}
+			obj.Spec.TargetCluster = &webappv1.TargetCluster{ClusterName: "west"}
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.targetCluster")))
+
+			obj.Spec.TargetCluster = nil
+			_, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.targetCluster")))
+
+			By("allowing a target cluster to be set on a local App")
+			_, err = validator.ValidateUpdate(ctx, obj, oldObj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should reject shrinking storage and single-node volumes with progressive rollouts", func() {
+			oldObj.Spec.Storage = &webappv1.StorageSpec{Size: resource.MustParse("10Gi"), MountPath: "/data"}
+			obj.Spec.Storage = oldObj.Spec.Storage.DeepCopy()
+			obj.Spec.Storage.Size = resource.MustParse("5Gi")
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.storage.size")))
+			obj.Spec.Storage.Size = resource.MustParse("20Gi")
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).To(BeEmpty())
+
+			obj.Spec.Strategy = &webappv1.StrategySpec{Type: webappv1.BlueGreenStrategy}
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.storage.accessModes")))
+			obj.Spec.Storage.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeEmpty())
+		})
+
+		It("Should reject containers repeating a container or port name", func() {
+			obj.Spec.Service = &webappv1.ServiceSpec{Ports: []webappv1.ServicePortSpec{{Name: "metrics", Port: 9090}}}
+			obj.Spec.Containers = []webappv1.Container{{
+				Name:  "worker",
+				Image: "worker:1.0",
+				Ports: []webappv1.ContainerPort{{Name: "admin", ContainerPort: 9000}, {ContainerPort: 9001}},
+			}}
+			obj.Spec.Sidecars = []webappv1.Container{{Name: "proxy", Image: "envoy:1.31"}}
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeEmpty())
+
+			obj.Spec.Sidecars[0].Name = "app-container"
+			obj.Spec.Sidecars[0].Ports = []webappv1.ContainerPort{{Name: "metrics", ContainerPort: 9100}}
+			obj.Spec.InitContainers = []webappv1.Container{{Name: "worker", Image: "Worker"}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.sidecars[0].name")))
+			Expect(err).To(MatchError(ContainSubstring("spec.sidecars[0].ports[0].name")))
+			Expect(err).To(MatchError(ContainSubstring("spec.initContainers[0].name")))
+			Expect(err).To(MatchError(ContainSubstring("spec.initContainers[0].image")))
+		})
+	})
+
+	Context("When AppPolicies apply to the App's namespace", func() {
+		var appPolicy *webappv1.AppPolicy
+
+		BeforeEach(func() {
+			appPolicy = &webappv1.AppPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "production"},
+				Spec: webappv1.AppPolicySpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+					Rules: []webappv1.AppPolicyRule{
+						{Name: "replicated", Expression: "object.spec.replicas >= 2", Message: "production Apps run at least two replicas"},
+						{Name: "no-scale-down", Expression: "oldObject == null || object.spec.replicas >= oldObject.spec.replicas"},
+					},
+				},
+			}
+		})
+
+		// withPolicies adds AppPolicies to the objects the validator reads.
+		withPolicies := func(policies ...*webappv1.AppPolicy) {
+			for _, p := range policies {
+				Expect(validator.Client.(client.Client).Create(ctx, p)).To(Succeed())
+			}
+		}
+
+		It("Should admit Apps satisfying the rules", func() {
+			withPolicies(appPolicy)
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeEmpty())
+		})
+
+		It("Should reject Apps breaking a rule with its message", func() {
+			withPolicies(appPolicy)
+			obj.Spec.Replicas = 1
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("AppPolicy production: production Apps run at least two replicas")))
+		})
+
+		It("Should give rules the previous App on updates", func() {
+			withPolicies(appPolicy)
+			oldObj.Spec.Replicas = 3
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("rule no-scale-down is not satisfied")))
+		})
+
+		It("Should ignore policies selecting other namespaces", func() {
+			appPolicy.Spec.NamespaceSelector.MatchLabels["environment"] = "staging"
+			withPolicies(appPolicy)
+			obj.Spec.Replicas = 1
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeEmpty())
+		})
+
+		It("Should reject Apps under a policy whose rules don't compile", func() {
+			appPolicy.Spec.Rules = []webappv1.AppPolicyRule{{Name: "broken", Expression: "object.spec.replicas >="}}
+			withPolicies(appPolicy)
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsForbidden(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("rule broken")))
+		})
+	})
+
+	Context("When Apps reference objects outside their namespace", func() {
+		BeforeEach(func() {
+			obj.Spec.Expose = &webappv1.ExposeSpec{
+				Mode:    webappv1.ExposeModeHTTPRoute,
+				Gateway: &webappv1.GatewayReference{Name: "public", Namespace: "infra"},
+			}
+		})
+
+		It("Should reject references no AppPolicy grants", func() {
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(apierrors.IsInvalid(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("spec.expose.gateway: Forbidden: references Gateway infra/public outside namespace default")))
+
+			_, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.expose.gateway")))
+		})
+
+		It("Should admit references granted by an AppPolicy selecting the namespace", func() {
+			grant := &webappv1.AppPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "shared-gateway"},
+				Spec: webappv1.AppPolicySpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"environment": "production"}},
+					ReferenceGrants:   []webappv1.AppPolicyReferenceGrant{{Kind: webappv1.ReferenceKindGateway, Namespace: "infra", Name: "public"}},
+				},
+			}
+			Expect(validator.Client.(client.Client).Create(ctx, grant)).To(Succeed())
+
+			Expect(validator.ValidateCreate(ctx, obj)).To(BeEmpty())
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).To(BeEmpty())
+		})
+
+		It("Should reject ClusterIssuers no AppPolicy grants", func() {
+			obj.Spec.Expose = nil
+			obj.Spec.TLS = &webappv1.TLSSpec{IssuerRef: webappv1.IssuerReference{Name: "letsencrypt", Kind: webappv1.ReferenceKindClusterIssuer}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.tls.issuerRef: Forbidden: references ClusterIssuer letsencrypt")))
+		})
+
+		It("Should reject namespace-qualified references in sidecars", func() {
+			obj.Spec.Expose = nil
+			obj.Spec.Sidecars = []webappv1.Container{{Name: "proxy", Image: "proxy:1.0", EnvFrom: []corev1.EnvFromSource{
+				{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "infra/proxy-token"}}},
+			}}}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.sidecars[0].envFrom[0].secretRef.name")))
+		})
+	})
+
+	Context("When converting App between versions", func() {
+		It("Should convert the first container to the v1 app container", func() {
+			spoke := &webappv2.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec: webappv2.AppSpec{
+					Containers: []webappv1.Container{{
+						Name:  "web",
+						Image: "nginx:1.27",
+						Args:  []string{"--verbose"},
+						Ports: []webappv1.ContainerPort{
+							{ContainerPort: 80, Protocol: corev1.ProtocolTCP, AppProtocol: ptr.To("http")},
+							{Name: "metrics", ContainerPort: 9090, ServicePort: 90, Protocol: corev1.ProtocolTCP},
+							{ContainerPort: 5353, Protocol: corev1.ProtocolUDP},
+						},
+					}, {Name: "worker", Image: "worker:1.0"}},
+					Sidecars: []webappv1.Container{{Name: "proxy", Image: "envoy:1.31"}},
+					Service:  &webappv2.ServiceSpec{Type: corev1.ServiceTypeNodePort},
+					Replicas: 2,
+				},
+			}
+			hub := &webappv1.App{}
+			Expect(spoke.ConvertTo(hub)).To(Succeed())
+			Expect(hub.Spec.Image).To(Equal("nginx:1.27"))
+			Expect(hub.Spec.ContainerName).To(Equal("web"))
+			Expect(hub.Spec.Args).To(Equal([]string{"--verbose"}))
+			Expect(hub.Spec.Port).To(Equal(int32(80)))
+			Expect(hub.Spec.AppProtocol).To(Equal(webappv1.AppProtocolHTTP))
+			Expect(hub.Spec.Replicas).To(Equal(int32(2)))
+			Expect(hub.Spec.Service.Type).To(Equal(corev1.ServiceTypeNodePort))
+			Expect(hub.Spec.Service.Ports).To(Equal([]webappv1.ServicePortSpec{
+				{Name: "metrics", Port: 90, TargetPort: 9090, Protocol: corev1.ProtocolTCP},
+				{Name: "udp-5353", Port: 5353, Protocol: corev1.ProtocolUDP},
+			}))
+			Expect(hub.Spec.Containers).To(Equal([]webappv1.Container{{Name: "worker", Image: "worker:1.0"}}))
+			Expect(hub.Spec.Sidecars).To(Equal(spoke.Spec.Sidecars))
+
+			Expect(hub.Annotations).To(HaveKey(webappv2.ConversionDataAnnotation), "v1 has no name for the third port")
+
+			By("converting it back")
+			back := &webappv2.App{}
+			Expect(back.ConvertFrom(hub)).To(Succeed())
+			Expect(back).To(Equal(spoke))
+		})
+
+		It("Should keep what v1 can't express of the app container's ports through v1", func() {
+			spoke := &webappv2.App{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Annotations: map[string]string{"team": "shop"}},
+				Spec: webappv2.AppSpec{
+					Containers: []webappv1.Container{{
+						Name:  "web",
+						Image: "nginx:1.27",
+						Ports: []webappv1.ContainerPort{
+							{Name: "dns", ContainerPort: 5353, ServicePort: 53, Protocol: corev1.ProtocolUDP},
+							{ContainerPort: 9090, ServicePort: 9090},
+						},
+					}},
+					Service: &webappv2.ServiceSpec{},
+				},
+			}
+			hub := &webappv1.App{}
+			Expect(spoke.ConvertTo(hub)).To(Succeed())
+			Expect(hub.Spec.Port).To(Equal(int32(5353)))
+			Expect(hub.Annotations).To(HaveKeyWithValue("team", "shop"))
+
+			back := &webappv2.App{}
+			Expect(back.ConvertFrom(hub)).To(Succeed())
+			Expect(back).To(Equal(spoke))
+
+			By("dropping the kept ports once a v1 client changes the fields they map to")
+			hub.Spec.Port = 8080
+			back = &webappv2.App{}
+			Expect(back.ConvertFrom(hub)).To(Succeed())
+			Expect(back.Annotations).To(Equal(map[string]string{"team": "shop"}))
+			Expect(back.Spec.Containers[0].Ports).To(Equal([]webappv1.ContainerPort{
+				{ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+				{Name: "tcp-9090", ContainerPort: 9090, Protocol: corev1.ProtocolTCP},
+			}))
+		})
+
+		It("Should keep v1 Apps unchanged through v2", func() {
+			obj.Spec.AppProtocol = webappv1.AppProtocolGRPC
+			obj.Spec.Env = []corev1.EnvVar{{Name: "MODE", Value: "production"}}
+			obj.Spec.Service = &webappv1.ServiceSpec{
+				SessionAffinity: corev1.ServiceAffinityClientIP,
+				Ports:           []webappv1.ServicePortSpec{{Name: "admin", Port: 81, TargetPort: 8081, Protocol: corev1.ProtocolTCP}},
+			}
+			obj.Spec.Containers = []webappv1.Container{{Name: "worker", Image: "worker:1.0"}}
+
+			spoke := &webappv2.App{}
+			Expect(spoke.ConvertFrom(obj)).To(Succeed())
+			Expect(spoke.Spec.Containers).To(HaveLen(2))
+			Expect(spoke.Spec.Containers[0].Name).To(Equal("app-container"))
+			Expect(spoke.Spec.Containers[0].Ports).To(HaveLen(2))
+			hub := &webappv1.App{}
+			Expect(spoke.ConvertTo(hub)).To(Succeed())
+			Expect(hub).To(Equal(obj))
+			Expect(hub.Annotations).NotTo(HaveKey(webappv2.ConversionDataAnnotation))
+		})
+	})
+})
@@ -0,0 +1,213 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package load
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	dto "github.com/prometheus/client_model/go"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	webappv1 "github.com/your-org/my-app-controller/api/v1"
+	controllers "github.com/your-org/my-app-controller/internal/controller"
+)
+
+// Optional Environment Variables:
+//   - LOAD_APPS: number of Apps to create (default 1000).
+//   - LOAD_TIMEOUT: how long the controller may take to create all Deployments (default 10m).
+//   - LOAD_MAX_REQUESTS_PER_APP: fail when the controller sends more API requests per App.
+//   - LOAD_MAX_HEAP_MB: fail when the controller's heap grows beyond this size.
+const appsPerNamespace = 100
+
+var _ = Describe("App controller under load", Ordered, func() {
+	var (
+		apps     int
+		timeout  time.Duration
+		requests atomic.Int64
+		loader   client.Client
+	)
+
+	BeforeAll(func() {
+		apps = envInt("LOAD_APPS", 1000)
+		timeout = envDuration("LOAD_TIMEOUT", 10*time.Minute)
+
+		// Apps are created without client-side rate limiting, and their requests are not counted.
+		loaderCfg := rest.CopyConfig(cfg)
+		loaderCfg.QPS = -1
+		var err error
+		loader, err = client.New(loaderCfg, client.Options{Scheme: scheme.Scheme})
+		Expect(err).NotTo(HaveOccurred())
+
+		By(fmt.Sprintf("creating %d Apps", apps))
+		for i := range apps {
+			namespace := fmt.Sprintf("load-%d", i/appsPerNamespace)
+			if i%appsPerNamespace == 0 {
+				Expect(loader.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).To(Succeed())
+			}
+			Expect(loader.Create(ctx, &webappv1.App{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("app-%d", i), Namespace: namespace},
+				Spec: webappv1.AppSpec{
+					Image:    "nginx:1.27",
+					Replicas: 1,
+					Port:     8080,
+				},
+			})).To(Succeed())
+		}
+	})
+
+	It("should converge within the time budget", func() {
+		By("starting the controller, as after a restart with every App already present")
+		// The manager gets the default --kube-api-qps and --kube-api-burst, and counts its requests.
+		managerCfg := rest.CopyConfig(cfg)
+		managerCfg.QPS, managerCfg.Burst = 20, 30
+		managerCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				requests.Add(1)
+				return rt.RoundTrip(req)
+			})
+		})
+		mgr, err := ctrl.NewManager(managerCfg, ctrl.Options{
+			Scheme:  scheme.Scheme,
+			Cache:   controllers.CacheOptions(""),
+			Metrics: metricsserver.Options{BindAddress: "0"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect((&controllers.AppReconciler{
+			Client:         mgr.GetClient(),
+			Scheme:         mgr.GetScheme(),
+			StatusInterval: 5 * time.Second,
+		}).SetupWithManager(mgr)).To(Succeed())
+
+		var heapBefore runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&heapBefore)
+
+		started := time.Now()
+		go func() {
+			defer GinkgoRecover()
+			Expect(mgr.Start(ctx)).To(Succeed())
+		}()
+
+		By("waiting for every App's Deployment")
+		Eventually(func(g Gomega) {
+			deployments := &appsv1.DeploymentList{}
+			g.Expect(loader.List(ctx, deployments, client.MatchingLabels{"controller": "app-controller"})).To(Succeed())
+			g.Expect(deployments.Items).To(HaveLen(apps))
+		}).WithTimeout(timeout).WithPolling(time.Second).Should(Succeed())
+		elapsed := time.Since(started)
+
+		var heapAfter runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&heapAfter)
+		heapMB := float64(heapAfter.HeapAlloc-min(heapBefore.HeapAlloc, heapAfter.HeapAlloc)) / (1 << 20)
+
+		reconciles, queueSeconds, queued := reconcileMetrics()
+		perApp := float64(requests.Load()) / float64(apps)
+
+		AddReportEntry("Apps", apps)
+		AddReportEntry("Time to converge", elapsed.Round(time.Millisecond).String())
+		AddReportEntry("Apps per second", fmt.Sprintf("%.1f", float64(apps)/elapsed.Seconds()))
+		AddReportEntry("Reconciles", reconciles)
+		AddReportEntry("Mean queue latency", (time.Duration(queueSeconds / max(queued, 1) * float64(time.Second))).Round(time.Millisecond).String())
+		AddReportEntry("API requests", requests.Load())
+		AddReportEntry("API requests per second", fmt.Sprintf("%.1f", float64(requests.Load())/elapsed.Seconds()))
+		AddReportEntry("API requests per App", fmt.Sprintf("%.1f", perApp))
+		AddReportEntry("Heap growth (MiB)", fmt.Sprintf("%.1f", heapMB))
+
+		if budget := envInt("LOAD_MAX_REQUESTS_PER_APP", 0); budget > 0 {
+			Expect(perApp).To(BeNumerically("<=", budget), "API requests per App")
+		}
+		if budget := envInt("LOAD_MAX_HEAP_MB", 0); budget > 0 {
+			Expect(heapMB).To(BeNumerically("<=", budget), "heap growth in MiB")
+		}
+	})
+})
+
+// reconcileMetrics returns the App controller's reconcile count, and the total and number of
+// observed seconds requests waited in its work queue.
+func reconcileMetrics() (reconciles float64, queueSeconds float64, queued float64) {
+	families, err := metrics.Registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch {
+			case family.GetName() == "controller_runtime_reconcile_total" && hasLabel(metric, "controller", "app"):
+				reconciles += metric.GetCounter().GetValue()
+			case family.GetName() == "workqueue_queue_duration_seconds" && hasLabel(metric, "name", "app"):
+				queueSeconds += metric.GetHistogram().GetSampleSum()
+				queued += float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	return reconciles, queueSeconds, queued
+}
+
+// hasLabel reports whether a metric carries the given label value.
+func hasLabel(metric *dto.Metric, name, value string) bool {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name {
+			return label.GetValue() == value
+		}
+	}
+	return false
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// envInt returns the integer in an environment variable, or def when it is unset.
+func envInt(name string, def int) int {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return def
+	}
+	i, err := strconv.Atoi(value)
+	Expect(err).NotTo(HaveOccurred(), name)
+	return i
+}
+
+// envDuration returns the duration in an environment variable, or def when it is unset.
+func envDuration(name string, def time.Duration) time.Duration {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	Expect(err).NotTo(HaveOccurred(), name)
+	return d
+}